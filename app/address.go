@@ -0,0 +1,266 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"graphql-backend/entity"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// postalCodeFormats lists the countries we ship to with the format of their postal codes,
+// countries mapped to nil don't use postal codes
+var postalCodeFormats = map[string]*regexp.Regexp{
+	"AE": nil,
+	"AU": regexp.MustCompile(`^\d{4}$`),
+	"BE": regexp.MustCompile(`^\d{4}$`),
+	"CA": regexp.MustCompile(`^[A-Z]\d[A-Z] ?\d[A-Z]\d$`),
+	"CH": regexp.MustCompile(`^\d{4}$`),
+	"DE": regexp.MustCompile(`^\d{5}$`),
+	"DK": regexp.MustCompile(`^\d{4}$`),
+	"ES": regexp.MustCompile(`^\d{5}$`),
+	"FR": regexp.MustCompile(`^\d{5}$`),
+	"GB": regexp.MustCompile(`^[A-Z]{1,2}\d[A-Z\d]? ?\d[A-Z]{2}$`),
+	"HK": nil,
+	"IE": regexp.MustCompile(`^[A-Z]\d[\dW] ?[A-Z\d]{4}$`),
+	"IN": regexp.MustCompile(`^\d{6}$`),
+	"IT": regexp.MustCompile(`^\d{5}$`),
+	"JP": regexp.MustCompile(`^\d{3}-?\d{4}$`),
+	"KR": regexp.MustCompile(`^\d{5}$`),
+	"NL": regexp.MustCompile(`^\d{4} ?[A-Z]{2}$`),
+	"NO": regexp.MustCompile(`^\d{4}$`),
+	"NZ": regexp.MustCompile(`^\d{4}$`),
+	"PL": regexp.MustCompile(`^\d{2}-\d{3}$`),
+	"SE": regexp.MustCompile(`^\d{3} ?\d{2}$`),
+	"SG": regexp.MustCompile(`^\d{6}$`),
+	"US": regexp.MustCompile(`^\d{5}(-\d{4})?$`),
+	"VN": regexp.MustCompile(`^\d{6}$`),
+}
+
+func (s service) CreateAddress(ctx context.Context, prs CreateAddressParams) (entity.Address, error) {
+	existing, err := s.repo.GetAddressesByUserID(ctx, prs.UserID)
+	if err != nil {
+		return entity.Address{}, err
+	}
+
+	now := time.Now()
+	address := entity.Address{
+		ID:                uuid.NewString(),
+		UserID:            prs.UserID,
+		FullName:          prs.FullName,
+		Line1:             prs.Line1,
+		Line2:             prs.Line2,
+		City:              prs.City,
+		Region:            prs.Region,
+		PostalCode:        prs.PostalCode,
+		Country:           prs.Country,
+		Phone:             prs.Phone,
+		IsDefaultShipping: prs.IsDefaultShipping || len(existing) == 0,
+		IsDefaultBilling:  prs.IsDefaultBilling || len(existing) == 0,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	err = normalizeAddress(&address)
+	if err != nil {
+		return entity.Address{}, err
+	}
+
+	err = s.repo.CreateAddress(ctx, address)
+	if err != nil {
+		return entity.Address{}, err
+	}
+
+	return address, nil
+}
+
+func (s service) UpdateAddress(ctx context.Context, prs UpdateAddressParams) (entity.Address, error) {
+	address, err := s.getUserAddress(ctx, prs.UserID, prs.ID)
+	if err != nil {
+		return entity.Address{}, err
+	}
+
+	prs.BindToAddress(&address)
+	err = normalizeAddress(&address)
+	if err != nil {
+		return entity.Address{}, err
+	}
+
+	address.UpdatedAt = time.Now()
+	err = s.repo.UpdateAddress(ctx, address)
+	if err != nil {
+		return entity.Address{}, err
+	}
+
+	return address, nil
+}
+
+func (s service) DeleteAddress(ctx context.Context, prs DeleteAddressParams) error {
+	address, err := s.getUserAddress(ctx, prs.UserID, prs.ID)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.DeleteAddress(ctx, address.ID)
+}
+
+// getUserAddress gets an address of the user, addresses of other users are not found
+func (s service) getUserAddress(ctx context.Context, userID, id string) (entity.Address, error) {
+	address, err := s.repo.GetAddressByID(ctx, id)
+	if err != nil {
+		return entity.Address{}, err
+	}
+
+	if address.UserID != userID {
+		return entity.Address{}, errors.New("address not found")
+	}
+
+	return address, nil
+}
+
+// orderAddresses resolves the addresses an order is shipped and billed to, falling back to the user's defaults.
+// The billing address falls back to the shipping one when there is no default billing address.
+func (s service) orderAddresses(ctx context.Context, userID string, shippingID, billingID *string) (*entity.OrderAddress, *entity.OrderAddress, error) {
+	var shipping, billing *entity.OrderAddress
+	if shippingID != nil {
+		address, err := s.getUserAddress(ctx, userID, *shippingID)
+		if err != nil {
+			return nil, nil, err
+		}
+		shipping = address.Snapshot()
+	}
+	if billingID != nil {
+		address, err := s.getUserAddress(ctx, userID, *billingID)
+		if err != nil {
+			return nil, nil, err
+		}
+		billing = address.Snapshot()
+	}
+
+	if shipping == nil || billing == nil {
+		addresses, err := s.repo.GetAddressesByUserID(ctx, userID)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, address := range addresses {
+			if shipping == nil && address.IsDefaultShipping {
+				shipping = address.Snapshot()
+			}
+			if billing == nil && address.IsDefaultBilling {
+				billing = address.Snapshot()
+			}
+		}
+	}
+
+	if billing == nil && shipping != nil {
+		billing = shipping
+	}
+
+	return shipping, billing, nil
+}
+
+// normalizeAddress trims the address fields and validates them
+func normalizeAddress(e *entity.Address) error {
+	for _, field := range []*string{&e.FullName, &e.Line1, &e.Line2, &e.City, &e.Region, &e.PostalCode, &e.Country, &e.Phone} {
+		*field = strings.TrimSpace(*field)
+	}
+	e.Country = strings.ToUpper(e.Country)
+	e.PostalCode = strings.ToUpper(e.PostalCode)
+
+	if e.FullName == "" {
+		return errors.New("full name cannot be empty")
+	}
+	if e.Line1 == "" {
+		return errors.New("address line 1 cannot be empty")
+	}
+	if e.City == "" {
+		return errors.New("city cannot be empty")
+	}
+
+	format, ok := postalCodeFormats[e.Country]
+	if !ok {
+		return errors.New("invalid or unsupported country, expected an ISO 3166-1 alpha-2 code")
+	}
+	if format == nil {
+		e.PostalCode = ""
+		return nil
+	}
+	if !format.MatchString(e.PostalCode) {
+		return errors.New("invalid postal code for " + e.Country)
+	}
+
+	return nil
+}
+
+type CreateAddressParams struct {
+	UserID string
+
+	FullName          string
+	Line1             string
+	Line2             string
+	City              string
+	Region            string
+	PostalCode        string
+	Country           string
+	Phone             string
+	IsDefaultShipping bool
+	IsDefaultBilling  bool
+}
+
+type UpdateAddressParams struct {
+	UserID string
+	ID     string
+
+	FullName          *string
+	Line1             *string
+	Line2             *string
+	City              *string
+	Region            *string
+	PostalCode        *string
+	Country           *string
+	Phone             *string
+	IsDefaultShipping *bool
+	IsDefaultBilling  *bool
+}
+
+func (p *UpdateAddressParams) BindToAddress(e *entity.Address) {
+	if e == nil {
+		return
+	}
+	if p.FullName != nil {
+		e.FullName = *p.FullName
+	}
+	if p.Line1 != nil {
+		e.Line1 = *p.Line1
+	}
+	if p.Line2 != nil {
+		e.Line2 = *p.Line2
+	}
+	if p.City != nil {
+		e.City = *p.City
+	}
+	if p.Region != nil {
+		e.Region = *p.Region
+	}
+	if p.PostalCode != nil {
+		e.PostalCode = *p.PostalCode
+	}
+	if p.Country != nil {
+		e.Country = *p.Country
+	}
+	if p.Phone != nil {
+		e.Phone = *p.Phone
+	}
+	if p.IsDefaultShipping != nil {
+		e.IsDefaultShipping = *p.IsDefaultShipping
+	}
+	if p.IsDefaultBilling != nil {
+		e.IsDefaultBilling = *p.IsDefaultBilling
+	}
+}
+
+type DeleteAddressParams struct {
+	UserID string
+	ID     string
+}
@@ -0,0 +1,122 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"github.com/google/uuid"
+	"graphql-backend/entity"
+	"graphql-backend/pkg/http-transport"
+	"time"
+)
+
+const apiKeyPrefix = "gbk_"
+
+func (s service) CreateAPIKey(ctx context.Context, prs CreateAPIKeyParams) (CreateAPIKeyResult, error) {
+	if prs.Name == "" {
+		return CreateAPIKeyResult{}, errors.New("api key name cannot be empty")
+	}
+	if len(prs.Scopes) == 0 {
+		return CreateAPIKeyResult{}, errors.New("api key scopes cannot be empty")
+	}
+	if prs.ExpiresAt != nil && !prs.ExpiresAt.After(time.Now()) {
+		return CreateAPIKeyResult{}, errors.New("api key expiry must be in the future")
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return CreateAPIKeyResult{}, err
+	}
+
+	id := uuid.NewString()
+	prefix := apiKeyPrefix + id[:8]
+	key := prefix + "_" + base64.RawURLEncoding.EncodeToString(secret)
+
+	apiKey := entity.APIKey{
+		ID:        id,
+		Name:      prs.Name,
+		Prefix:    prefix,
+		Hash:      hashAPIKey(key),
+		Scopes:    prs.Scopes,
+		CreatedBy: prs.CreatedBy,
+		CreatedAt: time.Now(),
+		ExpiresAt: prs.ExpiresAt,
+	}
+
+	err := s.repo.CreateAPIKey(ctx, apiKey)
+	if err != nil {
+		return CreateAPIKeyResult{}, err
+	}
+
+	return CreateAPIKeyResult{
+		Key:    key,
+		APIKey: apiKey,
+	}, nil
+}
+
+func (s service) RevokeAPIKey(ctx context.Context, id string) (entity.APIKey, error) {
+	apiKey, err := s.repo.GetAPIKeyByID(ctx, id)
+	if err != nil {
+		return entity.APIKey{}, err
+	}
+
+	if apiKey.RevokedAt != nil {
+		return apiKey, nil
+	}
+
+	now := time.Now()
+	apiKey.RevokedAt = &now
+	err = s.repo.UpdateAPIKey(ctx, apiKey)
+	if err != nil {
+		return entity.APIKey{}, err
+	}
+
+	return apiKey, nil
+}
+
+// AuthenticateAPIKey resolves a plain API key to the service principal it was issued for,
+// and records when the key was last used
+func (s service) AuthenticateAPIKey(ctx context.Context, key string) (*http_transport.ServicePrincipal, error) {
+	apiKey, err := s.repo.GetAPIKeyByHash(ctx, hashAPIKey(key))
+	if err != nil {
+		return nil, errors.New("invalid api key")
+	}
+
+	now := time.Now()
+	if !apiKey.IsActive(now) {
+		return nil, errors.New("invalid api key")
+	}
+
+	err = s.repo.TouchAPIKey(ctx, apiKey.ID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http_transport.ServicePrincipal{
+		KeyID:  apiKey.ID,
+		Name:   apiKey.Name,
+		Scopes: apiKey.Scopes,
+	}, nil
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+type CreateAPIKeyParams struct {
+	Name      string
+	Scopes    []string
+	ExpiresAt *time.Time
+	CreatedBy string
+}
+
+type CreateAPIKeyResult struct {
+	// Key is the plain API key, it is only available right after creation
+	Key string
+
+	APIKey entity.APIKey
+}
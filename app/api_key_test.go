@@ -0,0 +1,66 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"graphql-backend/entity"
+)
+
+// apiKeyRepo keeps the API keys, afterRead runs after a key is read like a concurrent change would.
+// The other methods of Repo are not used.
+type apiKeyRepo struct {
+	Repo
+	keys      map[string]entity.APIKey
+	afterRead func()
+}
+
+func (r *apiKeyRepo) GetAPIKeyByHash(ctx context.Context, hash string) (entity.APIKey, error) {
+	for _, apiKey := range r.keys {
+		if apiKey.Hash == hash {
+			if r.afterRead != nil {
+				r.afterRead()
+			}
+			return apiKey, nil
+		}
+	}
+	return entity.APIKey{}, errors.New("api key not found")
+}
+
+func (r *apiKeyRepo) UpdateAPIKey(ctx context.Context, e entity.APIKey) error {
+	r.keys[e.ID] = e
+	return nil
+}
+
+func (r *apiKeyRepo) TouchAPIKey(ctx context.Context, id string, at time.Time) error {
+	apiKey := r.keys[id]
+	apiKey.LastUsedAt = &at
+	r.keys[id] = apiKey
+	return nil
+}
+
+func TestAuthenticateAPIKeyKeepsARevocation(t *testing.T) {
+	repo := &apiKeyRepo{keys: map[string]entity.APIKey{
+		"warehouse": {ID: "warehouse", Hash: hashAPIKey("secret"), Scopes: []string{entity.APIKeyScopeReadProducts}},
+	}}
+	service := NewService(repo, nil, nil, nil, nil, nil, nil)
+
+	// the key is revoked while a request authenticates with it
+	repo.afterRead = func() {
+		apiKey := repo.keys["warehouse"]
+		now := time.Now()
+		apiKey.RevokedAt = &now
+		repo.keys["warehouse"] = apiKey
+	}
+	_, err := service.AuthenticateAPIKey(context.TODO(), "secret")
+	require.NoError(t, err)
+	require.NotNil(t, repo.keys["warehouse"].RevokedAt)
+	require.NotNil(t, repo.keys["warehouse"].LastUsedAt)
+
+	repo.afterRead = nil
+	_, err = service.AuthenticateAPIKey(context.TODO(), "secret")
+	require.Error(t, err)
+}
@@ -0,0 +1,504 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"graphql-backend/entity"
+	"log"
+	"time"
+)
+
+const MaxCartItemQuantity = 99
+
+// GuestCartExpiration is how long a guest cart is kept after its last change
+const GuestCartExpiration = 30 * 24 * time.Hour
+
+var ErrCartTokenRequired = errors.New("cart token is required")
+
+type CartItemIssue string
+
+const (
+	// CartItemIssuePriceChanged is informational, the current price is charged at checkout
+	CartItemIssuePriceChanged      CartItemIssue = "PriceChanged"
+	CartItemIssueInsufficientStock CartItemIssue = "InsufficientStock"
+	CartItemIssueOutOfStock        CartItemIssue = "OutOfStock"
+	CartItemIssueUnavailable       CartItemIssue = "Unavailable"
+)
+
+// CartView is a cart re-validated against the current products, with its totals
+type CartView struct {
+	Cart entity.Cart
+	// Token is the plain token of a guest cart
+	Token     string
+	Lines     []CartLine
+	Subtotal  entity.Money
+	ItemCount int32
+}
+
+type CartLine struct {
+	Item entity.CartItem
+	// Product is nil when the product or the variant no longer exists
+	Product *entity.Product
+	// Variant is set for items of products with variants
+	Variant   *entity.ProductVariant
+	UnitPrice entity.Money
+	LineTotal entity.Money
+	Issues    []CartItemIssue
+}
+
+// Orderable reports whether the line can be checked out as it is
+func (l CartLine) Orderable() bool {
+	for _, issue := range l.Issues {
+		if issue != CartItemIssuePriceChanged {
+			return false
+		}
+	}
+	return true
+}
+
+// Convert converts the amounts from the base currency, unit prices first as checkout does
+func (v CartView) Convert(currency string, rate float64) CartView {
+	lines := make([]CartLine, len(v.Lines))
+	v.Subtotal = entity.Money{Currency: currency}
+	for i, line := range v.Lines {
+		line.Item.AddedPrice = line.Item.AddedPrice.Convert(currency, rate)
+		line.UnitPrice = line.UnitPrice.Convert(currency, rate)
+		line.LineTotal = line.UnitPrice.Mul(int64(line.Item.Quantity))
+		if line.Product != nil {
+			product := line.Product.Convert(currency, rate)
+			line.Product = &product
+			if j := product.Variant(line.Item.VariantID); j >= 0 {
+				line.Variant = &product.Variants[j]
+			}
+		}
+		lines[i] = line
+		v.Subtotal = v.Subtotal.Add(line.LineTotal)
+	}
+	v.Lines = lines
+	return v
+}
+
+func (v CartView) Orderable() bool {
+	for _, line := range v.Lines {
+		if !line.Orderable() {
+			return false
+		}
+	}
+	return true
+}
+
+func (s service) AddToCart(ctx context.Context, prs CartItemParams) (CartView, error) {
+	if prs.Quantity <= 0 {
+		return CartView{}, errors.New("quantity must be positive")
+	}
+
+	product, err := s.repo.GetProductByID(ctx, prs.ProductID)
+	if err != nil {
+		return CartView{}, err
+	}
+	if err := product.CheckAvailable(); err != nil {
+		return CartView{}, err
+	}
+	if err := product.CheckVariant(prs.VariantID); err != nil {
+		return CartView{}, err
+	}
+
+	cart, token, err := getOwnerCart(ctx, s.repo, prs.Owner, true)
+	if err != nil {
+		return CartView{}, err
+	}
+
+	now := time.Now()
+	i := cart.Item(prs.Key())
+	if i < 0 {
+		cart.Items = append(cart.Items, entity.CartItem{ProductID: product.ID, VariantID: prs.VariantID, AddedAt: now})
+		i = len(cart.Items) - 1
+	}
+	// capped before it is added so that it can't overflow, checkCartQuantity still refuses it
+	quantity := min(prs.Quantity, MaxCartItemQuantity+1) + cart.Items[i].Quantity
+	if err := checkCartQuantity(product, prs.VariantID, quantity); err != nil {
+		return CartView{}, err
+	}
+	cart.Items[i].Quantity = quantity
+	cart.Items[i].AddedPrice = product.UnitPrice(prs.VariantID)
+
+	return s.saveCart(ctx, cart, token, now)
+}
+
+// UpdateCartItem sets the quantity of a product or variant in the cart, a quantity of 0 removes it
+func (s service) UpdateCartItem(ctx context.Context, prs CartItemParams) (CartView, error) {
+	if prs.Quantity < 0 {
+		return CartView{}, errors.New("quantity cannot be negative")
+	}
+	if prs.Quantity == 0 {
+		return s.RemoveFromCart(ctx, prs)
+	}
+
+	cart, token, err := getOwnerCart(ctx, s.repo, prs.Owner, false)
+	if err != nil {
+		return CartView{}, err
+	}
+
+	i := cart.Item(prs.Key())
+	if i < 0 {
+		return CartView{}, errors.New("product is not in the cart")
+	}
+
+	product, err := s.repo.GetProductByID(ctx, prs.ProductID)
+	if err != nil {
+		return CartView{}, err
+	}
+	if err := product.CheckAvailable(); err != nil {
+		return CartView{}, err
+	}
+	if err := product.CheckVariant(prs.VariantID); err != nil {
+		return CartView{}, err
+	}
+	if err := checkCartQuantity(product, prs.VariantID, prs.Quantity); err != nil {
+		return CartView{}, err
+	}
+	cart.Items[i].Quantity = prs.Quantity
+	cart.Items[i].AddedPrice = product.UnitPrice(prs.VariantID)
+
+	return s.saveCart(ctx, cart, token, time.Now())
+}
+
+func (s service) RemoveFromCart(ctx context.Context, prs CartItemParams) (CartView, error) {
+	cart, token, err := getOwnerCart(ctx, s.repo, prs.Owner, false)
+	if err != nil {
+		return CartView{}, err
+	}
+
+	i := cart.Item(prs.Key())
+	if i < 0 {
+		return CartView{}, errors.New("product is not in the cart")
+	}
+	cart.Items = append(cart.Items[:i], cart.Items[i+1:]...)
+
+	return s.saveCart(ctx, cart, token, time.Now())
+}
+
+func (s service) ClearCart(ctx context.Context, owner CartOwner) (CartView, error) {
+	cart, token, err := getOwnerCart(ctx, s.repo, owner, false)
+	if err != nil {
+		return CartView{}, err
+	}
+
+	cart.Items = []entity.CartItem{}
+
+	return s.saveCart(ctx, cart, token, time.Now())
+}
+
+// MergeGuestCart moves the items of a guest cart into the user's cart, when it is still there.
+// The quantities of products in both carts are summed and capped at the stock, then the guest cart is deleted.
+func (s service) MergeGuestCart(ctx context.Context, userID, token string) error {
+	guest, err := s.repo.GetCartByTokenHash(ctx, hashSecretToken(token))
+	if err != nil || guest.IsExpired(time.Now()) {
+		// an unknown or expired guest cart is nothing to merge
+		return nil
+	}
+
+	cart, err := getUserCart(ctx, s.repo, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range guest.Items {
+		product, err := s.repo.GetProductByID(ctx, item.ProductID)
+		if err != nil || product.CheckAvailable() != nil || product.CheckVariant(item.VariantID) != nil {
+			continue
+		}
+
+		i := cart.Item(item.Key())
+		if i < 0 {
+			cart.Items = append(cart.Items, entity.CartItem{
+				ProductID:  item.ProductID,
+				VariantID:  item.VariantID,
+				AddedPrice: item.AddedPrice,
+				AddedAt:    item.AddedAt,
+			})
+			i = len(cart.Items) - 1
+		}
+		cart.Items[i].Quantity = min(cart.Items[i].Quantity+item.Quantity, product.Stock(item.VariantID), MaxCartItemQuantity)
+		if cart.Items[i].Quantity <= 0 {
+			cart.Items = append(cart.Items[:i], cart.Items[i+1:]...)
+		}
+	}
+
+	if _, err := s.saveCart(ctx, cart, "", time.Now()); err != nil {
+		return err
+	}
+
+	return s.repo.DeleteCart(ctx, guest.ID)
+}
+
+func (s service) DeleteExpiredCarts(ctx context.Context, now time.Time) error {
+	return s.repo.DeleteExpiredCarts(ctx, now)
+}
+
+// RunCartSweeper deletes the expired guest carts every interval until the context is done,
+// they are already ignored in between
+func RunCartSweeper(ctx context.Context, service Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := service.DeleteExpiredCarts(ctx, now); err != nil {
+				log.Printf("failed to delete expired carts: %v", err)
+			}
+		}
+	}
+}
+
+// Checkout converts the cart into an order at the current prices. The stock is reserved,
+// the order created and the cart emptied atomically by the repo.
+func (s service) Checkout(ctx context.Context, prs CheckoutParams) (entity.Order, error) {
+	cart, err := getUserCart(ctx, s.repo, prs.UserID)
+	if err != nil {
+		return entity.Order{}, err
+	}
+	if len(cart.Items) == 0 {
+		return entity.Order{}, errors.New("cart is empty")
+	}
+
+	view, err := buildCartView(ctx, s.repo, cart)
+	if err != nil {
+		return entity.Order{}, err
+	}
+	if !view.Orderable() {
+		return entity.Order{}, errors.New("some items in the cart can't be ordered, review the cart")
+	}
+
+	shippingAddress, billingAddress, err := s.orderAddresses(ctx, prs.UserID, prs.AddressID, prs.BillingAddressID)
+	if err != nil {
+		return entity.Order{}, err
+	}
+
+	currency, rate, err := exchangeRate(ctx, s.rates, prs.Currency)
+	if err != nil {
+		return entity.Order{}, err
+	}
+
+	order := entity.Order{
+		ID:         uuid.NewString(),
+		UserID:     prs.UserID,
+		ProductIDs: make([]string, 0, len(view.Lines)),
+		Items:      make([]entity.OrderItem, 0, len(view.Lines)),
+		Status:     entity.OrderStatusAwaitingPayment,
+		CreatedAt:  time.Now(),
+
+		Currency:     currency,
+		ExchangeRate: rate,
+
+		ShippingAddress: shippingAddress,
+		BillingAddress:  billingAddress,
+		StockReserved:   true,
+	}
+	products := make(map[string]entity.Product, len(view.Lines))
+	for _, line := range view.Lines {
+		products[line.Item.ProductID] = *line.Product
+		order.ProductIDs = append(order.ProductIDs, line.Item.ProductID)
+		order.Items = append(order.Items, newOrderItem(*line.Product, line.Item.VariantID, line.Item.Quantity, line.UnitPrice.Convert(currency, rate)))
+	}
+
+	err = s.priceOrder(ctx, &order, products, prs.CouponCode)
+	if err != nil {
+		return entity.Order{}, err
+	}
+
+	err = s.repo.CheckoutCart(ctx, cart, order)
+	if err != nil {
+		return entity.Order{}, err
+	}
+
+	return order, nil
+}
+
+func (s service) saveCart(ctx context.Context, cart entity.Cart, token string, now time.Time) (CartView, error) {
+	if cart.CreatedAt.IsZero() {
+		cart.CreatedAt = now
+	}
+	cart.UpdatedAt = now
+	if cart.IsGuest() {
+		expiresAt := now.Add(GuestCartExpiration)
+		cart.ExpiresAt = &expiresAt
+	}
+
+	err := s.repo.SaveCart(ctx, cart)
+	if err != nil {
+		return CartView{}, err
+	}
+
+	view, err := buildCartView(ctx, s.repo, cart)
+	view.Token = token
+	return view, err
+}
+
+// GetCart returns the cart of the owner in the currency, guests without a token get an empty cart until they add something
+func (q *query) GetCart(ctx context.Context, owner CartOwner, currency string) (CartView, error) {
+	currency, rate, err := exchangeRate(ctx, q.rates, currency)
+	if err != nil {
+		return CartView{}, err
+	}
+
+	cart := entity.Cart{Items: []entity.CartItem{}}
+	var token string
+	if owner.UserID != "" || owner.GuestToken != "" {
+		cart, token, err = getOwnerCart(ctx, q.repo, owner, false)
+		if err != nil {
+			return CartView{}, err
+		}
+	}
+
+	view, err := buildCartView(ctx, q.repo, cart)
+	if err != nil {
+		return CartView{}, err
+	}
+	view.Token = token
+	return view.Convert(currency, rate), nil
+}
+
+// getOwnerCart gets the cart of a user or the guest cart of a token, along with the plain token for guests.
+// Without a token a new guest cart is started if create is set.
+func getOwnerCart(ctx context.Context, repo Repo, owner CartOwner, create bool) (entity.Cart, string, error) {
+	if owner.UserID != "" {
+		cart, err := getUserCart(ctx, repo, owner.UserID)
+		return cart, "", err
+	}
+
+	if owner.GuestToken != "" {
+		cart, err := repo.GetCartByTokenHash(ctx, hashSecretToken(owner.GuestToken))
+		if err != nil || cart.IsExpired(time.Now()) {
+			return entity.Cart{}, "", errors.New("cart not found")
+		}
+		return cart, owner.GuestToken, nil
+	}
+
+	if !create {
+		return entity.Cart{}, "", ErrCartTokenRequired
+	}
+
+	token, err := newSecretToken()
+	if err != nil {
+		return entity.Cart{}, "", err
+	}
+	return entity.Cart{
+		ID:        uuid.NewString(),
+		TokenHash: hashSecretToken(token),
+		Items:     []entity.CartItem{},
+	}, token, nil
+}
+
+func getUserCart(ctx context.Context, repo Repo, userID string) (entity.Cart, error) {
+	cart, err := repo.GetCartByUserID(ctx, userID)
+	if err == nil {
+		return cart, nil
+	}
+
+	// a user gets a cart the first time something is added to it
+	if _, err := repo.GetUserByID(ctx, userID); err != nil {
+		return entity.Cart{}, err
+	}
+	return entity.Cart{
+		ID:     uuid.NewString(),
+		UserID: userID,
+		Items:  []entity.CartItem{},
+	}, nil
+}
+
+// buildCartView re-validates the cart items against the current prices and stock
+func buildCartView(ctx context.Context, repo Repo, cart entity.Cart) (CartView, error) {
+	ids := make([]string, len(cart.Items))
+	for i, item := range cart.Items {
+		ids[i] = item.ProductID
+	}
+	products, err := repo.GetProductsByIDs(ctx, ids)
+	if err != nil {
+		return CartView{}, err
+	}
+	productsByID := make(map[string]entity.Product, len(products))
+	for _, product := range products {
+		productsByID[product.ID] = product
+	}
+
+	view := CartView{
+		Cart:     cart,
+		Lines:    make([]CartLine, len(cart.Items)),
+		Subtotal: entity.Money{Currency: entity.DefaultCurrency},
+	}
+	for i, item := range cart.Items {
+		line := CartLine{Item: item, Issues: []CartItemIssue{}}
+		product, ok := productsByID[item.ProductID]
+		// the product may have been archived, or the variant deleted, since the item was added
+		ok = ok && product.CheckAvailable() == nil && product.CheckVariant(item.VariantID) == nil
+		switch {
+		case !ok:
+			line.Issues = append(line.Issues, CartItemIssueUnavailable)
+		case product.Stock(item.VariantID) <= 0:
+			line.Issues = append(line.Issues, CartItemIssueOutOfStock)
+		case product.Stock(item.VariantID) < item.Quantity:
+			line.Issues = append(line.Issues, CartItemIssueInsufficientStock)
+		}
+		if ok {
+			line.Product = &product
+			if i := product.Variant(item.VariantID); i >= 0 {
+				line.Variant = &product.Variants[i]
+			}
+			line.UnitPrice = product.UnitPrice(item.VariantID)
+			line.LineTotal = line.UnitPrice.Mul(int64(item.Quantity))
+			if line.UnitPrice != item.AddedPrice {
+				line.Issues = append(line.Issues, CartItemIssuePriceChanged)
+			}
+		}
+
+		view.Lines[i] = line
+		view.ItemCount += item.Quantity
+		view.Subtotal = view.Subtotal.Add(line.LineTotal)
+	}
+
+	return view, nil
+}
+
+func checkCartQuantity(product entity.Product, variantID string, quantity int32) error {
+	if quantity > MaxCartItemQuantity {
+		return fmt.Errorf("cannot add more than %d of a product", MaxCartItemQuantity)
+	}
+	if stock := product.Stock(variantID); quantity > stock {
+		return fmt.Errorf("only %d of %s left in stock", stock, product.Name)
+	}
+	return nil
+}
+
+// CartOwner is either a user or a guest holding a cart token
+type CartOwner struct {
+	UserID     string
+	GuestToken string
+}
+
+type CartItemParams struct {
+	Owner     CartOwner
+	ProductID string
+	// VariantID is required for products with variants
+	VariantID string
+	Quantity  int32
+}
+
+func (p CartItemParams) Key() entity.ItemKey {
+	return entity.ItemKey{ProductID: p.ProductID, VariantID: p.VariantID}
+}
+
+type CheckoutParams struct {
+	UserID string
+	// AddressID and BillingAddressID default to the user's default shipping and billing addresses
+	AddressID        *string
+	BillingAddressID *string
+	CouponCode       string
+	// Currency the order is placed in, the base currency when empty
+	Currency string
+}
@@ -0,0 +1,786 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"graphql-backend/entity"
+	"io"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type CatalogFormat string
+
+const (
+	CatalogFormatCSV   CatalogFormat = "CSV"
+	CatalogFormatJSONL CatalogFormat = "JSONL"
+)
+
+// CatalogFormatOf returns the format of a file from its extension, .csv or .jsonl
+func CatalogFormatOf(filename string) (CatalogFormat, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return CatalogFormatCSV, nil
+	case ".jsonl", ".ndjson":
+		return CatalogFormatJSONL, nil
+	default:
+		return "", fmt.Errorf("can't tell the format of %q, give it or use a .csv or .jsonl file", filename)
+	}
+}
+
+// catalogColumns are the columns of the CSV files and the keys of the JSON Lines objects
+var catalogColumns = []string{"sku", "parent_sku", "name", "description", "price", "category", "in_stock", "options"}
+
+// CatalogRow is a line of a product import or export file: a product, or a variant of the product of ParentSKU.
+// Prices are in the base currency. Options are "Size=S|M; Color=Blue" for products and "Size=S; Color=Blue" for variants.
+// Nil fields are left as they are when a product or variant is updated.
+type CatalogRow struct {
+	SKU         string       `json:"sku"`
+	ParentSKU   string       `json:"parent_sku,omitempty"`
+	Name        *string      `json:"name,omitempty"`
+	Description *string      `json:"description,omitempty"`
+	Price       *json.Number `json:"price,omitempty"`
+	Category    *string      `json:"category,omitempty"`
+	InStock     *int32       `json:"in_stock,omitempty"`
+	Options     *string      `json:"options,omitempty"`
+}
+
+// catalogLine is a row read from a file with its line number, Err is set when the line could not be read
+type catalogLine struct {
+	Line int
+	Row  CatalogRow
+	Err  error
+}
+
+// catalogSKU is what a SKU of the catalog belongs to, a product or one of its variants
+type catalogSKU struct {
+	productID string
+	variantID string
+}
+
+// catalogImport applies the rows of an import to a copy of the catalog, nothing is saved until every row succeeded
+type catalogImport struct {
+	products   map[string]entity.Product
+	skus       map[string]catalogSKU
+	categories []entity.Category
+	// changed are the IDs of the created and updated products, in the order of the file
+	changed []string
+	updates map[string]*ProductUpdate
+}
+
+// ProductField is a field of a product or variant an import row sets
+type ProductField string
+
+const (
+	ProductFieldName        ProductField = "name"
+	ProductFieldSKU         ProductField = "sku"
+	ProductFieldDescription ProductField = "description"
+	ProductFieldPrice       ProductField = "price"
+	ProductFieldCategory    ProductField = "category"
+	ProductFieldInStock     ProductField = "in_stock"
+	ProductFieldOptions     ProductField = "options"
+)
+
+// ProductUpdate is a product created or changed by an import or an edit. Only the fields it sets are saved onto the
+// stored product, so that the changes made meanwhile, e.g. the stock taken by checkouts, are kept.
+type ProductUpdate struct {
+	Product entity.Product
+	// Created is set for new products, they are saved whole
+	Created bool
+	Fields  map[ProductField]bool
+	// VariantFields are the fields set on each variant by ID, the variants the stored product doesn't have are added whole
+	VariantFields map[string]map[ProductField]bool
+	// DeletedVariants are the IDs of the variants removed from the product
+	DeletedVariants []string
+}
+
+// Merge sets the fields of the update onto the stored product
+func (u ProductUpdate) Merge(stored entity.Product) entity.Product {
+	product := cloneProduct(stored)
+	for field := range u.Fields {
+		switch field {
+		case ProductFieldName:
+			product.Name = u.Product.Name
+		case ProductFieldSKU:
+			product.SKU = u.Product.SKU
+		case ProductFieldDescription:
+			product.Description = u.Product.Description
+		case ProductFieldPrice:
+			product.Price = u.Product.Price
+		case ProductFieldCategory:
+			product.CategoryID = u.Product.CategoryID
+			product.Category = u.Product.Category
+		case ProductFieldInStock:
+			product.InStock = u.Product.InStock
+		case ProductFieldOptions:
+			product.Options = u.Product.Options
+		}
+	}
+
+	for _, variant := range u.Product.Variants {
+		i := product.Variant(variant.ID)
+		if i < 0 {
+			product.Variants = append(product.Variants, variant)
+			continue
+		}
+		for field := range u.VariantFields[variant.ID] {
+			switch field {
+			case ProductFieldSKU:
+				product.Variants[i].SKU = variant.SKU
+			case ProductFieldPrice:
+				product.Variants[i].Price = variant.Price
+			case ProductFieldInStock:
+				product.Variants[i].InStock = variant.InStock
+			case ProductFieldOptions:
+				product.Variants[i].Options = variant.Options
+			}
+		}
+	}
+	product.Variants = slices.DeleteFunc(product.Variants, func(v entity.ProductVariant) bool {
+		return slices.Contains(u.DeletedVariants, v.ID)
+	})
+	if product.HasVariants() || len(u.DeletedVariants) > 0 {
+		sumVariantStock(&product)
+	}
+
+	return product
+}
+
+// Apply merges the update onto the stored product and checks the result, the variants it changes or deletes must still
+// exist and the options changed meanwhile must still match the variants
+func (u ProductUpdate) Apply(stored entity.Product) (entity.Product, error) {
+	for id := range u.VariantFields {
+		if stored.Variant(id) < 0 {
+			return entity.Product{}, errors.New("variant not found")
+		}
+	}
+	for _, id := range u.DeletedVariants {
+		if stored.Variant(id) < 0 {
+			return entity.Product{}, errors.New("variant not found")
+		}
+	}
+
+	product := u.Merge(stored)
+	err := normalizeProduct(&product)
+	if err != nil {
+		return entity.Product{}, err
+	}
+	return product, nil
+}
+
+// ImportProducts creates or updates the products and variants of the file, matched by SKU.
+// Every row is validated first, the import is only applied when none failed and it is not a dry run.
+func (s service) ImportProducts(ctx context.Context, prs ImportProductsParams) (ImportProductsResult, error) {
+	lines, err := readCatalog(prs.Format, prs.Content)
+	if err != nil {
+		return ImportProductsResult{}, err
+	}
+
+	products, err := s.repo.GetAllProducts(ctx)
+	if err != nil {
+		return ImportProductsResult{}, err
+	}
+	categories, err := s.repo.GetCategories(ctx)
+	if err != nil {
+		return ImportProductsResult{}, err
+	}
+
+	before := make(map[string]entity.Product, len(products))
+	for _, product := range products {
+		before[product.ID] = product
+	}
+
+	c := catalogImport{
+		products:   make(map[string]entity.Product, len(products)),
+		skus:       make(map[string]catalogSKU),
+		categories: categories,
+		updates:    make(map[string]*ProductUpdate),
+	}
+	for _, product := range products {
+		c.products[product.ID] = product
+		c.index(product)
+	}
+
+	result := ImportProductsResult{DryRun: prs.DryRun, Errors: []ImportRowError{}}
+	for _, line := range lines {
+		var created bool
+		err := line.Err
+		if err == nil {
+			created, err = c.apply(line.Row)
+		}
+		switch {
+		case err != nil:
+			result.Errors = append(result.Errors, ImportRowError{Line: int32(line.Line), SKU: strings.TrimSpace(line.Row.SKU), Message: err.Error()})
+		case created:
+			result.Created++
+		default:
+			result.Updated++
+		}
+	}
+	if len(result.Errors) > 0 || prs.DryRun {
+		return result, nil
+	}
+
+	// categories named in the file that don't exist yet are created by the repo now that the import is applied
+	updates := make([]ProductUpdate, len(c.changed))
+	for i, id := range c.changed {
+		updates[i] = *c.updates[id]
+	}
+	changed, err := s.repo.SaveProducts(ctx, updates)
+	if err != nil {
+		return ImportProductsResult{}, err
+	}
+	for _, product := range changed {
+		previous, ok := before[product.ID]
+		if !ok {
+			continue
+		}
+		err = s.recordPriceChanges(ctx, previous, product, entity.PriceChange{
+			ActorID: prs.ActorID,
+			Source:  entity.PriceChangeSourceImport,
+		})
+		if err != nil {
+			return ImportProductsResult{}, err
+		}
+		s.notifyBackInStock(ctx, previous.InStock, product)
+	}
+
+	result.Applied = true
+	return result, nil
+}
+
+// ExportProducts writes the products on sale and their variants, in the format of the imports
+func (q *query) ExportProducts(ctx context.Context, prs ExportProductsParams, w io.Writer) error {
+	products, err := q.repo.GetAllProducts(ctx)
+	if err != nil {
+		return err
+	}
+	sort.Slice(products, func(i, j int) bool {
+		if products[i].Name == products[j].Name {
+			return products[i].ID < products[j].ID
+		}
+		return products[i].Name < products[j].Name
+	})
+
+	var rows []CatalogRow
+	for _, product := range products {
+		if product.IsArchived() {
+			continue
+		}
+		rows = append(rows, productCatalogRow(product))
+		for _, variant := range product.Variants {
+			rows = append(rows, variantCatalogRow(product, variant))
+		}
+	}
+
+	return writeCatalog(prs.Format, w, rows)
+}
+
+// apply applies the row to the catalog and reports whether it created a product or variant
+func (c *catalogImport) apply(row CatalogRow) (bool, error) {
+	sku := strings.TrimSpace(row.SKU)
+	parentSKU := strings.TrimSpace(row.ParentSKU)
+	if sku == "" {
+		return false, errors.New("sku is required")
+	}
+	if sku == parentSKU {
+		return false, errors.New("parent_sku must be the SKU of another product")
+	}
+
+	existing, found := c.skus[sku]
+	switch {
+	case found && existing.variantID != "":
+		return false, c.updateVariant(existing, parentSKU, row)
+	case found && parentSKU != "":
+		return false, fmt.Errorf("SKU %s is a product, it can't become a variant", sku)
+	case found:
+		return false, c.updateProduct(existing.productID, row)
+	case parentSKU != "":
+		return true, c.createVariant(sku, parentSKU, row)
+	default:
+		return true, c.createProduct(sku, row)
+	}
+}
+
+func (c *catalogImport) createProduct(sku string, row CatalogRow) error {
+	if row.Name == nil || row.Price == nil || row.Category == nil {
+		return errors.New("name, price and category are required for new products")
+	}
+
+	product := entity.Product{ID: uuid.NewString(), SKU: sku}
+	c.update(product.ID).Created = true
+	if err := c.bindProduct(&product, row); err != nil {
+		return err
+	}
+
+	return c.save(product)
+}
+
+func (c *catalogImport) updateProduct(id string, row CatalogRow) error {
+	product := cloneProduct(c.products[id])
+	if row.InStock != nil && product.HasVariants() {
+		return errors.New("the stock of a product with variants is set on its variants")
+	}
+	if err := c.bindProduct(&product, row); err != nil {
+		return err
+	}
+
+	return c.save(product)
+}
+
+func (c *catalogImport) createVariant(sku string, parentSKU string, row CatalogRow) error {
+	parent, found := c.skus[parentSKU]
+	if !found || parent.variantID != "" {
+		return fmt.Errorf("parent product %s not found", parentSKU)
+	}
+	if row.Options == nil {
+		return errors.New("options are required for new variants")
+	}
+
+	product := cloneProduct(c.products[parent.productID])
+	product.Variants = append(product.Variants, entity.ProductVariant{ID: uuid.NewString(), SKU: sku})
+	if err := c.bindVariant(product.ID, &product.Variants[len(product.Variants)-1], row); err != nil {
+		return err
+	}
+
+	return c.save(product)
+}
+
+func (c *catalogImport) updateVariant(existing catalogSKU, parentSKU string, row CatalogRow) error {
+	if parentSKU != "" && c.skus[parentSKU] != (catalogSKU{productID: existing.productID}) {
+		return errors.New("the variant belongs to another product")
+	}
+
+	product := cloneProduct(c.products[existing.productID])
+	if err := c.bindVariant(product.ID, &product.Variants[product.Variant(existing.variantID)], row); err != nil {
+		return err
+	}
+
+	return c.save(product)
+}
+
+// bindProduct sets the fields of a product row and records them, variants only have a price, a stock and options
+func (c *catalogImport) bindProduct(product *entity.Product, row CatalogRow) error {
+	fields := c.update(product.ID).Fields
+	if row.Name != nil {
+		fields[ProductFieldName] = true
+		product.Name = strings.TrimSpace(*row.Name)
+		if product.Name == "" {
+			return errors.New("name cannot be empty")
+		}
+	}
+	if row.Description != nil {
+		fields[ProductFieldDescription] = true
+		product.Description = strings.TrimSpace(*row.Description)
+	}
+	if row.Price != nil {
+		fields[ProductFieldPrice] = true
+		price, err := parseCatalogPrice(*row.Price)
+		if err != nil {
+			return err
+		}
+		product.Price = price
+	}
+	if row.InStock != nil {
+		fields[ProductFieldInStock] = true
+		if *row.InStock < 0 {
+			return errors.New("stock cannot be negative")
+		}
+		product.InStock = *row.InStock
+	}
+	if row.Category != nil {
+		fields[ProductFieldCategory] = true
+		name := strings.TrimSpace(*row.Category)
+		if name == "" {
+			return errors.New("category cannot be empty")
+		}
+		if !strings.EqualFold(name, product.Category) {
+			category := c.category(name)
+			product.CategoryID = category.ID
+			product.Category = category.Name
+		}
+	}
+	if row.Options != nil {
+		fields[ProductFieldOptions] = true
+		options, err := parseProductOptions(*row.Options)
+		if err != nil {
+			return err
+		}
+		product.Options = options
+	}
+	return nil
+}
+
+func (c *catalogImport) bindVariant(productID string, variant *entity.ProductVariant, row CatalogRow) error {
+	if row.Name != nil || row.Description != nil || row.Category != nil {
+		return errors.New("name, description and category are set on the product row, not on its variants")
+	}
+
+	update := c.update(productID)
+	fields := update.VariantFields[variant.ID]
+	if fields == nil {
+		fields = make(map[ProductField]bool)
+		update.VariantFields[variant.ID] = fields
+	}
+	if row.Price != nil {
+		fields[ProductFieldPrice] = true
+		price, err := parseCatalogPrice(*row.Price)
+		if err != nil {
+			return err
+		}
+		variant.Price = &price
+	}
+	if row.InStock != nil {
+		fields[ProductFieldInStock] = true
+		variant.InStock = *row.InStock
+	}
+	if row.Options != nil {
+		fields[ProductFieldOptions] = true
+		options, err := parseVariantOptions(*row.Options)
+		if err != nil {
+			return err
+		}
+		variant.Options = options
+	}
+	return nil
+}
+
+// save checks the product and replaces it in the catalog copy
+func (c *catalogImport) save(product entity.Product) error {
+	if err := normalizeProduct(&product); err != nil {
+		return err
+	}
+	if product.HasVariants() {
+		sumVariantStock(&product)
+	}
+
+	c.update(product.ID).Product = product
+	c.products[product.ID] = product
+	c.index(product)
+	return nil
+}
+
+// update returns the update of the product, the product is added to the changed ones the first time
+func (c *catalogImport) update(id string) *ProductUpdate {
+	update, ok := c.updates[id]
+	if !ok {
+		update = &ProductUpdate{Fields: make(map[ProductField]bool), VariantFields: make(map[string]map[ProductField]bool)}
+		c.updates[id] = update
+		c.changed = append(c.changed, id)
+	}
+	return update
+}
+
+func (c *catalogImport) index(product entity.Product) {
+	if product.SKU != "" {
+		c.skus[product.SKU] = catalogSKU{productID: product.ID}
+	}
+	for _, variant := range product.Variants {
+		c.skus[variant.SKU] = catalogSKU{productID: product.ID, variantID: variant.ID}
+	}
+}
+
+// category finds the category of the slug or the top-level category of the name. Categories that don't exist yet
+// have no ID, they are created when the import is applied.
+func (c *catalogImport) category(name string) entity.Category {
+	for _, category := range c.categories {
+		if category.Slug == name {
+			return category
+		}
+	}
+	for _, category := range c.categories {
+		if category.IsTopLevel() && strings.EqualFold(category.Name, name) {
+			return category
+		}
+	}
+	return entity.Category{Name: name}
+}
+
+func cloneProduct(product entity.Product) entity.Product {
+	product.Options = slices.Clone(product.Options)
+	product.Variants = slices.Clone(product.Variants)
+	return product
+}
+
+func parseCatalogPrice(value json.Number) (entity.Money, error) {
+	price, err := entity.ParseMoney(value.String(), entity.DefaultCurrency)
+	if err != nil {
+		return entity.Money{}, err
+	}
+	if price.IsNegative() {
+		return entity.Money{}, errors.New("price cannot be negative")
+	}
+	return price, nil
+}
+
+// parseProductOptions parses "Size=S|M; Color=Blue"
+func parseProductOptions(s string) ([]entity.ProductOption, error) {
+	var options []entity.ProductOption
+	for _, part := range splitOptions(s) {
+		name, values, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("option %q must be a name and values, e.g. Size=S|M", part)
+		}
+		options = append(options, entity.ProductOption{Name: name, Values: strings.Split(values, "|")})
+	}
+	return options, nil
+}
+
+// parseVariantOptions parses "Size=S; Color=Blue"
+func parseVariantOptions(s string) ([]entity.VariantOption, error) {
+	var options []entity.VariantOption
+	for _, part := range splitOptions(s) {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok || strings.Contains(value, "|") {
+			return nil, fmt.Errorf("option %q must be a name and a value, e.g. Size=S", part)
+		}
+		options = append(options, entity.VariantOption{Name: name, Value: value})
+	}
+	return options, nil
+}
+
+func splitOptions(s string) []string {
+	var parts []string
+	for _, part := range strings.Split(s, ";") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+func productCatalogRow(product entity.Product) CatalogRow {
+	price := json.Number(product.Price.String())
+	row := CatalogRow{
+		SKU:      product.SKU,
+		Name:     &product.Name,
+		Price:    &price,
+		Category: &product.Category,
+	}
+	if product.Description != "" {
+		row.Description = &product.Description
+	}
+	// the stock of a product with variants is the total of its variants, it is not imported
+	if !product.HasVariants() {
+		row.InStock = &product.InStock
+	}
+	if len(product.Options) > 0 {
+		options := make([]string, len(product.Options))
+		for i, option := range product.Options {
+			options[i] = option.Name + "=" + strings.Join(option.Values, "|")
+		}
+		joined := strings.Join(options, "; ")
+		row.Options = &joined
+	}
+	return row
+}
+
+func variantCatalogRow(product entity.Product, variant entity.ProductVariant) CatalogRow {
+	options := make([]string, len(variant.Options))
+	for i, option := range variant.Options {
+		options[i] = option.Name + "=" + option.Value
+	}
+	joined := strings.Join(options, "; ")
+	row := CatalogRow{
+		SKU:       variant.SKU,
+		ParentSKU: product.SKU,
+		InStock:   &variant.InStock,
+		Options:   &joined,
+	}
+	if variant.Price != nil {
+		price := json.Number(variant.Price.String())
+		row.Price = &price
+	}
+	return row
+}
+
+// readCatalog reads the rows of the file, lines that can't be read are returned with their error.
+// CSV files start with a header naming their columns, in any order.
+func readCatalog(format CatalogFormat, r io.Reader) ([]catalogLine, error) {
+	switch format {
+	case CatalogFormatCSV:
+		return readCatalogCSV(r)
+	case CatalogFormatJSONL:
+		return readCatalogJSONL(r)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+func readCatalogCSV(r io.Reader) ([]catalogLine, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the CSV header: %w", err)
+	}
+	for i, column := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(column))
+		if !slices.Contains(catalogColumns, header[i]) {
+			return nil, fmt.Errorf("unknown column %q, the columns are %s", column, strings.Join(catalogColumns, ", "))
+		}
+	}
+	if !slices.Contains(header, "sku") {
+		return nil, errors.New("the sku column is required")
+	}
+
+	var lines []catalogLine
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return lines, nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			lines = append(lines, catalogLine{Line: parseErr.Line, Err: parseErr.Err})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		values := make(map[string]string, len(header))
+		for i, column := range header {
+			values[column] = record[i]
+		}
+		line := catalogLine{}
+		line.Line, _ = reader.FieldPos(0)
+		line.Err = bindCSVRow(&line.Row, values)
+		lines = append(lines, line)
+	}
+}
+
+// bindCSVRow sets the fields of the row from the cells, empty cells are left nil
+func bindCSVRow(row *CatalogRow, values map[string]string) error {
+	cell := func(column string) *string {
+		if value := values[column]; value != "" {
+			return &value
+		}
+		return nil
+	}
+
+	row.SKU = values["sku"]
+	row.ParentSKU = values["parent_sku"]
+	row.Name = cell("name")
+	row.Description = cell("description")
+	row.Category = cell("category")
+	row.Options = cell("options")
+	if price := cell("price"); price != nil {
+		number := json.Number(strings.TrimSpace(*price))
+		row.Price = &number
+	}
+	if stock := cell("in_stock"); stock != nil {
+		n, err := strconv.ParseInt(strings.TrimSpace(*stock), 10, 32)
+		if err != nil {
+			return fmt.Errorf("in_stock %q is not a whole number", *stock)
+		}
+		inStock := int32(n)
+		row.InStock = &inStock
+	}
+	return nil
+}
+
+func readCatalogJSONL(r io.Reader) ([]catalogLine, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+
+	var lines []catalogLine
+	for n := 1; scanner.Scan(); n++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		line := catalogLine{Line: n}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&line.Row); err != nil {
+			line.Err = fmt.Errorf("invalid JSON: %w", err)
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+func writeCatalog(format CatalogFormat, w io.Writer, rows []CatalogRow) error {
+	switch format {
+	case CatalogFormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(catalogColumns); err != nil {
+			return err
+		}
+		value := func(s *string) string {
+			if s == nil {
+				return ""
+			}
+			return *s
+		}
+		for _, row := range rows {
+			var price, inStock string
+			if row.Price != nil {
+				price = row.Price.String()
+			}
+			if row.InStock != nil {
+				inStock = strconv.Itoa(int(*row.InStock))
+			}
+			err := writer.Write([]string{row.SKU, row.ParentSKU, value(row.Name), value(row.Description), price, value(row.Category), inStock, value(row.Options)})
+			if err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	case CatalogFormatJSONL:
+		encoder := json.NewEncoder(w)
+		for _, row := range rows {
+			if err := encoder.Encode(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+type ImportProductsParams struct {
+	Format  CatalogFormat
+	Content io.Reader
+	// DryRun validates the rows without saving anything
+	DryRun bool
+	// ActorID is the user or API key importing the file, it is recorded in the price history
+	ActorID string
+}
+
+type ImportProductsResult struct {
+	DryRun bool
+	// Applied is false for dry runs and when any row failed, nothing is changed then
+	Applied bool
+	// Created and Updated count the products and variants of the rows that succeeded
+	Created int32
+	Updated int32
+	Errors  []ImportRowError
+}
+
+type ImportRowError struct {
+	Line    int32
+	SKU     string
+	Message string
+}
+
+type ExportProductsParams struct {
+	Format CatalogFormat
+}
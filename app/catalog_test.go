@@ -0,0 +1,52 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"graphql-backend/entity"
+)
+
+func TestProductUpdateMergeKeepsTheFieldsNotSet(t *testing.T) {
+	price := entity.NewMoney(1200, "USD")
+	imported := entity.Product{
+		ID:          "shirt",
+		Name:        "Shirt",
+		Description: "Cotton",
+		Price:       entity.NewMoney(2500, "USD"),
+		InStock:     7,
+		Variants: []entity.ProductVariant{
+			{ID: "s", SKU: "SHIRT-S", InStock: 3, Price: &price},
+			{ID: "m", SKU: "SHIRT-M", InStock: 4},
+			{ID: "l", SKU: "SHIRT-L", InStock: 2},
+		},
+	}
+	update := ProductUpdate{
+		Product:       imported,
+		Fields:        map[ProductField]bool{ProductFieldPrice: true},
+		VariantFields: map[string]map[ProductField]bool{"s": {ProductFieldPrice: true}},
+	}
+
+	// checkouts took stock and the description was edited while the file was imported
+	stored := entity.Product{
+		ID:          "shirt",
+		Name:        "Shirt",
+		Description: "Organic cotton",
+		Price:       entity.NewMoney(2000, "USD"),
+		InStock:     5,
+		Variants: []entity.ProductVariant{
+			{ID: "s", SKU: "SHIRT-S", InStock: 1},
+			{ID: "m", SKU: "SHIRT-M", InStock: 4},
+		},
+	}
+
+	merged := update.Merge(stored)
+	require.Equal(t, imported.Price, merged.Price)
+	require.Equal(t, "Organic cotton", merged.Description)
+	require.Equal(t, &price, merged.Variants[0].Price)
+	require.Equal(t, int32(1), merged.Variants[0].InStock)
+	// the variant added by the import comes whole and the stock is summed again
+	require.Equal(t, imported.Variants[2], merged.Variants[2])
+	require.Equal(t, int32(7), merged.InStock)
+	require.Len(t, stored.Variants, 2)
+}
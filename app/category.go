@@ -0,0 +1,228 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"graphql-backend/entity"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var categorySlugFormat = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// CategoryTree is a category with its subcategories
+type CategoryTree struct {
+	Category entity.Category
+	Children []CategoryTree
+}
+
+func (s service) CreateCategory(ctx context.Context, prs CreateCategoryParams) (entity.Category, error) {
+	now := time.Now()
+	category := entity.Category{
+		ID:        uuid.NewString(),
+		Name:      prs.Name,
+		Slug:      prs.Slug,
+		ParentID:  prs.ParentID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	err := normalizeCategory(&category)
+	if err != nil {
+		return entity.Category{}, err
+	}
+
+	err = s.repo.CreateCategory(ctx, category)
+	if err != nil {
+		return entity.Category{}, err
+	}
+
+	return category, nil
+}
+
+// UpdateCategory renames or moves a category, the repo checks that it is not moved below itself
+func (s service) UpdateCategory(ctx context.Context, prs UpdateCategoryParams) (entity.Category, error) {
+	category, err := s.repo.GetCategoryByID(ctx, prs.ID)
+	if err != nil {
+		return entity.Category{}, err
+	}
+
+	prs.BindToCategory(&category)
+	err = normalizeCategory(&category)
+	if err != nil {
+		return entity.Category{}, err
+	}
+
+	category.UpdatedAt = time.Now()
+	err = s.repo.UpdateCategory(ctx, category)
+	if err != nil {
+		return entity.Category{}, err
+	}
+
+	return category, nil
+}
+
+func (s service) DeleteCategory(ctx context.Context, id string) error {
+	return s.repo.DeleteCategory(ctx, id)
+}
+
+// setProductCategory puts the product in the category of the ID, or in the top-level category of the name.
+// Products can still be given a category name as before categories were entities, the repo finds the category
+// when the product is stored and creates it when missing.
+func (s service) setProductCategory(ctx context.Context, product *entity.Product, categoryID string, name string) error {
+	switch name = strings.TrimSpace(name); {
+	case categoryID != "":
+		category, err := s.repo.GetCategoryByID(ctx, categoryID)
+		if err != nil {
+			return err
+		}
+		product.CategoryID = category.ID
+		product.Category = category.Name
+	case name != "":
+		product.CategoryID = ""
+		product.Category = name
+	default:
+		return errors.New("category cannot be empty")
+	}
+	return nil
+}
+
+// productCategories returns the category of every product followed by its ancestors, by product ID
+func (s service) productCategories(ctx context.Context, products map[string]entity.Product) (map[string][]entity.Category, error) {
+	categories, err := s.repo.GetCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]entity.Category, len(categories))
+	for _, category := range categories {
+		byID[category.ID] = category
+	}
+	paths := make(map[string][]entity.Category, len(products))
+	for id, product := range products {
+		paths[id] = entity.CategoryPath(byID, product.CategoryID)
+	}
+	return paths, nil
+}
+
+// GetCategories returns the tree of all categories, sorted by name
+func (q *query) GetCategories(ctx context.Context) ([]CategoryTree, error) {
+	categories, err := q.repo.GetCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return categoryTree(categories, ""), nil
+}
+
+// GetCategory returns the category of the ID or the slug along with its subcategories
+func (q *query) GetCategory(ctx context.Context, prs CategoryParams) (CategoryTree, error) {
+	var category entity.Category
+	var err error
+	switch {
+	case prs.ID != "":
+		category, err = q.repo.GetCategoryByID(ctx, prs.ID)
+	case prs.Slug != "":
+		category, err = q.repo.GetCategoryBySlug(ctx, prs.Slug)
+	default:
+		return CategoryTree{}, errors.New("category ID or slug is required")
+	}
+	if err != nil {
+		return CategoryTree{}, err
+	}
+
+	categories, err := q.repo.GetCategories(ctx)
+	if err != nil {
+		return CategoryTree{}, err
+	}
+
+	return CategoryTree{Category: category, Children: categoryTree(categories, category.ID)}, nil
+}
+
+// categoryTree builds the trees of the subcategories of the parent, the top-level categories for an empty parent
+func categoryTree(categories []entity.Category, parentID string) []CategoryTree {
+	children := make(map[string][]entity.Category, len(categories))
+	for _, category := range categories {
+		children[category.ParentID] = append(children[category.ParentID], category)
+	}
+
+	var build func(parentID string) []CategoryTree
+	build = func(parentID string) []CategoryTree {
+		level := children[parentID]
+		sort.Slice(level, func(i, j int) bool {
+			if level[i].Name == level[j].Name {
+				return level[i].ID < level[j].ID
+			}
+			return level[i].Name < level[j].Name
+		})
+
+		trees := make([]CategoryTree, len(level))
+		for i, category := range level {
+			trees[i] = CategoryTree{Category: category, Children: build(category.ID)}
+		}
+		return trees
+	}
+
+	return build(parentID)
+}
+
+// normalizeCategory trims the name and derives the slug from it when none is given
+func normalizeCategory(e *entity.Category) error {
+	e.Name = strings.TrimSpace(e.Name)
+	if e.Name == "" {
+		return errors.New("category name cannot be empty")
+	}
+
+	e.Slug = strings.TrimSpace(e.Slug)
+	if e.Slug == "" {
+		e.Slug = entity.Slugify(e.Name)
+	}
+	if !categorySlugFormat.MatchString(e.Slug) || len(e.Slug) > 64 {
+		return errors.New("slug must be up to 64 lowercase letters, digits and single dashes")
+	}
+
+	return nil
+}
+
+type CreateCategoryParams struct {
+	Name string
+	// Slug is derived from the name when empty
+	Slug string
+	// ParentID is empty for a top-level category
+	ParentID string
+}
+
+type UpdateCategoryParams struct {
+	ID string
+
+	Name     *string
+	Slug     *string
+	ParentID *string
+	// MoveToTop makes the category a top-level category
+	MoveToTop bool
+}
+
+func (p *UpdateCategoryParams) BindToCategory(e *entity.Category) {
+	if e == nil {
+		return
+	}
+	if p.Name != nil {
+		e.Name = *p.Name
+	}
+	if p.Slug != nil {
+		e.Slug = *p.Slug
+	}
+	if p.ParentID != nil {
+		e.ParentID = *p.ParentID
+	}
+	if p.MoveToTop {
+		e.ParentID = ""
+	}
+}
+
+type CategoryParams struct {
+	ID   string
+	Slug string
+}
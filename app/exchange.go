@@ -0,0 +1,43 @@
+package app
+
+import (
+	"context"
+	"graphql-backend/entity"
+	"strings"
+)
+
+// ExchangeRateProvider gives the rates to convert prices from the base currency, e.g. from a file or a market data service
+type ExchangeRateProvider interface {
+	// Rate returns the price of one unit of the from currency in the to currency
+	Rate(ctx context.Context, from string, to string) (float64, error)
+}
+
+// exchangeRate returns the ISO 4217 code of the requested currency and its rate from the base currency,
+// an empty currency is the base currency
+func exchangeRate(ctx context.Context, rates ExchangeRateProvider, currency string) (string, float64, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" || currency == entity.DefaultCurrency {
+		return entity.DefaultCurrency, 1, nil
+	}
+
+	rate, err := rates.Rate(ctx, entity.DefaultCurrency, currency)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return currency, rate, nil
+}
+
+// convertPrices converts the prices of the products from the base currency
+func convertPrices(ctx context.Context, rates ExchangeRateProvider, products []entity.Product, currency string) error {
+	currency, rate, err := exchangeRate(ctx, rates, currency)
+	if err != nil {
+		return err
+	}
+
+	for i := range products {
+		products[i] = products[i].Convert(currency, rate)
+	}
+
+	return nil
+}
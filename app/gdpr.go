@@ -0,0 +1,311 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"graphql-backend/entity"
+	"time"
+)
+
+const DataExportExpiration = 7 * 24 * time.Hour
+
+var ErrDataExportExpired = errors.New("data export has expired")
+
+// RequestDataExport builds a JSON archive of the user's profile, addresses, orders, payments, returns, reviews, wishlist and sessions.
+// The archive can be downloaded until it expires.
+func (s service) RequestDataExport(ctx context.Context, prs DataExportParams) (entity.DataExport, error) {
+	user, err := s.repo.GetUserByID(ctx, prs.UserID)
+	if err != nil {
+		return entity.DataExport{}, err
+	}
+
+	if user.GetStatus() == entity.UserStatusDeleted {
+		return entity.DataExport{}, errors.New("user account is deleted")
+	}
+
+	orders, err := s.repo.GetOrdersByUserIDs(ctx, []string{user.ID})
+	if err != nil {
+		return entity.DataExport{}, err
+	}
+	payments, returns, err := s.getOrderRecords(ctx, orders[user.ID])
+	if err != nil {
+		return entity.DataExport{}, err
+	}
+	sessions, err := s.repo.GetSessionsByUserID(ctx, user.ID)
+	if err != nil {
+		return entity.DataExport{}, err
+	}
+	addresses, err := s.repo.GetAddressesByUserID(ctx, user.ID)
+	if err != nil {
+		return entity.DataExport{}, err
+	}
+	reviews, err := s.repo.GetReviewsByUserID(ctx, user.ID)
+	if err != nil {
+		return entity.DataExport{}, err
+	}
+	wishlist, err := s.repo.GetWishlist(ctx, user.ID)
+	if err != nil {
+		return entity.DataExport{}, err
+	}
+
+	now := time.Now()
+	archive := UserDataArchive{
+		ExportedAt: now,
+		Profile: UserProfileArchive{
+			ID:         user.ID,
+			Name:       user.Name,
+			Email:      user.Email,
+			Role:       user.Role,
+			Status:     user.GetStatus(),
+			Identities: user.Identities,
+			CreatedAt:  user.CreatedAt,
+			UpdatedAt:  user.UpdatedAt,
+		},
+		Addresses: addresses,
+		Orders:    orders[user.ID],
+		Payments:  payments,
+		Returns:   returns,
+		Reviews:   reviews,
+		Wishlist:  wishlist.Items,
+		Sessions:  sessions,
+	}
+	if user.PendingEmail != nil {
+		archive.Profile.PendingEmail = &user.PendingEmail.Email
+	}
+	if archive.Orders == nil {
+		archive.Orders = []entity.Order{}
+	}
+	if archive.Sessions == nil {
+		archive.Sessions = []entity.Session{}
+	}
+
+	content, err := json.MarshalIndent(archive, "", "  ")
+	if err != nil {
+		return entity.DataExport{}, err
+	}
+
+	export := entity.DataExport{
+		ID:          uuid.NewString(),
+		UserID:      user.ID,
+		RequestedBy: prs.RequestedBy,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(DataExportExpiration),
+	}
+	err = s.repo.CreateDataExport(ctx, export, content)
+	if err != nil {
+		return entity.DataExport{}, err
+	}
+
+	err = s.recordDataRequest(ctx, prs.RequestedBy, user.ID, entity.AuditActionDataExport, "requestDataExport")
+	if err != nil {
+		return entity.DataExport{}, err
+	}
+
+	return export, nil
+}
+
+// DeleteAccount anonymizes the user and removes their addresses, reviews, wishlist and cart items, the orders are kept for accounting and still point to the user ID.
+// So are their payments and returns, without the card digits and the reasons the user gave.
+// Sessions are revoked and previous data exports are removed.
+func (s service) DeleteAccount(ctx context.Context, prs DeleteAccountParams) (entity.User, error) {
+	user, err := s.repo.GetUserByID(ctx, prs.UserID)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	if user.GetStatus() == entity.UserStatusDeleted {
+		return user, nil
+	}
+
+	if user.Role == entity.RoleAdmin && prs.RequestedBy != user.ID {
+		return entity.User{}, errors.New("cannot delete another admin")
+	}
+
+	now := time.Now()
+	user.Name = "Deleted User"
+	user.Email = fmt.Sprintf("deleted+%s@invalid", user.ID)
+	user.Password = ""
+	user.Identities = nil
+	user.PendingEmail = nil
+	user.Status = entity.UserStatusDeleted
+	user.UpdatedAt = now
+	err = s.repo.UpdateUser(ctx, user)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	err = s.repo.RevokeUserSessions(ctx, user.ID, now)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	err = s.repo.DeleteUserDataExports(ctx, user.ID)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	err = s.repo.DeleteUserReviews(ctx, user.ID)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	err = s.repo.DeleteWishlist(ctx, user.ID)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	if cart, err := s.repo.GetCartByUserID(ctx, user.ID); err == nil {
+		cart.Items = []entity.CartItem{}
+		cart.UpdatedAt = now
+		err = s.repo.SaveCart(ctx, cart)
+		if err != nil {
+			return entity.User{}, err
+		}
+	}
+
+	// orders keep their own copy of the addresses they were placed with
+	addresses, err := s.repo.GetAddressesByUserID(ctx, user.ID)
+	if err != nil {
+		return entity.User{}, err
+	}
+	for _, address := range addresses {
+		err = s.repo.DeleteAddress(ctx, address.ID)
+		if err != nil {
+			return entity.User{}, err
+		}
+	}
+
+	orders, err := s.repo.GetOrdersByUserIDs(ctx, []string{user.ID})
+	if err != nil {
+		return entity.User{}, err
+	}
+	payments, returns, err := s.getOrderRecords(ctx, orders[user.ID])
+	if err != nil {
+		return entity.User{}, err
+	}
+	for _, payment := range payments {
+		if payment.CardLast4 == "" {
+			continue
+		}
+		payment.CardLast4 = ""
+		payment.UpdatedAt = now
+		err = s.repo.UpdatePayment(ctx, payment)
+		if err != nil {
+			return entity.User{}, err
+		}
+	}
+	for _, ret := range returns {
+		if ret.Reason == "" {
+			continue
+		}
+		ret.Reason = ""
+		ret.UpdatedAt = now
+		err = s.repo.UpdateReturn(ctx, ret)
+		if err != nil {
+			return entity.User{}, err
+		}
+	}
+
+	err = s.recordDataRequest(ctx, prs.RequestedBy, user.ID, entity.AuditActionDeleteAccount, "deleteAccount")
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	return user, nil
+}
+
+// getOrderRecords returns the payments and returns of the orders, in the order of the orders
+func (s service) getOrderRecords(ctx context.Context, orders []entity.Order) ([]entity.Payment, []entity.ReturnRequest, error) {
+	orderIDs := make([]string, 0, len(orders))
+	for _, order := range orders {
+		orderIDs = append(orderIDs, order.ID)
+	}
+
+	paymentsByOrder, err := s.repo.GetPaymentsByOrderIDs(ctx, orderIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	returnsByOrder, err := s.repo.GetReturnsByOrderIDs(ctx, orderIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	payments := []entity.Payment{}
+	returns := []entity.ReturnRequest{}
+	for _, id := range orderIDs {
+		payments = append(payments, paymentsByOrder[id]...)
+		returns = append(returns, returnsByOrder[id]...)
+	}
+
+	return payments, returns, nil
+}
+
+func (s service) recordDataRequest(ctx context.Context, actorID, userID, action, operation string) error {
+	return s.repo.CreateAuditEntry(ctx, entity.AuditEntry{
+		ID:        uuid.NewString(),
+		ActorID:   actorID,
+		UserID:    userID,
+		Action:    action,
+		Operation: operation,
+		Succeeded: true,
+		CreatedAt: time.Now(),
+	})
+}
+
+// GetDataExportContent returns the archive of an export that has not expired yet
+func (q *query) GetDataExportContent(ctx context.Context, id string) (entity.DataExport, []byte, error) {
+	export, err := q.repo.GetDataExport(ctx, id)
+	if err != nil {
+		return entity.DataExport{}, nil, err
+	}
+
+	if export.IsExpired(time.Now()) {
+		return entity.DataExport{}, nil, ErrDataExportExpired
+	}
+
+	content, err := q.repo.GetDataExportContent(ctx, id)
+	if err != nil {
+		return entity.DataExport{}, nil, err
+	}
+
+	return export, content, nil
+}
+
+type DataExportParams struct {
+	UserID      string
+	RequestedBy string
+}
+
+type DeleteAccountParams struct {
+	UserID      string
+	RequestedBy string
+}
+
+// UserDataArchive is the content of a data export
+type UserDataArchive struct {
+	ExportedAt time.Time              `json:"exported_at"`
+	Profile    UserProfileArchive     `json:"profile"`
+	Addresses  []entity.Address       `json:"addresses"`
+	Orders     []entity.Order         `json:"orders"`
+	Payments   []entity.Payment       `json:"payments"`
+	Returns    []entity.ReturnRequest `json:"returns"`
+	Reviews    []entity.Review        `json:"reviews"`
+	Wishlist   []entity.WishlistItem  `json:"wishlist"`
+	Sessions   []entity.Session       `json:"sessions"`
+}
+
+// UserProfileArchive is the exported profile, credentials and verification tokens are left out
+type UserProfileArchive struct {
+	ID           string                `json:"id"`
+	Name         string                `json:"name"`
+	Email        string                `json:"email"`
+	Role         string                `json:"role"`
+	Status       entity.UserStatus     `json:"status"`
+	Identities   []entity.UserIdentity `json:"identities,omitempty"`
+	PendingEmail *string               `json:"pending_email,omitempty"`
+	CreatedAt    time.Time             `json:"created_at"`
+	UpdatedAt    time.Time             `json:"updated_at"`
+}
@@ -0,0 +1,168 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"graphql-backend/entity"
+	"graphql-backend/pkg/imaging"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	// MaxImageSize is the largest image file accepted, in bytes
+	MaxImageSize = 5 << 20
+	// ThumbnailSize is the width and height the thumbnails fit in
+	ThumbnailSize = 256
+
+	// maxImagePixels bounds the memory taken to decode an image for its thumbnail, about 4 bytes per pixel
+	maxImagePixels   = 16_000_000
+	maxProductImages = 20
+)
+
+// BlobStorage keeps binary content, e.g. product images, under slash separated keys.
+// Get returns an error for missing keys, blob.ErrNotFound for the local storage.
+type BlobStorage interface {
+	// Put creates or replaces the blob of the key
+	Put(ctx context.Context, key string, content []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// imageExtensions are the accepted image types, detected from the content rather than trusted from the upload
+var imageExtensions = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+}
+
+// UploadProductImage validates the image, stores it with a thumbnail and adds it to the product images
+func (s service) UploadProductImage(ctx context.Context, prs UploadProductImageParams) (entity.Product, error) {
+	if prs.Size > MaxImageSize {
+		return entity.Product{}, fmt.Errorf("image cannot be larger than %d MB", MaxImageSize>>20)
+	}
+	content, err := io.ReadAll(io.LimitReader(prs.Content, MaxImageSize+1))
+	if err != nil {
+		return entity.Product{}, err
+	}
+	if len(content) > MaxImageSize {
+		return entity.Product{}, fmt.Errorf("image cannot be larger than %d MB", MaxImageSize>>20)
+	}
+
+	contentType := http.DetectContentType(content)
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		return entity.Product{}, errors.New("image must be a JPEG, PNG or GIF")
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return entity.Product{}, errors.New("image is corrupted")
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return entity.Product{}, errors.New("image has too many pixels")
+	}
+	thumbnail, thumbnailExt, err := makeThumbnail(content, contentType)
+	if err != nil {
+		return entity.Product{}, err
+	}
+
+	// the limit is checked before the blobs are stored, and again by the repo when the image is added
+	product, err := s.repo.GetProductByID(ctx, prs.ProductID)
+	if err != nil {
+		return entity.Product{}, err
+	}
+	if len(product.Images) >= maxProductImages {
+		return entity.Product{}, fmt.Errorf("a product cannot have more than %d images", maxProductImages)
+	}
+
+	id := uuid.NewString()
+	img := entity.ProductImage{
+		ID:           id,
+		Key:          fmt.Sprintf("products/%s/%s.%s", product.ID, id, ext),
+		ThumbnailKey: fmt.Sprintf("products/%s/%s-thumb.%s", product.ID, id, thumbnailExt),
+		ContentType:  contentType,
+		Width:        int32(config.Width),
+		Height:       int32(config.Height),
+		Size:         int64(len(content)),
+		CreatedAt:    time.Now(),
+	}
+	if err := s.blobs.Put(ctx, img.Key, content); err != nil {
+		return entity.Product{}, err
+	}
+	if err := s.blobs.Put(ctx, img.ThumbnailKey, thumbnail); err != nil {
+		s.deleteImageBlobs(ctx, img)
+		return entity.Product{}, err
+	}
+
+	product, err = s.repo.AddProductImage(ctx, product.ID, img, maxProductImages)
+	if err != nil {
+		s.deleteImageBlobs(ctx, img)
+		return entity.Product{}, err
+	}
+
+	return product, nil
+}
+
+func (s service) DeleteProductImage(ctx context.Context, prs DeleteProductImageParams) (entity.Product, error) {
+	product, img, err := s.repo.RemoveProductImage(ctx, prs.ProductID, prs.ID)
+	if err != nil {
+		return entity.Product{}, err
+	}
+
+	s.deleteImageBlobs(ctx, img)
+	return product, nil
+}
+
+// deleteImageBlobs removes the files of an image no product refers to anymore, failures only leave unused files behind
+func (s service) deleteImageBlobs(ctx context.Context, img entity.ProductImage) {
+	for _, key := range []string{img.Key, img.ThumbnailKey} {
+		if err := s.blobs.Delete(ctx, key); err != nil {
+			log.Printf("failed to delete blob %s: %v", key, err)
+		}
+	}
+}
+
+// makeThumbnail scales the image down to ThumbnailSize and returns it with its extension.
+// The thumbnails of JPEG images are JPEG, the others PNG to keep their transparency.
+func makeThumbnail(content []byte, contentType string) ([]byte, string, error) {
+	img, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, "", errors.New("image is corrupted")
+	}
+
+	var buf bytes.Buffer
+	thumbnail := imaging.Thumbnail(img, ThumbnailSize)
+	ext := "png"
+	if contentType == "image/jpeg" {
+		ext = "jpg"
+		err = jpeg.Encode(&buf, thumbnail, &jpeg.Options{Quality: 85})
+	} else {
+		err = png.Encode(&buf, thumbnail)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	return buf.Bytes(), ext, nil
+}
+
+type UploadProductImageParams struct {
+	ProductID string
+	Content   io.Reader
+	// Size is the size announced by the upload, the content is checked as well
+	Size int64
+}
+
+type DeleteProductImageParams struct {
+	ProductID string
+	ID        string
+}
@@ -0,0 +1,117 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"graphql-backend/entity"
+	"graphql-backend/pkg/http-transport"
+	"time"
+)
+
+const ImpersonationTokenExpiration = 15 * time.Minute
+
+// Impersonate issues a short-lived access token for an admin to act as another user.
+// The token carries both users, so that what happens under impersonation can be audited.
+func (s service) Impersonate(ctx context.Context, prs ImpersonateParams) (ImpersonationResult, error) {
+	if prs.ActorID == prs.UserID {
+		return ImpersonationResult{}, errors.New("cannot impersonate yourself")
+	}
+
+	user, err := s.repo.GetUserByID(ctx, prs.UserID)
+	if err != nil {
+		return ImpersonationResult{}, err
+	}
+
+	if user.Role == entity.RoleAdmin {
+		return ImpersonationResult{}, errors.New("cannot impersonate another admin")
+	}
+
+	if !user.IsActive() {
+		return ImpersonationResult{}, ErrUserDeactivated
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(ImpersonationTokenExpiration)
+	session := entity.Session{
+		ID:        uuid.NewString(),
+		UserID:    user.ID,
+		ActorID:   prs.ActorID,
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	}
+	err = s.repo.CreateSession(ctx, session)
+	if err != nil {
+		return ImpersonationResult{}, err
+	}
+
+	accessToken, err := s.jwtHandler.GenerateToken(ctx, http_transport.UserClaims{
+		UserID:  user.ID,
+		Role:    user.Role,
+		ActorID: prs.ActorID,
+		RegisteredClaims: &jwt.RegisteredClaims{
+			ID:        session.ID,
+			Issuer:    "graphql-backend",
+			Subject:   user.ID,
+			IssuedAt:  jwt.NewNumericDate(now.UTC()),
+			NotBefore: jwt.NewNumericDate(now.UTC()),
+			Audience:  jwt.ClaimStrings{"graphql-ecommerce-client"},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	})
+	if err != nil {
+		return ImpersonationResult{}, err
+	}
+
+	err = s.repo.CreateAuditEntry(ctx, entity.AuditEntry{
+		ID:        uuid.NewString(),
+		ActorID:   prs.ActorID,
+		UserID:    user.ID,
+		Action:    entity.AuditActionImpersonate,
+		Operation: "impersonate",
+		Succeeded: true,
+		CreatedAt: now,
+	})
+	if err != nil {
+		return ImpersonationResult{}, err
+	}
+
+	return ImpersonationResult{
+		AccessToken: accessToken,
+		ExpiresAt:   expiresAt,
+		User:        user,
+	}, nil
+}
+
+func (s service) RecordAuditEntry(ctx context.Context, prs AuditEntryParams) error {
+	return s.repo.CreateAuditEntry(ctx, entity.AuditEntry{
+		ID:        uuid.NewString(),
+		ActorID:   prs.ActorID,
+		UserID:    prs.UserID,
+		Action:    prs.Action,
+		Operation: prs.Operation,
+		Succeeded: prs.Succeeded,
+		CreatedAt: time.Now(),
+	})
+}
+
+type ImpersonateParams struct {
+	ActorID string
+	UserID  string
+}
+
+type ImpersonationResult struct {
+	AccessToken string
+	ExpiresAt   time.Time
+
+	User entity.User
+}
+
+type AuditEntryParams struct {
+	ActorID   string
+	UserID    string
+	Action    string
+	Operation string
+	Succeeded bool
+}
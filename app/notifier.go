@@ -0,0 +1,11 @@
+package app
+
+import (
+	"context"
+	"graphql-backend/entity"
+)
+
+// Notifier delivers messages to users, e.g. by email
+type Notifier interface {
+	Notify(ctx context.Context, n entity.Notification) error
+}
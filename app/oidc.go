@@ -0,0 +1,87 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"graphql-backend/entity"
+	"slices"
+	"strings"
+	"time"
+)
+
+// LoginWithOIDC signs in a user authenticated by an external OpenID Connect provider.
+// The identity is linked to an existing user by verified email, or a new customer is registered.
+func (s service) LoginWithOIDC(ctx context.Context, prs OIDCLoginParams) (LoginResult, error) {
+	if prs.Issuer == "" || prs.Subject == "" {
+		return LoginResult{}, errors.New("issuer and subject cannot be empty")
+	}
+
+	user, err := s.repo.GetUserByIdentity(ctx, prs.Issuer, prs.Subject)
+	if err == nil {
+		if !user.IsActive() {
+			return LoginResult{}, ErrUserDeactivated
+		}
+		return s.issueTokens(ctx, user)
+	}
+
+	// providers keep the case the user typed, the local part is matched without it like the domain
+	email := strings.ToLower(strings.TrimSpace(prs.Email))
+	if email == "" {
+		return LoginResult{}, errors.New("oidc provider did not share an email")
+	}
+
+	identity := entity.UserIdentity{Issuer: prs.Issuer, Subject: prs.Subject}
+
+	user, err = s.repo.GetUserByEmail(ctx, email)
+	if err == nil {
+		if !user.IsActive() {
+			return LoginResult{}, ErrUserDeactivated
+		}
+
+		// an unverified email could be used to take over the local account
+		if !prs.EmailVerified {
+			return LoginResult{}, errors.New("email must be verified by the oidc provider to link an existing account")
+		}
+
+		if !slices.Contains(user.Identities, identity) {
+			user.Identities = append(user.Identities, identity)
+			user.UpdatedAt = time.Now()
+		}
+		err = s.repo.UpdateUser(ctx, user)
+		if err != nil {
+			return LoginResult{}, err
+		}
+
+		return s.issueTokens(ctx, user)
+	}
+
+	name := prs.Name
+	if name == "" {
+		name = email
+	}
+	user = entity.User{
+		ID:         uuid.NewString(),
+		Role:       entity.RoleCustomer,
+		Name:       name,
+		Email:      email,
+		Status:     entity.UserStatusActive,
+		Identities: []entity.UserIdentity{identity},
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	err = s.repo.CreateUser(ctx, user)
+	if err != nil {
+		return LoginResult{}, err
+	}
+
+	return s.issueTokens(ctx, user)
+}
+
+type OIDCLoginParams struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
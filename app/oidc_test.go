@@ -0,0 +1,121 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"graphql-backend/entity"
+	http_transport "graphql-backend/pkg/http-transport"
+)
+
+// oidcRepo keeps the users and sessions the OIDC login reads and writes, the other methods of Repo are not used
+type oidcRepo struct {
+	Repo
+	users    map[string]entity.User
+	sessions int
+}
+
+func (r *oidcRepo) GetUserByIdentity(ctx context.Context, issuer, subject string) (entity.User, error) {
+	for _, user := range r.users {
+		for _, identity := range user.Identities {
+			if identity.Issuer == issuer && identity.Subject == subject {
+				return user, nil
+			}
+		}
+	}
+	return entity.User{}, errors.New("user not found")
+}
+
+func (r *oidcRepo) GetUserByEmail(ctx context.Context, email string) (entity.User, error) {
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return entity.User{}, errors.New("user not found")
+}
+
+func (r *oidcRepo) CreateUser(ctx context.Context, e entity.User) error {
+	r.users[e.ID] = e
+	return nil
+}
+
+func (r *oidcRepo) UpdateUser(ctx context.Context, e entity.User) error {
+	r.users[e.ID] = e
+	return nil
+}
+
+func (r *oidcRepo) CreateSession(ctx context.Context, e entity.Session) error {
+	r.sessions++
+	return nil
+}
+
+func newOIDCService(t *testing.T, users ...entity.User) (Service, *oidcRepo) {
+	keys, err := http_transport.LoadRSAKeys()
+	require.NoError(t, err)
+
+	repo := &oidcRepo{users: map[string]entity.User{}}
+	for _, user := range users {
+		repo.users[user.ID] = user
+	}
+	return NewService(repo, http_transport.NewJWTHandler(keys), nil, nil, nil, nil, nil), repo
+}
+
+func TestLoginWithOIDC(t *testing.T) {
+	alice := entity.User{ID: "alice", Name: "Alice", Email: "alice@example.com", Role: entity.RoleCustomer, Status: entity.UserStatusActive}
+	const issuer = "https://idp.example.com"
+
+	t.Run("links an existing user by verified email", func(t *testing.T) {
+		service, repo := newOIDCService(t, alice)
+		result, err := service.LoginWithOIDC(context.TODO(), OIDCLoginParams{
+			Issuer: issuer, Subject: "sub-1", Email: "Alice@Example.com", EmailVerified: true,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "alice", result.User.ID)
+		require.NotEmpty(t, result.AccessToken)
+		require.Len(t, repo.users, 1)
+		require.Equal(t, []entity.UserIdentity{{Issuer: issuer, Subject: "sub-1"}}, repo.users["alice"].Identities)
+
+		// the linked identity signs in without the email
+		result, err = service.LoginWithOIDC(context.TODO(), OIDCLoginParams{Issuer: issuer, Subject: "sub-1"})
+		require.NoError(t, err)
+		require.Equal(t, "alice", result.User.ID)
+		require.Equal(t, 2, repo.sessions)
+	})
+
+	t.Run("refuses to link an unverified email", func(t *testing.T) {
+		service, repo := newOIDCService(t, alice)
+		_, err := service.LoginWithOIDC(context.TODO(), OIDCLoginParams{
+			Issuer: issuer, Subject: "sub-1", Email: "alice@example.com", EmailVerified: false,
+		})
+		require.Error(t, err)
+		require.Empty(t, repo.users["alice"].Identities)
+		require.Zero(t, repo.sessions)
+	})
+
+	t.Run("creates a customer for a new email", func(t *testing.T) {
+		service, repo := newOIDCService(t, alice)
+		result, err := service.LoginWithOIDC(context.TODO(), OIDCLoginParams{
+			Issuer: issuer, Subject: "sub-2", Email: " Bob@Example.com", Name: "Bob",
+		})
+		require.NoError(t, err)
+		require.Len(t, repo.users, 2)
+		created := repo.users[result.User.ID]
+		require.Equal(t, "bob@example.com", created.Email)
+		require.Equal(t, "Bob", created.Name)
+		require.Equal(t, entity.RoleCustomer, created.Role)
+		require.Equal(t, []entity.UserIdentity{{Issuer: issuer, Subject: "sub-2"}}, created.Identities)
+	})
+
+	t.Run("refuses deactivated users", func(t *testing.T) {
+		deactivated := alice
+		deactivated.Status = entity.UserStatusDeactivated
+		service, _ := newOIDCService(t, deactivated)
+		_, err := service.LoginWithOIDC(context.TODO(), OIDCLoginParams{
+			Issuer: issuer, Subject: "sub-1", Email: "alice@example.com", EmailVerified: true,
+		})
+		require.ErrorIs(t, err, ErrUserDeactivated)
+	})
+}
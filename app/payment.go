@@ -0,0 +1,287 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"graphql-backend/entity"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// PaymentGateway talks to a payment service provider. Payments are authorized first, then captured,
+// authorizations that are not captured can be voided and captured payments refunded.
+// The transactions are identified by the stored payment, its Reference is the gateway's ID.
+type PaymentGateway interface {
+	Name() string
+	Authorize(ctx context.Context, req entity.PaymentAuthorization) (entity.PaymentResult, error)
+	Capture(ctx context.Context, payment entity.Payment, amount entity.Money) (entity.PaymentResult, error)
+	Void(ctx context.Context, payment entity.Payment) (entity.PaymentResult, error)
+	Refund(ctx context.Context, payment entity.Payment, amount entity.Money) (entity.PaymentResult, error)
+	// ParseWebhook verifies the signature of an asynchronous notification and returns the result it carries
+	ParseWebhook(payload []byte, header http.Header) (entity.PaymentResult, error)
+}
+
+func (s service) PayOrder(ctx context.Context, prs PayOrderParams) (entity.Payment, error) {
+	if prs.PaymentMethod == "" {
+		return entity.Payment{}, errors.New("payment method cannot be empty")
+	}
+
+	order, err := s.repo.GetOrder(ctx, OrderParams{ID: prs.OrderID, UserID: prs.UserID})
+	if err != nil {
+		return entity.Payment{}, err
+	}
+	if order.Status != entity.OrderStatusAwaitingPayment {
+		return entity.Payment{}, errors.New("order is not awaiting payment")
+	}
+
+	now := time.Now()
+	payment := entity.Payment{
+		ID:             uuid.NewString(),
+		OrderID:        order.ID,
+		UserID:         order.UserID,
+		Amount:         order.Total,
+		Gateway:        s.payments.Name(),
+		Status:         entity.PaymentStatusPending,
+		RefundedAmount: entity.Money{Currency: order.Total.Currency},
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	err = s.repo.CreatePayment(ctx, payment)
+	if err != nil {
+		return entity.Payment{}, err
+	}
+
+	result, err := s.payments.Authorize(ctx, entity.PaymentAuthorization{
+		PaymentID:     payment.ID,
+		Amount:        payment.Amount,
+		PaymentMethod: prs.PaymentMethod,
+	})
+	if err != nil {
+		result = entity.PaymentResult{Status: entity.PaymentStatusFailed, FailureReason: "payment gateway unavailable"}
+	}
+
+	return s.settlePayment(ctx, payment, result)
+}
+
+// HandlePaymentWebhook applies an asynchronous confirmation of the gateway to a pending payment.
+// Notifications of payments that are no longer pending are ignored, gateways may deliver them more than once.
+func (s service) HandlePaymentWebhook(ctx context.Context, payload []byte, header http.Header) error {
+	result, err := s.payments.ParseWebhook(payload, header)
+	if err != nil {
+		return err
+	}
+
+	payment, err := s.repo.GetPaymentByReference(ctx, s.payments.Name(), result.Reference)
+	if err != nil {
+		return err
+	}
+	if payment.Status != entity.PaymentStatusPending {
+		return nil
+	}
+
+	_, err = s.settlePayment(ctx, payment, result)
+	return err
+}
+
+// settlePayment records the gateway result on the payment. Authorizations are captured in full right away,
+// and the order is paid once its payment is captured. An authorization that can't be captured is voided
+// and the payment failed, so that the order can be paid again.
+func (s service) settlePayment(ctx context.Context, payment entity.Payment, result entity.PaymentResult) (entity.Payment, error) {
+	if result.Reference != "" {
+		payment.Reference = result.Reference
+	}
+	if result.CardLast4 != "" {
+		payment.CardLast4 = result.CardLast4
+	}
+
+	if result.Status == entity.PaymentStatusAuthorized {
+		captured, err := s.payments.Capture(ctx, payment, payment.Amount)
+		if err != nil {
+			log.Printf("failed to capture payment %s: %v", payment.ID, err)
+			if _, err := s.payments.Void(ctx, payment); err != nil {
+				log.Printf("failed to void payment %s: %v", payment.ID, err)
+			}
+			captured = entity.PaymentResult{Status: entity.PaymentStatusFailed, FailureReason: "capture_failed"}
+		}
+		result = captured
+	}
+
+	// the order is claimed before the capture is recorded, so that a cancellation never refunds it as well.
+	// The payment of an order that was cancelled meanwhile is refunded.
+	if result.Status == entity.PaymentStatusCaptured {
+		_, err := s.repo.ClaimOrder(ctx, payment.OrderID, []entity.OrderStatus{entity.OrderStatusAwaitingPayment}, entity.OrderStatusPaid)
+		if err != nil {
+			log.Printf("refunding payment %s, its order can't be paid: %v", payment.ID, err)
+			result, err = s.payments.Refund(ctx, payment, payment.Amount)
+			if err != nil {
+				return entity.Payment{}, err
+			}
+			payment.RefundedAmount = result.RefundedAmount
+		}
+	}
+
+	payment.Status = result.Status
+	payment.FailureReason = result.FailureReason
+	payment.UpdatedAt = time.Now()
+	err := s.repo.UpdatePayment(ctx, payment)
+	if err != nil {
+		return entity.Payment{}, err
+	}
+
+	return payment, nil
+}
+
+// UpdateOrderStatus completes a paid order or cancels an open one. Cancelling voids or refunds its payment,
+// the repo puts the reserved stock back.
+func (s service) UpdateOrderStatus(ctx context.Context, prs UpdateOrderStatusParams) (entity.Order, error) {
+	order, err := s.repo.GetOrder(ctx, OrderParams{ID: prs.ID})
+	if err != nil {
+		return entity.Order{}, err
+	}
+
+	switch prs.Status {
+	case entity.OrderStatusCompleted:
+		switch order.Status {
+		case entity.OrderStatusPaid, entity.OrderStatusPartiallyRefunded:
+		default:
+			return entity.Order{}, errors.New("only paid orders can be completed")
+		}
+		// the repo checks the status again
+		err = s.repo.UpdateOrderStatus(ctx, order.ID, prs.Status)
+		if err != nil {
+			return entity.Order{}, err
+		}
+		order.Status = prs.Status
+		return order, nil
+	case entity.OrderStatusCancelled:
+		if !slices.Contains(cancellableStatuses, order.Status) {
+			return entity.Order{}, errors.New("order can no longer be cancelled")
+		}
+	default:
+		return entity.Order{}, errors.New("order status can only be changed to Completed or Cancelled")
+	}
+
+	// the order is claimed so that a concurrent refund or cancellation can't refund its payment twice
+	order, err = s.repo.ClaimOrder(ctx, order.ID, cancellableStatuses, entity.OrderStatusRefunding)
+	if err != nil {
+		return entity.Order{}, err
+	}
+	err = s.releaseOrderPayments(ctx, &order, prs.ActorID)
+	if err != nil {
+		s.releaseOrder(ctx, order)
+		return entity.Order{}, err
+	}
+
+	claimed := order
+	order.Status = prs.Status
+	err = s.repo.UpdateOrder(ctx, order)
+	if err != nil {
+		s.releaseOrder(ctx, claimed)
+		return entity.Order{}, err
+	}
+
+	return order, nil
+}
+
+var cancellableStatuses = []entity.OrderStatus{
+	entity.OrderStatusPending, entity.OrderStatusAwaitingPayment, entity.OrderStatusPaid, entity.OrderStatusPartiallyRefunded,
+}
+
+// releaseOrderPayments voids the open authorizations of the order and refunds what is left of its captured payment
+func (s service) releaseOrderPayments(ctx context.Context, order *entity.Order, actorID string) error {
+	payments, err := s.repo.GetPaymentsByOrderIDs(ctx, []string{order.ID})
+	if err != nil {
+		return err
+	}
+
+	for _, payment := range payments[order.ID] {
+		switch payment.Status {
+		case entity.PaymentStatusPending, entity.PaymentStatusAuthorized:
+			result, err := s.payments.Void(ctx, payment)
+			if err != nil {
+				return err
+			}
+			payment.Status = result.Status
+			payment.UpdatedAt = time.Now()
+			err = s.repo.UpdatePayment(ctx, payment)
+			if err != nil {
+				return err
+			}
+		case entity.PaymentStatusCaptured:
+			_, err = s.refundPayment(ctx, order, payment, payment.Amount.Sub(payment.RefundedAmount), "Order cancelled", "", actorID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// capturedPayment returns the payment that settled the order
+func (s service) capturedPayment(ctx context.Context, orderID string) (entity.Payment, error) {
+	payments, err := s.repo.GetPaymentsByOrderIDs(ctx, []string{orderID})
+	if err != nil {
+		return entity.Payment{}, err
+	}
+
+	for _, payment := range payments[orderID] {
+		if payment.Status == entity.PaymentStatusCaptured {
+			return payment, nil
+		}
+	}
+
+	return entity.Payment{}, errors.New("order has no captured payment to refund")
+}
+
+// refundPayment refunds an amount of the payment and records the refund on the order, the order is not stored
+func (s service) refundPayment(ctx context.Context, order *entity.Order, payment entity.Payment, amount entity.Money, reason string, returnID string, actorID string) (entity.Refund, error) {
+	if amount.IsZero() || amount.IsNegative() {
+		return entity.Refund{}, errors.New("refund amount must be positive")
+	}
+	if payment.Amount.Sub(payment.RefundedAmount).LessThan(amount) {
+		return entity.Refund{}, errors.New("refund exceeds the amount left to refund")
+	}
+
+	result, err := s.payments.Refund(ctx, payment, amount)
+	if err != nil {
+		return entity.Refund{}, err
+	}
+
+	now := time.Now()
+	payment.Status = result.Status
+	payment.RefundedAmount = result.RefundedAmount
+	payment.UpdatedAt = now
+	err = s.repo.UpdatePayment(ctx, payment)
+	if err != nil {
+		return entity.Refund{}, err
+	}
+
+	refund := entity.Refund{
+		ID:        uuid.NewString(),
+		PaymentID: payment.ID,
+		ReturnID:  returnID,
+		Amount:    amount,
+		Reason:    reason,
+		CreatedBy: actorID,
+		CreatedAt: now,
+	}
+	order.Refunds = append(order.Refunds, refund)
+
+	return refund, nil
+}
+
+type PayOrderParams struct {
+	UserID        string
+	OrderID       string
+	PaymentMethod string
+}
+
+type UpdateOrderStatusParams struct {
+	ID      string
+	Status  entity.OrderStatus
+	ActorID string
+}
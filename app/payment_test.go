@@ -0,0 +1,102 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"graphql-backend/entity"
+)
+
+// paymentRepo keeps the payments and order statuses the settlement writes, the other methods of Repo are not used
+type paymentRepo struct {
+	Repo
+	payments map[string]entity.Payment
+	statuses map[string]entity.OrderStatus
+}
+
+func (r *paymentRepo) UpdatePayment(ctx context.Context, e entity.Payment) error {
+	r.payments[e.ID] = e
+	return nil
+}
+
+func (r *paymentRepo) ClaimOrder(ctx context.Context, id string, from []entity.OrderStatus, to entity.OrderStatus) (entity.Order, error) {
+	status := r.statuses[id]
+	if !slices.Contains(from, status) {
+		return entity.Order{}, fmt.Errorf("order is %s", status)
+	}
+	r.statuses[id] = to
+	return entity.Order{ID: id, Status: status}, nil
+}
+
+// uncapturableGateway authorizes payments but fails to capture them, the other methods of PaymentGateway are not used
+type uncapturableGateway struct {
+	PaymentGateway
+	voided []string
+}
+
+func (g *uncapturableGateway) Capture(ctx context.Context, payment entity.Payment, amount entity.Money) (entity.PaymentResult, error) {
+	return entity.PaymentResult{}, errors.New("gateway timeout")
+}
+
+func (g *uncapturableGateway) Void(ctx context.Context, payment entity.Payment) (entity.PaymentResult, error) {
+	g.voided = append(g.voided, payment.Reference)
+	return entity.PaymentResult{Reference: payment.Reference, Status: entity.PaymentStatusVoided}, nil
+}
+
+func TestFailedCaptureVoidsTheAuthorization(t *testing.T) {
+	repo := &paymentRepo{payments: map[string]entity.Payment{}, statuses: map[string]entity.OrderStatus{"order-1": entity.OrderStatusAwaitingPayment}}
+	gateway := &uncapturableGateway{}
+	s := service{repo: repo, payments: gateway}
+
+	payment, err := s.settlePayment(context.TODO(), entity.Payment{
+		ID:      "payment-1",
+		OrderID: "order-1",
+		Amount:  entity.NewMoney(1000, "USD"),
+		Status:  entity.PaymentStatusPending,
+	}, entity.PaymentResult{Reference: "ref-1", Status: entity.PaymentStatusAuthorized})
+	require.NoError(t, err)
+	require.Equal(t, entity.PaymentStatusFailed, payment.Status)
+	require.Equal(t, "capture_failed", payment.FailureReason)
+	require.Equal(t, payment, repo.payments["payment-1"])
+	require.Equal(t, []string{"ref-1"}, gateway.voided)
+	// the order stays unpaid, it can be paid again
+	require.Equal(t, entity.OrderStatusAwaitingPayment, repo.statuses["order-1"])
+}
+
+// refundingGateway captures payments in full and records the refunds, the other methods of PaymentGateway are not used
+type refundingGateway struct {
+	PaymentGateway
+	refunded []entity.Money
+}
+
+func (g *refundingGateway) Capture(ctx context.Context, payment entity.Payment, amount entity.Money) (entity.PaymentResult, error) {
+	return entity.PaymentResult{Reference: payment.Reference, Status: entity.PaymentStatusCaptured}, nil
+}
+
+func (g *refundingGateway) Refund(ctx context.Context, payment entity.Payment, amount entity.Money) (entity.PaymentResult, error) {
+	g.refunded = append(g.refunded, amount)
+	return entity.PaymentResult{Reference: payment.Reference, Status: entity.PaymentStatusRefunded, RefundedAmount: amount}, nil
+}
+
+func TestCaptureOfACancelledOrderIsRefunded(t *testing.T) {
+	repo := &paymentRepo{payments: map[string]entity.Payment{}, statuses: map[string]entity.OrderStatus{"order-1": entity.OrderStatusCancelled}}
+	gateway := &refundingGateway{}
+	s := service{repo: repo, payments: gateway}
+
+	payment, err := s.settlePayment(context.TODO(), entity.Payment{
+		ID:      "payment-1",
+		OrderID: "order-1",
+		Amount:  entity.NewMoney(1000, "USD"),
+		Status:  entity.PaymentStatusPending,
+	}, entity.PaymentResult{Reference: "ref-1", Status: entity.PaymentStatusAuthorized})
+	require.NoError(t, err)
+	require.Equal(t, entity.PaymentStatusRefunded, payment.Status)
+	require.Equal(t, entity.NewMoney(1000, "USD"), payment.RefundedAmount)
+	require.Equal(t, payment, repo.payments["payment-1"])
+	require.Equal(t, []entity.Money{entity.NewMoney(1000, "USD")}, gateway.refunded)
+	require.Equal(t, entity.OrderStatusCancelled, repo.statuses["order-1"])
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"graphql-backend/pkg/http-transport"
+	"reflect"
+)
+
+var ErrPermissionDenied = errors.New("permission denied")
+
+// Ownable is implemented by resources that belong to a single user
+type Ownable interface {
+	GetOwnerID() string
+}
+
+// Policy decides whether the current user may access a resolved object
+type Policy interface {
+	Authorize(ctx context.Context, obj any, overrideRole string) error
+}
+
+type policy struct{}
+
+// Authorize allows access when the user owns obj, or when the user has the override role.
+// obj may be a single Ownable, a slice of them, or nil (nothing was resolved).
+func (p policy) Authorize(ctx context.Context, obj any, overrideRole string) error {
+	user := http_transport.GetUserFromContext(ctx)
+	if user == nil {
+		return errors.New("unauthorized")
+	}
+
+	if overrideRole != "" && user.Role == overrideRole {
+		return nil
+	}
+
+	return checkOwnership(user.UserID, obj)
+}
+
+func checkOwnership(userID string, obj any) error {
+	if obj == nil {
+		return nil
+	}
+
+	if o, ok := obj.(Ownable); ok {
+		v := reflect.ValueOf(obj)
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			return nil
+		}
+		if o.GetOwnerID() != userID {
+			return ErrPermissionDenied
+		}
+		return nil
+	}
+
+	v := reflect.ValueOf(obj)
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return checkOwnership(userID, v.Elem().Interface())
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := checkOwnership(userID, v.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// objects without an owner cannot be checked, deny rather than leak them
+	return ErrPermissionDenied
+}
+
+func NewPolicy() Policy {
+	return &policy{}
+}
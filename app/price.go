@@ -0,0 +1,311 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"graphql-backend/entity"
+	"log"
+	"time"
+)
+
+// ErrPriceChanged is returned when the price of a product was changed by someone else meanwhile
+var ErrPriceChanged = errors.New("price of the product has changed")
+
+// SchedulePrice sets a price the product takes at StartsAt. With an end the price the product has by then comes back
+// at EndsAt, e.g. for a sale.
+func (s service) SchedulePrice(ctx context.Context, prs SchedulePriceParams) (entity.ScheduledPrice, error) {
+	if prs.Price.IsNegative() {
+		return entity.ScheduledPrice{}, errors.New("price cannot be negative")
+	}
+	if !prs.StartsAt.After(time.Now()) {
+		return entity.ScheduledPrice{}, errors.New("scheduled price must start in the future")
+	}
+	if prs.EndsAt != nil && !prs.EndsAt.After(prs.StartsAt) {
+		return entity.ScheduledPrice{}, errors.New("scheduled price must end after it starts")
+	}
+	if _, err := s.repo.GetProductByID(ctx, prs.ProductID); err != nil {
+		return entity.ScheduledPrice{}, err
+	}
+
+	now := time.Now()
+	scheduled := entity.ScheduledPrice{
+		ID:        uuid.NewString(),
+		ProductID: prs.ProductID,
+		Price:     prs.Price,
+		StartsAt:  prs.StartsAt,
+		EndsAt:    prs.EndsAt,
+		Status:    entity.ScheduledPriceStatusScheduled,
+		CreatedBy: prs.ActorID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	err := s.repo.CreateScheduledPrice(ctx, scheduled)
+	if err != nil {
+		return entity.ScheduledPrice{}, err
+	}
+
+	return scheduled, nil
+}
+
+// CancelScheduledPrice drops a scheduled price before it starts, or ends an active sale right away
+func (s service) CancelScheduledPrice(ctx context.Context, prs CancelScheduledPriceParams) (entity.ScheduledPrice, error) {
+	scheduled, err := s.repo.GetScheduledPriceByID(ctx, prs.ID)
+	if err != nil {
+		return entity.ScheduledPrice{}, err
+	}
+
+	switch scheduled.Status {
+	case entity.ScheduledPriceStatusScheduled:
+		return s.closeScheduledPrice(ctx, scheduled.ID, entity.ScheduledPriceStatusScheduled, entity.ScheduledPriceStatusCancelled, time.Now())
+	case entity.ScheduledPriceStatusActive:
+		return s.endScheduledPrice(ctx, scheduled, entity.ScheduledPriceStatusCancelled, prs.ActorID, time.Now())
+	default:
+		return entity.ScheduledPrice{}, fmt.Errorf("scheduled price is already %s", scheduled.Status)
+	}
+}
+
+func (s service) ApplyScheduledPrices(ctx context.Context, now time.Time) error {
+	due, err := s.repo.GetDueScheduledPrices(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, scheduled := range due {
+		if scheduled.Status == entity.ScheduledPriceStatusScheduled {
+			_, err = s.startScheduledPrice(ctx, scheduled, now)
+		} else {
+			_, err = s.endScheduledPrice(ctx, scheduled, entity.ScheduledPriceStatusCompleted, scheduled.CreatedBy, now)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("scheduled price %s: %w", scheduled.ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// RunPriceScheduler applies the scheduled prices every interval until the context is done
+func RunPriceScheduler(ctx context.Context, service Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := service.ApplyScheduledPrices(ctx, now); err != nil {
+				log.Printf("failed to apply scheduled prices: %v", err)
+			}
+		}
+	}
+}
+
+// startScheduledPrice sets the price of the product, a sale that was already over by the time it is started,
+// e.g. while the server was down, is completed without changing the price.
+// The scheduled price is claimed first, so that it can't be cancelled while the price changes.
+func (s service) startScheduledPrice(ctx context.Context, scheduled entity.ScheduledPrice, now time.Time) (entity.ScheduledPrice, error) {
+	if scheduled.EndsAt != nil && !scheduled.EndsAt.After(now) {
+		return s.closeScheduledPrice(ctx, scheduled.ID, entity.ScheduledPriceStatusScheduled, entity.ScheduledPriceStatusCompleted, now)
+	}
+
+	scheduled, err := s.repo.ClaimScheduledPrice(ctx, scheduled.ID, entity.ScheduledPriceStatusScheduled, entity.ScheduledPriceStatusApplying)
+	if err != nil {
+		return entity.ScheduledPrice{}, err
+	}
+	product, err := s.repo.GetProductByID(ctx, scheduled.ProductID)
+	if err != nil {
+		s.releaseScheduledPrice(ctx, scheduled.ID, entity.ScheduledPriceStatusScheduled)
+		return entity.ScheduledPrice{}, err
+	}
+	// a price changed meanwhile leaves the scheduled price to the next run
+	previousPrice := product.Price
+	product, err = s.repo.SetProductPrice(ctx, product.ID, previousPrice, scheduled.Price)
+	if err != nil {
+		s.releaseScheduledPrice(ctx, scheduled.ID, entity.ScheduledPriceStatusScheduled)
+		return entity.ScheduledPrice{}, err
+	}
+
+	scheduled.PreviousPrice = &previousPrice
+	scheduled.Status = entity.ScheduledPriceStatusActive
+	if scheduled.EndsAt == nil {
+		scheduled.Status = entity.ScheduledPriceStatusCompleted
+	}
+	scheduled.UpdatedAt = now
+	err = s.repo.UpdateScheduledPrice(ctx, scheduled)
+	if err != nil {
+		return entity.ScheduledPrice{}, err
+	}
+
+	return scheduled, s.recordPriceChange(ctx, entity.PriceChange{
+		ProductID:        product.ID,
+		OldPrice:         previousPrice,
+		NewPrice:         product.Price,
+		ActorID:          scheduled.CreatedBy,
+		Source:           entity.PriceChangeSourceSchedule,
+		ScheduledPriceID: scheduled.ID,
+	})
+}
+
+// endScheduledPrice restores the price the product had before the sale. When the price was changed during the sale,
+// the new price is kept. The sale is claimed first, so that the scheduler and a cancellation can't both end it.
+func (s service) endScheduledPrice(ctx context.Context, scheduled entity.ScheduledPrice, status entity.ScheduledPriceStatus, actorID string, now time.Time) (entity.ScheduledPrice, error) {
+	scheduled, err := s.repo.ClaimScheduledPrice(ctx, scheduled.ID, entity.ScheduledPriceStatusActive, entity.ScheduledPriceStatusApplying)
+	if err != nil {
+		return entity.ScheduledPrice{}, err
+	}
+	product, err := s.repo.GetProductByID(ctx, scheduled.ProductID)
+	if err != nil {
+		s.releaseScheduledPrice(ctx, scheduled.ID, entity.ScheduledPriceStatusActive)
+		return entity.ScheduledPrice{}, err
+	}
+
+	scheduled.Status = status
+	scheduled.UpdatedAt = now
+	if scheduled.PreviousPrice == nil || product.Price != scheduled.Price {
+		return scheduled, s.repo.UpdateScheduledPrice(ctx, scheduled)
+	}
+
+	salePrice := product.Price
+	product, err = s.repo.SetProductPrice(ctx, product.ID, salePrice, *scheduled.PreviousPrice)
+	if errors.Is(err, ErrPriceChanged) {
+		return scheduled, s.repo.UpdateScheduledPrice(ctx, scheduled)
+	}
+	if err != nil {
+		s.releaseScheduledPrice(ctx, scheduled.ID, entity.ScheduledPriceStatusActive)
+		return entity.ScheduledPrice{}, err
+	}
+	err = s.repo.UpdateScheduledPrice(ctx, scheduled)
+	if err != nil {
+		return entity.ScheduledPrice{}, err
+	}
+
+	return scheduled, s.recordPriceChange(ctx, entity.PriceChange{
+		ProductID:        product.ID,
+		OldPrice:         salePrice,
+		NewPrice:         product.Price,
+		ActorID:          actorID,
+		Source:           entity.PriceChangeSourceSchedule,
+		ScheduledPriceID: scheduled.ID,
+	})
+}
+
+// closeScheduledPrice moves a scheduled price that doesn't change the product price to its final status
+func (s service) closeScheduledPrice(ctx context.Context, id string, from, to entity.ScheduledPriceStatus, now time.Time) (entity.ScheduledPrice, error) {
+	scheduled, err := s.repo.ClaimScheduledPrice(ctx, id, from, to)
+	if err != nil {
+		return entity.ScheduledPrice{}, err
+	}
+
+	scheduled.UpdatedAt = now
+	err = s.repo.UpdateScheduledPrice(ctx, scheduled)
+	if err != nil {
+		return entity.ScheduledPrice{}, err
+	}
+	return scheduled, nil
+}
+
+// releaseScheduledPrice gives a claimed scheduled price its status back after the price of the product couldn't be changed
+func (s service) releaseScheduledPrice(ctx context.Context, id string, status entity.ScheduledPriceStatus) {
+	if _, err := s.repo.ClaimScheduledPrice(ctx, id, entity.ScheduledPriceStatusApplying, status); err != nil {
+		log.Printf("failed to release scheduled price %s: %v", id, err)
+	}
+}
+
+// recordPriceChanges records the change of the price of the product and of the price overrides of its variants,
+// a new variant was at the product price before. The change sets the actor and the source.
+func (s service) recordPriceChanges(ctx context.Context, before, after entity.Product, change entity.PriceChange) error {
+	change.ProductID = after.ID
+	change.OldPrice, change.NewPrice = before.Price, after.Price
+	err := s.recordPriceChange(ctx, change)
+	if err != nil {
+		return err
+	}
+
+	for _, variant := range after.Variants {
+		oldPrice, overridden := before.Price, false
+		if i := before.Variant(variant.ID); i >= 0 {
+			oldPrice, overridden = before.VariantPrice(before.Variants[i]), before.Variants[i].Price != nil
+		}
+		// variants at the product price change with it
+		if variant.Price == nil && !overridden {
+			continue
+		}
+
+		change.VariantID = variant.ID
+		change.OldPrice, change.NewPrice = oldPrice, after.VariantPrice(variant)
+		err = s.recordPriceChange(ctx, change)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordPriceChange adds the change to the price history of the product, nothing is recorded when the price is the same
+func (s service) recordPriceChange(ctx context.Context, change entity.PriceChange) error {
+	if change.OldPrice == change.NewPrice {
+		return nil
+	}
+
+	change.ID = uuid.NewString()
+	change.ChangedAt = time.Now()
+	return s.repo.CreatePriceChange(ctx, change)
+}
+
+func (q *query) GetScheduledPrices(ctx context.Context, prs ScheduledPricesParams) ([]entity.ScheduledPrice, error) {
+	prs.SetDefaults()
+	return q.repo.GetScheduledPrices(ctx, prs)
+}
+
+type SchedulePriceParams struct {
+	ProductID string
+	Price     entity.Money
+	StartsAt  time.Time
+	// EndsAt restores the previous price, the scheduled price stays without it
+	EndsAt  *time.Time
+	ActorID string
+}
+
+type CancelScheduledPriceParams struct {
+	ID      string
+	ActorID string
+}
+
+type ScheduledPricesParams struct {
+	Limit     *int32
+	Offset    *int32
+	Status    *entity.ScheduledPriceStatus
+	ProductID string
+}
+
+func (p *ScheduledPricesParams) SetDefaults() {
+	if p.Limit == nil || *p.Limit <= 0 {
+		defaultLimit := int32(10)
+		p.Limit = &defaultLimit
+	}
+	if p.Offset == nil || *p.Offset < 0 {
+		defaultOffset := int32(0)
+		p.Offset = &defaultOffset
+	}
+}
+
+// PriceHistoryParams pages the price changes of a product
+type PriceHistoryParams struct {
+	Limit  *int32
+	Offset *int32
+}
+
+func (p *PriceHistoryParams) SetDefaults() {
+	if p.Limit == nil || *p.Limit <= 0 {
+		defaultLimit := int32(10)
+		p.Limit = &defaultLimit
+	}
+	if p.Offset == nil || *p.Offset < 0 {
+		defaultOffset := int32(0)
+		p.Offset = &defaultOffset
+	}
+}
@@ -0,0 +1,182 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"graphql-backend/entity"
+)
+
+// priceRepo keeps the products and scheduled prices the scheduler reads and writes, the other methods of Repo are not
+// used. afterRead runs after a product is read, like a concurrent change would.
+type priceRepo struct {
+	Repo
+	products  map[string]entity.Product
+	scheduled map[string]entity.ScheduledPrice
+	changes   []entity.PriceChange
+	afterRead func()
+}
+
+func (r *priceRepo) GetProductByID(ctx context.Context, id string) (entity.Product, error) {
+	product, ok := r.products[id]
+	if !ok {
+		return entity.Product{}, errors.New("product not found")
+	}
+	if r.afterRead != nil {
+		r.afterRead()
+	}
+	return product, nil
+}
+
+func (r *priceRepo) SetProductPrice(ctx context.Context, productID string, from, to entity.Money) (entity.Product, error) {
+	product := r.products[productID]
+	if product.Price != from {
+		return entity.Product{}, ErrPriceChanged
+	}
+	product.Price = to
+	r.products[productID] = product
+	return product, nil
+}
+
+func (r *priceRepo) GetDueScheduledPrices(ctx context.Context, now time.Time) ([]entity.ScheduledPrice, error) {
+	var due []entity.ScheduledPrice
+	for _, scheduled := range r.scheduled {
+		if scheduled.IsPending() {
+			due = append(due, scheduled)
+		}
+	}
+	return due, nil
+}
+
+func (r *priceRepo) GetScheduledPriceByID(ctx context.Context, id string) (entity.ScheduledPrice, error) {
+	return r.scheduled[id], nil
+}
+
+func (r *priceRepo) UpdateScheduledPrice(ctx context.Context, e entity.ScheduledPrice) error {
+	r.scheduled[e.ID] = e
+	return nil
+}
+
+func (r *priceRepo) ClaimScheduledPrice(ctx context.Context, id string, from, to entity.ScheduledPriceStatus) (entity.ScheduledPrice, error) {
+	scheduled := r.scheduled[id]
+	if scheduled.Status != from {
+		return entity.ScheduledPrice{}, fmt.Errorf("scheduled price is %s", scheduled.Status)
+	}
+	scheduled.Status = to
+	r.scheduled[id] = scheduled
+	return scheduled, nil
+}
+
+func (r *priceRepo) CreatePriceChange(ctx context.Context, e entity.PriceChange) error {
+	r.changes = append(r.changes, e)
+	return nil
+}
+
+func TestScheduledPricesKeepConcurrentChanges(t *testing.T) {
+	now := time.Now()
+	endsAt := now.Add(time.Hour)
+	newRepo := func(price int64, scheduled entity.ScheduledPrice) *priceRepo {
+		return &priceRepo{
+			products:  map[string]entity.Product{"mug": {ID: "mug", Price: entity.NewMoney(price, "USD"), InStock: 5}},
+			scheduled: map[string]entity.ScheduledPrice{scheduled.ID: scheduled},
+		}
+	}
+
+	t.Run("a sale keeps the stock sold while it starts", func(t *testing.T) {
+		repo := newRepo(2000, entity.ScheduledPrice{
+			ID: "sale", ProductID: "mug", Price: entity.NewMoney(1500, "USD"), StartsAt: now, EndsAt: &endsAt,
+			Status: entity.ScheduledPriceStatusScheduled,
+		})
+		repo.afterRead = func() {
+			product := repo.products["mug"]
+			product.InStock--
+			repo.products["mug"] = product
+		}
+
+		service := NewService(repo, nil, nil, nil, nil, nil, nil)
+		require.NoError(t, service.ApplyScheduledPrices(context.TODO(), now))
+		require.Equal(t, int64(1500), repo.products["mug"].Price.Amount)
+		require.Equal(t, int32(4), repo.products["mug"].InStock)
+		require.Equal(t, entity.ScheduledPriceStatusActive, repo.scheduled["sale"].Status)
+		require.Len(t, repo.changes, 1)
+	})
+
+	t.Run("a price changed while the sale ends is kept", func(t *testing.T) {
+		previousPrice := entity.NewMoney(2000, "USD")
+		repo := newRepo(1500, entity.ScheduledPrice{
+			ID: "sale", ProductID: "mug", Price: entity.NewMoney(1500, "USD"), StartsAt: now, EndsAt: &now,
+			Status: entity.ScheduledPriceStatusActive, PreviousPrice: &previousPrice,
+		})
+		repo.afterRead = func() {
+			product := repo.products["mug"]
+			product.Price = entity.NewMoney(1700, "USD")
+			repo.products["mug"] = product
+		}
+
+		service := NewService(repo, nil, nil, nil, nil, nil, nil)
+		require.NoError(t, service.ApplyScheduledPrices(context.TODO(), now))
+		require.Equal(t, int64(1700), repo.products["mug"].Price.Amount)
+		require.Equal(t, entity.ScheduledPriceStatusCompleted, repo.scheduled["sale"].Status)
+		require.Empty(t, repo.changes)
+	})
+
+	t.Run("a price in another currency is kept when the sale ends", func(t *testing.T) {
+		previousPrice := entity.NewMoney(2000, "USD")
+		repo := newRepo(1500, entity.ScheduledPrice{
+			ID: "sale", ProductID: "mug", Price: entity.NewMoney(1500, "USD"), StartsAt: now, EndsAt: &now,
+			Status: entity.ScheduledPriceStatusActive, PreviousPrice: &previousPrice,
+		})
+		repo.afterRead = func() {
+			product := repo.products["mug"]
+			product.Price = entity.NewMoney(1500, "EUR")
+			repo.products["mug"] = product
+		}
+
+		service := NewService(repo, nil, nil, nil, nil, nil, nil)
+		require.NoError(t, service.ApplyScheduledPrices(context.TODO(), now))
+		require.Equal(t, entity.NewMoney(1500, "EUR"), repo.products["mug"].Price)
+		require.Equal(t, entity.ScheduledPriceStatusCompleted, repo.scheduled["sale"].Status)
+		require.Empty(t, repo.changes)
+	})
+
+	t.Run("a sale can't be cancelled while it starts", func(t *testing.T) {
+		repo := newRepo(2000, entity.ScheduledPrice{
+			ID: "sale", ProductID: "mug", Price: entity.NewMoney(1500, "USD"), StartsAt: now, EndsAt: &endsAt,
+			Status: entity.ScheduledPriceStatusScheduled,
+		})
+		service := NewService(repo, nil, nil, nil, nil, nil, nil)
+		var cancelErr error
+		repo.afterRead = func() {
+			_, cancelErr = service.CancelScheduledPrice(context.TODO(), CancelScheduledPriceParams{ID: "sale"})
+		}
+
+		require.NoError(t, service.ApplyScheduledPrices(context.TODO(), now))
+		require.EqualError(t, cancelErr, "scheduled price is already Applying")
+		require.Equal(t, int64(1500), repo.products["mug"].Price.Amount)
+		require.Equal(t, entity.ScheduledPriceStatusActive, repo.scheduled["sale"].Status)
+	})
+
+	t.Run("a sale ended by a cancellation is not ended again", func(t *testing.T) {
+		previousPrice := entity.NewMoney(2000, "USD")
+		repo := newRepo(1500, entity.ScheduledPrice{
+			ID: "sale", ProductID: "mug", Price: entity.NewMoney(1500, "USD"), StartsAt: now, EndsAt: &now,
+			Status: entity.ScheduledPriceStatusActive, PreviousPrice: &previousPrice,
+		})
+		s := service{repo: repo}
+		due, err := repo.GetDueScheduledPrices(context.TODO(), now)
+		require.NoError(t, err)
+
+		_, err = s.CancelScheduledPrice(context.TODO(), CancelScheduledPriceParams{ID: "sale"})
+		require.NoError(t, err)
+		// the scheduler read the sale before it was cancelled
+		_, err = s.endScheduledPrice(context.TODO(), due[0], entity.ScheduledPriceStatusCompleted, "", now)
+		require.EqualError(t, err, "scheduled price is Cancelled")
+		require.Equal(t, int64(2000), repo.products["mug"].Price.Amount)
+		require.Equal(t, entity.ScheduledPriceStatusCancelled, repo.scheduled["sale"].Status)
+		require.Len(t, repo.changes, 1)
+	})
+}
@@ -0,0 +1,335 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"graphql-backend/entity"
+	"slices"
+	"strings"
+	"time"
+)
+
+const maxSKULength = 64
+
+// CreateProductVariant adds a variant to the product, the product options must be set first
+func (s service) CreateProductVariant(ctx context.Context, prs CreateProductVariantParams) (entity.Product, error) {
+	product, err := s.repo.GetProductByID(ctx, prs.ProductID)
+	if err != nil {
+		return entity.Product{}, err
+	}
+
+	before := product
+	variant := entity.ProductVariant{
+		ID:      uuid.NewString(),
+		SKU:     prs.SKU,
+		Options: prs.Options,
+		Price:   prs.Price,
+		InStock: prs.InStock,
+	}
+	product.Variants = append(slices.Clone(product.Variants), variant)
+
+	product, err = s.saveVariant(ctx, product, variant.ID, nil)
+	if err != nil {
+		return entity.Product{}, err
+	}
+
+	return product, s.recordPriceChanges(ctx, before, product, entity.PriceChange{
+		ActorID: prs.ActorID,
+		Source:  entity.PriceChangeSourceManual,
+	})
+}
+
+func (s service) UpdateProductVariant(ctx context.Context, prs UpdateProductVariantParams) (entity.Product, error) {
+	product, err := s.repo.GetProductByID(ctx, prs.ProductID)
+	if err != nil {
+		return entity.Product{}, err
+	}
+
+	i := product.Variant(prs.ID)
+	if i < 0 {
+		return entity.Product{}, errors.New("variant not found")
+	}
+	before := product
+	product.Variants = slices.Clone(product.Variants)
+	prs.BindToVariant(&product.Variants[i])
+
+	product, err = s.saveVariant(ctx, product, prs.ID, prs.fields())
+	if err != nil {
+		return entity.Product{}, err
+	}
+
+	return product, s.recordPriceChanges(ctx, before, product, entity.PriceChange{
+		ActorID: prs.ActorID,
+		Source:  entity.PriceChangeSourceManual,
+	})
+}
+
+// ArchiveProduct withdraws the product from sale, orders and carts that have it can still read it
+func (s service) ArchiveProduct(ctx context.Context, id string) (entity.Product, error) {
+	product, err := s.repo.GetProductByID(ctx, id)
+	if err != nil {
+		return entity.Product{}, err
+	}
+	if product.IsArchived() {
+		return product, nil
+	}
+
+	now := time.Now()
+	return s.repo.SetProductArchived(ctx, id, &now)
+}
+
+// RestoreProduct puts an archived product back on sale
+func (s service) RestoreProduct(ctx context.Context, id string) (entity.Product, error) {
+	product, err := s.repo.GetProductByID(ctx, id)
+	if err != nil {
+		return entity.Product{}, err
+	}
+	if !product.IsArchived() {
+		return product, nil
+	}
+
+	return s.repo.SetProductArchived(ctx, id, nil)
+}
+
+// DeleteProduct deletes a product that was never ordered along with its images, the repo refuses products orders or promotions refer to
+func (s service) DeleteProduct(ctx context.Context, id string) error {
+	product, err := s.repo.GetProductByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.DeleteProduct(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	for _, img := range product.Images {
+		s.deleteImageBlobs(ctx, img)
+	}
+	return nil
+}
+
+// DeleteProductVariant removes the variant from the product, orders keep the SKU and options of the variants they have
+func (s service) DeleteProductVariant(ctx context.Context, prs DeleteProductVariantParams) (entity.Product, error) {
+	product, err := s.repo.GetProductByID(ctx, prs.ProductID)
+	if err != nil {
+		return entity.Product{}, err
+	}
+	if product.Variant(prs.ID) < 0 {
+		return entity.Product{}, errors.New("variant not found")
+	}
+
+	return s.repo.SaveProduct(ctx, ProductUpdate{Product: entity.Product{ID: product.ID}, DeletedVariants: []string{prs.ID}})
+}
+
+// saveVariant saves the fields of the variant set on the product, a variant the stored product doesn't have is added.
+// Only that variant is saved, the stock of the others may have been taken meanwhile.
+func (s service) saveVariant(ctx context.Context, product entity.Product, id string, fields map[ProductField]bool) (entity.Product, error) {
+	err := normalizeProduct(&product)
+	if err != nil {
+		return entity.Product{}, err
+	}
+
+	variant := product.Variants[product.Variant(id)]
+	update := ProductUpdate{Product: entity.Product{ID: product.ID, Variants: []entity.ProductVariant{variant}}}
+	if fields != nil {
+		update.VariantFields = map[string]map[ProductField]bool{id: fields}
+	}
+	saved, err := s.repo.SaveProduct(ctx, update)
+	if err != nil {
+		return entity.Product{}, err
+	}
+
+	s.notifyBackInStock(ctx, product.InStock, saved)
+	return saved, nil
+}
+
+// sumVariantStock sets the stock of a product with variants to the total of its variants, without variants left it is out of stock
+func sumVariantStock(e *entity.Product) {
+	e.InStock = 0
+	for _, variant := range e.Variants {
+		e.InStock += variant.InStock
+	}
+}
+
+// normalizeProduct checks the options and variants of the product. The values of the variants are sorted in the order
+// of the product options, every variant must be a distinct combination of them.
+func normalizeProduct(e *entity.Product) error {
+	e.SKU = strings.TrimSpace(e.SKU)
+	if len(e.SKU) > maxSKULength {
+		return fmt.Errorf("SKU must be up to %d characters", maxSKULength)
+	}
+
+	options := make([]entity.ProductOption, len(e.Options))
+	for i, option := range e.Options {
+		option.Name = strings.TrimSpace(option.Name)
+		if option.Name == "" {
+			return errors.New("option name cannot be empty")
+		}
+		if slices.ContainsFunc(options[:i], func(o entity.ProductOption) bool { return strings.EqualFold(o.Name, option.Name) }) {
+			return fmt.Errorf("option %s is defined twice", option.Name)
+		}
+		if len(option.Values) == 0 {
+			return fmt.Errorf("option %s needs at least one value", option.Name)
+		}
+
+		values := make([]string, len(option.Values))
+		for j, value := range option.Values {
+			values[j] = strings.TrimSpace(value)
+			if values[j] == "" || slices.Contains(values[:j], values[j]) {
+				return fmt.Errorf("values of option %s must be distinct and not empty", option.Name)
+			}
+		}
+		options[i] = entity.ProductOption{Name: option.Name, Values: values}
+	}
+	e.Options = options
+
+	if len(e.Variants) > 0 && len(e.Options) == 0 {
+		return errors.New("add the options of the product before its variants")
+	}
+
+	variants := make([]entity.ProductVariant, len(e.Variants))
+	combinations := make(map[string]bool, len(e.Variants))
+	for i, variant := range e.Variants {
+		variant.SKU = strings.TrimSpace(variant.SKU)
+		if variant.SKU == "" || len(variant.SKU) > maxSKULength {
+			return fmt.Errorf("SKU must be 1 to %d characters", maxSKULength)
+		}
+		if variant.SKU == e.SKU || slices.ContainsFunc(variants[:i], func(v entity.ProductVariant) bool { return v.SKU == variant.SKU }) {
+			return fmt.Errorf("SKU %s is used by another variant", variant.SKU)
+		}
+		if variant.InStock < 0 {
+			return errors.New("stock cannot be negative")
+		}
+		if variant.Price != nil && variant.Price.IsNegative() {
+			return errors.New("price cannot be negative")
+		}
+
+		values, err := variantOptions(e.Options, variant.Options)
+		if err != nil {
+			return fmt.Errorf("variant %s: %w", variant.SKU, err)
+		}
+		variant.Options = values
+
+		combination := make([]string, len(values))
+		for j, value := range values {
+			combination[j] = value.Value
+		}
+		key := strings.Join(combination, "\x00")
+		if combinations[key] {
+			return fmt.Errorf("variant %s has the same options as another variant", variant.SKU)
+		}
+		combinations[key] = true
+		variants[i] = variant
+	}
+	if len(variants) > 0 {
+		e.Variants = variants
+	} else {
+		e.Variants = nil
+	}
+
+	return nil
+}
+
+// variantOptions matches the values of a variant with the product options, every option needs one of its values
+func variantOptions(options []entity.ProductOption, values []entity.VariantOption) ([]entity.VariantOption, error) {
+	if len(values) != len(options) {
+		return nil, errors.New("a value is needed for each option of the product")
+	}
+
+	matched := make([]entity.VariantOption, len(options))
+	for i, option := range options {
+		j := slices.IndexFunc(values, func(v entity.VariantOption) bool { return strings.EqualFold(strings.TrimSpace(v.Name), option.Name) })
+		if j < 0 {
+			return nil, fmt.Errorf("no value for option %s", option.Name)
+		}
+		value := strings.TrimSpace(values[j].Value)
+		if !slices.Contains(option.Values, value) {
+			return nil, fmt.Errorf("%s is not a value of option %s", value, option.Name)
+		}
+		matched[i] = entity.VariantOption{Name: option.Name, Value: value}
+	}
+
+	return matched, nil
+}
+
+// newOrderItem copies the variant of the product to the order item, the order keeps it when the variant changes
+func newOrderItem(product entity.Product, variantID string, quantity int32, unitPrice entity.Money) entity.OrderItem {
+	item := entity.OrderItem{ProductID: product.ID, SKU: product.SKU, Quantity: quantity, UnitPrice: unitPrice}
+	if i := product.Variant(variantID); i >= 0 {
+		item.VariantID = variantID
+		item.SKU = product.Variants[i].SKU
+		item.Options = product.Variants[i].Options
+	}
+	return item
+}
+
+type CreateProductVariantParams struct {
+	ProductID string
+	SKU       string
+	// Options has a value for each option of the product
+	Options []entity.VariantOption
+	// Price overrides the product price, the variant is sold at the product price without it
+	Price   *entity.Money
+	InStock int32
+	ActorID string
+}
+
+type UpdateProductVariantParams struct {
+	ProductID string
+	ID        string
+
+	SKU     *string
+	Options []entity.VariantOption
+	Price   *entity.Money
+	// ClearPrice removes the price override, the variant is sold at the product price again
+	ClearPrice bool
+	InStock    *int32
+	ActorID    string
+}
+
+// fields are the variant fields the params set
+func (p *UpdateProductVariantParams) fields() map[ProductField]bool {
+	fields := make(map[ProductField]bool)
+	if p.SKU != nil {
+		fields[ProductFieldSKU] = true
+	}
+	if p.Options != nil {
+		fields[ProductFieldOptions] = true
+	}
+	if p.Price != nil || p.ClearPrice {
+		fields[ProductFieldPrice] = true
+	}
+	if p.InStock != nil {
+		fields[ProductFieldInStock] = true
+	}
+	return fields
+}
+
+func (p *UpdateProductVariantParams) BindToVariant(e *entity.ProductVariant) {
+	if e == nil {
+		return
+	}
+	if p.SKU != nil {
+		e.SKU = *p.SKU
+	}
+	if p.Options != nil {
+		e.Options = p.Options
+	}
+	if p.Price != nil {
+		e.Price = p.Price
+	}
+	if p.ClearPrice {
+		e.Price = nil
+	}
+	if p.InStock != nil {
+		e.InStock = *p.InStock
+	}
+}
+
+type DeleteProductVariantParams struct {
+	ProductID string
+	ID        string
+}
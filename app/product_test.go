@@ -0,0 +1,158 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"graphql-backend/entity"
+)
+
+// productRepo keeps the products the edits read and save, the other methods of Repo are not used.
+// afterRead runs after a product is read, like a concurrent change would.
+type productRepo struct {
+	Repo
+	products  map[string]entity.Product
+	afterRead func()
+}
+
+func (r *productRepo) GetProductByID(ctx context.Context, id string) (entity.Product, error) {
+	product, ok := r.products[id]
+	if !ok {
+		return entity.Product{}, errors.New("product not found")
+	}
+	if r.afterRead != nil {
+		r.afterRead()
+	}
+	return product, nil
+}
+
+func (r *productRepo) SaveProduct(ctx context.Context, update ProductUpdate) (entity.Product, error) {
+	product, err := update.Apply(r.products[update.Product.ID])
+	if err != nil {
+		return entity.Product{}, err
+	}
+	r.products[product.ID] = product
+	return product, nil
+}
+
+func (r *productRepo) SetProductArchived(ctx context.Context, id string, archivedAt *time.Time) (entity.Product, error) {
+	product := r.products[id]
+	product.ArchivedAt = archivedAt
+	r.products[id] = product
+	return product, nil
+}
+
+func TestProductEditsKeepConcurrentChanges(t *testing.T) {
+	size := []entity.ProductOption{{Name: "Size", Values: []string{"S", "M"}}}
+	newRepo := func() *productRepo {
+		repo := &productRepo{products: map[string]entity.Product{"shirt": {
+			ID:      "shirt",
+			Name:    "Shirt",
+			Price:   entity.NewMoney(2000, "USD"),
+			InStock: 8,
+			Options: size,
+			Variants: []entity.ProductVariant{
+				{ID: "s", SKU: "SHIRT-S", Options: []entity.VariantOption{{Name: "Size", Value: "S"}}, InStock: 3},
+				{ID: "m", SKU: "SHIRT-M", Options: []entity.VariantOption{{Name: "Size", Value: "M"}}, InStock: 5},
+			},
+		}}}
+		// a checkout takes two M shirts and an image is added while the product is edited
+		repo.afterRead = func() {
+			product := cloneProduct(repo.products["shirt"])
+			product.Variants[1].InStock -= 2
+			product.InStock -= 2
+			product.Images = append(product.Images, entity.ProductImage{ID: "front"})
+			repo.products["shirt"] = product
+		}
+		return repo
+	}
+
+	t.Run("updating the product", func(t *testing.T) {
+		repo := newRepo()
+		name := "Linen shirt"
+		product, err := service{repo: repo}.UpdateProduct(context.TODO(), UpdateProductParams{ID: "shirt", Name: &name})
+		require.NoError(t, err)
+		require.Equal(t, "Linen shirt", product.Name)
+		require.Equal(t, int32(3), product.Variants[1].InStock)
+		require.Equal(t, int32(6), product.InStock)
+		require.Len(t, product.Images, 1)
+	})
+
+	t.Run("updating a variant", func(t *testing.T) {
+		repo := newRepo()
+		inStock := int32(10)
+		product, err := service{repo: repo}.UpdateProductVariant(context.TODO(), UpdateProductVariantParams{
+			ProductID: "shirt", ID: "s", InStock: &inStock,
+		})
+		require.NoError(t, err)
+		require.Equal(t, int32(10), product.Variants[0].InStock)
+		require.Equal(t, int32(3), product.Variants[1].InStock)
+		require.Equal(t, int32(13), product.InStock)
+		require.Len(t, product.Images, 1)
+	})
+
+	t.Run("adding a variant", func(t *testing.T) {
+		repo := newRepo()
+		repo.products["shirt"] = entity.Product{
+			ID: "shirt", Price: entity.NewMoney(2000, "USD"), InStock: 3, Options: size,
+			Variants: repo.products["shirt"].Variants[:1],
+		}
+		repo.afterRead = func() {
+			product := cloneProduct(repo.products["shirt"])
+			product.Variants[0].InStock--
+			product.InStock--
+			product.Images = append(product.Images, entity.ProductImage{ID: "front"})
+			repo.products["shirt"] = product
+		}
+		product, err := service{repo: repo}.CreateProductVariant(context.TODO(), CreateProductVariantParams{
+			ProductID: "shirt", SKU: "SHIRT-M", Options: []entity.VariantOption{{Name: "Size", Value: "M"}}, InStock: 4,
+		})
+		require.NoError(t, err)
+		require.Len(t, product.Variants, 2)
+		require.Equal(t, int32(2), product.Variants[0].InStock)
+		require.Equal(t, int32(6), product.InStock)
+		require.Len(t, product.Images, 1)
+	})
+
+	t.Run("deleting a variant", func(t *testing.T) {
+		repo := newRepo()
+		product, err := service{repo: repo}.DeleteProductVariant(context.TODO(), DeleteProductVariantParams{ProductID: "shirt", ID: "s"})
+		require.NoError(t, err)
+		require.Len(t, product.Variants, 1)
+		require.Equal(t, int32(3), product.InStock)
+		require.Len(t, product.Images, 1)
+	})
+
+	t.Run("archiving and restoring the product", func(t *testing.T) {
+		repo := newRepo()
+		product, err := service{repo: repo}.ArchiveProduct(context.TODO(), "shirt")
+		require.NoError(t, err)
+		require.True(t, product.IsArchived())
+		require.Equal(t, int32(6), product.InStock)
+		require.Len(t, product.Images, 1)
+
+		product, err = service{repo: repo}.RestoreProduct(context.TODO(), "shirt")
+		require.NoError(t, err)
+		require.False(t, product.IsArchived())
+		require.Equal(t, int32(4), product.InStock)
+		require.Len(t, product.Images, 2)
+	})
+
+	t.Run("updating a variant deleted meanwhile", func(t *testing.T) {
+		repo := newRepo()
+		repo.afterRead = func() {
+			product := cloneProduct(repo.products["shirt"])
+			product.Variants = product.Variants[1:]
+			repo.products["shirt"] = product
+		}
+		inStock := int32(10)
+		_, err := service{repo: repo}.UpdateProductVariant(context.TODO(), UpdateProductVariantParams{
+			ProductID: "shirt", ID: "s", InStock: &inStock,
+		})
+		require.EqualError(t, err, "variant not found")
+		require.Len(t, repo.products["shirt"].Variants, 1)
+	})
+}
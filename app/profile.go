@@ -0,0 +1,140 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"graphql-backend/entity"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+const EmailVerificationExpiration = 24 * time.Hour
+
+// UpdateProfile lets users edit their own profile. A new email is not applied right away,
+// a verification token is sent to it and the change is applied by VerifyEmail.
+func (s service) UpdateProfile(ctx context.Context, prs UpdateProfileParams) (entity.User, error) {
+	user, err := s.repo.GetUserByID(ctx, prs.UserID)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	if prs.Name != nil {
+		name := strings.TrimSpace(*prs.Name)
+		if name == "" {
+			return entity.User{}, errors.New("name cannot be empty")
+		}
+		user.Name = name
+	}
+
+	var token string
+	if prs.Email != nil && !strings.EqualFold(*prs.Email, user.Email) {
+		if prs.ActorID != "" {
+			return entity.User{}, errors.New("email cannot be changed while impersonating a user")
+		}
+
+		addr, err := mail.ParseAddress(*prs.Email)
+		if err != nil || addr.Address != *prs.Email {
+			return entity.User{}, errors.New("invalid email")
+		}
+		if _, err := s.repo.GetUserByEmail(ctx, addr.Address); err == nil {
+			return entity.User{}, errors.New("email is already in use")
+		}
+
+		token, err = newSecretToken()
+		if err != nil {
+			return entity.User{}, err
+		}
+		user.PendingEmail = &entity.EmailChange{
+			Email:     addr.Address,
+			TokenHash: hashSecretToken(token),
+			ExpiresAt: time.Now().Add(EmailVerificationExpiration),
+		}
+	}
+
+	user.UpdatedAt = time.Now()
+	err = s.repo.UpdateUser(ctx, user)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	if token != "" {
+		err = s.notifier.Notify(ctx, entity.Notification{
+			UserID:  user.ID,
+			To:      user.PendingEmail.Email,
+			Subject: "Verify your new email",
+			Body:    fmt.Sprintf("Use this token with the verifyEmail mutation to confirm your new email: %s", token),
+		})
+		if err != nil {
+			return entity.User{}, err
+		}
+	}
+
+	return user, nil
+}
+
+// VerifyEmail applies the pending email change when the token matches
+func (s service) VerifyEmail(ctx context.Context, prs VerifyEmailParams) (entity.User, error) {
+	user, err := s.repo.GetUserByID(ctx, prs.UserID)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	pending := user.PendingEmail
+	if pending == nil || time.Now().After(pending.ExpiresAt) {
+		return entity.User{}, errors.New("no pending email change")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashSecretToken(prs.Token)), []byte(pending.TokenHash)) != 1 {
+		return entity.User{}, errors.New("invalid verification token")
+	}
+
+	// the address could have been taken since the change was requested
+	if _, err := s.repo.GetUserByEmail(ctx, pending.Email); err == nil {
+		return entity.User{}, errors.New("email is already in use")
+	}
+
+	user.Email = pending.Email
+	user.PendingEmail = nil
+	user.UpdatedAt = time.Now()
+	err = s.repo.UpdateUser(ctx, user)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	return user, nil
+}
+
+// newSecretToken generates an opaque token, only its hash is stored
+func newSecretToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashSecretToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+type UpdateProfileParams struct {
+	UserID string
+	// ActorID is set when an admin is impersonating the user
+	ActorID string
+
+	Name  *string
+	Email *string
+}
+
+type VerifyEmailParams struct {
+	UserID string
+	Token  string
+}
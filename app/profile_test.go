@@ -0,0 +1,85 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"graphql-backend/entity"
+)
+
+// profileRepo keeps the users a profile update reads and writes, the other methods of Repo are not used
+type profileRepo struct {
+	Repo
+	users map[string]entity.User
+}
+
+func (r *profileRepo) GetUserByID(ctx context.Context, id string) (entity.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return entity.User{}, errors.New("user not found")
+	}
+	return user, nil
+}
+
+func (r *profileRepo) GetUserByEmail(ctx context.Context, email string) (entity.User, error) {
+	for _, user := range r.users {
+		if strings.EqualFold(user.Email, email) {
+			return user, nil
+		}
+	}
+	return entity.User{}, errors.New("user not found")
+}
+
+func (r *profileRepo) UpdateUser(ctx context.Context, e entity.User) error {
+	r.users[e.ID] = e
+	return nil
+}
+
+// sentNotifications records the notifications instead of delivering them
+type sentNotifications []entity.Notification
+
+func (n *sentNotifications) Notify(ctx context.Context, notification entity.Notification) error {
+	*n = append(*n, notification)
+	return nil
+}
+
+func TestVerifyEmail(t *testing.T) {
+	repo := &profileRepo{users: map[string]entity.User{
+		"alice": {ID: "alice", Name: "Alice", Email: "alice@example.com"},
+		"bob":   {ID: "bob", Name: "Bob", Email: "bob@example.com"},
+	}}
+	notifications := &sentNotifications{}
+	service := NewService(repo, nil, notifications, nil, nil, nil, nil)
+
+	newEmail := "alice.new@example.com"
+	user, err := service.UpdateProfile(context.TODO(), UpdateProfileParams{UserID: "alice", Email: &newEmail})
+	require.NoError(t, err)
+	require.Equal(t, "alice@example.com", user.Email)
+	require.Len(t, *notifications, 1)
+	sent := (*notifications)[0]
+	require.Equal(t, newEmail, sent.To)
+	token := sent.Body[strings.LastIndex(sent.Body, " ")+1:]
+
+	_, err = service.VerifyEmail(context.TODO(), VerifyEmailParams{UserID: "alice", Token: "wrong-token"})
+	require.Error(t, err)
+	_, err = service.VerifyEmail(context.TODO(), VerifyEmailParams{UserID: "bob", Token: token})
+	require.Error(t, err)
+
+	user, err = service.VerifyEmail(context.TODO(), VerifyEmailParams{UserID: "alice", Token: token})
+	require.NoError(t, err)
+	require.Equal(t, newEmail, user.Email)
+	require.Nil(t, user.PendingEmail)
+	require.Equal(t, newEmail, repo.users["alice"].Email)
+
+	// the token is single use
+	_, err = service.VerifyEmail(context.TODO(), VerifyEmailParams{UserID: "alice", Token: token})
+	require.Error(t, err)
+
+	// emails are taken whatever their case
+	taken := "ALICE.NEW@example.com"
+	_, err = service.UpdateProfile(context.TODO(), UpdateProfileParams{UserID: "bob", Email: &taken})
+	require.Error(t, err)
+}
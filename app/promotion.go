@@ -0,0 +1,360 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"graphql-backend/entity"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var promotionCodeFormat = regexp.MustCompile(`^[A-Z0-9_-]{3,32}$`)
+
+func (s service) CreatePromotion(ctx context.Context, prs CreatePromotionParams) (entity.Promotion, error) {
+	now := time.Now()
+	promotion := entity.Promotion{
+		ID:                uuid.NewString(),
+		Code:              prs.Code,
+		Description:       prs.Description,
+		Type:              prs.Type,
+		Percentage:        prs.Percentage,
+		Amount:            prs.Amount,
+		Scope:             prs.Scope,
+		CategoryIDs:       prs.CategoryIDs,
+		ProductIDs:        prs.ProductIDs,
+		MinOrderValue:     prs.MinOrderValue,
+		UsageLimit:        prs.UsageLimit,
+		UsageLimitPerUser: prs.UsageLimitPerUser,
+		Active:            prs.Active,
+		StartsAt:          prs.StartsAt,
+		EndsAt:            prs.EndsAt,
+		CreatedBy:         prs.CreatedBy,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	err := normalizePromotion(&promotion)
+	if err != nil {
+		return entity.Promotion{}, err
+	}
+	err = s.checkPromotionCategories(ctx, promotion)
+	if err != nil {
+		return entity.Promotion{}, err
+	}
+
+	err = s.repo.CreatePromotion(ctx, promotion)
+	if err != nil {
+		return entity.Promotion{}, err
+	}
+
+	return promotion, nil
+}
+
+func (s service) UpdatePromotion(ctx context.Context, prs UpdatePromotionParams) (entity.Promotion, error) {
+	promotion, err := s.repo.GetPromotionByID(ctx, prs.ID)
+	if err != nil {
+		return entity.Promotion{}, err
+	}
+
+	prs.BindToPromotion(&promotion)
+	err = normalizePromotion(&promotion)
+	if err != nil {
+		return entity.Promotion{}, err
+	}
+	err = s.checkPromotionCategories(ctx, promotion)
+	if err != nil {
+		return entity.Promotion{}, err
+	}
+
+	promotion.UpdatedAt = time.Now()
+	err = s.repo.UpdatePromotion(ctx, promotion)
+	if err != nil {
+		return entity.Promotion{}, err
+	}
+
+	return promotion, nil
+}
+
+// priceOrder computes the subtotal of the order items, applies the coupon when there is one, adds the taxes and sets the total.
+// The usage limits of the coupon are enforced by the repo when the order is stored.
+func (s service) priceOrder(ctx context.Context, order *entity.Order, products map[string]entity.Product, couponCode string) error {
+	subtotal := entity.Money{Currency: order.Currency}
+	taxable := make([]entity.Money, len(order.Items))
+	for i, item := range order.Items {
+		taxable[i] = item.UnitPrice.Mul(int64(item.Quantity))
+		subtotal = subtotal.Add(taxable[i])
+	}
+	order.Subtotal = subtotal
+	order.Discounts = nil
+	order.TaxLines = nil
+
+	categories, err := s.productCategories(ctx, products)
+	if err != nil {
+		return err
+	}
+
+	total := subtotal
+	if couponCode = strings.TrimSpace(couponCode); couponCode != "" {
+		discount, err := s.applyCoupon(ctx, *order, products, categories, couponCode, taxable)
+		if err != nil {
+			return err
+		}
+		order.Discounts = []entity.OrderDiscount{discount}
+		total = total.Sub(discount.Amount)
+	}
+
+	taxLines, err := s.taxOrder(ctx, *order, categories, taxable)
+	if err != nil {
+		return err
+	}
+	order.TaxLines = taxLines
+	order.Total = total.Add(order.GetTaxTotal())
+
+	return nil
+}
+
+// applyCoupon computes the discount of the coupon and spreads it over the taxable amounts of the items it applies to
+func (s service) applyCoupon(ctx context.Context, order entity.Order, products map[string]entity.Product, categories map[string][]entity.Category, couponCode string, taxable []entity.Money) (entity.OrderDiscount, error) {
+	promotion, err := s.repo.GetPromotionByCode(ctx, couponCode)
+	if err != nil || !promotion.IsRedeemable(order.CreatedAt) {
+		return entity.OrderDiscount{}, errors.New("invalid or expired coupon code")
+	}
+	// promotion amounts are in the base currency
+	if order.Subtotal.LessThan(promotion.MinOrderValue.Convert(order.Currency, order.ExchangeRate)) {
+		return entity.OrderDiscount{}, errors.New("order does not reach the minimum value of the coupon")
+	}
+
+	eligible := entity.Money{Currency: order.Subtotal.Currency}
+	eligibleItems := make([]bool, len(order.Items))
+	for i, item := range order.Items {
+		if product, ok := products[item.ProductID]; ok && promotion.AppliesTo(product, categories[item.ProductID]) {
+			eligibleItems[i] = true
+			eligible = eligible.Add(taxable[i])
+		}
+	}
+	if eligible.IsZero() {
+		return entity.OrderDiscount{}, errors.New("coupon does not apply to any item of the order")
+	}
+
+	var amount entity.Money
+	switch promotion.Type {
+	case entity.PromotionTypePercentage:
+		amount = eligible.Percent(promotion.Percentage)
+	case entity.PromotionTypeFixedAmount:
+		amount = entity.MinMoney(promotion.Amount.Convert(order.Currency, order.ExchangeRate), eligible)
+	}
+
+	// the last eligible item takes the rounding remainder, so that the shares add up to the discount
+	remaining := amount
+	last := -1
+	for i := range taxable {
+		if eligibleItems[i] {
+			last = i
+		}
+	}
+	for i := range taxable {
+		if !eligibleItems[i] {
+			continue
+		}
+		share := amount.Share(taxable[i], eligible)
+		if i == last {
+			share = remaining
+		}
+		remaining = remaining.Sub(share)
+		taxable[i] = taxable[i].Sub(share)
+	}
+
+	return entity.OrderDiscount{
+		PromotionID: promotion.ID,
+		Code:        promotion.Code,
+		Description: promotion.Description,
+		Amount:      amount,
+	}, nil
+}
+
+// checkPromotionCategories checks that the categories of the promotion exist
+func (s service) checkPromotionCategories(ctx context.Context, e entity.Promotion) error {
+	for _, id := range e.CategoryIDs {
+		if _, err := s.repo.GetCategoryByID(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// normalizePromotion upper-cases the code and validates the promotion
+func normalizePromotion(e *entity.Promotion) error {
+	e.Code = strings.ToUpper(strings.TrimSpace(e.Code))
+	if !promotionCodeFormat.MatchString(e.Code) {
+		return errors.New("coupon code must be 3 to 32 letters, digits, dashes or underscores")
+	}
+
+	switch e.Type {
+	case entity.PromotionTypePercentage:
+		if e.Percentage <= 0 || e.Percentage > 100 {
+			return errors.New("percentage must be between 0 and 100")
+		}
+		e.Amount = entity.Money{}
+	case entity.PromotionTypeFixedAmount:
+		if e.Amount.Currency != entity.DefaultCurrency || e.Amount.IsNegative() || e.Amount.IsZero() {
+			return errors.New("amount must be positive and in the base currency")
+		}
+		e.Percentage = 0
+	default:
+		return errors.New("invalid promotion type")
+	}
+
+	switch e.Scope {
+	case entity.PromotionScopeOrder:
+		e.CategoryIDs = nil
+		e.ProductIDs = nil
+	case entity.PromotionScopeCategory:
+		if len(e.CategoryIDs) == 0 {
+			return errors.New("category promotions need at least one category")
+		}
+		e.ProductIDs = nil
+	case entity.PromotionScopeProduct:
+		if len(e.ProductIDs) == 0 {
+			return errors.New("product promotions need at least one product")
+		}
+		e.CategoryIDs = nil
+	default:
+		return errors.New("invalid promotion scope")
+	}
+
+	if e.MinOrderValue.IsNegative() {
+		return errors.New("minimum order value cannot be negative")
+	}
+	if e.UsageLimit != nil && *e.UsageLimit <= 0 {
+		return errors.New("usage limit must be positive")
+	}
+	if e.UsageLimitPerUser != nil && *e.UsageLimitPerUser <= 0 {
+		return errors.New("usage limit per user must be positive")
+	}
+	if e.StartsAt != nil && e.EndsAt != nil && !e.EndsAt.After(*e.StartsAt) {
+		return errors.New("promotion must end after it starts")
+	}
+
+	return nil
+}
+
+type CreatePromotionParams struct {
+	Code              string
+	Description       string
+	Type              entity.PromotionType
+	Percentage        float64
+	Amount            entity.Money
+	Scope             entity.PromotionScope
+	CategoryIDs       []string
+	ProductIDs        []string
+	MinOrderValue     entity.Money
+	UsageLimit        *int32
+	UsageLimitPerUser *int32
+	Active            bool
+	StartsAt          *time.Time
+	EndsAt            *time.Time
+	CreatedBy         string
+}
+
+type UpdatePromotionParams struct {
+	ID string
+
+	Code              *string
+	Description       *string
+	Type              *entity.PromotionType
+	Percentage        *float64
+	Amount            *entity.Money
+	Scope             *entity.PromotionScope
+	CategoryIDs       []string
+	ProductIDs        []string
+	MinOrderValue     *entity.Money
+	UsageLimit        *int32
+	UsageLimitPerUser *int32
+	Active            *bool
+	StartsAt          *time.Time
+	EndsAt            *time.Time
+
+	// the Clear fields remove the optional limits, they take precedence over the new values
+	ClearUsageLimit        bool
+	ClearUsageLimitPerUser bool
+	ClearStartsAt          bool
+	ClearEndsAt            bool
+}
+
+func (p *UpdatePromotionParams) BindToPromotion(e *entity.Promotion) {
+	if e == nil {
+		return
+	}
+	if p.Code != nil {
+		e.Code = *p.Code
+	}
+	if p.Description != nil {
+		e.Description = *p.Description
+	}
+	if p.Type != nil {
+		e.Type = *p.Type
+	}
+	if p.Percentage != nil {
+		e.Percentage = *p.Percentage
+	}
+	if p.Amount != nil {
+		e.Amount = *p.Amount
+	}
+	if p.Scope != nil {
+		e.Scope = *p.Scope
+	}
+	if p.CategoryIDs != nil {
+		e.CategoryIDs = p.CategoryIDs
+	}
+	if p.ProductIDs != nil {
+		e.ProductIDs = p.ProductIDs
+	}
+	if p.MinOrderValue != nil {
+		e.MinOrderValue = *p.MinOrderValue
+	}
+	if p.UsageLimit != nil {
+		e.UsageLimit = p.UsageLimit
+	}
+	if p.UsageLimitPerUser != nil {
+		e.UsageLimitPerUser = p.UsageLimitPerUser
+	}
+	if p.Active != nil {
+		e.Active = *p.Active
+	}
+	if p.StartsAt != nil {
+		e.StartsAt = p.StartsAt
+	}
+	if p.EndsAt != nil {
+		e.EndsAt = p.EndsAt
+	}
+	if p.ClearUsageLimit {
+		e.UsageLimit = nil
+	}
+	if p.ClearUsageLimitPerUser {
+		e.UsageLimitPerUser = nil
+	}
+	if p.ClearStartsAt {
+		e.StartsAt = nil
+	}
+	if p.ClearEndsAt {
+		e.EndsAt = nil
+	}
+}
+
+type PromotionsParams struct {
+	Limit  *int32
+	Offset *int32
+	Active *bool
+}
+
+func (p *PromotionsParams) SetDefaults() {
+	if p.Limit == nil || *p.Limit <= 0 {
+		defaultLimit := int32(10)
+		p.Limit = &defaultLimit
+	}
+	if p.Offset == nil || *p.Offset < 0 {
+		defaultOffset := int32(0)
+		p.Offset = &defaultOffset
+	}
+}
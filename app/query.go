@@ -3,26 +3,85 @@ package app
 import (
 	"context"
 	"graphql-backend/entity"
+	"io"
 )
 
 type Query interface {
 	GetProducts(ctx context.Context, prs ProductsParams) ([]entity.Product, error)
-	GetProduct(ctx context.Context, id string) (entity.Product, error)
+	GetProduct(ctx context.Context, prs ProductParams) (entity.Product, error)
+	GetCategories(ctx context.Context) ([]CategoryTree, error)
+	GetCategory(ctx context.Context, prs CategoryParams) (CategoryTree, error)
+	ExportProducts(ctx context.Context, prs ExportProductsParams, w io.Writer) error
 
 	GetOrders(ctx context.Context, prs OrdersParams) ([]entity.Order, error)
 	GetOrder(ctx context.Context, prs OrderParams) (entity.Order, error)
 
 	GetUser(ctx context.Context, id string) (entity.User, error)
+	GetUsers(ctx context.Context, prs UsersParams) ([]entity.User, error)
+
+	GetAPIKeys(ctx context.Context) ([]entity.APIKey, error)
+	GetAuditEntries(ctx context.Context, prs AuditEntriesParams) ([]entity.AuditEntry, error)
+
+	GetDataExportContent(ctx context.Context, id string) (entity.DataExport, []byte, error)
+
+	GetAddresses(ctx context.Context, userID string) ([]entity.Address, error)
+	GetCart(ctx context.Context, owner CartOwner, currency string) (CartView, error)
+	GetWishlist(ctx context.Context, userID string) (entity.Wishlist, error)
+
+	GetPromotions(ctx context.Context, prs PromotionsParams) ([]entity.Promotion, error)
+	GetPromotion(ctx context.Context, id string) (entity.Promotion, error)
+
+	GetReturns(ctx context.Context, prs ReturnsParams) ([]entity.ReturnRequest, error)
+	GetReviews(ctx context.Context, prs ReviewsParams) ([]entity.Review, error)
+	GetScheduledPrices(ctx context.Context, prs ScheduledPricesParams) ([]entity.ScheduledPrice, error)
 }
 
 type query struct {
-	repo Repo
+	repo  Repo
+	rates ExchangeRateProvider
 }
 
 func (q *query) GetUser(ctx context.Context, id string) (entity.User, error) {
 	return q.repo.GetUserByID(ctx, id)
 }
 
+func (q *query) GetUsers(ctx context.Context, prs UsersParams) ([]entity.User, error) {
+	prs.SetDefaults()
+	return q.repo.GetUsers(ctx, prs)
+}
+
+func (q *query) GetAPIKeys(ctx context.Context) ([]entity.APIKey, error) {
+	return q.repo.GetAPIKeys(ctx)
+}
+
+func (q *query) GetAuditEntries(ctx context.Context, prs AuditEntriesParams) ([]entity.AuditEntry, error) {
+	prs.SetDefaults()
+	return q.repo.GetAuditEntries(ctx, prs)
+}
+
+func (q *query) GetAddresses(ctx context.Context, userID string) ([]entity.Address, error) {
+	return q.repo.GetAddressesByUserID(ctx, userID)
+}
+
+func (q *query) GetPromotions(ctx context.Context, prs PromotionsParams) ([]entity.Promotion, error) {
+	prs.SetDefaults()
+	return q.repo.GetPromotions(ctx, prs)
+}
+
+func (q *query) GetPromotion(ctx context.Context, id string) (entity.Promotion, error) {
+	return q.repo.GetPromotionByID(ctx, id)
+}
+
+func (q *query) GetReturns(ctx context.Context, prs ReturnsParams) ([]entity.ReturnRequest, error) {
+	prs.SetDefaults()
+	return q.repo.GetReturns(ctx, prs)
+}
+
+func (q *query) GetReviews(ctx context.Context, prs ReviewsParams) ([]entity.Review, error) {
+	prs.SetDefaults()
+	return q.repo.GetReviews(ctx, prs)
+}
+
 func (q *query) GetOrders(ctx context.Context, prs OrdersParams) ([]entity.Order, error) {
 	prs.SetDefaults()
 	return q.repo.GetOrders(ctx, prs)
@@ -32,23 +91,56 @@ func (q *query) GetOrder(ctx context.Context, prs OrderParams) (entity.Order, er
 	return q.repo.GetOrder(ctx, prs)
 }
 
-func (q *query) GetProduct(ctx context.Context, id string) (entity.Product, error) {
-	return q.repo.GetProductByID(ctx, id)
+func (q *query) GetProduct(ctx context.Context, prs ProductParams) (entity.Product, error) {
+	product, err := q.repo.GetProductByID(ctx, prs.ID)
+	if err != nil {
+		return entity.Product{}, err
+	}
+
+	products := []entity.Product{product}
+	err = convertPrices(ctx, q.rates, products, prs.Currency)
+	if err != nil {
+		return entity.Product{}, err
+	}
+
+	return products[0], nil
 }
 
 func (q *query) GetProducts(ctx context.Context, prs ProductsParams) ([]entity.Product, error) {
 	prs.SetDefaults()
-	return q.repo.GetProducts(ctx, prs)
+	products, err := q.repo.GetProducts(ctx, prs)
+	if err != nil {
+		return nil, err
+	}
+
+	err = convertPrices(ctx, q.rates, products, prs.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+func NewQuery(repo Repo, rates ExchangeRateProvider) Query {
+	return &query{repo: repo, rates: rates}
 }
 
-func NewQuery(repo Repo) Query {
-	return &query{repo: repo}
+type ProductParams struct {
+	ID string
+	// Currency the price is converted to, the base currency when empty
+	Currency string
 }
 
 type ProductsParams struct {
-	Limit    *int32
-	Offset   *int32
-	Category *string
+	Limit  *int32
+	Offset *int32
+	// Category matches the category names containing it, CategoryID the products of the category and its subcategories
+	Category   *string
+	CategoryID *string
+	// Archived lists the archived products instead of the ones on sale
+	Archived bool
+	// Currency the prices are converted to, the base currency when empty
+	Currency string
 }
 
 func (p *ProductsParams) SetDefaults() {
@@ -84,6 +176,49 @@ func (o *OrdersParams) SetDefaults() {
 }
 
 type OrderParams struct {
-	ID     string
+	ID string
+	// UserID restricts the lookup to orders of that user when set
 	UserID string
 }
+
+type UsersParams struct {
+	Limit  *int32
+	Offset *int32
+	Role   *string
+	Status *entity.UserStatus
+	// Search matches a part of the name or email, case-insensitive
+	Search *string
+}
+
+func (u *UsersParams) SetDefaults() {
+	if u.Limit == nil || *u.Limit <= 0 {
+		defaultLimit := int32(10)
+		u.Limit = &defaultLimit
+	}
+	if u.Offset == nil || *u.Offset < 0 {
+		defaultOffset := int32(0)
+		u.Offset = &defaultOffset
+	}
+	if u.Search == nil {
+		defaultSearch := ""
+		u.Search = &defaultSearch
+	}
+}
+
+type AuditEntriesParams struct {
+	Limit   *int32
+	Offset  *int32
+	ActorID *string
+	UserID  *string
+}
+
+func (a *AuditEntriesParams) SetDefaults() {
+	if a.Limit == nil || *a.Limit <= 0 {
+		defaultLimit := int32(10)
+		a.Limit = &defaultLimit
+	}
+	if a.Offset == nil || *a.Offset < 0 {
+		defaultOffset := int32(0)
+		a.Offset = &defaultOffset
+	}
+}
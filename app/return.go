@@ -0,0 +1,317 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"graphql-backend/entity"
+	"log"
+	"slices"
+	"strings"
+	"time"
+)
+
+// RequestReturn records a customer's request to return items of a paid order, the quantities are checked by the repo
+func (s service) RequestReturn(ctx context.Context, prs RequestReturnParams) (entity.ReturnRequest, error) {
+	reason := strings.TrimSpace(prs.Reason)
+	if reason == "" {
+		return entity.ReturnRequest{}, errors.New("reason cannot be empty")
+	}
+
+	order, err := s.repo.GetOrder(ctx, OrderParams{ID: prs.OrderID, UserID: prs.UserID})
+	if err != nil {
+		return entity.ReturnRequest{}, err
+	}
+	if !isRefundable(order) {
+		return entity.ReturnRequest{}, errors.New("only paid orders can be returned")
+	}
+
+	items, err := returnItems(order, prs.Items)
+	if err != nil {
+		return entity.ReturnRequest{}, err
+	}
+
+	now := time.Now()
+	request := entity.ReturnRequest{
+		ID:        uuid.NewString(),
+		OrderID:   order.ID,
+		UserID:    order.UserID,
+		Items:     items,
+		Reason:    reason,
+		Status:    entity.ReturnStatusRequested,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	err = s.repo.CreateReturn(ctx, request)
+	if err != nil {
+		return entity.ReturnRequest{}, err
+	}
+
+	return request, nil
+}
+
+// ApproveReturn refunds the share of the order total paid for the returned items and puts them back in stock when asked to.
+// The return and its order are claimed first, so that concurrent reviews and refunds can't refund twice.
+func (s service) ApproveReturn(ctx context.Context, prs ReviewReturnParams) (entity.ReturnRequest, error) {
+	request, err := s.repo.ClaimReturn(ctx, prs.ID, entity.ReturnStatusRequested, entity.ReturnStatusApproving)
+	if err != nil {
+		return entity.ReturnRequest{}, err
+	}
+	order, err := s.repo.ClaimOrder(ctx, request.OrderID, refundableStatuses, entity.OrderStatusRefunding)
+	if err != nil {
+		s.releaseReturn(ctx, request)
+		return entity.ReturnRequest{}, err
+	}
+
+	refund, err := s.refundReturn(ctx, &order, request, prs.ReviewerID)
+	if err != nil {
+		s.releaseOrder(ctx, order)
+		s.releaseReturn(ctx, request)
+		return entity.ReturnRequest{}, err
+	}
+
+	claimed := order
+	order.Status = refundedStatus(order)
+	err = s.repo.UpdateOrder(ctx, order)
+	if err != nil {
+		s.releaseOrder(ctx, claimed)
+		s.releaseReturn(ctx, request)
+		return entity.ReturnRequest{}, err
+	}
+
+	// the refund is recorded by now, a failed restock leaves the return approved without restocking it
+	if prs.Restock {
+		err = s.repo.RestockProducts(ctx, request.Items)
+		if err != nil {
+			log.Printf("failed to restock the items of return request %s: %v", request.ID, err)
+		}
+		request.Restocked = err == nil
+	}
+
+	request.Status = entity.ReturnStatusApproved
+	request.RefundID = refund.ID
+	return s.saveReview(ctx, request, prs)
+}
+
+func (s service) RejectReturn(ctx context.Context, prs ReviewReturnParams) (entity.ReturnRequest, error) {
+	request, err := s.repo.ClaimReturn(ctx, prs.ID, entity.ReturnStatusRequested, entity.ReturnStatusRejected)
+	if err != nil {
+		return entity.ReturnRequest{}, err
+	}
+
+	request.Status = entity.ReturnStatusRejected
+	return s.saveReview(ctx, request, prs)
+}
+
+func (s service) refundReturn(ctx context.Context, order *entity.Order, request entity.ReturnRequest, reviewerID string) (entity.Refund, error) {
+	amount, err := s.returnAmount(ctx, *order, request)
+	if err != nil {
+		return entity.Refund{}, err
+	}
+	payment, err := s.capturedPayment(ctx, order.ID)
+	if err != nil {
+		return entity.Refund{}, err
+	}
+
+	return s.refundPayment(ctx, order, payment, amount, "Return: "+request.Reason, request.ID, reviewerID)
+}
+
+// RefundOrder refunds an amount of the order without a return, e.g. as a goodwill gesture.
+// The whole amount left to refund is refunded when no amount is given.
+func (s service) RefundOrder(ctx context.Context, prs RefundOrderParams) (entity.Order, error) {
+	reason := strings.TrimSpace(prs.Reason)
+	if reason == "" {
+		return entity.Order{}, errors.New("reason cannot be empty")
+	}
+
+	// the order is claimed so that concurrent refunds can't refund twice
+	order, err := s.repo.ClaimOrder(ctx, prs.OrderID, refundableStatuses, entity.OrderStatusRefunding)
+	if err != nil {
+		return entity.Order{}, err
+	}
+
+	err = s.refundOrder(ctx, &order, prs.Amount, reason, prs.ActorID)
+	if err != nil {
+		s.releaseOrder(ctx, order)
+		return entity.Order{}, err
+	}
+
+	claimed := order
+	order.Status = refundedStatus(order)
+	err = s.repo.UpdateOrder(ctx, order)
+	if err != nil {
+		s.releaseOrder(ctx, claimed)
+		return entity.Order{}, err
+	}
+
+	return order, nil
+}
+
+// refundOrder refunds the amount, in major units of the order currency, or everything left to refund when it is nil
+func (s service) refundOrder(ctx context.Context, order *entity.Order, requested *entity.Money, reason string, actorID string) error {
+	payment, err := s.capturedPayment(ctx, order.ID)
+	if err != nil {
+		return err
+	}
+
+	amount := payment.Amount.Sub(payment.RefundedAmount)
+	if requested != nil {
+		amount, err = entity.ParseMoney(requested.String(), order.Currency)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = s.refundPayment(ctx, order, payment, amount, reason, "", actorID)
+	return err
+}
+
+// releaseOrder gives a claimed order its status back after its refund or its update failed
+func (s service) releaseOrder(ctx context.Context, order entity.Order) {
+	if _, err := s.repo.ClaimOrder(ctx, order.ID, []entity.OrderStatus{entity.OrderStatusRefunding}, order.Status); err != nil {
+		log.Printf("failed to release order %s: %v", order.ID, err)
+	}
+}
+
+// releaseReturn puts a claimed return request back in review after its refund or the order update failed
+func (s service) releaseReturn(ctx context.Context, request entity.ReturnRequest) {
+	if _, err := s.repo.ClaimReturn(ctx, request.ID, entity.ReturnStatusApproving, entity.ReturnStatusRequested); err != nil {
+		log.Printf("failed to release return request %s: %v", request.ID, err)
+	}
+}
+
+func (s service) saveReview(ctx context.Context, request entity.ReturnRequest, prs ReviewReturnParams) (entity.ReturnRequest, error) {
+	request.ReviewNote = strings.TrimSpace(prs.Note)
+	request.ReviewedBy = prs.ReviewerID
+	request.UpdatedAt = time.Now()
+	err := s.repo.UpdateReturn(ctx, request)
+	if err != nil {
+		return entity.ReturnRequest{}, err
+	}
+
+	return request, nil
+}
+
+// returnAmount is the share of the order total paid for the returned items, discounts and taxes included.
+// The return that brings back the last ordered items gets what is left, so that rounding doesn't leave cents behind.
+func (s service) returnAmount(ctx context.Context, order entity.Order, request entity.ReturnRequest) (entity.Money, error) {
+	unitPrices := make(map[entity.ItemKey]entity.Money, len(order.Items))
+	remaining := map[entity.ItemKey]int32{}
+	for _, item := range order.Items {
+		unitPrices[item.Key()] = item.UnitPrice
+		remaining[item.Key()] += item.Quantity
+	}
+
+	returned := entity.Money{Currency: order.Currency}
+	for _, item := range request.Items {
+		returned = returned.Add(unitPrices[item.Key()].Mul(int64(item.Quantity)))
+		remaining[item.Key()] -= item.Quantity
+	}
+
+	requests, err := s.repo.GetReturnsByOrderIDs(ctx, []string{order.ID})
+	if err != nil {
+		return entity.Money{}, err
+	}
+	for _, approved := range requests[order.ID] {
+		if approved.Status != entity.ReturnStatusApproved {
+			continue
+		}
+		for _, item := range approved.Items {
+			remaining[item.Key()] -= item.Quantity
+		}
+	}
+
+	left := order.Total.Sub(order.GetRefundedTotal())
+	for _, quantity := range remaining {
+		if quantity > 0 {
+			return entity.MinMoney(order.Total.Share(returned, order.Subtotal), left), nil
+		}
+	}
+	return left, nil
+}
+
+// returnItems merges the requested quantities by product and variant and checks that they were ordered
+func returnItems(order entity.Order, requested []entity.ReturnItem) ([]entity.ReturnItem, error) {
+	if len(requested) == 0 {
+		return nil, errors.New("items cannot be empty")
+	}
+
+	ordered := make(map[entity.ItemKey]bool, len(order.Items))
+	for _, item := range order.Items {
+		ordered[item.Key()] = true
+	}
+
+	var items []entity.ReturnItem
+	index := map[entity.ItemKey]int{}
+	for _, item := range requested {
+		if item.Quantity <= 0 {
+			return nil, errors.New("quantity must be positive")
+		}
+		if !ordered[item.Key()] {
+			return nil, errors.New("product " + item.ProductID + " is not an item of the order")
+		}
+		if i, ok := index[item.Key()]; ok {
+			items[i].Quantity += item.Quantity
+			continue
+		}
+		index[item.Key()] = len(items)
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// refundableStatuses are the statuses of the orders that were paid and not refunded in full
+var refundableStatuses = []entity.OrderStatus{entity.OrderStatusPaid, entity.OrderStatusCompleted, entity.OrderStatusPartiallyRefunded}
+
+func isRefundable(order entity.Order) bool {
+	return slices.Contains(refundableStatuses, order.Status)
+}
+
+// refundedStatus is the status of an order after a refund, Refunded once the whole total is refunded
+func refundedStatus(order entity.Order) entity.OrderStatus {
+	if order.GetRefundedTotal().LessThan(order.Total) {
+		return entity.OrderStatusPartiallyRefunded
+	}
+	return entity.OrderStatusRefunded
+}
+
+type RequestReturnParams struct {
+	UserID  string
+	OrderID string
+	Items   []entity.ReturnItem
+	Reason  string
+}
+
+type ReviewReturnParams struct {
+	ID         string
+	ReviewerID string
+	Note       string
+	// Restock puts the returned quantities back in stock when the return is approved
+	Restock bool
+}
+
+type RefundOrderParams struct {
+	OrderID string
+	// Amount is in major units of the order currency, nil refunds everything left
+	Amount  *entity.Money
+	Reason  string
+	ActorID string
+}
+
+type ReturnsParams struct {
+	Limit  *int32
+	Offset *int32
+	Status *entity.ReturnStatus
+}
+
+func (p *ReturnsParams) SetDefaults() {
+	if p.Limit == nil || *p.Limit <= 0 {
+		defaultLimit := int32(10)
+		p.Limit = &defaultLimit
+	}
+	if p.Offset == nil || *p.Offset < 0 {
+		defaultOffset := int32(0)
+		p.Offset = &defaultOffset
+	}
+}
@@ -0,0 +1,98 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"graphql-backend/entity"
+)
+
+// refundRepo keeps an order, its payment and return requests but fails to update the order, the other methods of Repo are not used
+type refundRepo struct {
+	Repo
+	order    entity.Order
+	payment  entity.Payment
+	requests map[string]entity.ReturnRequest
+}
+
+func (r *refundRepo) ClaimOrder(ctx context.Context, id string, from []entity.OrderStatus, to entity.OrderStatus) (entity.Order, error) {
+	if !slices.Contains(from, r.order.Status) {
+		return entity.Order{}, fmt.Errorf("order is %s", r.order.Status)
+	}
+	order := r.order
+	r.order.Status = to
+	return order, nil
+}
+
+func (r *refundRepo) ClaimReturn(ctx context.Context, id string, from entity.ReturnStatus, to entity.ReturnStatus) (entity.ReturnRequest, error) {
+	request := r.requests[id]
+	if request.Status != from {
+		return entity.ReturnRequest{}, fmt.Errorf("return request is %s", request.Status)
+	}
+	claimed := request
+	claimed.Status = to
+	r.requests[id] = claimed
+	return request, nil
+}
+
+func (r *refundRepo) UpdateOrder(ctx context.Context, e entity.Order) error {
+	return errors.New("database unavailable")
+}
+
+func (r *refundRepo) GetPaymentsByOrderIDs(ctx context.Context, ids []string) (map[string][]entity.Payment, error) {
+	return map[string][]entity.Payment{r.payment.OrderID: {r.payment}}, nil
+}
+
+func (r *refundRepo) UpdatePayment(ctx context.Context, e entity.Payment) error {
+	r.payment = e
+	return nil
+}
+
+func (r *refundRepo) GetReturnsByOrderIDs(ctx context.Context, ids []string) (map[string][]entity.ReturnRequest, error) {
+	return map[string][]entity.ReturnRequest{}, nil
+}
+
+func newRefundRepo() *refundRepo {
+	total := entity.NewMoney(1000, "USD")
+	return &refundRepo{
+		order: entity.Order{
+			ID:       "order-1",
+			Status:   entity.OrderStatusPaid,
+			Currency: "USD",
+			Items:    []entity.OrderItem{{ProductID: "product-1", Quantity: 1, UnitPrice: total}},
+			Subtotal: total,
+			Total:    total,
+		},
+		payment: entity.Payment{ID: "payment-1", OrderID: "order-1", Amount: total, Status: entity.PaymentStatusCaptured},
+		requests: map[string]entity.ReturnRequest{"return-1": {
+			ID:      "return-1",
+			OrderID: "order-1",
+			Items:   []entity.ReturnItem{{ProductID: "product-1", Quantity: 1}},
+			Status:  entity.ReturnStatusRequested,
+		}},
+	}
+}
+
+func TestFailedRefundUpdateReleasesTheOrder(t *testing.T) {
+	repo := newRefundRepo()
+	s := service{repo: repo, payments: &refundingGateway{}}
+
+	_, err := s.RefundOrder(context.TODO(), RefundOrderParams{OrderID: "order-1", Reason: "Goodwill"})
+	require.Error(t, err)
+	// the order isn't left in Refunding, it can be refunded or cancelled again
+	require.Equal(t, entity.OrderStatusPaid, repo.order.Status)
+}
+
+func TestFailedReturnApprovalReleasesTheOrderAndReturn(t *testing.T) {
+	repo := newRefundRepo()
+	s := service{repo: repo, payments: &refundingGateway{}}
+
+	_, err := s.ApproveReturn(context.TODO(), ReviewReturnParams{ID: "return-1"})
+	require.Error(t, err)
+	require.Equal(t, entity.OrderStatusPaid, repo.order.Status)
+	require.Equal(t, entity.ReturnStatusRequested, repo.requests["return-1"].Status)
+}
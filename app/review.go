@@ -0,0 +1,125 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"graphql-backend/entity"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const maxReviewLength = 5000
+
+// CreateReview records the review of a customer with a completed order of the product, it waits for moderation
+func (s service) CreateReview(ctx context.Context, prs CreateReviewParams) (entity.Review, error) {
+	if prs.Rating < 1 || prs.Rating > 5 {
+		return entity.Review{}, errors.New("rating must be between 1 and 5")
+	}
+	body := strings.TrimSpace(prs.Body)
+	if utf8.RuneCountInString(body) > maxReviewLength {
+		return entity.Review{}, fmt.Errorf("review cannot be longer than %d characters", maxReviewLength)
+	}
+
+	user, err := s.repo.GetUserByID(ctx, prs.UserID)
+	if err != nil {
+		return entity.Review{}, err
+	}
+	product, err := s.repo.GetProductByID(ctx, prs.ProductID)
+	if err != nil {
+		return entity.Review{}, err
+	}
+
+	orders, err := s.repo.GetOrdersByUserIDs(ctx, []string{user.ID})
+	if err != nil {
+		return entity.Review{}, err
+	}
+	received := slices.ContainsFunc(orders[user.ID], func(order entity.Order) bool {
+		return order.Status == entity.OrderStatusCompleted && slices.Contains(order.ProductIDs, product.ID)
+	})
+	if !received {
+		return entity.Review{}, errors.New("only products of completed orders can be reviewed")
+	}
+
+	now := time.Now()
+	review := entity.Review{
+		ID:         uuid.NewString(),
+		ProductID:  product.ID,
+		UserID:     user.ID,
+		AuthorName: user.Name,
+		Rating:     prs.Rating,
+		Body:       body,
+		Status:     entity.ReviewStatusPending,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	err = s.repo.CreateReview(ctx, review)
+	if err != nil {
+		return entity.Review{}, err
+	}
+
+	return review, nil
+}
+
+// ApproveReview publishes a pending or hidden review, it counts in the rating of the product from then on
+func (s service) ApproveReview(ctx context.Context, prs ModerateReviewParams) (entity.Review, error) {
+	return s.moderateReview(ctx, prs, entity.ReviewStatusApproved)
+}
+
+// HideReview takes a review out of the product reviews and rating, it can be approved again
+func (s service) HideReview(ctx context.Context, prs ModerateReviewParams) (entity.Review, error) {
+	return s.moderateReview(ctx, prs, entity.ReviewStatusHidden)
+}
+
+func (s service) moderateReview(ctx context.Context, prs ModerateReviewParams, status entity.ReviewStatus) (entity.Review, error) {
+	review, err := s.repo.GetReviewByID(ctx, prs.ID)
+	if err != nil {
+		return entity.Review{}, err
+	}
+	if review.Status == status {
+		return review, nil
+	}
+
+	review.Status = status
+	review.ModeratedBy = prs.ModeratorID
+	review.UpdatedAt = time.Now()
+	err = s.repo.UpdateReview(ctx, review)
+	if err != nil {
+		return entity.Review{}, err
+	}
+
+	return review, nil
+}
+
+type CreateReviewParams struct {
+	UserID    string
+	ProductID string
+	Rating    int32
+	Body      string
+}
+
+type ModerateReviewParams struct {
+	ID          string
+	ModeratorID string
+}
+
+type ReviewsParams struct {
+	Limit     *int32
+	Offset    *int32
+	Status    *entity.ReviewStatus
+	ProductID string
+}
+
+func (p *ReviewsParams) SetDefaults() {
+	if p.Limit == nil || *p.Limit <= 0 {
+		defaultLimit := int32(10)
+		p.Limit = &defaultLimit
+	}
+	if p.Offset == nil || *p.Offset < 0 {
+		defaultOffset := int32(0)
+		p.Offset = &defaultOffset
+	}
+}
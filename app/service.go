@@ -7,6 +7,7 @@ import (
 	"github.com/google/uuid"
 	"graphql-backend/entity"
 	"graphql-backend/pkg/http-transport"
+	"net/http"
 	"time"
 )
 
@@ -16,33 +17,226 @@ const RefreshTokenExpiration = 7 * 24 * time.Hour
 type Service interface {
 	CreateProduct(ctx context.Context, prs CreateProductParams) (entity.Product, error)
 	UpdateProduct(ctx context.Context, prs UpdateProductParams) (entity.Product, error)
+	CreateProductVariant(ctx context.Context, prs CreateProductVariantParams) (entity.Product, error)
+	UpdateProductVariant(ctx context.Context, prs UpdateProductVariantParams) (entity.Product, error)
+	DeleteProductVariant(ctx context.Context, prs DeleteProductVariantParams) (entity.Product, error)
+	ArchiveProduct(ctx context.Context, id string) (entity.Product, error)
+	RestoreProduct(ctx context.Context, id string) (entity.Product, error)
+	DeleteProduct(ctx context.Context, id string) error
+	UploadProductImage(ctx context.Context, prs UploadProductImageParams) (entity.Product, error)
+	ImportProducts(ctx context.Context, prs ImportProductsParams) (ImportProductsResult, error)
+	DeleteProductImage(ctx context.Context, prs DeleteProductImageParams) (entity.Product, error)
+
+	CreateCategory(ctx context.Context, prs CreateCategoryParams) (entity.Category, error)
+	UpdateCategory(ctx context.Context, prs UpdateCategoryParams) (entity.Category, error)
+	DeleteCategory(ctx context.Context, id string) error
 
 	PlaceOrder(ctx context.Context, prs PlaceOrderParams) (entity.Order, error)
 	Login(ctx context.Context, prs LoginParams) (LoginResult, error)
+	LoginWithOIDC(ctx context.Context, prs OIDCLoginParams) (LoginResult, error)
+	Impersonate(ctx context.Context, prs ImpersonateParams) (ImpersonationResult, error)
+	ValidateSession(ctx context.Context, claims *http_transport.UserClaims) error
+	RecordAuditEntry(ctx context.Context, prs AuditEntryParams) error
+
+	CreateAPIKey(ctx context.Context, prs CreateAPIKeyParams) (CreateAPIKeyResult, error)
+	RevokeAPIKey(ctx context.Context, id string) (entity.APIKey, error)
+	AuthenticateAPIKey(ctx context.Context, key string) (*http_transport.ServicePrincipal, error)
+
+	CreateUser(ctx context.Context, prs CreateUserParams) (entity.User, error)
+	UpdateUserRole(ctx context.Context, prs UpdateUserRoleParams) (entity.User, error)
+	DeactivateUser(ctx context.Context, prs UserStatusParams) (entity.User, error)
+	ReactivateUser(ctx context.Context, prs UserStatusParams) (entity.User, error)
+	UpdateProfile(ctx context.Context, prs UpdateProfileParams) (entity.User, error)
+	VerifyEmail(ctx context.Context, prs VerifyEmailParams) (entity.User, error)
+
+	RequestDataExport(ctx context.Context, prs DataExportParams) (entity.DataExport, error)
+	DeleteAccount(ctx context.Context, prs DeleteAccountParams) (entity.User, error)
+
+	CreateAddress(ctx context.Context, prs CreateAddressParams) (entity.Address, error)
+	UpdateAddress(ctx context.Context, prs UpdateAddressParams) (entity.Address, error)
+	DeleteAddress(ctx context.Context, prs DeleteAddressParams) error
+
+	AddToCart(ctx context.Context, prs CartItemParams) (CartView, error)
+	UpdateCartItem(ctx context.Context, prs CartItemParams) (CartView, error)
+	RemoveFromCart(ctx context.Context, prs CartItemParams) (CartView, error)
+	ClearCart(ctx context.Context, owner CartOwner) (CartView, error)
+	MergeGuestCart(ctx context.Context, userID, token string) error
+	// DeleteExpiredCarts deletes the guest carts expired by now, see RunCartSweeper
+	DeleteExpiredCarts(ctx context.Context, now time.Time) error
+	Checkout(ctx context.Context, prs CheckoutParams) (entity.Order, error)
+
+	CreatePromotion(ctx context.Context, prs CreatePromotionParams) (entity.Promotion, error)
+	UpdatePromotion(ctx context.Context, prs UpdatePromotionParams) (entity.Promotion, error)
+
+	PayOrder(ctx context.Context, prs PayOrderParams) (entity.Payment, error)
+	HandlePaymentWebhook(ctx context.Context, payload []byte, header http.Header) error
+	UpdateOrderStatus(ctx context.Context, prs UpdateOrderStatusParams) (entity.Order, error)
+
+	RequestReturn(ctx context.Context, prs RequestReturnParams) (entity.ReturnRequest, error)
+	ApproveReturn(ctx context.Context, prs ReviewReturnParams) (entity.ReturnRequest, error)
+	RejectReturn(ctx context.Context, prs ReviewReturnParams) (entity.ReturnRequest, error)
+	RefundOrder(ctx context.Context, prs RefundOrderParams) (entity.Order, error)
+
+	CreateReview(ctx context.Context, prs CreateReviewParams) (entity.Review, error)
+	ApproveReview(ctx context.Context, prs ModerateReviewParams) (entity.Review, error)
+	HideReview(ctx context.Context, prs ModerateReviewParams) (entity.Review, error)
+
+	AddToWishlist(ctx context.Context, prs WishlistItemParams) (entity.Wishlist, error)
+	RemoveFromWishlist(ctx context.Context, prs WishlistItemParams) (entity.Wishlist, error)
+
+	SchedulePrice(ctx context.Context, prs SchedulePriceParams) (entity.ScheduledPrice, error)
+	CancelScheduledPrice(ctx context.Context, prs CancelScheduledPriceParams) (entity.ScheduledPrice, error)
+	// ApplyScheduledPrices starts and ends the scheduled prices due by now, see RunPriceScheduler
+	ApplyScheduledPrices(ctx context.Context, now time.Time) error
 }
 
 type Repo interface {
 	GetOrders(ctx context.Context, prs OrdersParams) ([]entity.Order, error)
 	GetOrder(ctx context.Context, prs OrderParams) (entity.Order, error)
+	GetOrdersByUserIDs(ctx context.Context, userIDs []string) (map[string][]entity.Order, error)
 
 	GetProductByID(ctx context.Context, id string) (entity.Product, error)
 	GetProducts(ctx context.Context, prs ProductsParams) ([]entity.Product, error)
 	GetProductsByIDs(ctx context.Context, ids []string) ([]entity.Product, error)
+	// GetAllProducts returns every product, archived ones included
+	GetAllProducts(ctx context.Context) ([]entity.Product, error)
 
 	GetUsersByIDs(ctx context.Context, ids []string) ([]entity.User, error)
 	GetUserByID(ctx context.Context, id string) (entity.User, error)
+	GetUsers(ctx context.Context, prs UsersParams) ([]entity.User, error)
 
 	GetUserByEmail(ctx context.Context, email string) (entity.User, error)
+	GetUserByIdentity(ctx context.Context, issuer, subject string) (entity.User, error)
+	CreateUser(ctx context.Context, e entity.User) error
+	UpdateUser(ctx context.Context, e entity.User) error
 
+	// CreateProduct, SaveProduct and SaveProducts put the products without a category ID in the top-level category of
+	// their category name, created when missing
 	CreateProduct(ctx context.Context, e entity.Product) error
-	UpdateProduct(ctx context.Context, e entity.Product) error
-
+	// SaveProduct applies the update onto the stored product, so that the stock, images and prices changed meanwhile are kept
+	SaveProduct(ctx context.Context, update ProductUpdate) (entity.Product, error)
+	DeleteProduct(ctx context.Context, id string) error
+	// SaveProducts creates the new products and merges the updates onto the stored ones, it returns the saved products.
+	// Nothing is saved when a product was deleted meanwhile or a SKU is taken.
+	SaveProducts(ctx context.Context, updates []ProductUpdate) ([]entity.Product, error)
+	// AddProductImage and RemoveProductImage change the images of the product only, so that concurrent changes are kept
+	AddProductImage(ctx context.Context, productID string, img entity.ProductImage, limit int) (entity.Product, error)
+	RemoveProductImage(ctx context.Context, productID string, id string) (entity.Product, entity.ProductImage, error)
+	// SetProductPrice changes the price of the product only, it returns ErrPriceChanged when the price is no longer from
+	SetProductPrice(ctx context.Context, productID string, from, to entity.Money) (entity.Product, error)
+	// SetProductArchived changes when the product was archived only, nil puts it back on sale
+	SetProductArchived(ctx context.Context, id string, archivedAt *time.Time) (entity.Product, error)
+
+	GetCategories(ctx context.Context) ([]entity.Category, error)
+	GetCategoryByID(ctx context.Context, id string) (entity.Category, error)
+	GetCategoryBySlug(ctx context.Context, slug string) (entity.Category, error)
+	CreateCategory(ctx context.Context, e entity.Category) error
+	UpdateCategory(ctx context.Context, e entity.Category) error
+	DeleteCategory(ctx context.Context, id string) error
+
+	// CreateOrder takes the items out of stock when the order reserves stock, nothing is created when one is short
 	CreateOrder(ctx context.Context, e entity.Order) error
+	UpdateOrder(ctx context.Context, e entity.Order) error
+
+	GetAPIKeys(ctx context.Context) ([]entity.APIKey, error)
+	GetAPIKeyByID(ctx context.Context, id string) (entity.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, hash string) (entity.APIKey, error)
+	CreateAPIKey(ctx context.Context, e entity.APIKey) error
+	UpdateAPIKey(ctx context.Context, e entity.APIKey) error
+	// TouchAPIKey sets when the key was last used and nothing else
+	TouchAPIKey(ctx context.Context, id string, at time.Time) error
+
+	CreateAuditEntry(ctx context.Context, e entity.AuditEntry) error
+	GetAuditEntries(ctx context.Context, prs AuditEntriesParams) ([]entity.AuditEntry, error)
+
+	CreateSession(ctx context.Context, e entity.Session) error
+	GetSession(ctx context.Context, id string) (entity.Session, error)
+	// RevokeUserSessions revokes the sessions of the user and the impersonation sessions the user started
+	RevokeUserSessions(ctx context.Context, userID string, revokedAt time.Time) error
+	GetSessionsByUserID(ctx context.Context, userID string) ([]entity.Session, error)
+
+	CreateDataExport(ctx context.Context, e entity.DataExport, content []byte) error
+	GetDataExport(ctx context.Context, id string) (entity.DataExport, error)
+	GetDataExportContent(ctx context.Context, id string) ([]byte, error)
+	DeleteUserDataExports(ctx context.Context, userID string) error
+
+	GetAddressByID(ctx context.Context, id string) (entity.Address, error)
+	GetAddressesByUserID(ctx context.Context, userID string) ([]entity.Address, error)
+	CreateAddress(ctx context.Context, e entity.Address) error
+	UpdateAddress(ctx context.Context, e entity.Address) error
+	DeleteAddress(ctx context.Context, id string) error
+
+	GetCartByUserID(ctx context.Context, userID string) (entity.Cart, error)
+	GetCartByTokenHash(ctx context.Context, tokenHash string) (entity.Cart, error)
+	SaveCart(ctx context.Context, e entity.Cart) error
+	DeleteCart(ctx context.Context, id string) error
+	DeleteExpiredCarts(ctx context.Context, now time.Time) error
+	CheckoutCart(ctx context.Context, cart entity.Cart, order entity.Order) error
+
+	GetPromotions(ctx context.Context, prs PromotionsParams) ([]entity.Promotion, error)
+	GetPromotionByID(ctx context.Context, id string) (entity.Promotion, error)
+	GetPromotionByCode(ctx context.Context, code string) (entity.Promotion, error)
+	CreatePromotion(ctx context.Context, e entity.Promotion) error
+	UpdatePromotion(ctx context.Context, e entity.Promotion) error
+
+	GetPaymentByID(ctx context.Context, id string) (entity.Payment, error)
+	GetPaymentByReference(ctx context.Context, gateway string, reference string) (entity.Payment, error)
+	GetPaymentsByOrderIDs(ctx context.Context, orderIDs []string) (map[string][]entity.Payment, error)
+	CreatePayment(ctx context.Context, e entity.Payment) error
+	UpdatePayment(ctx context.Context, e entity.Payment) error
+	UpdateOrderStatus(ctx context.Context, id string, status entity.OrderStatus) error
+	// ClaimOrder moves the order to the status when it is in one of the from statuses, it returns the order as it was before
+	ClaimOrder(ctx context.Context, id string, from []entity.OrderStatus, to entity.OrderStatus) (entity.Order, error)
+
+	GetReturnByID(ctx context.Context, id string) (entity.ReturnRequest, error)
+	GetReturns(ctx context.Context, prs ReturnsParams) ([]entity.ReturnRequest, error)
+	GetReturnsByOrderIDs(ctx context.Context, orderIDs []string) (map[string][]entity.ReturnRequest, error)
+	CreateReturn(ctx context.Context, e entity.ReturnRequest) error
+	UpdateReturn(ctx context.Context, e entity.ReturnRequest) error
+	// ClaimReturn moves the return request to the status when it is still in the from status
+	ClaimReturn(ctx context.Context, id string, from, to entity.ReturnStatus) (entity.ReturnRequest, error)
+	RestockProducts(ctx context.Context, items []entity.ReturnItem) error
+
+	GetReviewByID(ctx context.Context, id string) (entity.Review, error)
+	GetReviews(ctx context.Context, prs ReviewsParams) ([]entity.Review, error)
+	// GetApprovedReviewsByProductIDs returns the approved reviews of every product, newest first
+	GetApprovedReviewsByProductIDs(ctx context.Context, productIDs []string) (map[string][]entity.Review, error)
+	GetReviewsByUserID(ctx context.Context, userID string) ([]entity.Review, error)
+	// CreateReview and UpdateReview keep the rating of the product in line with the approved reviews
+	CreateReview(ctx context.Context, e entity.Review) error
+	UpdateReview(ctx context.Context, e entity.Review) error
+	DeleteUserReviews(ctx context.Context, userID string) error
+
+	// GetWishlist returns the user's wishlist, an empty one when the user has none yet
+	GetWishlist(ctx context.Context, userID string) (entity.Wishlist, error)
+	GetWishlistsByProductID(ctx context.Context, productID string) ([]entity.Wishlist, error)
+	SaveWishlist(ctx context.Context, e entity.Wishlist) error
+	// SetWishlistNotified records when the user was told the product is back in stock
+	SetWishlistNotified(ctx context.Context, userID string, productID string, at time.Time) error
+	DeleteWishlist(ctx context.Context, userID string) error
+
+	// GetPriceChangesByProductIDs returns the price history of every product, newest first
+	GetPriceChangesByProductIDs(ctx context.Context, productIDs []string) (map[string][]entity.PriceChange, error)
+	CreatePriceChange(ctx context.Context, e entity.PriceChange) error
+	GetScheduledPriceByID(ctx context.Context, id string) (entity.ScheduledPrice, error)
+	GetScheduledPrices(ctx context.Context, prs ScheduledPricesParams) ([]entity.ScheduledPrice, error)
+	// GetDueScheduledPrices returns the scheduled prices that start or end by the given time, in the order they are due
+	GetDueScheduledPrices(ctx context.Context, now time.Time) ([]entity.ScheduledPrice, error)
+	// CreateScheduledPrice refuses scheduled prices that overlap the pending ones of the product
+	CreateScheduledPrice(ctx context.Context, e entity.ScheduledPrice) error
+	UpdateScheduledPrice(ctx context.Context, e entity.ScheduledPrice) error
+	// ClaimScheduledPrice changes the status of the scheduled price only when it is still from, it returns the claimed one
+	ClaimScheduledPrice(ctx context.Context, id string, from, to entity.ScheduledPriceStatus) (entity.ScheduledPrice, error)
 }
 
 type service struct {
-	repo       Repo
-	jwtHandler http_transport.JwtHandler
+	repo          Repo
+	jwtHandler    http_transport.JwtHandler
+	notifier      Notifier
+	taxCalculator TaxCalculator
+	rates         ExchangeRateProvider
+	payments      PaymentGateway
+	blobs         BlobStorage
 }
 
 func (s service) Login(ctx context.Context, prs LoginParams) (LoginResult, error) {
@@ -59,10 +253,38 @@ func (s service) Login(ctx context.Context, prs LoginParams) (LoginResult, error
 		return LoginResult{}, errors.New("invalid credentials")
 	}
 
+	if !user.IsActive() {
+		return LoginResult{}, ErrUserDeactivated
+	}
+
+	if prs.CartToken != "" {
+		err = s.MergeGuestCart(ctx, user.ID, prs.CartToken)
+		if err != nil {
+			return LoginResult{}, err
+		}
+	}
+
+	return s.issueTokens(ctx, user)
+}
+
+// issueTokens starts a session for an authenticated user and generates its access and refresh token pair
+func (s service) issueTokens(ctx context.Context, user entity.User) (LoginResult, error) {
+	session := entity.Session{
+		ID:        uuid.NewString(),
+		UserID:    user.ID,
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(RefreshTokenExpiration),
+	}
+	err := s.repo.CreateSession(ctx, session)
+	if err != nil {
+		return LoginResult{}, err
+	}
+
 	accessToken, err := s.jwtHandler.GenerateToken(ctx, http_transport.UserClaims{
 		UserID: user.ID,
 		Role:   user.Role,
 		RegisteredClaims: &jwt.RegisteredClaims{
+			ID:        session.ID,
 			Issuer:    "graphql-backend",
 			Subject:   user.ID,
 			IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
@@ -78,6 +300,7 @@ func (s service) Login(ctx context.Context, prs LoginParams) (LoginResult, error
 		UserID: user.ID,
 		Role:   user.Role,
 		RegisteredClaims: &jwt.RegisteredClaims{
+			ID:        session.ID,
 			Issuer:    "graphql-backend",
 			Subject:   user.ID,
 			IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
@@ -99,12 +322,21 @@ func (s service) Login(ctx context.Context, prs LoginParams) (LoginResult, error
 }
 
 func (s service) PlaceOrder(ctx context.Context, prs PlaceOrderParams) (entity.Order, error) {
-	if len(prs.ProductIDs) == 0 {
+	requested := make([]OrderItemParams, 0, len(prs.ProductIDs)+len(prs.Items))
+	for _, id := range prs.ProductIDs {
+		requested = append(requested, OrderItemParams{ProductID: id, Quantity: 1})
+	}
+	requested = append(requested, prs.Items...)
+	if len(requested) == 0 {
 		return entity.Order{}, errors.New("product IDs cannot be empty")
 	}
 
 	// Fetch products
-	products, err := s.repo.GetProductsByIDs(ctx, prs.ProductIDs)
+	ids := make([]string, len(requested))
+	for i, item := range requested {
+		ids[i] = item.ProductID
+	}
+	products, err := s.repo.GetProductsByIDs(ctx, ids)
 	if err != nil {
 		return entity.Order{}, err
 	}
@@ -113,18 +345,75 @@ func (s service) PlaceOrder(ctx context.Context, prs PlaceOrderParams) (entity.O
 		return entity.Order{}, errors.New("no products found for the given IDs")
 	}
 
+	shippingAddress, billingAddress, err := s.orderAddresses(ctx, prs.UserID, prs.AddressID, prs.BillingAddressID)
+	if err != nil {
+		return entity.Order{}, err
+	}
+
+	currency, rate, err := exchangeRate(ctx, s.rates, prs.Currency)
+	if err != nil {
+		return entity.Order{}, err
+	}
+
 	// Create order
-	var totalPrice float64
+	productsByID := make(map[string]entity.Product, len(products))
 	for _, product := range products {
-		totalPrice += product.Price
+		productsByID[product.ID] = product
+	}
+	var items []entity.OrderItem
+	var productIDs []string
+	itemIndex := map[entity.ItemKey]int{}
+	for _, item := range requested {
+		product, ok := productsByID[item.ProductID]
+		if !ok {
+			continue
+		}
+		if item.Quantity <= 0 {
+			return entity.Order{}, errors.New("quantity must be positive")
+		}
+		if err := product.CheckAvailable(); err != nil {
+			return entity.Order{}, err
+		}
+		if err := product.CheckVariant(item.VariantID); err != nil {
+			return entity.Order{}, err
+		}
+
+		// the same limits as the cart apply, the quantity is capped before adding so that it can't overflow
+		quantity := item.Quantity
+		i, ok := itemIndex[item.Key()]
+		if ok {
+			quantity = min(quantity, MaxCartItemQuantity+1) + items[i].Quantity
+		}
+		if err := checkCartQuantity(product, item.VariantID, quantity); err != nil {
+			return entity.Order{}, err
+		}
+		if ok {
+			items[i].Quantity = quantity
+			continue
+		}
+		itemIndex[item.Key()] = len(items)
+		productIDs = append(productIDs, product.ID)
+		items = append(items, newOrderItem(product, item.VariantID, quantity, product.UnitPrice(item.VariantID).Convert(currency, rate)))
 	}
 	order := entity.Order{
 		ID:         uuid.NewString(),
 		UserID:     prs.UserID,
-		ProductIDs: prs.ProductIDs,
-		Total:      totalPrice,
-		Status:     entity.OrderStatusPending,
+		ProductIDs: productIDs,
+		Items:      items,
+		Status:     entity.OrderStatusAwaitingPayment,
 		CreatedAt:  time.Now(),
+
+		Currency:     currency,
+		ExchangeRate: rate,
+
+		ShippingAddress: shippingAddress,
+		BillingAddress:  billingAddress,
+		StockReserved:   true,
+	}
+
+	err = s.priceOrder(ctx, &order, productsByID, prs.CouponCode)
+	if err != nil {
+		return entity.Order{}, err
 	}
 
 	err = s.repo.CreateOrder(ctx, order)
@@ -139,18 +428,28 @@ func (s service) CreateProduct(ctx context.Context, prs CreateProductParams) (en
 	product := entity.Product{
 		ID:          uuid.NewString(),
 		Name:        prs.Name,
+		SKU:         prs.SKU,
 		Description: prs.Description,
 		Price:       prs.Price,
 		InStock:     prs.InStock,
-		Category:    prs.Category,
+		Options:     prs.Options,
+	}
+	err := normalizeProduct(&product)
+	if err != nil {
+		return entity.Product{}, err
+	}
+	err = s.setProductCategory(ctx, &product, prs.CategoryID, prs.Category)
+	if err != nil {
+		return entity.Product{}, err
 	}
 
-	err := s.repo.CreateProduct(ctx, product)
+	err = s.repo.CreateProduct(ctx, product)
 	if err != nil {
 		return entity.Product{}, err
 	}
 
-	return product, nil
+	// the repo sets the category of a category name
+	return s.repo.GetProductByID(ctx, product.ID)
 }
 
 func (s service) UpdateProduct(ctx context.Context, prs UpdateProductParams) (entity.Product, error) {
@@ -159,35 +458,102 @@ func (s service) UpdateProduct(ctx context.Context, prs UpdateProductParams) (en
 		return entity.Product{}, err
 	}
 
+	if prs.InStock != nil && product.HasVariants() {
+		return entity.Product{}, errors.New("the stock of a product with variants is set on its variants")
+	}
+
+	before := product
 	prs.BindToProduct(&product)
-	err = s.repo.UpdateProduct(ctx, product)
+	err = normalizeProduct(&product)
+	if err != nil {
+		return entity.Product{}, err
+	}
+	switch {
+	case prs.CategoryID != nil:
+		err = s.setProductCategory(ctx, &product, *prs.CategoryID, "")
+	case prs.Category != nil:
+		err = s.setProductCategory(ctx, &product, "", *prs.Category)
+	}
 	if err != nil {
 		return entity.Product{}, err
 	}
 
+	// the variants are left out, the ones deleted meanwhile would be added back
+	product.Variants = nil
+	product, err = s.repo.SaveProduct(ctx, ProductUpdate{Product: product, Fields: prs.fields()})
+	if err != nil {
+		return entity.Product{}, err
+	}
+	err = s.recordPriceChanges(ctx, before, product, entity.PriceChange{
+		ActorID: prs.ActorID,
+		Source:  entity.PriceChangeSourceManual,
+	})
+	if err != nil {
+		return entity.Product{}, err
+	}
+
+	s.notifyBackInStock(ctx, before.InStock, product)
 	return product, nil
 }
 
-func NewService(repo Repo, jwtHandler http_transport.JwtHandler) Service {
-	return &service{repo: repo, jwtHandler: jwtHandler}
+func NewService(repo Repo, jwtHandler http_transport.JwtHandler, notifier Notifier, taxCalculator TaxCalculator, rates ExchangeRateProvider, payments PaymentGateway, blobs BlobStorage) Service {
+	return &service{repo: repo, jwtHandler: jwtHandler, notifier: notifier, taxCalculator: taxCalculator, rates: rates, payments: payments, blobs: blobs}
 }
 
 type CreateProductParams struct {
 	Name        string
+	SKU         string
 	Description string
-	Price       float64
+	Price       entity.Money
 	InStock     int32
-	Category    string
+	// CategoryID is the category of the product, or Category the name of a top-level category created when missing
+	Category   string
+	CategoryID string
+	// Options are the axes of the variants added afterwards
+	Options []entity.ProductOption
 }
 
 type UpdateProductParams struct {
 	ID string
 
 	Name        *string
+	SKU         *string
 	Description *string
-	Price       *float64
+	Price       *entity.Money
 	InStock     *int32
 	Category    *string
+	CategoryID  *string
+	// Options replace the product options, the variants must still match them
+	Options []entity.ProductOption
+	// ActorID is the user or API key changing the product, it is recorded in the price history
+	ActorID string
+}
+
+// fields are the product fields the params set
+func (p *UpdateProductParams) fields() map[ProductField]bool {
+	fields := make(map[ProductField]bool)
+	if p.Name != nil {
+		fields[ProductFieldName] = true
+	}
+	if p.SKU != nil {
+		fields[ProductFieldSKU] = true
+	}
+	if p.Description != nil {
+		fields[ProductFieldDescription] = true
+	}
+	if p.Price != nil {
+		fields[ProductFieldPrice] = true
+	}
+	if p.InStock != nil {
+		fields[ProductFieldInStock] = true
+	}
+	if p.Category != nil || p.CategoryID != nil {
+		fields[ProductFieldCategory] = true
+	}
+	if p.Options != nil {
+		fields[ProductFieldOptions] = true
+	}
+	return fields
 }
 
 func (p *UpdateProductParams) BindToProduct(e *entity.Product) {
@@ -197,6 +563,9 @@ func (p *UpdateProductParams) BindToProduct(e *entity.Product) {
 	if p.Name != nil {
 		e.Name = *p.Name
 	}
+	if p.SKU != nil {
+		e.SKU = *p.SKU
+	}
 	if p.Description != nil {
 		e.Description = *p.Description
 	}
@@ -206,19 +575,40 @@ func (p *UpdateProductParams) BindToProduct(e *entity.Product) {
 	if p.InStock != nil {
 		e.InStock = *p.InStock
 	}
-	if p.Category != nil {
-		e.Category = *p.Category
+	if p.Options != nil {
+		e.Options = p.Options
 	}
 }
 
 type PlaceOrderParams struct {
-	UserID     string
+	UserID string
+	// ProductIDs are ordered once per ID, products with variants are ordered as Items
 	ProductIDs []string
+	Items      []OrderItemParams
+	// AddressID and BillingAddressID default to the user's default shipping and billing addresses
+	AddressID        *string
+	BillingAddressID *string
+	CouponCode       string
+	// Currency the order is placed in, the base currency when empty
+	Currency string
+}
+
+type OrderItemParams struct {
+	ProductID string
+	// VariantID is required for products with variants
+	VariantID string
+	Quantity  int32
+}
+
+func (p OrderItemParams) Key() entity.ItemKey {
+	return entity.ItemKey{ProductID: p.ProductID, VariantID: p.VariantID}
 }
 
 type LoginParams struct {
 	Email    string
 	Password string
+	// CartToken is a guest cart to merge into the user's cart
+	CartToken string
 }
 
 type LoginResult struct {
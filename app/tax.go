@@ -0,0 +1,29 @@
+package app
+
+import (
+	"context"
+	"graphql-backend/entity"
+)
+
+// TaxCalculator computes the taxes owed on an order, e.g. from a rule table or by calling a tax service
+type TaxCalculator interface {
+	CalculateTax(ctx context.Context, req entity.TaxRequest) ([]entity.TaxLine, error)
+}
+
+// taxOrder asks the tax calculator for the taxes of the order items, taxable is the amount of each item after discounts
+// and categories the category path of each product
+func (s service) taxOrder(ctx context.Context, order entity.Order, categories map[string][]entity.Category, taxable []entity.Money) ([]entity.TaxLine, error) {
+	req := entity.TaxRequest{
+		Address: order.ShippingAddress,
+		Lines:   make([]entity.TaxableLine, len(order.Items)),
+	}
+	for i, item := range order.Items {
+		req.Lines[i] = entity.TaxableLine{
+			ProductID:  item.ProductID,
+			Categories: categories[item.ProductID],
+			Amount:     taxable[i],
+		}
+	}
+
+	return s.taxCalculator.CalculateTax(ctx, req)
+}
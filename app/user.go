@@ -0,0 +1,202 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"graphql-backend/entity"
+	"graphql-backend/pkg/http-transport"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+var ErrUserDeactivated = errors.New("user is deactivated")
+
+func (s service) CreateUser(ctx context.Context, prs CreateUserParams) (entity.User, error) {
+	if prs.Role != entity.RoleAdmin && prs.Role != entity.RoleCustomer {
+		return entity.User{}, errors.New("invalid role")
+	}
+
+	name := strings.TrimSpace(prs.Name)
+	if name == "" {
+		return entity.User{}, errors.New("name cannot be empty")
+	}
+	if prs.Password == "" {
+		return entity.User{}, errors.New("password cannot be empty")
+	}
+
+	addr, err := mail.ParseAddress(prs.Email)
+	if err != nil || addr.Address != prs.Email {
+		return entity.User{}, errors.New("invalid email")
+	}
+
+	now := time.Now()
+	user := entity.User{
+		ID:        uuid.NewString(),
+		Name:      name,
+		Email:     addr.Address,
+		Password:  prs.Password,
+		Role:      prs.Role,
+		Status:    entity.UserStatusActive,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	err = s.repo.CreateUser(ctx, user)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	return user, nil
+}
+
+func (s service) UpdateUserRole(ctx context.Context, prs UpdateUserRoleParams) (entity.User, error) {
+	if prs.Role != entity.RoleAdmin && prs.Role != entity.RoleCustomer {
+		return entity.User{}, errors.New("invalid role")
+	}
+	if prs.ActorID == prs.UserID {
+		return entity.User{}, errors.New("cannot change your own role")
+	}
+
+	user, err := s.repo.GetUserByID(ctx, prs.UserID)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	if user.Role == prs.Role {
+		return user, nil
+	}
+
+	user.Role = prs.Role
+	user.UpdatedAt = time.Now()
+	err = s.repo.UpdateUser(ctx, user)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	// the role is part of the tokens, the user has to log in again to get the new one.
+	// A demoted admin also loses the impersonation sessions they started
+	err = s.repo.RevokeUserSessions(ctx, user.ID, time.Now())
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	return user, nil
+}
+
+func (s service) DeactivateUser(ctx context.Context, prs UserStatusParams) (entity.User, error) {
+	if prs.ActorID == prs.UserID {
+		return entity.User{}, errors.New("cannot deactivate yourself")
+	}
+
+	user, err := s.repo.GetUserByID(ctx, prs.UserID)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	if user.GetStatus() == entity.UserStatusDeleted {
+		return entity.User{}, errors.New("user account is deleted")
+	}
+
+	user, err = s.setUserStatus(ctx, prs.UserID, entity.UserStatusDeactivated)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	err = s.repo.RevokeUserSessions(ctx, user.ID, time.Now())
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	return user, nil
+}
+
+func (s service) ReactivateUser(ctx context.Context, prs UserStatusParams) (entity.User, error) {
+	user, err := s.repo.GetUserByID(ctx, prs.UserID)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	// deleted accounts are anonymized, there is nothing left to reactivate
+	if user.GetStatus() == entity.UserStatusDeleted {
+		return entity.User{}, errors.New("user account is deleted")
+	}
+
+	return s.setUserStatus(ctx, prs.UserID, entity.UserStatusActive)
+}
+
+func (s service) setUserStatus(ctx context.Context, userID string, status entity.UserStatus) (entity.User, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	if user.GetStatus() == status {
+		return user, nil
+	}
+
+	user.Status = status
+	user.UpdatedAt = time.Now()
+	err = s.repo.UpdateUser(ctx, user)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	return user, nil
+}
+
+// ValidateSession checks that the session a token was issued for is neither revoked nor expired,
+// and that its user is still active. An impersonation session also needs its actor to still be an active admin
+func (s service) ValidateSession(ctx context.Context, claims *http_transport.UserClaims) error {
+	if claims.RegisteredClaims == nil || claims.ID == "" {
+		return errors.New("token has no session")
+	}
+
+	session, err := s.repo.GetSession(ctx, claims.ID)
+	if err != nil {
+		return err
+	}
+
+	if session.UserID != claims.UserID || session.ActorID != claims.ActorID || !session.IsActive(time.Now()) {
+		return errors.New("session is no longer valid")
+	}
+
+	if session.ActorID != "" {
+		actor, err := s.repo.GetUserByID(ctx, session.ActorID)
+		if err != nil {
+			return err
+		}
+		if actor.Role != entity.RoleAdmin || !actor.IsActive() {
+			return errors.New("session is no longer valid")
+		}
+	}
+
+	user, err := s.repo.GetUserByID(ctx, session.UserID)
+	if err != nil {
+		return err
+	}
+
+	if !user.IsActive() {
+		return ErrUserDeactivated
+	}
+
+	return nil
+}
+
+type CreateUserParams struct {
+	Name     string
+	Email    string
+	Password string
+	Role     string
+}
+
+type UpdateUserRoleParams struct {
+	ActorID string
+	UserID  string
+	Role    string
+}
+
+type UserStatusParams struct {
+	ActorID string
+	UserID  string
+}
@@ -0,0 +1,109 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"graphql-backend/entity"
+	"log"
+	"slices"
+	"time"
+)
+
+const maxWishlistItems = 100
+
+func (s service) AddToWishlist(ctx context.Context, prs WishlistItemParams) (entity.Wishlist, error) {
+	product, err := s.repo.GetProductByID(ctx, prs.ProductID)
+	if err != nil {
+		return entity.Wishlist{}, err
+	}
+	if err := product.CheckAvailable(); err != nil {
+		return entity.Wishlist{}, err
+	}
+
+	wishlist, err := s.repo.GetWishlist(ctx, prs.UserID)
+	if err != nil {
+		return entity.Wishlist{}, err
+	}
+	if wishlist.Item(product.ID) >= 0 {
+		return wishlist, nil
+	}
+	if len(wishlist.Items) >= maxWishlistItems {
+		return entity.Wishlist{}, fmt.Errorf("a wishlist cannot have more than %d products", maxWishlistItems)
+	}
+
+	now := time.Now()
+	wishlist.Items = append(slices.Clone(wishlist.Items), entity.WishlistItem{ProductID: product.ID, AddedAt: now})
+	wishlist.UpdatedAt = now
+	err = s.repo.SaveWishlist(ctx, wishlist)
+	if err != nil {
+		return entity.Wishlist{}, err
+	}
+
+	return wishlist, nil
+}
+
+func (s service) RemoveFromWishlist(ctx context.Context, prs WishlistItemParams) (entity.Wishlist, error) {
+	wishlist, err := s.repo.GetWishlist(ctx, prs.UserID)
+	if err != nil {
+		return entity.Wishlist{}, err
+	}
+
+	i := wishlist.Item(prs.ProductID)
+	if i < 0 {
+		return entity.Wishlist{}, errors.New("product is not in the wishlist")
+	}
+	wishlist.Items = slices.Delete(slices.Clone(wishlist.Items), i, i+1)
+	wishlist.UpdatedAt = time.Now()
+	err = s.repo.SaveWishlist(ctx, wishlist)
+	if err != nil {
+		return entity.Wishlist{}, err
+	}
+
+	return wishlist, nil
+}
+
+func (q *query) GetWishlist(ctx context.Context, userID string) (entity.Wishlist, error) {
+	return q.repo.GetWishlist(ctx, userID)
+}
+
+// notifyBackInStock tells the users who wishlisted the product that it can be bought again, when a change of the
+// product brought its stock up from zero. The product is saved by then, so failures are only logged.
+func (s service) notifyBackInStock(ctx context.Context, stockBefore int32, after entity.Product) {
+	if stockBefore > 0 || after.InStock <= 0 || after.IsArchived() {
+		return
+	}
+
+	wishlists, err := s.repo.GetWishlistsByProductID(ctx, after.ID)
+	if err != nil {
+		log.Printf("failed to get the wishlists of product %s: %v", after.ID, err)
+		return
+	}
+	for _, wishlist := range wishlists {
+		user, err := s.repo.GetUserByID(ctx, wishlist.UserID)
+		if err != nil || !user.IsActive() {
+			continue
+		}
+
+		err = s.notifier.Notify(ctx, entity.Notification{
+			UserID:  user.ID,
+			To:      user.Email,
+			Subject: after.Name + " is back in stock",
+			Body:    fmt.Sprintf("%s from your wishlist is back in stock.", after.Name),
+		})
+		if err != nil {
+			log.Printf("failed to notify user %s that product %s is back in stock: %v", user.ID, after.ID, err)
+			continue
+		}
+
+		err = s.repo.SetWishlistNotified(ctx, user.ID, after.ID, time.Now())
+		if err != nil {
+			log.Printf("failed to save the back in stock notification of user %s: %v", user.ID, err)
+		}
+	}
+}
+
+type WishlistItemParams struct {
+	UserID    string
+	ProductID string
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"graphql-backend/app"
+	"graphql-backend/pkg/notify"
+	"graphql-backend/store"
+	"os"
+)
+
+const productsUsage = `usage:
+  products import [-dry-run] [-format csv|jsonl] FILE
+  products export [-format csv|jsonl] [-o FILE]`
+
+// runProducts runs the products command, it works on the data files directly so the server should not be running
+func runProducts(args []string) error {
+	if len(args) == 0 {
+		return errors.New(productsUsage)
+	}
+
+	switch args[0] {
+	case "import":
+		return importProducts(args[1:])
+	case "export":
+		return exportProducts(args[1:])
+	default:
+		return errors.New(productsUsage)
+	}
+}
+
+func importProducts(args []string) error {
+	flags := flag.NewFlagSet("products import", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "validate the file without saving anything")
+	format := flags.String("format", "", "csv or jsonl, inferred from the file extension when empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New(productsUsage)
+	}
+
+	filename := flags.Arg(0)
+	catalogFormat, err := catalogFormat(filename, *format)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	repo := store.NewRepo(ctx)
+	// The catalog commands only use the repo, and the notifier to tell wishlists about products back in stock
+	service := app.NewService(repo, nil, notify.NewLogNotifier(), nil, nil, nil, nil)
+
+	result, err := service.ImportProducts(ctx, app.ImportProductsParams{
+		Format:  catalogFormat,
+		Content: f,
+		DryRun:  *dryRun,
+	})
+	if err != nil {
+		return err
+	}
+	for _, rowErr := range result.Errors {
+		fmt.Fprintf(os.Stderr, "line %d: %s\n", rowErr.Line, rowErr.Message)
+	}
+	if !result.Applied {
+		if len(result.Errors) > 0 {
+			return fmt.Errorf("the import failed on %d rows, nothing was saved", len(result.Errors))
+		}
+		fmt.Printf("dry run: %d would be created, %d updated\n", result.Created, result.Updated)
+		return nil
+	}
+
+	if err := store.Flush(repo); err != nil {
+		return err
+	}
+	fmt.Printf("%d created, %d updated\n", result.Created, result.Updated)
+	return nil
+}
+
+func exportProducts(args []string) error {
+	flags := flag.NewFlagSet("products export", flag.ContinueOnError)
+	format := flags.String("format", "csv", "csv or jsonl")
+	output := flags.String("o", "", "file to write to, standard output when empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.New(productsUsage)
+	}
+
+	catalogFormat, err := catalogFormat(*output, *format)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	query := app.NewQuery(store.NewRepo(ctx), nil)
+
+	if *output == "" {
+		return query.ExportProducts(ctx, app.ExportProductsParams{Format: catalogFormat}, os.Stdout)
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	err = query.ExportProducts(ctx, app.ExportProductsParams{Format: catalogFormat}, f)
+	return errors.Join(err, f.Close())
+}
+
+func catalogFormat(filename string, format string) (app.CatalogFormat, error) {
+	switch format {
+	case "csv":
+		return app.CatalogFormatCSV, nil
+	case "jsonl":
+		return app.CatalogFormatJSONL, nil
+	case "":
+		return app.CatalogFormatOf(filename)
+	default:
+		return "", fmt.Errorf("unknown format %q, use csv or jsonl", format)
+	}
+}
@@ -2,14 +2,22 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"graphql-backend/app"
 	loaders "graphql-backend/data-loader"
 	"graphql-backend/graph"
+	"graphql-backend/pkg/blob"
+	"graphql-backend/pkg/exchange"
 	http_transport "graphql-backend/pkg/http-transport"
+	"graphql-backend/pkg/notify"
+	"graphql-backend/pkg/oidc"
+	"graphql-backend/pkg/payment"
+	"graphql-backend/pkg/tax"
 	"graphql-backend/store"
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/handler/extension"
@@ -23,6 +31,14 @@ import (
 const defaultPort = "8080"
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "products" {
+		if err := runProducts(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = defaultPort
@@ -36,11 +52,64 @@ func main() {
 	}
 
 	jwtHandler := http_transport.NewJWTHandler(jwtKeyPair)
-	authMw := http_transport.AuthMiddleware(jwtHandler)
+
+	// Tax rates are read from TAX_RATES_FILE, the built-in rates are used when it is not set
+	taxCalculator, err := tax.LoadRuleTable(os.Getenv("TAX_RATES_FILE"))
+	if err != nil {
+		panic("failed to load tax rates: " + err.Error())
+	}
+
+	// Exchange rates are read from EXCHANGE_RATES_FILE, the built-in rates are used when it is not set
+	exchangeRates, err := exchange.LoadStaticRates(os.Getenv("EXCHANGE_RATES_FILE"))
+	if err != nil {
+		panic("failed to load exchange rates: " + err.Error())
+	}
+
+	// Payments go through the local fake gateway, it posts its asynchronous decisions back to the webhook of this server.
+	// The webhook is disabled without PAYMENT_WEBHOOK_SECRET, asynchronous payments then stay pending.
+	webhookSecret := os.Getenv("PAYMENT_WEBHOOK_SECRET")
+	var webhookURL string
+	if webhookSecret != "" {
+		webhookURL = os.Getenv("PAYMENT_WEBHOOK_URL")
+		if webhookURL == "" {
+			webhookURL = "http://localhost:" + port + trans.PaymentWebhookPath
+		}
+	} else {
+		log.Printf("PAYMENT_WEBHOOK_SECRET is not set, the payment webhook is disabled")
+	}
+	paymentGateway := payment.NewFakeGateway(payment.FakeConfig{WebhookURL: webhookURL, WebhookSecret: webhookSecret})
+
+	// Responses of mutations sent with an Idempotency-Key header are replayed to retries for IDEMPOTENCY_KEY_TTL
+	idempotencyWindow := 24 * time.Hour
+	if ttl := os.Getenv("IDEMPOTENCY_KEY_TTL"); ttl != "" {
+		idempotencyWindow, err = time.ParseDuration(ttl)
+		if err != nil {
+			panic("invalid IDEMPOTENCY_KEY_TTL: " + err.Error())
+		}
+	}
+
+	// Uploaded images are kept in BLOB_STORAGE_DIR
+	blobDir := os.Getenv("BLOB_STORAGE_DIR")
+	if blobDir == "" {
+		blobDir = "store/data/blobs"
+	}
+	blobs := blob.NewLocalStorage(blobDir)
 
 	repo := store.NewRepo(ctx)
-	query := app.NewQuery(repo)
-	service := app.NewService(repo, jwtHandler)
+	query := app.NewQuery(repo, exchangeRates)
+	service := app.NewService(repo, jwtHandler, notify.NewLogNotifier(), taxCalculator, exchangeRates, paymentGateway, blobs)
+	policy := app.NewPolicy()
+
+	// Scheduled prices are started and ended every PRICE_SCHEDULER_INTERVAL
+	priceSchedulerInterval := time.Second
+	if interval := os.Getenv("PRICE_SCHEDULER_INTERVAL"); interval != "" {
+		priceSchedulerInterval, err = time.ParseDuration(interval)
+		if err != nil || priceSchedulerInterval <= 0 {
+			panic(fmt.Sprintf("invalid PRICE_SCHEDULER_INTERVAL %q", interval))
+		}
+	}
+	go app.RunPriceScheduler(ctx, service, priceSchedulerInterval)
+	go app.RunCartSweeper(ctx, service, time.Hour)
 
 	api := trans.NewAPI(query, service)
 	c := graph.Config{Resolvers: &graph.Resolver{
@@ -49,6 +118,8 @@ func main() {
 	c.Directives = graph.DirectiveRoot{
 		HasRole:          http_transport.HasRole,
 		HasAuthenticated: http_transport.HasAuthenticated,
+		IsOwnerOrHasRole: trans.IsOwnerOrHasRole(policy),
+		NotImpersonated:  http_transport.NotImpersonated,
 	}
 
 	srv := handler.New(graph.NewExecutableSchema(c))
@@ -56,20 +127,51 @@ func main() {
 	srv.AddTransport(transport.Options{})
 	srv.AddTransport(transport.GET{})
 	srv.AddTransport(transport.POST{})
+	srv.AddTransport(transport.MultipartForm{
+		MaxUploadSize: app.MaxImageSize + 1<<20,
+		MaxMemory:     8 << 20,
+	})
 
 	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
 
+	srv.AroundOperations(trans.AuditImpersonation(service))
+
 	srv.Use(extension.Introspection{})
 	srv.Use(extension.AutomaticPersistedQuery{
 		Cache: lru.New[string](100),
 	})
 
 	// Middleware for authentication and data loaders
-	handler := authMw(srv)
+	authMw := http_transport.AuthMiddleware(jwtHandler, service, service)
+	handler := authMw(http_transport.IdempotencyMiddleware(http_transport.NewMemoryIdempotencyStore(), idempotencyWindow)(srv))
 	handler = loaders.Middleware(handler, repo)
 
 	http.Handle("/", playground.Handler("GraphQL playground", "/query"))
 	http.Handle("/query", handler)
+	http.Handle(trans.DataExportPath, authMw(trans.NewDataExportHandler(query)))
+	if webhookSecret != "" {
+		http.Handle(trans.PaymentWebhookPath, trans.NewPaymentWebhookHandler(service))
+	} else {
+		http.Handle(trans.PaymentWebhookPath, http.NotFoundHandler())
+	}
+	http.Handle(trans.ImagePath, trans.NewImageHandler(blobs))
+
+	// OpenID Connect login is enabled when a provider is configured
+	if issuerURL := os.Getenv("OIDC_ISSUER_URL"); issuerURL != "" {
+		provider, err := oidc.Discover(ctx, oidc.Config{
+			IssuerURL:    issuerURL,
+			ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+			ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+			RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+		}, nil)
+		if err != nil {
+			panic("failed to set up oidc login: " + err.Error())
+		}
+
+		oidcHandler := trans.NewOIDCHandler(provider, service)
+		http.HandleFunc("/auth/oidc/login", oidcHandler.Login)
+		http.HandleFunc("/auth/oidc/callback", oidcHandler.Callback)
+	}
 
 	log.Printf("connect to http://localhost:%s/ for GraphQL playground", port)
 	log.Fatal(http.ListenAndServe(":"+port, nil))
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"graphql-backend/pkg/oidc/oidctest"
+	"log"
+	"os"
+	"os/signal"
+)
+
+// A local OpenID Connect provider that signs in a fixed user, to try the OIDC login without a real provider
+func main() {
+	clientID := os.Getenv("OIDC_CLIENT_ID")
+	if clientID == "" {
+		clientID = "graphql-backend"
+	}
+	email := os.Getenv("MOCK_OIDC_EMAIL")
+	if email == "" {
+		email = "customer@example.com"
+	}
+
+	issuer, err := oidctest.NewServer(clientID, oidctest.User{
+		Subject:       "mock-" + email,
+		Email:         email,
+		EmailVerified: true,
+		Name:          "Mock OIDC User",
+	})
+	if err != nil {
+		panic("failed to start mock oidc issuer: " + err.Error())
+	}
+	defer issuer.Close()
+
+	log.Printf("mock oidc issuer for client %q signing in %s running at %s", clientID, email, issuer.URL)
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt)
+	<-stop
+}
@@ -3,7 +3,9 @@ package loaders
 // import vikstrous/dataloadgen with your other imports
 import (
 	"context"
+	"errors"
 	"graphql-backend/app"
+	"graphql-backend/entity"
 	"graphql-backend/graph/model"
 	trans "graphql-backend/transport"
 	"net/http"
@@ -19,8 +21,34 @@ const (
 )
 
 type Loaders struct {
-	UserLoader    *dataloadgen.Loader[string, *model.User]
-	ProductLoader *dataloadgen.Loader[string, *model.Product]
+	UserLoader           *dataloadgen.Loader[string, *model.User]
+	ProductLoader        *dataloadgen.Loader[string, *model.Product]
+	UserOrdersLoader     *dataloadgen.Loader[UserOrdersKey, []*model.Order]
+	OrderPaymentsLoader  *dataloadgen.Loader[string, []*model.Payment]
+	OrderReturnsLoader   *dataloadgen.Loader[string, []*model.ReturnRequest]
+	ProductReviewsLoader *dataloadgen.Loader[ProductReviewsKey, []*model.Review]
+	PriceHistoryLoader   *dataloadgen.Loader[PriceHistoryKey, []*model.PriceChange]
+}
+
+// UserOrdersKey identifies a page of a user's orders
+type UserOrdersKey struct {
+	UserID string
+	Limit  int32
+	Offset int32
+}
+
+// ProductReviewsKey identifies a page of a product's reviews
+type ProductReviewsKey struct {
+	ProductID string
+	Limit     int32
+	Offset    int32
+}
+
+// PriceHistoryKey identifies a page of a product's price changes
+type PriceHistoryKey struct {
+	ProductID string
+	Limit     int32
+	Offset    int32
 }
 
 type reader struct {
@@ -41,26 +69,163 @@ func (u *reader) getUsers(ctx context.Context, ids []string) ([]*model.User, []e
 	return res.Res, nil
 }
 
-// getProducts implements a batch function that can retrieve many products by ID,
-// for use in a dataloader
+// getProducts implements a batch function that can retrieve many products by ID, archived ones included,
+// for use in a dataloader. The repo skips unknown IDs, so the results are matched to the IDs.
 func (u *reader) getProducts(ctx context.Context, ids []string) ([]*model.Product, []error) {
 	products, err := u.repo.GetProductsByIDs(ctx, ids)
 	if err != nil {
 		return nil, []error{err}
 	}
 
-	res := trans.ProductsRes{}
-	res.Bind(products)
+	productsByID := make(map[string]entity.Product, len(products))
+	for _, product := range products {
+		productsByID[product.ID] = product
+	}
 
-	return res.Res, nil
+	res := make([]*model.Product, len(ids))
+	errs := make([]error, len(ids))
+	for i, id := range ids {
+		product, ok := productsByID[id]
+		if !ok {
+			errs[i] = errors.New("product not found")
+			continue
+		}
+		productRes := trans.ProductRes{}
+		productRes.Bind(product)
+		res[i] = productRes.Res
+	}
+
+	return res, errs
+}
+
+// getUserOrders implements a batch function that can retrieve pages of orders for many users,
+// for use in a dataloader
+func (u *reader) getUserOrders(ctx context.Context, keys []UserOrdersKey) ([][]*model.Order, []error) {
+	userIDs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		userIDs = append(userIDs, key.UserID)
+	}
+
+	orders, err := u.repo.GetOrdersByUserIDs(ctx, userIDs)
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	res := make([][]*model.Order, len(keys))
+	for i, key := range keys {
+		userOrders := orders[key.UserID]
+		start := min(int(key.Offset), len(userOrders))
+		end := min(start+int(key.Limit), len(userOrders))
+
+		page := trans.OrdersRes{}
+		page.Bind(userOrders[start:end])
+		res[i] = page.Res
+	}
+
+	return res, nil
+}
+
+// getOrderPayments implements a batch function that can retrieve the payments of many orders,
+// for use in a dataloader
+func (u *reader) getOrderPayments(ctx context.Context, orderIDs []string) ([][]*model.Payment, []error) {
+	payments, err := u.repo.GetPaymentsByOrderIDs(ctx, orderIDs)
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	res := make([][]*model.Payment, len(orderIDs))
+	for i, orderID := range orderIDs {
+		orderPayments := trans.PaymentsRes{}
+		orderPayments.Bind(payments[orderID])
+		res[i] = orderPayments.Res
+	}
+
+	return res, nil
+}
+
+// getOrderReturns implements a batch function that can retrieve the return requests of many orders,
+// for use in a dataloader
+func (u *reader) getOrderReturns(ctx context.Context, orderIDs []string) ([][]*model.ReturnRequest, []error) {
+	requests, err := u.repo.GetReturnsByOrderIDs(ctx, orderIDs)
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	res := make([][]*model.ReturnRequest, len(orderIDs))
+	for i, orderID := range orderIDs {
+		orderReturns := trans.ReturnRequestsRes{}
+		orderReturns.Bind(requests[orderID])
+		res[i] = orderReturns.Res
+	}
+
+	return res, nil
+}
+
+// getProductReviews implements a batch function that can retrieve pages of approved reviews for many products,
+// for use in a dataloader
+func (u *reader) getProductReviews(ctx context.Context, keys []ProductReviewsKey) ([][]*model.Review, []error) {
+	productIDs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		productIDs = append(productIDs, key.ProductID)
+	}
+
+	reviews, err := u.repo.GetApprovedReviewsByProductIDs(ctx, productIDs)
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	res := make([][]*model.Review, len(keys))
+	for i, key := range keys {
+		productReviews := reviews[key.ProductID]
+		start := min(int(key.Offset), len(productReviews))
+		end := min(start+int(key.Limit), len(productReviews))
+
+		page := trans.ReviewsRes{}
+		page.Bind(productReviews[start:end])
+		res[i] = page.Res
+	}
+
+	return res, nil
+}
+
+// getPriceHistory implements a batch function that can retrieve pages of price changes for many products,
+// for use in a dataloader
+func (u *reader) getPriceHistory(ctx context.Context, keys []PriceHistoryKey) ([][]*model.PriceChange, []error) {
+	productIDs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		productIDs = append(productIDs, key.ProductID)
+	}
+
+	changes, err := u.repo.GetPriceChangesByProductIDs(ctx, productIDs)
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	res := make([][]*model.PriceChange, len(keys))
+	for i, key := range keys {
+		productChanges := changes[key.ProductID]
+		start := min(int(key.Offset), len(productChanges))
+		end := min(start+int(key.Limit), len(productChanges))
+
+		page := trans.PriceChangesRes{}
+		page.Bind(productChanges[start:end])
+		res[i] = page.Res
+	}
+
+	return res, nil
 }
 
 func NewLoaders(repo app.Repo) *Loaders {
 	// define the data loader
 	ur := &reader{repo: repo}
 	return &Loaders{
-		UserLoader:    dataloadgen.NewLoader(ur.getUsers, dataloadgen.WithWait(time.Millisecond)),
-		ProductLoader: dataloadgen.NewLoader(ur.getProducts, dataloadgen.WithWait(time.Millisecond)),
+		UserLoader:           dataloadgen.NewLoader(ur.getUsers, dataloadgen.WithWait(time.Millisecond)),
+		ProductLoader:        dataloadgen.NewLoader(ur.getProducts, dataloadgen.WithWait(time.Millisecond)),
+		UserOrdersLoader:     dataloadgen.NewLoader(ur.getUserOrders, dataloadgen.WithWait(time.Millisecond)),
+		OrderPaymentsLoader:  dataloadgen.NewLoader(ur.getOrderPayments, dataloadgen.WithWait(time.Millisecond)),
+		OrderReturnsLoader:   dataloadgen.NewLoader(ur.getOrderReturns, dataloadgen.WithWait(time.Millisecond)),
+		ProductReviewsLoader: dataloadgen.NewLoader(ur.getProductReviews, dataloadgen.WithWait(time.Millisecond)),
+		PriceHistoryLoader:   dataloadgen.NewLoader(ur.getPriceHistory, dataloadgen.WithWait(time.Millisecond)),
 	}
 }
 
@@ -97,3 +262,49 @@ func GetProducts(ctx context.Context, ids []string) ([]*model.Product, error) {
 	loaders := For(ctx)
 	return loaders.ProductLoader.LoadAll(ctx, ids)
 }
+
+func GetUserOrders(ctx context.Context, userID string, limit *int32, offset *int32) ([]*model.Order, error) {
+	prs := app.OrdersParams{Limit: limit, Offset: offset, UserID: userID}
+	prs.SetDefaults()
+
+	loaders := For(ctx)
+	return loaders.UserOrdersLoader.Load(ctx, UserOrdersKey{
+		UserID: prs.UserID,
+		Limit:  *prs.Limit,
+		Offset: *prs.Offset,
+	})
+}
+
+func GetOrderPayments(ctx context.Context, orderID string) ([]*model.Payment, error) {
+	loaders := For(ctx)
+	return loaders.OrderPaymentsLoader.Load(ctx, orderID)
+}
+
+func GetOrderReturns(ctx context.Context, orderID string) ([]*model.ReturnRequest, error) {
+	loaders := For(ctx)
+	return loaders.OrderReturnsLoader.Load(ctx, orderID)
+}
+
+func GetProductReviews(ctx context.Context, productID string, limit *int32, offset *int32) ([]*model.Review, error) {
+	prs := app.ReviewsParams{Limit: limit, Offset: offset, ProductID: productID}
+	prs.SetDefaults()
+
+	loaders := For(ctx)
+	return loaders.ProductReviewsLoader.Load(ctx, ProductReviewsKey{
+		ProductID: prs.ProductID,
+		Limit:     *prs.Limit,
+		Offset:    *prs.Offset,
+	})
+}
+
+func GetPriceHistory(ctx context.Context, productID string, limit *int32, offset *int32) ([]*model.PriceChange, error) {
+	prs := app.PriceHistoryParams{Limit: limit, Offset: offset}
+	prs.SetDefaults()
+
+	loaders := For(ctx)
+	return loaders.PriceHistoryLoader.Load(ctx, PriceHistoryKey{
+		ProductID: productID,
+		Limit:     *prs.Limit,
+		Offset:    *prs.Offset,
+	})
+}
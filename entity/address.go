@@ -0,0 +1,48 @@
+package entity
+
+import "time"
+
+// Address is a shipping or billing destination saved by a user
+type Address struct {
+	ID         string `json:"id"`
+	UserID     string `json:"user_id"`
+	FullName   string `json:"full_name"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2,omitempty"`
+	City       string `json:"city"`
+	Region     string `json:"region,omitempty"`
+	PostalCode string `json:"postal_code,omitempty"`
+	// Country is an ISO 3166-1 alpha-2 code
+	Country           string    `json:"country"`
+	Phone             string    `json:"phone,omitempty"`
+	IsDefaultShipping bool      `json:"is_default_shipping"`
+	IsDefaultBilling  bool      `json:"is_default_billing"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// Snapshot copies the address contents, so that later edits of the address don't alter an order
+func (a Address) Snapshot() *OrderAddress {
+	return &OrderAddress{
+		FullName:   a.FullName,
+		Line1:      a.Line1,
+		Line2:      a.Line2,
+		City:       a.City,
+		Region:     a.Region,
+		PostalCode: a.PostalCode,
+		Country:    a.Country,
+		Phone:      a.Phone,
+	}
+}
+
+// OrderAddress is the address an order was placed with
+type OrderAddress struct {
+	FullName   string `json:"full_name"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2,omitempty"`
+	City       string `json:"city"`
+	Region     string `json:"region,omitempty"`
+	PostalCode string `json:"postal_code,omitempty"`
+	Country    string `json:"country"`
+	Phone      string `json:"phone,omitempty"`
+}
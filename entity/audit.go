@@ -0,0 +1,21 @@
+package entity
+
+import "time"
+
+// AuditEntry records an action performed by an actor on behalf of a user, e.g. an admin impersonating a customer
+type AuditEntry struct {
+	ID        string    `json:"id"`
+	ActorID   string    `json:"actor_id"`
+	UserID    string    `json:"user_id"`
+	Action    string    `json:"action"`
+	Operation string    `json:"operation"`
+	Succeeded bool      `json:"succeeded"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+const (
+	AuditActionImpersonate   = "Impersonate"
+	AuditActionMutation      = "Mutation"
+	AuditActionDataExport    = "DataExport"
+	AuditActionDeleteAccount = "DeleteAccount"
+)
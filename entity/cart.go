@@ -0,0 +1,46 @@
+package entity
+
+import "time"
+
+// Cart is the basket a user fills before checking out.
+// Guest carts have no user, they are identified by an opaque token and expire when left unused.
+type Cart struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id,omitempty"`
+	TokenHash string     `json:"token_hash,omitempty"`
+	Items     []CartItem `json:"items"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+func (c Cart) IsGuest() bool {
+	return c.UserID == ""
+}
+
+func (c Cart) IsExpired(now time.Time) bool {
+	return c.ExpiresAt != nil && !now.Before(*c.ExpiresAt)
+}
+
+// CartItem keeps the price a product had when it was added, to tell the user when it changed
+type CartItem struct {
+	ProductID  string    `json:"product_id"`
+	VariantID  string    `json:"variant_id,omitempty"`
+	Quantity   int32     `json:"quantity"`
+	AddedPrice Money     `json:"added_price"`
+	AddedAt    time.Time `json:"added_at"`
+}
+
+func (i CartItem) Key() ItemKey {
+	return ItemKey{ProductID: i.ProductID, VariantID: i.VariantID}
+}
+
+// Item returns the index of the item of the product or variant, or -1 when it is not in the cart
+func (c Cart) Item(key ItemKey) int {
+	for i, item := range c.Items {
+		if item.Key() == key {
+			return i
+		}
+	}
+	return -1
+}
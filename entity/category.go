@@ -0,0 +1,49 @@
+package entity
+
+import (
+	"strings"
+	"time"
+)
+
+// Category groups products in a tree, products of a category are also listed under its ancestors
+type Category struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Slug identifies the category in URLs, it is unique across all categories
+	Slug string `json:"slug"`
+	// ParentID is empty for top-level categories
+	ParentID  string    `json:"parent_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (c Category) IsTopLevel() bool {
+	return c.ParentID == ""
+}
+
+// CategoryPath returns the category of the ID followed by its ancestors, up to the top-level category
+func CategoryPath(categories map[string]Category, id string) []Category {
+	var path []Category
+	for category, ok := categories[id]; ok && len(path) <= len(categories); category, ok = categories[category.ParentID] {
+		path = append(path, category)
+	}
+	return path
+}
+
+// Slugify turns a name into a slug of ASCII lowercase letters, digits and dashes, e.g. "Men's T-Shirts" into "men-s-t-shirts"
+func Slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	return b.String()
+}
@@ -0,0 +1,15 @@
+package entity_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"graphql-backend/entity"
+)
+
+func TestSlugify(t *testing.T) {
+	require.Equal(t, "men-s-t-shirts", entity.Slugify("Men's T-Shirts"))
+	require.Equal(t, "mobile-phone", entity.Slugify("  mobile--phone "))
+	require.Equal(t, "caf-2", entity.Slugify("Café #2"))
+	require.Equal(t, "", entity.Slugify("!!"))
+}
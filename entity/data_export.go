@@ -0,0 +1,16 @@
+package entity
+
+import "time"
+
+// DataExport is a JSON archive of everything stored about a user, produced for data-subject requests
+type DataExport struct {
+	ID          string    `json:"id"`
+	UserID      string    `json:"user_id"`
+	RequestedBy string    `json:"requested_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+func (e DataExport) IsExpired(now time.Time) bool {
+	return !now.Before(e.ExpiresAt)
+}
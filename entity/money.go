@@ -0,0 +1,186 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the base currency products are priced in, amounts stored as plain numbers before currencies were recorded are in it
+const DefaultCurrency = "USD"
+
+// zeroDecimalCurrencies are the ISO 4217 currencies without a minor unit, the others have cents
+var zeroDecimalCurrencies = map[string]bool{
+	"CLP": true,
+	"ISK": true,
+	"JPY": true,
+	"KRW": true,
+	"VND": true,
+}
+
+// Money is an exact amount in the minor unit of its ISO 4217 currency, e.g. cents
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// NewMoneyFromFloat rounds an amount in major units, e.g. 19.99, to the minor unit of the currency
+func NewMoneyFromFloat(amount float64, currency string) Money {
+	return Money{Amount: int64(math.Round(amount * math.Pow10(decimals(currency)))), Currency: currency}
+}
+
+// ParseMoney parses a decimal amount in major units, e.g. "19.99", without going through floats.
+// Amounts more precise than the minor unit of the currency are rejected.
+func ParseMoney(s string, currency string) (Money, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	whole, fraction, _ := strings.Cut(s, ".")
+	fraction = strings.TrimRight(fraction, "0")
+	digits := decimals(currency)
+	if whole == "" || len(fraction) > digits {
+		return Money{}, errors.New("invalid amount " + s + " for currency " + currency)
+	}
+
+	amount, err := strconv.ParseInt(whole+fraction+strings.Repeat("0", digits-len(fraction)), 10, 64)
+	if err != nil || strings.ContainsAny(whole+fraction, "+-") {
+		return Money{}, errors.New("invalid amount " + s + " for currency " + currency)
+	}
+	if negative {
+		amount = -amount
+	}
+
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// Float64 returns the amount in major units, for display only
+func (m Money) Float64() float64 {
+	return float64(m.Amount) / math.Pow10(decimals(m.Currency))
+}
+
+// String returns the amount in major units, e.g. "19.99"
+func (m Money) String() string {
+	digits := decimals(m.Currency)
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	s := strconv.FormatInt(amount, 10)
+	if digits == 0 {
+		return sign + s
+	}
+	if len(s) <= digits {
+		s = strings.Repeat("0", digits-len(s)+1) + s
+	}
+	return sign + s[:len(s)-digits] + "." + s[len(s)-digits:]
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+func (m Money) LessThan(o Money) bool {
+	return m.Amount < o.Amount
+}
+
+// Add sums two amounts of the same currency, the zero value takes the currency of the other amount.
+// It panics when the currencies differ, amounts must be converted first.
+func (m Money) Add(o Money) Money {
+	return Money{Amount: m.Amount + o.Amount, Currency: currencyOf(m, o)}
+}
+
+func (m Money) Sub(o Money) Money {
+	return Money{Amount: m.Amount - o.Amount, Currency: currencyOf(m, o)}
+}
+
+func (m Money) Mul(n int64) Money {
+	return Money{Amount: m.Amount * n, Currency: m.Currency}
+}
+
+// Percent returns the given percentage of the amount, rounded half away from zero to the minor unit
+func (m Money) Percent(rate float64) Money {
+	return Money{Amount: int64(math.Round(float64(m.Amount) * rate / 100)), Currency: m.Currency}
+}
+
+// Share returns the part/whole share of the amount, rounded to the minor unit
+func (m Money) Share(part Money, whole Money) Money {
+	if whole.Amount == 0 {
+		return Money{Currency: m.Currency}
+	}
+	return Money{Amount: int64(math.Round(float64(m.Amount) * float64(part.Amount) / float64(whole.Amount))), Currency: m.Currency}
+}
+
+// Convert returns the amount in another currency, rate is the price of one unit of the amount's currency in the other one.
+// The result is rounded to the minor unit of the other currency.
+func (m Money) Convert(currency string, rate float64) Money {
+	if currency == m.Currency {
+		return m
+	}
+	amount := float64(m.Amount) * rate * math.Pow10(decimals(currency)-decimals(m.Currency))
+	return Money{Amount: int64(math.Round(amount)), Currency: currency}
+}
+
+func MinMoney(a Money, b Money) Money {
+	if b.LessThan(a) {
+		return b
+	}
+	return a
+}
+
+// UnmarshalJSON reads both amounts with their currency and the plain numbers amounts were stored as before,
+// the latter are in the default currency
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '{' {
+		type money Money
+		return json.Unmarshal(data, (*money)(m))
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return err
+	}
+
+	parsed, err := ParseMoney(number.String(), DefaultCurrency)
+	if err != nil {
+		// totals summed as floats may carry rounding noise, e.g. 0.30000000000000004
+		f, err := number.Float64()
+		if err != nil {
+			return err
+		}
+		parsed = NewMoneyFromFloat(f, DefaultCurrency)
+	}
+
+	*m = parsed
+	return nil
+}
+
+func currencyOf(m Money, o Money) string {
+	if m.Currency == "" {
+		return o.Currency
+	}
+	if o.Currency != "" && o.Currency != m.Currency {
+		panic("money: mismatched currencies " + m.Currency + " and " + o.Currency)
+	}
+	return m.Currency
+}
+
+func decimals(currency string) int {
+	if zeroDecimalCurrencies[currency] {
+		return 0
+	}
+	return 2
+}
@@ -0,0 +1,76 @@
+package entity_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"graphql-backend/entity"
+)
+
+func TestParseMoney(t *testing.T) {
+	m, err := entity.ParseMoney("19.99", "USD")
+	require.NoError(t, err)
+	require.Equal(t, entity.NewMoney(1999, "USD"), m)
+
+	m, err = entity.ParseMoney("-0.5", "EUR")
+	require.NoError(t, err)
+	require.Equal(t, int64(-50), m.Amount)
+
+	m, err = entity.ParseMoney("1500", "JPY")
+	require.NoError(t, err)
+	require.Equal(t, int64(1500), m.Amount)
+
+	_, err = entity.ParseMoney("1.234", "USD")
+	require.Error(t, err)
+	_, err = entity.ParseMoney("1.5", "JPY")
+	require.Error(t, err)
+	_, err = entity.ParseMoney("abc", "USD")
+	require.Error(t, err)
+}
+
+func TestMoneyString(t *testing.T) {
+	require.Equal(t, "0.30", entity.NewMoney(10, "USD").Add(entity.NewMoney(20, "USD")).String())
+	require.Equal(t, "-0.05", entity.NewMoney(-5, "USD").String())
+	require.Equal(t, "1500", entity.NewMoney(1500, "JPY").String())
+}
+
+func TestAddMismatchedCurrencies(t *testing.T) {
+	require.Equal(t, entity.NewMoney(150, "EUR"), entity.Money{}.Add(entity.NewMoney(150, "EUR")))
+	require.Panics(t, func() {
+		entity.NewMoney(100, "USD").Add(entity.NewMoney(100, "EUR"))
+	})
+	require.Panics(t, func() {
+		entity.NewMoney(100, "USD").Sub(entity.NewMoney(100, "EUR"))
+	})
+}
+
+func TestMoneyPercent(t *testing.T) {
+	require.Equal(t, int64(218), entity.NewMoney(3000, "USD").Percent(7.25).Amount)
+	require.Equal(t, int64(33), entity.NewMoney(100, "USD").Share(entity.NewMoney(1, "USD"), entity.NewMoney(3, "USD")).Amount)
+}
+
+func TestUnmarshalLegacyAmounts(t *testing.T) {
+	var product entity.Product
+	err := json.Unmarshal([]byte(`{"id":"p1","price":10.5}`), &product)
+	require.NoError(t, err)
+	require.Equal(t, entity.NewMoney(1050, entity.DefaultCurrency), product.Price)
+
+	var m entity.Money
+	err = json.Unmarshal([]byte(`0.30000000000000004`), &m)
+	require.NoError(t, err)
+	require.Equal(t, int64(30), m.Amount)
+
+	bts, err := json.Marshal(entity.NewMoney(1999, "EUR"))
+	require.NoError(t, err)
+	err = json.Unmarshal(bts, &m)
+	require.NoError(t, err)
+	require.Equal(t, entity.NewMoney(1999, "EUR"), m)
+}
+
+func TestMoneyConvert(t *testing.T) {
+	require.Equal(t, entity.NewMoney(920, "EUR"), entity.NewMoney(1000, "USD").Convert("EUR", 0.92))
+	// JPY has no minor unit
+	require.Equal(t, entity.NewMoney(1500, "JPY"), entity.NewMoney(1000, "USD").Convert("JPY", 150))
+	require.Equal(t, entity.NewMoney(1999, "USD"), entity.NewMoney(1999, "USD").Convert("USD", 1))
+}
@@ -0,0 +1,10 @@
+package entity
+
+// Notification is a message delivered to a user, e.g. by email
+type Notification struct {
+	UserID string
+	// To is the address to deliver to, it may differ from the user's current email
+	To      string
+	Subject string
+	Body    string
+}
@@ -1,20 +1,128 @@
 package entity
 
-import "time"
+import (
+	"slices"
+	"time"
+)
 
 type Order struct {
-	ID         string      `json:"id"`
-	UserID     string      `json:"user_id"`
-	ProductIDs []string    `json:"product_ids"`
-	Total      float64     `json:"total"`
-	CreatedAt  time.Time   `json:"created_at"`
-	Status     OrderStatus `json:"status"`
+	ID         string   `json:"id"`
+	UserID     string   `json:"user_id"`
+	ProductIDs []string `json:"product_ids"`
+	// Items are the ordered quantities and the prices paid, older orders only have ProductIDs
+	Items []OrderItem `json:"items,omitempty"`
+	// Subtotal is the sum of the items before discounts and taxes, Total the grand total the customer pays
+	Subtotal  Money           `json:"subtotal"`
+	Discounts []OrderDiscount `json:"discounts,omitempty"`
+	TaxLines  []TaxLine       `json:"tax_lines,omitempty"`
+	Total     Money           `json:"total"`
+	// Currency is the currency of all amounts of the order, ExchangeRate the rate from the base currency used to convert the prices
+	Currency     string      `json:"currency"`
+	ExchangeRate float64     `json:"exchange_rate"`
+	CreatedAt    time.Time   `json:"created_at"`
+	Status       OrderStatus `json:"status"`
+	// ShippingAddress and BillingAddress are copied from the user's addresses when the order is placed
+	ShippingAddress *OrderAddress `json:"shipping_address,omitempty"`
+	BillingAddress  *OrderAddress `json:"billing_address,omitempty"`
+	Refunds         []Refund      `json:"refunds,omitempty"`
+	// StockReserved is set when the items were taken out of stock as the order was placed, they are put back when it is cancelled
+	StockReserved bool `json:"stock_reserved,omitempty"`
+}
+
+// GetTaxTotal returns the sum of the tax lines
+func (o Order) GetTaxTotal() Money {
+	total := Money{Currency: o.Total.Currency}
+	for _, line := range o.TaxLines {
+		total = total.Add(line.Amount)
+	}
+	return total
+}
+
+// GetRefundedTotal returns the sum of the refunds
+func (o Order) GetRefundedTotal() Money {
+	total := Money{Currency: o.Total.Currency}
+	for _, refund := range o.Refunds {
+		total = total.Add(refund.Amount)
+	}
+	return total
+}
+
+// HasPromotion reports whether the promotion was applied to the order
+func (o Order) HasPromotion(promotionID string) bool {
+	for _, discount := range o.Discounts {
+		if discount.PromotionID == promotionID {
+			return true
+		}
+	}
+	return false
+}
+
+type OrderItem struct {
+	ProductID string `json:"product_id"`
+	// VariantID, SKU and Options are copied from the ordered variant
+	VariantID string          `json:"variant_id,omitempty"`
+	SKU       string          `json:"sku,omitempty"`
+	Options   []VariantOption `json:"options,omitempty"`
+	Quantity  int32           `json:"quantity"`
+	UnitPrice Money           `json:"unit_price"`
+}
+
+func (i OrderItem) Key() ItemKey {
+	return ItemKey{ProductID: i.ProductID, VariantID: i.VariantID}
+}
+
+// TaxLine is a tax charged on the items of an order, prices are net of taxes
+type TaxLine struct {
+	Name string `json:"name"`
+	// Rate is a percentage
+	Rate          float64 `json:"rate"`
+	TaxableAmount Money   `json:"taxable_amount"`
+	Amount        Money   `json:"amount"`
+}
+
+// Refund is money paid back to the customer through the payment of the order
+type Refund struct {
+	ID        string `json:"id"`
+	PaymentID string `json:"payment_id"`
+	// ReturnID is set when the refund is for returned items
+	ReturnID  string    `json:"return_id,omitempty"`
+	Amount    Money     `json:"amount"`
+	Reason    string    `json:"reason"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type OrderStatus string
 
 const (
-	OrderStatusPending   OrderStatus = "Pending"
-	OrderStatusCompleted OrderStatus = "Completed"
-	OrderStatusCancelled OrderStatus = "Cancelled"
+	// OrderStatusPending is the status of orders placed before payments were taken
+	OrderStatusPending         OrderStatus = "Pending"
+	OrderStatusAwaitingPayment OrderStatus = "AwaitingPayment"
+	OrderStatusPaid            OrderStatus = "Paid"
+	OrderStatusCompleted       OrderStatus = "Completed"
+	OrderStatusCancelled       OrderStatus = "Cancelled"
+	// OrderStatusPartiallyRefunded and OrderStatusRefunded follow refunds of a part or all of the total
+	OrderStatusPartiallyRefunded OrderStatus = "PartiallyRefunded"
+	OrderStatusRefunded          OrderStatus = "Refunded"
+	// OrderStatusRefunding is held while a refund of the order is on its way to the gateway
+	OrderStatusRefunding OrderStatus = "Refunding"
 )
+
+// orderTransitions are the statuses an order can change to from each status. Orders are claimed as Refunding
+// while their payments are refunded, they go back to their status when the refund fails.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending:           {OrderStatusAwaitingPayment, OrderStatusCancelled, OrderStatusRefunding},
+	OrderStatusAwaitingPayment:   {OrderStatusPaid, OrderStatusCancelled, OrderStatusRefunding},
+	OrderStatusPaid:              {OrderStatusCompleted, OrderStatusCancelled, OrderStatusRefunding},
+	OrderStatusPartiallyRefunded: {OrderStatusCompleted, OrderStatusCancelled, OrderStatusRefunding},
+	OrderStatusCompleted:         {OrderStatusRefunding},
+	OrderStatusRefunding: {
+		OrderStatusPending, OrderStatusAwaitingPayment, OrderStatusPaid, OrderStatusCompleted,
+		OrderStatusPartiallyRefunded, OrderStatusRefunded, OrderStatusCancelled,
+	},
+}
+
+// CanChangeTo reports whether an order can change from the status to the other one, cancelled and refunded orders can't
+func (s OrderStatus) CanChangeTo(to OrderStatus) bool {
+	return slices.Contains(orderTransitions[s], to)
+}
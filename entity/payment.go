@@ -0,0 +1,66 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+
+// Payment is an attempt to pay an order through a payment gateway, an order may have several when some fail
+type Payment struct {
+	ID      string `json:"id"`
+	OrderID string `json:"order_id"`
+	UserID  string `json:"user_id"`
+	Amount  Money  `json:"amount"`
+	// Gateway is the name of the payment gateway, Reference its ID for the transaction
+	Gateway   string        `json:"gateway"`
+	Reference string        `json:"reference,omitempty"`
+	Status    PaymentStatus `json:"status"`
+	CardLast4 string        `json:"card_last4,omitempty"`
+	// FailureReason is the gateway's reason for declining the payment
+	FailureReason  string    `json:"failure_reason,omitempty"`
+	RefundedAmount Money     `json:"refunded_amount"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// IsOpen reports whether the payment may still settle the order
+func (p Payment) IsOpen() bool {
+	switch p.Status {
+	case PaymentStatusPending, PaymentStatusAuthorized, PaymentStatusCaptured:
+		return true
+	default:
+		return false
+	}
+}
+
+type PaymentStatus string
+
+const (
+	// PaymentStatusPending waits for the gateway to confirm the authorization asynchronously
+	PaymentStatusPending    PaymentStatus = "Pending"
+	PaymentStatusAuthorized PaymentStatus = "Authorized"
+	PaymentStatusCaptured   PaymentStatus = "Captured"
+	PaymentStatusVoided     PaymentStatus = "Voided"
+	PaymentStatusRefunded   PaymentStatus = "Refunded"
+	PaymentStatusFailed     PaymentStatus = "Failed"
+)
+
+type PaymentAuthorization struct {
+	PaymentID string
+	Amount    Money
+	// PaymentMethod is a card number or a token of the gateway's client SDK, it is never stored
+	PaymentMethod string
+}
+
+// PaymentResult is the state of a transaction at the gateway.
+// Declines are results with the Failed status, errors are reserved for failures to reach the gateway.
+type PaymentResult struct {
+	Reference     string
+	Status        PaymentStatus
+	CardLast4     string
+	FailureReason string
+	// RefundedAmount is the total refunded so far
+	RefundedAmount Money
+}
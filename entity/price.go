@@ -0,0 +1,72 @@
+package entity
+
+import "time"
+
+// PriceChange records a change of the price of a product, or of one of its variants
+type PriceChange struct {
+	ID        string `json:"id"`
+	ProductID string `json:"product_id"`
+	// VariantID is set for the changes of the price of a variant
+	VariantID string `json:"variant_id,omitempty"`
+	OldPrice  Money  `json:"old_price"`
+	NewPrice  Money  `json:"new_price"`
+	// ActorID is the user or API key that changed the price, or that scheduled it. Command line imports have none.
+	ActorID          string            `json:"actor_id,omitempty"`
+	Source           PriceChangeSource `json:"source"`
+	ScheduledPriceID string            `json:"scheduled_price_id,omitempty"`
+	ChangedAt        time.Time         `json:"changed_at"`
+}
+
+type PriceChangeSource string
+
+const (
+	PriceChangeSourceManual   PriceChangeSource = "Manual"
+	PriceChangeSourceImport   PriceChangeSource = "Import"
+	PriceChangeSourceSchedule PriceChangeSource = "Schedule"
+)
+
+// ScheduledPrice is a price a product takes from StartsAt, e.g. a sale. With an end the previous price comes back
+// at EndsAt, without one the price stays.
+type ScheduledPrice struct {
+	ID        string               `json:"id"`
+	ProductID string               `json:"product_id"`
+	Price     Money                `json:"price"`
+	StartsAt  time.Time            `json:"starts_at"`
+	EndsAt    *time.Time           `json:"ends_at,omitempty"`
+	Status    ScheduledPriceStatus `json:"status"`
+	// PreviousPrice is the price of the product when the scheduled price was applied, it is restored at the end
+	PreviousPrice *Money    `json:"previous_price,omitempty"`
+	CreatedBy     string    `json:"created_by"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type ScheduledPriceStatus string
+
+const (
+	ScheduledPriceStatusScheduled ScheduledPriceStatus = "Scheduled"
+	ScheduledPriceStatusActive    ScheduledPriceStatus = "Active"
+	ScheduledPriceStatusCompleted ScheduledPriceStatus = "Completed"
+	ScheduledPriceStatusCancelled ScheduledPriceStatus = "Cancelled"
+	// ScheduledPriceStatusApplying is held while the price of the product is changed as the scheduled price starts or ends
+	ScheduledPriceStatusApplying ScheduledPriceStatus = "Applying"
+)
+
+// IsPending reports whether the scheduled price is yet to start or to end
+func (e ScheduledPrice) IsPending() bool {
+	return e.Status == ScheduledPriceStatusScheduled || e.Status == ScheduledPriceStatusActive ||
+		e.Status == ScheduledPriceStatusApplying
+}
+
+// Overlaps reports whether the two scheduled prices are in effect at the same time,
+// scheduled prices without an end only take effect at their start
+func (e ScheduledPrice) Overlaps(o ScheduledPrice) bool {
+	return e.StartsAt.Before(o.effectiveUntil()) && o.StartsAt.Before(e.effectiveUntil())
+}
+
+func (e ScheduledPrice) effectiveUntil() time.Time {
+	if e.EndsAt == nil {
+		return e.StartsAt.Add(time.Nanosecond)
+	}
+	return *e.EndsAt
+}
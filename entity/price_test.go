@@ -0,0 +1,26 @@
+package entity_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"graphql-backend/entity"
+)
+
+func TestScheduledPriceOverlaps(t *testing.T) {
+	start := time.Date(2026, 11, 27, 0, 0, 0, 0, time.UTC)
+	end := start.Add(72 * time.Hour)
+	sale := entity.ScheduledPrice{StartsAt: start, EndsAt: &end}
+
+	require.True(t, sale.Overlaps(entity.ScheduledPrice{StartsAt: start.Add(time.Hour)}))
+	require.True(t, sale.Overlaps(entity.ScheduledPrice{StartsAt: start}))
+	require.False(t, sale.Overlaps(entity.ScheduledPrice{StartsAt: end}))
+	require.False(t, sale.Overlaps(entity.ScheduledPrice{StartsAt: start.Add(-time.Hour)}))
+
+	// a permanent price before the sale starts doesn't overlap, one during it does
+	earlierEnd := start
+	require.False(t, sale.Overlaps(entity.ScheduledPrice{StartsAt: start.Add(-time.Hour), EndsAt: &earlierEnd}))
+	laterEnd := end.Add(time.Hour)
+	require.True(t, sale.Overlaps(entity.ScheduledPrice{StartsAt: end.Add(-time.Hour), EndsAt: &laterEnd}))
+}
@@ -1,10 +1,196 @@
 package entity
 
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
 type Product struct {
-	ID          string  `json:"id"`
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
-	Category    string  `json:"category"`
-	InStock     int32   `json:"inStock"`
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// SKU is optional for products with variants, each variant has its own. SKUs are unique across products and variants.
+	SKU         string `json:"sku,omitempty"`
+	Description string `json:"description"`
+	Price       Money  `json:"price"`
+	// Category is the name of the category, CategoryID the category itself. Tax rules and promotions match the category ID
+	// and the IDs of its ancestors.
+	Category   string `json:"category"`
+	CategoryID string `json:"category_id,omitempty"`
+	// InStock is the total of the variants for products with variants
+	InStock int32 `json:"inStock"`
+	// Options are the axes the variants differ by, e.g. Size and Color
+	Options  []ProductOption  `json:"options,omitempty"`
+	Variants []ProductVariant `json:"variants,omitempty"`
+	Images   []ProductImage   `json:"images,omitempty"`
+	// ArchivedAt is set once the product is withdrawn from sale, archived products stay readable for the orders that have them
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+	// RatingTotal and ReviewCount sum up the approved reviews, the repo keeps them up to date as reviews are moderated
+	RatingTotal int64 `json:"rating_total,omitempty"`
+	ReviewCount int32 `json:"review_count,omitempty"`
+}
+
+// AverageRating returns the average of the approved reviews, nil when there are none
+func (p Product) AverageRating() *float64 {
+	if p.ReviewCount == 0 {
+		return nil
+	}
+	average := float64(p.RatingTotal) / float64(p.ReviewCount)
+	return &average
+}
+
+type ProductOption struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+// ProductVariant is a sellable combination of the product options, e.g. a T-shirt in size M and color blue
+type ProductVariant struct {
+	ID  string `json:"id"`
+	SKU string `json:"sku"`
+	// Options has one value for each option of the product
+	Options []VariantOption `json:"options"`
+	// Price overrides the product price when set
+	Price   *Money `json:"price,omitempty"`
+	InStock int32  `json:"in_stock"`
+}
+
+// ProductImage is an image of the product kept in the blob storage, along with the thumbnail made of it on upload
+type ProductImage struct {
+	ID           string `json:"id"`
+	Key          string `json:"key"`
+	ThumbnailKey string `json:"thumbnail_key"`
+	ContentType  string `json:"content_type"`
+	Width        int32  `json:"width"`
+	Height       int32  `json:"height"`
+	// Size is the size of the image file in bytes
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type VariantOption struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// ItemKey identifies a product, or one of its variants, among the items of a cart, an order or a return
+type ItemKey struct {
+	ProductID string
+	VariantID string
+}
+
+func (p Product) HasVariants() bool {
+	return len(p.Variants) > 0
+}
+
+func (p Product) IsArchived() bool {
+	return p.ArchivedAt != nil
+}
+
+// CheckAvailable checks that the product is still sold
+func (p Product) CheckAvailable() error {
+	if p.IsArchived() {
+		return errors.New(p.Name + " is no longer available")
+	}
+	return nil
+}
+
+// Image returns the index of the image, or -1 when the product has no such image
+func (p Product) Image(id string) int {
+	for i, image := range p.Images {
+		if image.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// SKUs returns the SKU of the product and of its variants
+func (p Product) SKUs() []string {
+	var skus []string
+	if p.SKU != "" {
+		skus = append(skus, p.SKU)
+	}
+	for _, variant := range p.Variants {
+		skus = append(skus, variant.SKU)
+	}
+	return skus
+}
+
+// Variant returns the index of the variant, or -1 when the product has no such variant
+func (p Product) Variant(id string) int {
+	for i, variant := range p.Variants {
+		if variant.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// CheckVariant checks what is sold: products with variants are sold through one of them, other products without a variant
+func (p Product) CheckVariant(variantID string) error {
+	switch {
+	case variantID == "" && p.HasVariants():
+		return errors.New("choose a variant of " + p.Name)
+	case variantID != "" && p.Variant(variantID) < 0:
+		return errors.New("variant not found")
+	}
+	return nil
+}
+
+// VariantPrice is the price override of the variant, or the product price
+func (p Product) VariantPrice(variant ProductVariant) Money {
+	if variant.Price != nil {
+		return *variant.Price
+	}
+	return p.Price
+}
+
+// UnitPrice is the price of the variant, or of the product without a variant
+func (p Product) UnitPrice(variantID string) Money {
+	if i := p.Variant(variantID); i >= 0 {
+		return p.VariantPrice(p.Variants[i])
+	}
+	return p.Price
+}
+
+// Stock is the stock of the variant, or of the product without a variant
+func (p Product) Stock(variantID string) int32 {
+	if i := p.Variant(variantID); i >= 0 {
+		return p.Variants[i].InStock
+	}
+	if variantID != "" {
+		return 0
+	}
+	return p.InStock
+}
+
+// AddStock adds the quantity to the stock of the variant and to the product total, unknown variants are ignored.
+// The variants are copied, products read before keep their stock.
+func (p *Product) AddStock(variantID string, quantity int32) {
+	if variantID == "" {
+		p.InStock += quantity
+		return
+	}
+
+	i := p.Variant(variantID)
+	if i < 0 {
+		return
+	}
+	p.Variants = slices.Clone(p.Variants)
+	p.Variants[i].InStock += quantity
+	p.InStock += quantity
+}
+
+// Convert returns the product with its prices converted from the base currency
+func (p Product) Convert(currency string, rate float64) Product {
+	p.Price = p.Price.Convert(currency, rate)
+	p.Variants = slices.Clone(p.Variants)
+	for i, variant := range p.Variants {
+		if variant.Price != nil {
+			price := variant.Price.Convert(currency, rate)
+			p.Variants[i].Price = &price
+		}
+	}
+	return p
 }
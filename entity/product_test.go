@@ -0,0 +1,43 @@
+package entity_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"graphql-backend/entity"
+)
+
+func TestProductVariants(t *testing.T) {
+	override := entity.NewMoney(1500, "USD")
+	product := entity.Product{
+		Name:    "Shirt",
+		Price:   entity.NewMoney(1000, "USD"),
+		InStock: 5,
+		Variants: []entity.ProductVariant{
+			{ID: "s", InStock: 2},
+			{ID: "m", InStock: 3, Price: &override},
+		},
+	}
+
+	require.Error(t, product.CheckVariant(""))
+	require.Error(t, product.CheckVariant("xl"))
+	require.NoError(t, product.CheckVariant("m"))
+	require.Equal(t, entity.NewMoney(1000, "USD"), product.UnitPrice("s"))
+	require.Equal(t, override, product.UnitPrice("m"))
+	require.Equal(t, int32(0), product.Stock("xl"))
+
+	// Products read before keep their stock
+	restocked := product
+	restocked.AddStock("m", 2)
+	require.Equal(t, int32(5), restocked.Stock("m"))
+	require.Equal(t, int32(7), restocked.InStock)
+	require.Equal(t, int32(3), product.Stock("m"))
+
+	converted := product.Convert("EUR", 0.5)
+	require.Equal(t, entity.NewMoney(750, "EUR"), converted.UnitPrice("m"))
+	require.Equal(t, override, product.UnitPrice("m"))
+
+	simple := entity.Product{InStock: 1}
+	require.NoError(t, simple.CheckVariant(""))
+	require.Error(t, simple.CheckVariant("s"))
+}
@@ -0,0 +1,83 @@
+package entity
+
+import (
+	"slices"
+	"time"
+)
+
+// Promotion is a coupon code customers apply when placing an order
+type Promotion struct {
+	ID          string        `json:"id"`
+	Code        string        `json:"code"`
+	Description string        `json:"description"`
+	Type        PromotionType `json:"type"`
+	// Percentage is the discount of Percentage promotions, Amount the one of FixedAmount promotions in the default currency
+	Percentage float64        `json:"percentage,omitempty"`
+	Amount     Money          `json:"amount"`
+	Scope      PromotionScope `json:"scope"`
+	// CategoryIDs and ProductIDs restrict Category and Product scoped promotions, subcategories are in the scope of their ancestors
+	CategoryIDs   []string `json:"category_ids,omitempty"`
+	ProductIDs    []string `json:"product_ids,omitempty"`
+	MinOrderValue Money    `json:"min_order_value"`
+	// UsageLimit and UsageLimitPerUser are unlimited when nil
+	UsageLimit        *int32     `json:"usage_limit,omitempty"`
+	UsageLimitPerUser *int32     `json:"usage_limit_per_user,omitempty"`
+	UsageCount        int32      `json:"usage_count"`
+	Active            bool       `json:"active"`
+	StartsAt          *time.Time `json:"starts_at,omitempty"`
+	EndsAt            *time.Time `json:"ends_at,omitempty"`
+	CreatedBy         string     `json:"created_by"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+type PromotionType string
+
+const (
+	PromotionTypePercentage  PromotionType = "Percentage"
+	PromotionTypeFixedAmount PromotionType = "FixedAmount"
+)
+
+type PromotionScope string
+
+const (
+	PromotionScopeOrder    PromotionScope = "Order"
+	PromotionScopeCategory PromotionScope = "Category"
+	PromotionScopeProduct  PromotionScope = "Product"
+)
+
+// IsRedeemable reports whether the promotion is enabled and within its validity window
+func (p Promotion) IsRedeemable(now time.Time) bool {
+	if !p.Active {
+		return false
+	}
+	if p.StartsAt != nil && now.Before(*p.StartsAt) {
+		return false
+	}
+	if p.EndsAt != nil && !now.Before(*p.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// AppliesTo reports whether a product is in the scope of the promotion, categories are the category of the product followed by its ancestors
+func (p Promotion) AppliesTo(product Product, categories []Category) bool {
+	switch p.Scope {
+	case PromotionScopeCategory:
+		return slices.ContainsFunc(categories, func(category Category) bool {
+			return slices.Contains(p.CategoryIDs, category.ID)
+		})
+	case PromotionScopeProduct:
+		return slices.Contains(p.ProductIDs, product.ID)
+	default:
+		return true
+	}
+}
+
+// OrderDiscount is a promotion applied to an order and the amount it took off
+type OrderDiscount struct {
+	PromotionID string `json:"promotion_id"`
+	Code        string `json:"code"`
+	Description string `json:"description"`
+	Amount      Money  `json:"amount"`
+}
@@ -0,0 +1,41 @@
+package entity
+
+import "time"
+
+// ReturnRequest is a customer's request to send back items of an order, admins approve or reject it
+type ReturnRequest struct {
+	ID      string       `json:"id"`
+	OrderID string       `json:"order_id"`
+	UserID  string       `json:"user_id"`
+	Items   []ReturnItem `json:"items"`
+	Reason  string       `json:"reason"`
+	Status  ReturnStatus `json:"status"`
+	// RefundID is the refund of an approved return
+	RefundID string `json:"refund_id,omitempty"`
+	// Restocked is set when the returned quantities were put back in stock
+	Restocked  bool      `json:"restocked"`
+	ReviewNote string    `json:"review_note,omitempty"`
+	ReviewedBy string    `json:"reviewed_by,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type ReturnItem struct {
+	ProductID string `json:"product_id"`
+	VariantID string `json:"variant_id,omitempty"`
+	Quantity  int32  `json:"quantity"`
+}
+
+func (i ReturnItem) Key() ItemKey {
+	return ItemKey{ProductID: i.ProductID, VariantID: i.VariantID}
+}
+
+type ReturnStatus string
+
+const (
+	ReturnStatusRequested ReturnStatus = "Requested"
+	ReturnStatusApproved  ReturnStatus = "Approved"
+	ReturnStatusRejected  ReturnStatus = "Rejected"
+	// ReturnStatusApproving is held while the refund of an approved return is on its way to the gateway
+	ReturnStatusApproving ReturnStatus = "Approving"
+)
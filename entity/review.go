@@ -0,0 +1,26 @@
+package entity
+
+import "time"
+
+// Review is a customer's rating of a product they received, it is shown once an admin approves it
+type Review struct {
+	ID        string `json:"id"`
+	ProductID string `json:"product_id"`
+	UserID    string `json:"user_id"`
+	// AuthorName is the name of the customer when the review was written
+	AuthorName  string       `json:"author_name"`
+	Rating      int32        `json:"rating"`
+	Body        string       `json:"body,omitempty"`
+	Status      ReviewStatus `json:"status"`
+	ModeratedBy string       `json:"moderated_by,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+type ReviewStatus string
+
+const (
+	ReviewStatusPending  ReviewStatus = "Pending"
+	ReviewStatusApproved ReviewStatus = "Approved"
+	ReviewStatusHidden   ReviewStatus = "Hidden"
+)
@@ -0,0 +1,18 @@
+package entity
+
+import "time"
+
+// Session is created on login, every token issued for it carries its ID,
+// so that revoking the session invalidates the tokens before they expire
+type Session struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id"`
+	ActorID   string     `json:"actor_id,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (s Session) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
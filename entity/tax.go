@@ -0,0 +1,16 @@
+package entity
+
+// TaxRequest is the order a tax calculator computes the taxes of
+type TaxRequest struct {
+	// Address is the shipping destination, it is nil for orders picked up at the store
+	Address *OrderAddress
+	Lines   []TaxableLine
+}
+
+type TaxableLine struct {
+	ProductID string
+	// Categories are the category of the product followed by its ancestors
+	Categories []Category
+	// Amount is the line total after discounts
+	Amount Money
+}
@@ -1,9 +1,56 @@
 package entity
 
+import "time"
+
 type User struct {
-	ID       string `json:"id"`
-	Role     string `json:"role"`
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	ID         string         `json:"id"`
+	Role       string         `json:"role"`
+	Name       string         `json:"name"`
+	Email      string         `json:"email"`
+	Password   string         `json:"password"`
+	Status     UserStatus     `json:"status,omitempty"`
+	Identities []UserIdentity `json:"identities,omitempty"`
+	// PendingEmail is set while a new email waits for verification
+	PendingEmail *EmailChange `json:"pending_email,omitempty"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+}
+
+// UserIdentity links a user to an account at an external OpenID Connect provider
+type UserIdentity struct {
+	Issuer  string `json:"issuer"`
+	Subject string `json:"subject"`
+}
+
+const (
+	RoleAdmin    = "Admin"
+	RoleCustomer = "Customer"
+)
+
+type UserStatus string
+
+const (
+	UserStatusActive      UserStatus = "Active"
+	UserStatusDeactivated UserStatus = "Deactivated"
+	// UserStatusDeleted users are anonymized, only their orders are kept for accounting
+	UserStatusDeleted UserStatus = "Deleted"
+)
+
+// GetStatus returns the user status, users stored before statuses existed are active
+func (u User) GetStatus() UserStatus {
+	if u.Status == "" {
+		return UserStatusActive
+	}
+	return u.Status
+}
+
+func (u User) IsActive() bool {
+	return u.GetStatus() == UserStatusActive
+}
+
+// EmailChange is a pending email change, it is applied once the new address is verified
+type EmailChange struct {
+	Email     string    `json:"email"`
+	TokenHash string    `json:"token_hash"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
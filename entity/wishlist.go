@@ -0,0 +1,27 @@
+package entity
+
+import "time"
+
+// Wishlist keeps the products a user saved for later, users are told when one of them is back in stock
+type Wishlist struct {
+	UserID    string         `json:"user_id"`
+	Items     []WishlistItem `json:"items"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+type WishlistItem struct {
+	ProductID string    `json:"product_id"`
+	AddedAt   time.Time `json:"added_at"`
+	// NotifiedAt is the last time the user was told the product is back in stock
+	NotifiedAt *time.Time `json:"notified_at,omitempty"`
+}
+
+// Item returns the index of the item of the product, or -1 when it is not in the wishlist
+func (w Wishlist) Item(productID string) int {
+	for i, item := range w.Items {
+		if item.ProductID == productID {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,13 @@
+package model
+
+type AuditEntry struct {
+	ID        string `json:"id"`
+	Actor     *User  `json:"actor"`
+	ActorID   string `json:"actorId"`
+	User      *User  `json:"user"`
+	UserID    string `json:"userId"`
+	Action    string `json:"action"`
+	Operation string `json:"operation"`
+	Succeeded bool   `json:"succeeded"`
+	CreatedAt string `json:"createdAt"`
+}
@@ -5,101 +5,613 @@ package model
 import (
 	"bytes"
 	"fmt"
+	"graphql-backend/entity"
 	"io"
 	"strconv"
 )
 
+type Address struct {
+	ID         string  `json:"id"`
+	FullName   string  `json:"fullName"`
+	Line1      string  `json:"line1"`
+	Line2      *string `json:"line2,omitempty"`
+	City       string  `json:"city"`
+	Region     *string `json:"region,omitempty"`
+	PostalCode *string `json:"postalCode,omitempty"`
+	// ISO 3166-1 alpha-2 country code
+	Country           string  `json:"country"`
+	Phone             *string `json:"phone,omitempty"`
+	IsDefaultShipping bool    `json:"isDefaultShipping"`
+	IsDefaultBilling  bool    `json:"isDefaultBilling"`
+	CreatedAt         string  `json:"createdAt"`
+	UpdatedAt         string  `json:"updatedAt"`
+}
+
+type APIKey struct {
+	ID         string        `json:"id"`
+	Name       string        `json:"name"`
+	Prefix     string        `json:"prefix"`
+	Scopes     []APIKeyScope `json:"scopes"`
+	CreatedAt  string        `json:"createdAt"`
+	ExpiresAt  *string       `json:"expiresAt,omitempty"`
+	LastUsedAt *string       `json:"lastUsedAt,omitempty"`
+	RevokedAt  *string       `json:"revokedAt,omitempty"`
+}
+
 type AuthPayload struct {
 	AccessToken  string `json:"accessToken"`
 	RefreshToken string `json:"refreshToken"`
 	User         *User  `json:"user"`
 }
 
+// The cart is re-validated against the current prices and stock every time it is read
+type Cart struct {
+	ID string `json:"id"`
+	// Token of a guest cart, pass it as cartToken to keep using the cart and to login to merge it
+	Token     *string     `json:"token,omitempty"`
+	Items     []*CartItem `json:"items"`
+	ItemCount int32       `json:"itemCount"`
+	// ISO 4217 code of the currency of the cart amounts
+	Currency string       `json:"currency"`
+	Subtotal entity.Money `json:"subtotal"`
+	// False when an item has an issue other than PriceChanged
+	Orderable bool    `json:"orderable"`
+	UpdatedAt *string `json:"updatedAt,omitempty"`
+}
+
+type CartItem struct {
+	ProductID string  `json:"productId"`
+	VariantID *string `json:"variantId,omitempty"`
+	// Null when the product or the variant no longer exists
+	Product  *Product        `json:"product,omitempty"`
+	Variant  *ProductVariant `json:"variant,omitempty"`
+	Quantity int32           `json:"quantity"`
+	// The current price, charged at checkout
+	UnitPrice entity.Money `json:"unitPrice"`
+	// The price when the product was added
+	AddedPrice entity.Money    `json:"addedPrice"`
+	LineTotal  entity.Money    `json:"lineTotal"`
+	Issues     []CartItemIssue `json:"issues"`
+}
+
+type Category struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Unique across all categories
+	Slug string `json:"slug"`
+	// Null for top-level categories
+	ParentID  *string     `json:"parentId,omitempty"`
+	Children  []*Category `json:"children"`
+	CreatedAt string      `json:"createdAt"`
+	UpdatedAt string      `json:"updatedAt"`
+}
+
+// The first address of a user becomes the default shipping and billing address
+type CreateAddressInput struct {
+	FullName          string  `json:"fullName"`
+	Line1             string  `json:"line1"`
+	Line2             *string `json:"line2,omitempty"`
+	City              string  `json:"city"`
+	Region            *string `json:"region,omitempty"`
+	PostalCode        *string `json:"postalCode,omitempty"`
+	Country           string  `json:"country"`
+	Phone             *string `json:"phone,omitempty"`
+	IsDefaultShipping *bool   `json:"isDefaultShipping,omitempty"`
+	IsDefaultBilling  *bool   `json:"isDefaultBilling,omitempty"`
+}
+
+type CreateAPIKeyInput struct {
+	Name   string        `json:"name"`
+	Scopes []APIKeyScope `json:"scopes"`
+	// RFC 3339 timestamp after which the key stops working
+	ExpiresAt *string `json:"expiresAt,omitempty"`
+}
+
+type CreateAPIKeyPayload struct {
+	// The plain API key, send it in the X-API-Key header. It is only returned once.
+	Key    string  `json:"key"`
+	APIKey *APIKey `json:"apiKey"`
+}
+
+type CreateCategoryInput struct {
+	Name string `json:"name"`
+	// Derived from the name when not given
+	Slug     *string `json:"slug,omitempty"`
+	ParentID *string `json:"parentId,omitempty"`
+}
+
 type CreateProductInput struct {
-	Name        string  `json:"name"`
-	Price       float64 `json:"price"`
-	InStock     int32   `json:"inStock"`
-	Description *string `json:"description,omitempty"`
-	Category    string  `json:"category"`
+	Name string `json:"name"`
+	// Unique across products and variants, products with variants don't need one
+	Sku         *string      `json:"sku,omitempty"`
+	Price       entity.Money `json:"price"`
+	InStock     int32        `json:"inStock"`
+	Description *string      `json:"description,omitempty"`
+	// The category of the product, or the name of a top-level category created when missing
+	CategoryID *string               `json:"categoryId,omitempty"`
+	Category   *string               `json:"category,omitempty"`
+	Options    []*ProductOptionInput `json:"options,omitempty"`
+}
+
+type CreateProductVariantInput struct {
+	ProductID string `json:"productId"`
+	// Unique across all products
+	Sku     string                `json:"sku"`
+	Options []*VariantOptionInput `json:"options"`
+	// Overrides the product price
+	Price   *entity.Money `json:"price,omitempty"`
+	InStock int32         `json:"inStock"`
+}
+
+type CreatePromotionInput struct {
+	Code        string        `json:"code"`
+	Description *string       `json:"description,omitempty"`
+	Type        PromotionType `json:"type"`
+	// Required for Percentage promotions
+	Percentage *float64 `json:"percentage,omitempty"`
+	// Required for FixedAmount promotions, in the base currency
+	Amount            *entity.Money  `json:"amount,omitempty"`
+	Scope             PromotionScope `json:"scope"`
+	CategoryIds       []string       `json:"categoryIds,omitempty"`
+	ProductIds        []string       `json:"productIds,omitempty"`
+	MinOrderValue     *entity.Money  `json:"minOrderValue,omitempty"`
+	UsageLimit        *int32         `json:"usageLimit,omitempty"`
+	UsageLimitPerUser *int32         `json:"usageLimitPerUser,omitempty"`
+	Active            *bool          `json:"active,omitempty"`
+	// RFC 3339 timestamps of the validity window
+	StartsAt *string `json:"startsAt,omitempty"`
+	EndsAt   *string `json:"endsAt,omitempty"`
+}
+
+type CreateReviewInput struct {
+	ProductID string  `json:"productId"`
+	Rating    int32   `json:"rating"`
+	Body      *string `json:"body,omitempty"`
+}
+
+type CreateUserInput struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	Role     Role   `json:"role"`
+}
+
+type DataExport struct {
+	ID        string `json:"id"`
+	CreatedAt string `json:"createdAt"`
+	ExpiresAt string `json:"expiresAt"`
+	// Path of the JSON archive, download it with the same bearer token before it expires
+	DownloadURL string `json:"downloadUrl"`
+}
+
+type ImpersonationPayload struct {
+	// Short-lived access token to act as the user, there is no refresh token
+	AccessToken string `json:"accessToken"`
+	ExpiresAt   string `json:"expiresAt"`
+	User        *User  `json:"user"`
 }
 
 type LoginInput struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	// Guest cart to merge into the user's cart, quantities are summed and capped at the stock
+	CartToken *string `json:"cartToken,omitempty"`
 }
 
 type Mutation struct {
 }
 
+type OrderAddress struct {
+	FullName   string  `json:"fullName"`
+	Line1      string  `json:"line1"`
+	Line2      *string `json:"line2,omitempty"`
+	City       string  `json:"city"`
+	Region     *string `json:"region,omitempty"`
+	PostalCode *string `json:"postalCode,omitempty"`
+	Country    string  `json:"country"`
+	Phone      *string `json:"phone,omitempty"`
+}
+
+type OrderDiscount struct {
+	Code        string       `json:"code"`
+	Description string       `json:"description"`
+	Amount      entity.Money `json:"amount"`
+}
+
+type Payment struct {
+	ID        string        `json:"id"`
+	OrderID   string        `json:"orderId"`
+	Amount    entity.Money  `json:"amount"`
+	Status    PaymentStatus `json:"status"`
+	CardLast4 *string       `json:"cardLast4,omitempty"`
+	// The gateway's reason for declining the payment
+	FailureReason  *string      `json:"failureReason,omitempty"`
+	RefundedAmount entity.Money `json:"refundedAmount"`
+	CreatedAt      string       `json:"createdAt"`
+	UpdatedAt      string       `json:"updatedAt"`
+}
+
+type PlaceOrderItemInput struct {
+	ProductID string `json:"productId"`
+	// Required for products with variants
+	VariantID *string `json:"variantId,omitempty"`
+	Quantity  int32   `json:"quantity"`
+}
+
+type PriceChange struct {
+	ID string `json:"id"`
+	// The variant whose price changed, null for changes of the product price
+	VariantID *string      `json:"variantId,omitempty"`
+	OldPrice  entity.Money `json:"oldPrice"`
+	NewPrice  entity.Money `json:"newPrice"`
+	// The user or API key that changed the price or scheduled the change, null for command line imports
+	ActorID          *string           `json:"actorId,omitempty"`
+	Source           PriceChangeSource `json:"source"`
+	ScheduledPriceID *string           `json:"scheduledPriceId,omitempty"`
+	ChangedAt        string            `json:"changedAt"`
+}
+
 type Product struct {
-	ID          string  `json:"id"`
-	Name        string  `json:"name"`
-	Price       float64 `json:"price"`
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Unique across products and variants, optional for products with variants
+	Sku   *string      `json:"sku,omitempty"`
+	Price entity.Money `json:"price"`
+	// ISO 4217 code of the price currency, products are priced in the base currency unless converted
+	Currency string `json:"currency"`
+	// The total of the variants for products with variants
 	InStock     int32   `json:"inStock"`
 	Description *string `json:"description,omitempty"`
-	Category    string  `json:"category"`
+	// Name of the product category
+	Category   string  `json:"category"`
+	CategoryID *string `json:"categoryId,omitempty"`
+	// The axes the variants differ by, e.g. Size and Color
+	Options []*ProductOption `json:"options"`
+	// Products with variants are ordered through one of their variants
+	Variants []*ProductVariant `json:"variants"`
+	Images   []*ProductImage   `json:"images"`
+	// Set once the product is withdrawn from sale, archived products are left out of the listings but still resolve in orders
+	ArchivedAt *string `json:"archivedAt,omitempty"`
+	// Approved reviews, newest first
+	Reviews []*Review `json:"reviews"`
+	// Average of the approved reviews, null until one is approved
+	AverageRating *float64 `json:"averageRating,omitempty"`
+	ReviewCount   int32    `json:"reviewCount"`
+	// Changes of the price in the base currency, newest first
+	PriceHistory []*PriceChange `json:"priceHistory"`
+}
+
+type ProductImage struct {
+	ID string `json:"id"`
+	// Path of the image on this server, served under /images/
+	URL string `json:"url"`
+	// A version of the image fitting in 256x256 pixels
+	ThumbnailURL string `json:"thumbnailUrl"`
+	ContentType  string `json:"contentType"`
+	Width        int32  `json:"width"`
+	Height       int32  `json:"height"`
+	// Size of the image file in bytes
+	Size      int32  `json:"size"`
+	CreatedAt string `json:"createdAt"`
+}
+
+type ProductImportError struct {
+	// Line of the row in the file, the CSV header is line 1
+	Line    int32   `json:"line"`
+	Sku     *string `json:"sku,omitempty"`
+	Message string  `json:"message"`
+}
+
+type ProductImportResult struct {
+	DryRun bool `json:"dryRun"`
+	// False for dry runs and when any row failed, nothing is changed then
+	Applied bool `json:"applied"`
+	// The products and variants the rows create and update
+	Created int32                 `json:"created"`
+	Updated int32                 `json:"updated"`
+	Errors  []*ProductImportError `json:"errors"`
+}
+
+type ProductOption struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+type ProductOptionInput struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+type ProductVariant struct {
+	ID  string `json:"id"`
+	Sku string `json:"sku"`
+	// One value for each option of the product
+	Options []*VariantOption `json:"options"`
+	// The price override of the variant, or the product price
+	Price entity.Money `json:"price"`
+	// False when the variant is sold at the product price
+	PriceOverridden bool  `json:"priceOverridden"`
+	InStock         int32 `json:"inStock"`
+}
+
+type Promotion struct {
+	ID string `json:"id"`
+	// The coupon code, matched case-insensitively
+	Code        string        `json:"code"`
+	Description string        `json:"description"`
+	Type        PromotionType `json:"type"`
+	// The discount of Percentage promotions
+	Percentage *float64 `json:"percentage,omitempty"`
+	// The discount of FixedAmount promotions, in the base currency
+	Amount *entity.Money  `json:"amount,omitempty"`
+	Scope  PromotionScope `json:"scope"`
+	// The categories of Category promotions, their subcategories are included
+	CategoryIds       []string     `json:"categoryIds"`
+	ProductIds        []string     `json:"productIds"`
+	MinOrderValue     entity.Money `json:"minOrderValue"`
+	UsageLimit        *int32       `json:"usageLimit,omitempty"`
+	UsageLimitPerUser *int32       `json:"usageLimitPerUser,omitempty"`
+	// The orders placed with the promotion, cancelled ones excluded
+	UsageCount int32   `json:"usageCount"`
+	Active     bool    `json:"active"`
+	StartsAt   *string `json:"startsAt,omitempty"`
+	EndsAt     *string `json:"endsAt,omitempty"`
+	CreatedAt  string  `json:"createdAt"`
+	UpdatedAt  string  `json:"updatedAt"`
 }
 
 type Query struct {
 }
 
+type Refund struct {
+	ID     string       `json:"id"`
+	Amount entity.Money `json:"amount"`
+	Reason string       `json:"reason"`
+	// Set when the refund is for an approved return
+	ReturnID  *string `json:"returnId,omitempty"`
+	CreatedAt string  `json:"createdAt"`
+}
+
+type RequestReturnInput struct {
+	OrderID string             `json:"orderId"`
+	Items   []*ReturnItemInput `json:"items"`
+	Reason  string             `json:"reason"`
+}
+
+type ReturnItem struct {
+	Product   *Product `json:"product,omitempty"`
+	ProductID string   `json:"productId"`
+	VariantID *string  `json:"variantId,omitempty"`
+	Quantity  int32    `json:"quantity"`
+}
+
+type ReturnItemInput struct {
+	ProductID string  `json:"productId"`
+	VariantID *string `json:"variantId,omitempty"`
+	Quantity  int32   `json:"quantity"`
+}
+
+type ReturnRequest struct {
+	ID       string        `json:"id"`
+	OrderID  string        `json:"orderId"`
+	Items    []*ReturnItem `json:"items"`
+	Reason   string        `json:"reason"`
+	Status   ReturnStatus  `json:"status"`
+	RefundID *string       `json:"refundId,omitempty"`
+	// True when the returned quantities were put back in stock
+	Restocked  bool    `json:"restocked"`
+	ReviewNote *string `json:"reviewNote,omitempty"`
+	CreatedAt  string  `json:"createdAt"`
+	UpdatedAt  string  `json:"updatedAt"`
+}
+
+type Review struct {
+	ID        string `json:"id"`
+	ProductID string `json:"productId"`
+	// Name of the customer when the review was written
+	AuthorName string `json:"authorName"`
+	// From 1 to 5
+	Rating int32   `json:"rating"`
+	Body   *string `json:"body,omitempty"`
+	// Reviews are published once approved
+	Status    ReviewStatus `json:"status"`
+	CreatedAt string       `json:"createdAt"`
+	UpdatedAt string       `json:"updatedAt"`
+}
+
+type SchedulePriceInput struct {
+	ProductID string       `json:"productId"`
+	Price     entity.Money `json:"price"`
+	// RFC 3339 timestamps, the price starts in the future and stays when it has no end
+	StartsAt string  `json:"startsAt"`
+	EndsAt   *string `json:"endsAt,omitempty"`
+}
+
+// A price a product takes at startsAt, e.g. a sale. With an end the previous price comes back at endsAt
+type ScheduledPrice struct {
+	ID        string               `json:"id"`
+	ProductID string               `json:"productId"`
+	Price     entity.Money         `json:"price"`
+	StartsAt  string               `json:"startsAt"`
+	EndsAt    *string              `json:"endsAt,omitempty"`
+	Status    ScheduledPriceStatus `json:"status"`
+	// The price of the product when the scheduled price started, restored at the end unless it was changed meanwhile
+	PreviousPrice *entity.Money `json:"previousPrice,omitempty"`
+	CreatedBy     string        `json:"createdBy"`
+	CreatedAt     string        `json:"createdAt"`
+	UpdatedAt     string        `json:"updatedAt"`
+}
+
+type TaxLine struct {
+	Name string `json:"name"`
+	// A percentage
+	Rate          float64      `json:"rate"`
+	TaxableAmount entity.Money `json:"taxableAmount"`
+	Amount        entity.Money `json:"amount"`
+}
+
+type UpdateAddressInput struct {
+	ID                string  `json:"id"`
+	FullName          *string `json:"fullName,omitempty"`
+	Line1             *string `json:"line1,omitempty"`
+	Line2             *string `json:"line2,omitempty"`
+	City              *string `json:"city,omitempty"`
+	Region            *string `json:"region,omitempty"`
+	PostalCode        *string `json:"postalCode,omitempty"`
+	Country           *string `json:"country,omitempty"`
+	Phone             *string `json:"phone,omitempty"`
+	IsDefaultShipping *bool   `json:"isDefaultShipping,omitempty"`
+	IsDefaultBilling  *bool   `json:"isDefaultBilling,omitempty"`
+}
+
+type UpdateCategoryInput struct {
+	ID       string  `json:"id"`
+	Name     *string `json:"name,omitempty"`
+	Slug     *string `json:"slug,omitempty"`
+	ParentID *string `json:"parentId,omitempty"`
+	// Makes the category a top-level category
+	MoveToTop *bool `json:"moveToTop,omitempty"`
+}
+
 type UpdateProductInput struct {
-	ID          string   `json:"id"`
-	Name        *string  `json:"name,omitempty"`
-	Price       *float64 `json:"price,omitempty"`
-	InStock     *int32   `json:"inStock,omitempty"`
-	Description *string  `json:"description,omitempty"`
-	Category    *string  `json:"category,omitempty"`
+	ID    string        `json:"id"`
+	Name  *string       `json:"name,omitempty"`
+	Sku   *string       `json:"sku,omitempty"`
+	Price *entity.Money `json:"price,omitempty"`
+	// The stock of a product with variants is set on its variants
+	InStock     *int32  `json:"inStock,omitempty"`
+	Description *string `json:"description,omitempty"`
+	CategoryID  *string `json:"categoryId,omitempty"`
+	Category    *string `json:"category,omitempty"`
+	// Replaces the options, the variants must still match them
+	Options []*ProductOptionInput `json:"options,omitempty"`
+}
+
+type UpdateProductVariantInput struct {
+	ProductID string                `json:"productId"`
+	ID        string                `json:"id"`
+	Sku       *string               `json:"sku,omitempty"`
+	Options   []*VariantOptionInput `json:"options,omitempty"`
+	Price     *entity.Money         `json:"price,omitempty"`
+	// Removes the price override, the variant is sold at the product price again
+	ClearPrice *bool  `json:"clearPrice,omitempty"`
+	InStock    *int32 `json:"inStock,omitempty"`
+}
+
+type UpdateProfileInput struct {
+	Name *string `json:"name,omitempty"`
+	// A new email is applied once verified with the token sent to it
+	Email *string `json:"email,omitempty"`
+}
+
+type UpdatePromotionInput struct {
+	ID            string          `json:"id"`
+	Code          *string         `json:"code,omitempty"`
+	Description   *string         `json:"description,omitempty"`
+	Type          *PromotionType  `json:"type,omitempty"`
+	Percentage    *float64        `json:"percentage,omitempty"`
+	Amount        *entity.Money   `json:"amount,omitempty"`
+	Scope         *PromotionScope `json:"scope,omitempty"`
+	CategoryIds   []string        `json:"categoryIds,omitempty"`
+	ProductIds    []string        `json:"productIds,omitempty"`
+	MinOrderValue *entity.Money   `json:"minOrderValue,omitempty"`
+	UsageLimit    *int32          `json:"usageLimit,omitempty"`
+	// Removes the usage limit
+	ClearUsageLimit   *bool  `json:"clearUsageLimit,omitempty"`
+	UsageLimitPerUser *int32 `json:"usageLimitPerUser,omitempty"`
+	// Removes the usage limit per user
+	ClearUsageLimitPerUser *bool   `json:"clearUsageLimitPerUser,omitempty"`
+	Active                 *bool   `json:"active,omitempty"`
+	StartsAt               *string `json:"startsAt,omitempty"`
+	// Removes the start, the promotion applies right away
+	ClearStartsAt *bool   `json:"clearStartsAt,omitempty"`
+	EndsAt        *string `json:"endsAt,omitempty"`
+	// Removes the end, the promotion applies until it is deactivated
+	ClearEndsAt *bool `json:"clearEndsAt,omitempty"`
 }
 
 type User struct {
-	ID    string `json:"id"`
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Email     string     `json:"email"`
+	Role      Role       `json:"role"`
+	Status    UserStatus `json:"status"`
+	CreatedAt string     `json:"createdAt"`
+	UpdatedAt string     `json:"updatedAt"`
+	// Set while a new email waits for verification
+	PendingEmail *string `json:"pendingEmail,omitempty"`
+	// Most recent orders first
+	Orders []*Order `json:"orders"`
+}
+
+type UsersFilter struct {
+	Role   *Role       `json:"role,omitempty"`
+	Status *UserStatus `json:"status,omitempty"`
+	// Matches a part of the name or email, case-insensitive
+	Search *string `json:"search,omitempty"`
+}
+
+type VariantOption struct {
 	Name  string `json:"name"`
-	Email string `json:"email"`
+	Value string `json:"value"`
 }
 
-type Role string
+type VariantOptionInput struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type WishlistItem struct {
+	ProductID string `json:"productId"`
+	// Archived products stay in the wishlist, see archivedAt
+	Product *Product `json:"product,omitempty"`
+	AddedAt string   `json:"addedAt"`
+	// The last time the user was told the product is back in stock
+	NotifiedAt *string `json:"notifiedAt,omitempty"`
+}
+
+type APIKeyScope string
 
 const (
-	RoleAdmin    Role = "Admin"
-	RoleCustomer Role = "Customer"
+	APIKeyScopeReadProducts  APIKeyScope = "ReadProducts"
+	APIKeyScopeWriteProducts APIKeyScope = "WriteProducts"
 )
 
-var AllRole = []Role{
-	RoleAdmin,
-	RoleCustomer,
+var AllAPIKeyScope = []APIKeyScope{
+	APIKeyScopeReadProducts,
+	APIKeyScopeWriteProducts,
 }
 
-func (e Role) IsValid() bool {
+func (e APIKeyScope) IsValid() bool {
 	switch e {
-	case RoleAdmin, RoleCustomer:
+	case APIKeyScopeReadProducts, APIKeyScopeWriteProducts:
 		return true
 	}
 	return false
 }
 
-func (e Role) String() string {
+func (e APIKeyScope) String() string {
 	return string(e)
 }
 
-func (e *Role) UnmarshalGQL(v any) error {
+func (e *APIKeyScope) UnmarshalGQL(v any) error {
 	str, ok := v.(string)
 	if !ok {
 		return fmt.Errorf("enums must be strings")
 	}
 
-	*e = Role(str)
+	*e = APIKeyScope(str)
 	if !e.IsValid() {
-		return fmt.Errorf("%s is not a valid Role", str)
+		return fmt.Errorf("%s is not a valid ApiKeyScope", str)
 	}
 	return nil
 }
 
-func (e Role) MarshalGQL(w io.Writer) {
+func (e APIKeyScope) MarshalGQL(w io.Writer) {
 	fmt.Fprint(w, strconv.Quote(e.String()))
 }
 
-func (e *Role) UnmarshalJSON(b []byte) error {
+func (e *APIKeyScope) UnmarshalJSON(b []byte) error {
 	s, err := strconv.Unquote(string(b))
 	if err != nil {
 		return err
@@ -107,7 +619,646 @@ func (e *Role) UnmarshalJSON(b []byte) error {
 	return e.UnmarshalGQL(s)
 }
 
-func (e Role) MarshalJSON() ([]byte, error) {
+func (e APIKeyScope) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type CartItemIssue string
+
+const (
+	CartItemIssuePriceChanged      CartItemIssue = "PriceChanged"
+	CartItemIssueInsufficientStock CartItemIssue = "InsufficientStock"
+	CartItemIssueOutOfStock        CartItemIssue = "OutOfStock"
+	CartItemIssueUnavailable       CartItemIssue = "Unavailable"
+)
+
+var AllCartItemIssue = []CartItemIssue{
+	CartItemIssuePriceChanged,
+	CartItemIssueInsufficientStock,
+	CartItemIssueOutOfStock,
+	CartItemIssueUnavailable,
+}
+
+func (e CartItemIssue) IsValid() bool {
+	switch e {
+	case CartItemIssuePriceChanged, CartItemIssueInsufficientStock, CartItemIssueOutOfStock, CartItemIssueUnavailable:
+		return true
+	}
+	return false
+}
+
+func (e CartItemIssue) String() string {
+	return string(e)
+}
+
+func (e *CartItemIssue) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = CartItemIssue(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid CartItemIssue", str)
+	}
+	return nil
+}
+
+func (e CartItemIssue) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *CartItemIssue) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e CartItemIssue) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+// File formats of the product imports and exports. CSV files start with a header naming their columns,
+// JSON Lines files have an object per line with the same keys
+type CatalogFormat string
+
+const (
+	CatalogFormatCSV   CatalogFormat = "CSV"
+	CatalogFormatJSONL CatalogFormat = "JSONL"
+)
+
+var AllCatalogFormat = []CatalogFormat{
+	CatalogFormatCSV,
+	CatalogFormatJSONL,
+}
+
+func (e CatalogFormat) IsValid() bool {
+	switch e {
+	case CatalogFormatCSV, CatalogFormatJSONL:
+		return true
+	}
+	return false
+}
+
+func (e CatalogFormat) String() string {
+	return string(e)
+}
+
+func (e *CatalogFormat) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = CatalogFormat(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid CatalogFormat", str)
+	}
+	return nil
+}
+
+func (e CatalogFormat) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *CatalogFormat) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e CatalogFormat) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type PaymentStatus string
+
+const (
+	PaymentStatusPending    PaymentStatus = "Pending"
+	PaymentStatusAuthorized PaymentStatus = "Authorized"
+	PaymentStatusCaptured   PaymentStatus = "Captured"
+	PaymentStatusVoided     PaymentStatus = "Voided"
+	PaymentStatusRefunded   PaymentStatus = "Refunded"
+	PaymentStatusFailed     PaymentStatus = "Failed"
+)
+
+var AllPaymentStatus = []PaymentStatus{
+	PaymentStatusPending,
+	PaymentStatusAuthorized,
+	PaymentStatusCaptured,
+	PaymentStatusVoided,
+	PaymentStatusRefunded,
+	PaymentStatusFailed,
+}
+
+func (e PaymentStatus) IsValid() bool {
+	switch e {
+	case PaymentStatusPending, PaymentStatusAuthorized, PaymentStatusCaptured, PaymentStatusVoided, PaymentStatusRefunded, PaymentStatusFailed:
+		return true
+	}
+	return false
+}
+
+func (e PaymentStatus) String() string {
+	return string(e)
+}
+
+func (e *PaymentStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = PaymentStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid PaymentStatus", str)
+	}
+	return nil
+}
+
+func (e PaymentStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *PaymentStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e PaymentStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type PriceChangeSource string
+
+const (
+	PriceChangeSourceManual   PriceChangeSource = "Manual"
+	PriceChangeSourceImport   PriceChangeSource = "Import"
+	PriceChangeSourceSchedule PriceChangeSource = "Schedule"
+)
+
+var AllPriceChangeSource = []PriceChangeSource{
+	PriceChangeSourceManual,
+	PriceChangeSourceImport,
+	PriceChangeSourceSchedule,
+}
+
+func (e PriceChangeSource) IsValid() bool {
+	switch e {
+	case PriceChangeSourceManual, PriceChangeSourceImport, PriceChangeSourceSchedule:
+		return true
+	}
+	return false
+}
+
+func (e PriceChangeSource) String() string {
+	return string(e)
+}
+
+func (e *PriceChangeSource) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = PriceChangeSource(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid PriceChangeSource", str)
+	}
+	return nil
+}
+
+func (e PriceChangeSource) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *PriceChangeSource) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e PriceChangeSource) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type PromotionScope string
+
+const (
+	PromotionScopeOrder    PromotionScope = "Order"
+	PromotionScopeCategory PromotionScope = "Category"
+	PromotionScopeProduct  PromotionScope = "Product"
+)
+
+var AllPromotionScope = []PromotionScope{
+	PromotionScopeOrder,
+	PromotionScopeCategory,
+	PromotionScopeProduct,
+}
+
+func (e PromotionScope) IsValid() bool {
+	switch e {
+	case PromotionScopeOrder, PromotionScopeCategory, PromotionScopeProduct:
+		return true
+	}
+	return false
+}
+
+func (e PromotionScope) String() string {
+	return string(e)
+}
+
+func (e *PromotionScope) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = PromotionScope(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid PromotionScope", str)
+	}
+	return nil
+}
+
+func (e PromotionScope) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *PromotionScope) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e PromotionScope) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type PromotionType string
+
+const (
+	PromotionTypePercentage  PromotionType = "Percentage"
+	PromotionTypeFixedAmount PromotionType = "FixedAmount"
+)
+
+var AllPromotionType = []PromotionType{
+	PromotionTypePercentage,
+	PromotionTypeFixedAmount,
+}
+
+func (e PromotionType) IsValid() bool {
+	switch e {
+	case PromotionTypePercentage, PromotionTypeFixedAmount:
+		return true
+	}
+	return false
+}
+
+func (e PromotionType) String() string {
+	return string(e)
+}
+
+func (e *PromotionType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = PromotionType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid PromotionType", str)
+	}
+	return nil
+}
+
+func (e PromotionType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *PromotionType) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e PromotionType) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type ReturnStatus string
+
+const (
+	ReturnStatusRequested ReturnStatus = "Requested"
+	// The return was approved and its refund is in progress
+	ReturnStatusApproving ReturnStatus = "Approving"
+	ReturnStatusApproved  ReturnStatus = "Approved"
+	ReturnStatusRejected  ReturnStatus = "Rejected"
+)
+
+var AllReturnStatus = []ReturnStatus{
+	ReturnStatusRequested,
+	ReturnStatusApproving,
+	ReturnStatusApproved,
+	ReturnStatusRejected,
+}
+
+func (e ReturnStatus) IsValid() bool {
+	switch e {
+	case ReturnStatusRequested, ReturnStatusApproving, ReturnStatusApproved, ReturnStatusRejected:
+		return true
+	}
+	return false
+}
+
+func (e ReturnStatus) String() string {
+	return string(e)
+}
+
+func (e *ReturnStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ReturnStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ReturnStatus", str)
+	}
+	return nil
+}
+
+func (e ReturnStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *ReturnStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e ReturnStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type ReviewStatus string
+
+const (
+	ReviewStatusPending  ReviewStatus = "Pending"
+	ReviewStatusApproved ReviewStatus = "Approved"
+	ReviewStatusHidden   ReviewStatus = "Hidden"
+)
+
+var AllReviewStatus = []ReviewStatus{
+	ReviewStatusPending,
+	ReviewStatusApproved,
+	ReviewStatusHidden,
+}
+
+func (e ReviewStatus) IsValid() bool {
+	switch e {
+	case ReviewStatusPending, ReviewStatusApproved, ReviewStatusHidden:
+		return true
+	}
+	return false
+}
+
+func (e ReviewStatus) String() string {
+	return string(e)
+}
+
+func (e *ReviewStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ReviewStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ReviewStatus", str)
+	}
+	return nil
+}
+
+func (e ReviewStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *ReviewStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e ReviewStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type Role string
+
+const (
+	RoleAdmin    Role = "Admin"
+	RoleCustomer Role = "Customer"
+)
+
+var AllRole = []Role{
+	RoleAdmin,
+	RoleCustomer,
+}
+
+func (e Role) IsValid() bool {
+	switch e {
+	case RoleAdmin, RoleCustomer:
+		return true
+	}
+	return false
+}
+
+func (e Role) String() string {
+	return string(e)
+}
+
+func (e *Role) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = Role(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid Role", str)
+	}
+	return nil
+}
+
+func (e Role) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *Role) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e Role) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type ScheduledPriceStatus string
+
+const (
+	ScheduledPriceStatusScheduled ScheduledPriceStatus = "Scheduled"
+	ScheduledPriceStatusActive    ScheduledPriceStatus = "Active"
+	ScheduledPriceStatusCompleted ScheduledPriceStatus = "Completed"
+	ScheduledPriceStatusCancelled ScheduledPriceStatus = "Cancelled"
+	// The price of the product is being changed as the scheduled price starts or ends
+	ScheduledPriceStatusApplying ScheduledPriceStatus = "Applying"
+)
+
+var AllScheduledPriceStatus = []ScheduledPriceStatus{
+	ScheduledPriceStatusScheduled,
+	ScheduledPriceStatusActive,
+	ScheduledPriceStatusCompleted,
+	ScheduledPriceStatusCancelled,
+	ScheduledPriceStatusApplying,
+}
+
+func (e ScheduledPriceStatus) IsValid() bool {
+	switch e {
+	case ScheduledPriceStatusScheduled, ScheduledPriceStatusActive, ScheduledPriceStatusCompleted, ScheduledPriceStatusCancelled, ScheduledPriceStatusApplying:
+		return true
+	}
+	return false
+}
+
+func (e ScheduledPriceStatus) String() string {
+	return string(e)
+}
+
+func (e *ScheduledPriceStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ScheduledPriceStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ScheduledPriceStatus", str)
+	}
+	return nil
+}
+
+func (e ScheduledPriceStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *ScheduledPriceStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e ScheduledPriceStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type UserStatus string
+
+const (
+	UserStatusActive      UserStatus = "Active"
+	UserStatusDeactivated UserStatus = "Deactivated"
+	UserStatusDeleted     UserStatus = "Deleted"
+)
+
+var AllUserStatus = []UserStatus{
+	UserStatusActive,
+	UserStatusDeactivated,
+	UserStatusDeleted,
+}
+
+func (e UserStatus) IsValid() bool {
+	switch e {
+	case UserStatusActive, UserStatusDeactivated, UserStatusDeleted:
+		return true
+	}
+	return false
+}
+
+func (e UserStatus) String() string {
+	return string(e)
+}
+
+func (e *UserStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = UserStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid UserStatus", str)
+	}
+	return nil
+}
+
+func (e UserStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *UserStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e UserStatus) MarshalJSON() ([]byte, error) {
 	var buf bytes.Buffer
 	e.MarshalGQL(&buf)
 	return buf.Bytes(), nil
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"graphql-backend/entity"
+	"io"
+	"strconv"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// MarshalMoney writes the amount as a JSON number in major units, e.g. 19.99,
+// formatted from the minor units so that it is exact
+func MarshalMoney(m entity.Money) graphql.Marshaler {
+	return graphql.WriterFunc(func(w io.Writer) {
+		_, _ = io.WriteString(w, m.String())
+	})
+}
+
+// UnmarshalMoney reads an amount in major units of the default currency, as a number or a decimal string,
+// amounts more precise than a cent are rejected
+func UnmarshalMoney(v any) (entity.Money, error) {
+	switch v := v.(type) {
+	case json.Number:
+		return entity.ParseMoney(v.String(), entity.DefaultCurrency)
+	case string:
+		return entity.ParseMoney(v, entity.DefaultCurrency)
+	case float64:
+		// the shortest decimal of the float, so that sub-cent amounts are rejected as they are for json.Number
+		return entity.ParseMoney(strconv.FormatFloat(v, 'f', -1, 64), entity.DefaultCurrency)
+	case int:
+		return entity.ParseMoney(fmt.Sprint(v), entity.DefaultCurrency)
+	case int64:
+		return entity.ParseMoney(fmt.Sprint(v), entity.DefaultCurrency)
+	default:
+		return entity.Money{}, fmt.Errorf("%T is not a valid money amount", v)
+	}
+}
@@ -0,0 +1,24 @@
+package model_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"graphql-backend/entity"
+	"graphql-backend/graph/model"
+)
+
+func TestUnmarshalMoney(t *testing.T) {
+	for _, v := range []any{19.99, json.Number("19.99"), "19.99"} {
+		m, err := model.UnmarshalMoney(v)
+		require.NoError(t, err)
+		require.Equal(t, entity.NewMoney(1999, entity.DefaultCurrency), m)
+	}
+
+	// sub-cent amounts are rejected however they are sent
+	for _, v := range []any{0.005, json.Number("0.005"), "0.005"} {
+		_, err := model.UnmarshalMoney(v)
+		require.Error(t, err)
+	}
+}
@@ -1,12 +1,40 @@
 package model
 
+import "graphql-backend/entity"
+
 type Order struct {
-	ID         string     `json:"id"`
-	Products   []*Product `json:"products"`
-	ProductIDs []string   `json:"productIds"`
-	Total      float64    `json:"total"`
-	CreatedAt  string     `json:"createdAt"`
-	Status     string     `json:"status"`
-	User       *User      `json:"user"`
-	UserID     string     `json:"userId"`
+	ID           string       `json:"id"`
+	Products     []*Product   `json:"products"`
+	ProductIDs   []string     `json:"productIds"`
+	Total        entity.Money `json:"total"`
+	Currency     string       `json:"currency"`
+	ExchangeRate float64      `json:"exchangeRate"`
+	CreatedAt    string       `json:"createdAt"`
+	Status       string       `json:"status"`
+	User         *User        `json:"user"`
+	UserID       string       `json:"userId"`
+
+	ShippingAddress *OrderAddress    `json:"shippingAddress,omitempty"`
+	BillingAddress  *OrderAddress    `json:"billingAddress,omitempty"`
+	Items           []*OrderItem     `json:"items"`
+	Subtotal        entity.Money     `json:"subtotal"`
+	Discounts       []*OrderDiscount `json:"discounts"`
+	TaxLines        []*TaxLine       `json:"taxLines"`
+	TaxTotal        entity.Money     `json:"taxTotal"`
+	Refunds         []*Refund        `json:"refunds"`
+	RefundedTotal   entity.Money     `json:"refundedTotal"`
+}
+
+type OrderItem struct {
+	Product   *Product         `json:"product,omitempty"`
+	ProductID string           `json:"productId"`
+	VariantID *string          `json:"variantId,omitempty"`
+	Sku       *string          `json:"sku,omitempty"`
+	Options   []*VariantOption `json:"options"`
+	Quantity  int32            `json:"quantity"`
+	UnitPrice entity.Money     `json:"unitPrice"`
+}
+
+func (o *Order) GetOwnerID() string {
+	return o.UserID
 }
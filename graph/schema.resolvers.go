@@ -7,9 +7,22 @@ package graph
 import (
 	"context"
 	loaders "graphql-backend/data-loader"
+	"graphql-backend/entity"
 	"graphql-backend/graph/model"
+
+	"github.com/99designs/gqlgen/graphql"
 )
 
+// Actor is the resolver for the actor field.
+func (r *auditEntryResolver) Actor(ctx context.Context, obj *model.AuditEntry) (*model.User, error) {
+	return loaders.GetUser(ctx, obj.ActorID)
+}
+
+// User is the resolver for the user field.
+func (r *auditEntryResolver) User(ctx context.Context, obj *model.AuditEntry) (*model.User, error) {
+	return loaders.GetUser(ctx, obj.UserID)
+}
+
 // CreateProduct is the resolver for the createProduct field.
 func (r *mutationResolver) CreateProduct(ctx context.Context, input model.CreateProductInput) (*model.Product, error) {
 	return r.Api.CreateProduct(ctx, input)
@@ -20,9 +33,79 @@ func (r *mutationResolver) UpdateProduct(ctx context.Context, input model.Update
 	return r.Api.UpdateProduct(ctx, input)
 }
 
+// UploadProductImage is the resolver for the uploadProductImage field.
+func (r *mutationResolver) UploadProductImage(ctx context.Context, productID string, file graphql.Upload) (*model.Product, error) {
+	return r.Api.UploadProductImage(ctx, productID, file)
+}
+
+// DeleteProductImage is the resolver for the deleteProductImage field.
+func (r *mutationResolver) DeleteProductImage(ctx context.Context, productID string, id string) (*model.Product, error) {
+	return r.Api.DeleteProductImage(ctx, productID, id)
+}
+
+// ImportProducts is the resolver for the importProducts field.
+func (r *mutationResolver) ImportProducts(ctx context.Context, file graphql.Upload, format *model.CatalogFormat, dryRun bool) (*model.ProductImportResult, error) {
+	return r.Api.ImportProducts(ctx, file, format, dryRun)
+}
+
+// ArchiveProduct is the resolver for the archiveProduct field.
+func (r *mutationResolver) ArchiveProduct(ctx context.Context, id string) (*model.Product, error) {
+	return r.Api.ArchiveProduct(ctx, id)
+}
+
+// RestoreProduct is the resolver for the restoreProduct field.
+func (r *mutationResolver) RestoreProduct(ctx context.Context, id string) (*model.Product, error) {
+	return r.Api.RestoreProduct(ctx, id)
+}
+
+// DeleteProduct is the resolver for the deleteProduct field.
+func (r *mutationResolver) DeleteProduct(ctx context.Context, id string) (bool, error) {
+	return r.Api.DeleteProduct(ctx, id)
+}
+
+// SchedulePrice is the resolver for the schedulePrice field.
+func (r *mutationResolver) SchedulePrice(ctx context.Context, input model.SchedulePriceInput) (*model.ScheduledPrice, error) {
+	return r.Api.SchedulePrice(ctx, input)
+}
+
+// CancelScheduledPrice is the resolver for the cancelScheduledPrice field.
+func (r *mutationResolver) CancelScheduledPrice(ctx context.Context, id string) (*model.ScheduledPrice, error) {
+	return r.Api.CancelScheduledPrice(ctx, id)
+}
+
+// CreateCategory is the resolver for the createCategory field.
+func (r *mutationResolver) CreateCategory(ctx context.Context, input model.CreateCategoryInput) (*model.Category, error) {
+	return r.Api.CreateCategory(ctx, input)
+}
+
+// UpdateCategory is the resolver for the updateCategory field.
+func (r *mutationResolver) UpdateCategory(ctx context.Context, input model.UpdateCategoryInput) (*model.Category, error) {
+	return r.Api.UpdateCategory(ctx, input)
+}
+
+// DeleteCategory is the resolver for the deleteCategory field.
+func (r *mutationResolver) DeleteCategory(ctx context.Context, id string) (bool, error) {
+	return r.Api.DeleteCategory(ctx, id)
+}
+
+// CreateProductVariant is the resolver for the createProductVariant field.
+func (r *mutationResolver) CreateProductVariant(ctx context.Context, input model.CreateProductVariantInput) (*model.Product, error) {
+	return r.Api.CreateProductVariant(ctx, input)
+}
+
+// UpdateProductVariant is the resolver for the updateProductVariant field.
+func (r *mutationResolver) UpdateProductVariant(ctx context.Context, input model.UpdateProductVariantInput) (*model.Product, error) {
+	return r.Api.UpdateProductVariant(ctx, input)
+}
+
+// DeleteProductVariant is the resolver for the deleteProductVariant field.
+func (r *mutationResolver) DeleteProductVariant(ctx context.Context, productID string, id string) (*model.Product, error) {
+	return r.Api.DeleteProductVariant(ctx, productID, id)
+}
+
 // PlaceOrder is the resolver for the placeOrder field.
-func (r *mutationResolver) PlaceOrder(ctx context.Context, productIds []string) (*model.Order, error) {
-	return r.Api.PlaceOrder(ctx, productIds)
+func (r *mutationResolver) PlaceOrder(ctx context.Context, productIds []string, items []*model.PlaceOrderItemInput, addressID *string, billingAddressID *string, couponCode *string, currency *string) (*model.Order, error) {
+	return r.Api.PlaceOrder(ctx, productIds, items, addressID, billingAddressID, couponCode, currency)
 }
 
 // Login is the resolver for the login field.
@@ -30,6 +113,176 @@ func (r *mutationResolver) Login(ctx context.Context, input model.LoginInput) (*
 	return r.Api.Login(ctx, input)
 }
 
+// CreateAPIKey is the resolver for the createApiKey field.
+func (r *mutationResolver) CreateAPIKey(ctx context.Context, input model.CreateAPIKeyInput) (*model.CreateAPIKeyPayload, error) {
+	return r.Api.CreateAPIKey(ctx, input)
+}
+
+// RevokeAPIKey is the resolver for the revokeApiKey field.
+func (r *mutationResolver) RevokeAPIKey(ctx context.Context, id string) (*model.APIKey, error) {
+	return r.Api.RevokeAPIKey(ctx, id)
+}
+
+// Impersonate is the resolver for the impersonate field.
+func (r *mutationResolver) Impersonate(ctx context.Context, userID string) (*model.ImpersonationPayload, error) {
+	return r.Api.Impersonate(ctx, userID)
+}
+
+// CreateUser is the resolver for the createUser field.
+func (r *mutationResolver) CreateUser(ctx context.Context, input model.CreateUserInput) (*model.User, error) {
+	return r.Api.CreateUser(ctx, input)
+}
+
+// UpdateUserRole is the resolver for the updateUserRole field.
+func (r *mutationResolver) UpdateUserRole(ctx context.Context, id string, role model.Role) (*model.User, error) {
+	return r.Api.UpdateUserRole(ctx, id, role)
+}
+
+// DeactivateUser is the resolver for the deactivateUser field.
+func (r *mutationResolver) DeactivateUser(ctx context.Context, id string) (*model.User, error) {
+	return r.Api.DeactivateUser(ctx, id)
+}
+
+// ReactivateUser is the resolver for the reactivateUser field.
+func (r *mutationResolver) ReactivateUser(ctx context.Context, id string) (*model.User, error) {
+	return r.Api.ReactivateUser(ctx, id)
+}
+
+// UpdateProfile is the resolver for the updateProfile field.
+func (r *mutationResolver) UpdateProfile(ctx context.Context, input model.UpdateProfileInput) (*model.User, error) {
+	return r.Api.UpdateProfile(ctx, input)
+}
+
+// VerifyEmail is the resolver for the verifyEmail field.
+func (r *mutationResolver) VerifyEmail(ctx context.Context, token string) (*model.User, error) {
+	return r.Api.VerifyEmail(ctx, token)
+}
+
+// RequestMyDataExport is the resolver for the requestMyDataExport field.
+func (r *mutationResolver) RequestMyDataExport(ctx context.Context) (*model.DataExport, error) {
+	return r.Api.RequestMyDataExport(ctx)
+}
+
+// DeleteMyAccount is the resolver for the deleteMyAccount field.
+func (r *mutationResolver) DeleteMyAccount(ctx context.Context) (*model.User, error) {
+	return r.Api.DeleteMyAccount(ctx)
+}
+
+// RequestUserDataExport is the resolver for the requestUserDataExport field.
+func (r *mutationResolver) RequestUserDataExport(ctx context.Context, userID string) (*model.DataExport, error) {
+	return r.Api.RequestUserDataExport(ctx, userID)
+}
+
+// DeleteUserAccount is the resolver for the deleteUserAccount field.
+func (r *mutationResolver) DeleteUserAccount(ctx context.Context, userID string) (*model.User, error) {
+	return r.Api.DeleteUserAccount(ctx, userID)
+}
+
+// CreateAddress is the resolver for the createAddress field.
+func (r *mutationResolver) CreateAddress(ctx context.Context, input model.CreateAddressInput) (*model.Address, error) {
+	return r.Api.CreateAddress(ctx, input)
+}
+
+// UpdateAddress is the resolver for the updateAddress field.
+func (r *mutationResolver) UpdateAddress(ctx context.Context, input model.UpdateAddressInput) (*model.Address, error) {
+	return r.Api.UpdateAddress(ctx, input)
+}
+
+// DeleteAddress is the resolver for the deleteAddress field.
+func (r *mutationResolver) DeleteAddress(ctx context.Context, id string) (bool, error) {
+	return r.Api.DeleteAddress(ctx, id)
+}
+
+// AddToCart is the resolver for the addToCart field.
+func (r *mutationResolver) AddToCart(ctx context.Context, productID string, variantID *string, quantity int32, cartToken *string) (*model.Cart, error) {
+	return r.Api.AddToCart(ctx, productID, variantID, quantity, cartToken)
+}
+
+// UpdateCartItem is the resolver for the updateCartItem field.
+func (r *mutationResolver) UpdateCartItem(ctx context.Context, productID string, variantID *string, quantity int32, cartToken *string) (*model.Cart, error) {
+	return r.Api.UpdateCartItem(ctx, productID, variantID, quantity, cartToken)
+}
+
+// RemoveFromCart is the resolver for the removeFromCart field.
+func (r *mutationResolver) RemoveFromCart(ctx context.Context, productID string, variantID *string, cartToken *string) (*model.Cart, error) {
+	return r.Api.RemoveFromCart(ctx, productID, variantID, cartToken)
+}
+
+// ClearCart is the resolver for the clearCart field.
+func (r *mutationResolver) ClearCart(ctx context.Context, cartToken *string) (*model.Cart, error) {
+	return r.Api.ClearCart(ctx, cartToken)
+}
+
+// AddToWishlist is the resolver for the addToWishlist field.
+func (r *mutationResolver) AddToWishlist(ctx context.Context, productID string) ([]*model.WishlistItem, error) {
+	return r.Api.AddToWishlist(ctx, productID)
+}
+
+// RemoveFromWishlist is the resolver for the removeFromWishlist field.
+func (r *mutationResolver) RemoveFromWishlist(ctx context.Context, productID string) ([]*model.WishlistItem, error) {
+	return r.Api.RemoveFromWishlist(ctx, productID)
+}
+
+// Checkout is the resolver for the checkout field.
+func (r *mutationResolver) Checkout(ctx context.Context, addressID *string, billingAddressID *string, couponCode *string, currency *string) (*model.Order, error) {
+	return r.Api.Checkout(ctx, addressID, billingAddressID, couponCode, currency)
+}
+
+// CreatePromotion is the resolver for the createPromotion field.
+func (r *mutationResolver) CreatePromotion(ctx context.Context, input model.CreatePromotionInput) (*model.Promotion, error) {
+	return r.Api.CreatePromotion(ctx, input)
+}
+
+// UpdatePromotion is the resolver for the updatePromotion field.
+func (r *mutationResolver) UpdatePromotion(ctx context.Context, input model.UpdatePromotionInput) (*model.Promotion, error) {
+	return r.Api.UpdatePromotion(ctx, input)
+}
+
+// PayOrder is the resolver for the payOrder field.
+func (r *mutationResolver) PayOrder(ctx context.Context, orderID string, paymentMethod string) (*model.Payment, error) {
+	return r.Api.PayOrder(ctx, orderID, paymentMethod)
+}
+
+// UpdateOrderStatus is the resolver for the updateOrderStatus field.
+func (r *mutationResolver) UpdateOrderStatus(ctx context.Context, id string, status string) (*model.Order, error) {
+	return r.Api.UpdateOrderStatus(ctx, id, status)
+}
+
+// RequestReturn is the resolver for the requestReturn field.
+func (r *mutationResolver) RequestReturn(ctx context.Context, input model.RequestReturnInput) (*model.ReturnRequest, error) {
+	return r.Api.RequestReturn(ctx, input)
+}
+
+// ApproveReturn is the resolver for the approveReturn field.
+func (r *mutationResolver) ApproveReturn(ctx context.Context, id string, restock *bool, note *string) (*model.ReturnRequest, error) {
+	return r.Api.ApproveReturn(ctx, id, restock, note)
+}
+
+// RejectReturn is the resolver for the rejectReturn field.
+func (r *mutationResolver) RejectReturn(ctx context.Context, id string, note *string) (*model.ReturnRequest, error) {
+	return r.Api.RejectReturn(ctx, id, note)
+}
+
+// RefundOrder is the resolver for the refundOrder field.
+func (r *mutationResolver) RefundOrder(ctx context.Context, orderID string, amount *entity.Money, reason string) (*model.Order, error) {
+	return r.Api.RefundOrder(ctx, orderID, amount, reason)
+}
+
+// CreateReview is the resolver for the createReview field.
+func (r *mutationResolver) CreateReview(ctx context.Context, input model.CreateReviewInput) (*model.Review, error) {
+	return r.Api.CreateReview(ctx, input)
+}
+
+// ApproveReview is the resolver for the approveReview field.
+func (r *mutationResolver) ApproveReview(ctx context.Context, id string) (*model.Review, error) {
+	return r.Api.ApproveReview(ctx, id)
+}
+
+// HideReview is the resolver for the hideReview field.
+func (r *mutationResolver) HideReview(ctx context.Context, id string) (*model.Review, error) {
+	return r.Api.HideReview(ctx, id)
+}
+
 // Products is the resolver for the products field.
 func (r *orderResolver) Products(ctx context.Context, obj *model.Order) ([]*model.Product, error) {
 	return loaders.GetProducts(ctx, obj.ProductIDs)
@@ -40,14 +293,59 @@ func (r *orderResolver) User(ctx context.Context, obj *model.Order) (*model.User
 	return loaders.GetUser(ctx, obj.UserID)
 }
 
+// Payments is the resolver for the payments field.
+func (r *orderResolver) Payments(ctx context.Context, obj *model.Order) ([]*model.Payment, error) {
+	return loaders.GetOrderPayments(ctx, obj.ID)
+}
+
+// Returns is the resolver for the returns field.
+func (r *orderResolver) Returns(ctx context.Context, obj *model.Order) ([]*model.ReturnRequest, error) {
+	return loaders.GetOrderReturns(ctx, obj.ID)
+}
+
+// Product is the resolver for the product field.
+func (r *orderItemResolver) Product(ctx context.Context, obj *model.OrderItem) (*model.Product, error) {
+	return loaders.GetProduct(ctx, obj.ProductID)
+}
+
+// Reviews is the resolver for the reviews field.
+func (r *productResolver) Reviews(ctx context.Context, obj *model.Product, limit *int32, offset *int32) ([]*model.Review, error) {
+	return loaders.GetProductReviews(ctx, obj.ID, limit, offset)
+}
+
+// PriceHistory is the resolver for the priceHistory field.
+func (r *productResolver) PriceHistory(ctx context.Context, obj *model.Product, limit *int32, offset *int32) ([]*model.PriceChange, error) {
+	return loaders.GetPriceHistory(ctx, obj.ID, limit, offset)
+}
+
 // Products is the resolver for the products field.
-func (r *queryResolver) Products(ctx context.Context, limit *int32, offset *int32, category *string) ([]*model.Product, error) {
-	return r.Api.Products(ctx, limit, offset, category)
+func (r *queryResolver) Products(ctx context.Context, limit *int32, offset *int32, category *string, categoryID *string, currency *string) ([]*model.Product, error) {
+	return r.Api.Products(ctx, limit, offset, category, categoryID, currency)
 }
 
 // Product is the resolver for the product field.
-func (r *queryResolver) Product(ctx context.Context, id string) (*model.Product, error) {
-	return r.Api.Product(ctx, id)
+func (r *queryResolver) Product(ctx context.Context, id string, currency *string) (*model.Product, error) {
+	return r.Api.Product(ctx, id, currency)
+}
+
+// ArchivedProducts is the resolver for the archivedProducts field.
+func (r *queryResolver) ArchivedProducts(ctx context.Context, limit *int32, offset *int32, category *string, categoryID *string) ([]*model.Product, error) {
+	return r.Api.ArchivedProducts(ctx, limit, offset, category, categoryID)
+}
+
+// ExportProducts is the resolver for the exportProducts field.
+func (r *queryResolver) ExportProducts(ctx context.Context, format model.CatalogFormat) (string, error) {
+	return r.Api.ExportProducts(ctx, format)
+}
+
+// Categories is the resolver for the categories field.
+func (r *queryResolver) Categories(ctx context.Context) ([]*model.Category, error) {
+	return r.Api.Categories(ctx)
+}
+
+// Category is the resolver for the category field.
+func (r *queryResolver) Category(ctx context.Context, id *string, slug *string) (*model.Category, error) {
+	return r.Api.Category(ctx, id, slug)
 }
 
 // Orders is the resolver for the orders field.
@@ -65,15 +363,114 @@ func (r *queryResolver) Me(ctx context.Context) (*model.User, error) {
 	return r.Api.Me(ctx)
 }
 
+// APIKeys is the resolver for the apiKeys field.
+func (r *queryResolver) APIKeys(ctx context.Context) ([]*model.APIKey, error) {
+	return r.Api.APIKeys(ctx)
+}
+
+// AuditLog is the resolver for the auditLog field.
+func (r *queryResolver) AuditLog(ctx context.Context, limit *int32, offset *int32, actorID *string, userID *string) ([]*model.AuditEntry, error) {
+	return r.Api.AuditLog(ctx, limit, offset, actorID, userID)
+}
+
+// Users is the resolver for the users field.
+func (r *queryResolver) Users(ctx context.Context, filter *model.UsersFilter, limit *int32, offset *int32) ([]*model.User, error) {
+	return r.Api.Users(ctx, filter, limit, offset)
+}
+
+// User is the resolver for the user field.
+func (r *queryResolver) User(ctx context.Context, id string) (*model.User, error) {
+	return r.Api.User(ctx, id)
+}
+
+// Addresses is the resolver for the addresses field.
+func (r *queryResolver) Addresses(ctx context.Context) ([]*model.Address, error) {
+	return r.Api.Addresses(ctx)
+}
+
+// Cart is the resolver for the cart field.
+func (r *queryResolver) Cart(ctx context.Context, cartToken *string, currency *string) (*model.Cart, error) {
+	return r.Api.Cart(ctx, cartToken, currency)
+}
+
+// Wishlist is the resolver for the wishlist field.
+func (r *queryResolver) Wishlist(ctx context.Context) ([]*model.WishlistItem, error) {
+	return r.Api.Wishlist(ctx)
+}
+
+// Promotions is the resolver for the promotions field.
+func (r *queryResolver) Promotions(ctx context.Context, limit *int32, offset *int32, active *bool) ([]*model.Promotion, error) {
+	return r.Api.Promotions(ctx, limit, offset, active)
+}
+
+// Promotion is the resolver for the promotion field.
+func (r *queryResolver) Promotion(ctx context.Context, id string) (*model.Promotion, error) {
+	return r.Api.Promotion(ctx, id)
+}
+
+// ReturnRequests is the resolver for the returnRequests field.
+func (r *queryResolver) ReturnRequests(ctx context.Context, status *model.ReturnStatus, limit *int32, offset *int32) ([]*model.ReturnRequest, error) {
+	return r.Api.ReturnRequests(ctx, status, limit, offset)
+}
+
+// Reviews is the resolver for the reviews field.
+func (r *queryResolver) Reviews(ctx context.Context, status *model.ReviewStatus, productID *string, limit *int32, offset *int32) ([]*model.Review, error) {
+	return r.Api.Reviews(ctx, status, productID, limit, offset)
+}
+
+// ScheduledPrices is the resolver for the scheduledPrices field.
+func (r *queryResolver) ScheduledPrices(ctx context.Context, status *model.ScheduledPriceStatus, productID *string, limit *int32, offset *int32) ([]*model.ScheduledPrice, error) {
+	return r.Api.ScheduledPrices(ctx, status, productID, limit, offset)
+}
+
+// Product is the resolver for the product field.
+func (r *returnItemResolver) Product(ctx context.Context, obj *model.ReturnItem) (*model.Product, error) {
+	return loaders.GetProduct(ctx, obj.ProductID)
+}
+
+// Orders is the resolver for the orders field.
+func (r *userResolver) Orders(ctx context.Context, obj *model.User, limit *int32, offset *int32) ([]*model.Order, error) {
+	return loaders.GetUserOrders(ctx, obj.ID, limit, offset)
+}
+
+// Product is the resolver for the product field.
+func (r *wishlistItemResolver) Product(ctx context.Context, obj *model.WishlistItem) (*model.Product, error) {
+	return loaders.GetProduct(ctx, obj.ProductID)
+}
+
+// AuditEntry returns AuditEntryResolver implementation.
+func (r *Resolver) AuditEntry() AuditEntryResolver { return &auditEntryResolver{r} }
+
 // Mutation returns MutationResolver implementation.
 func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
 
 // Order returns OrderResolver implementation.
 func (r *Resolver) Order() OrderResolver { return &orderResolver{r} }
 
+// OrderItem returns OrderItemResolver implementation.
+func (r *Resolver) OrderItem() OrderItemResolver { return &orderItemResolver{r} }
+
+// Product returns ProductResolver implementation.
+func (r *Resolver) Product() ProductResolver { return &productResolver{r} }
+
 // Query returns QueryResolver implementation.
 func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
 
+// ReturnItem returns ReturnItemResolver implementation.
+func (r *Resolver) ReturnItem() ReturnItemResolver { return &returnItemResolver{r} }
+
+// User returns UserResolver implementation.
+func (r *Resolver) User() UserResolver { return &userResolver{r} }
+
+// WishlistItem returns WishlistItemResolver implementation.
+func (r *Resolver) WishlistItem() WishlistItemResolver { return &wishlistItemResolver{r} }
+
+type auditEntryResolver struct{ *Resolver }
 type mutationResolver struct{ *Resolver }
 type orderResolver struct{ *Resolver }
+type orderItemResolver struct{ *Resolver }
+type productResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
+type returnItemResolver struct{ *Resolver }
+type userResolver struct{ *Resolver }
+type wishlistItemResolver struct{ *Resolver }
@@ -0,0 +1,35 @@
+package blob_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"graphql-backend/pkg/blob"
+)
+
+func TestLocalStorage(t *testing.T) {
+	ctx := context.TODO()
+	storage := blob.NewLocalStorage(t.TempDir())
+
+	err := storage.Put(ctx, "products/p1/image.png", []byte("first"))
+	require.NoError(t, err)
+	err = storage.Put(ctx, "products/p1/image.png", []byte("second"))
+	require.NoError(t, err)
+
+	content, err := storage.Get(ctx, "products/p1/image.png")
+	require.NoError(t, err)
+	require.Equal(t, "second", string(content))
+
+	require.NoError(t, storage.Delete(ctx, "products/p1/image.png"))
+	require.NoError(t, storage.Delete(ctx, "products/p1/image.png"))
+	_, err = storage.Get(ctx, "products/p1/image.png")
+	require.ErrorIs(t, err, blob.ErrNotFound)
+
+	// Keys can't escape the directory
+	for _, key := range []string{"../outside", "/etc/passwd", "", "products/../../outside"} {
+		require.Error(t, storage.Put(ctx, key, []byte("x")), key)
+		_, err = storage.Get(ctx, key)
+		require.Error(t, err, key)
+	}
+}
@@ -0,0 +1,94 @@
+package exchange
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// defaultRates are the rates used when no rates file is configured, for local use only
+//
+//go:embed rates.json
+var defaultRates []byte
+
+// StaticRates is an ExchangeRateProvider with fixed rates relative to a base currency
+type StaticRates struct {
+	base  string
+	rates map[string]float64
+}
+
+func (p *StaticRates) Rate(ctx context.Context, from string, to string) (float64, error) {
+	fromRate, err := p.rate(from)
+	if err != nil {
+		return 0, err
+	}
+	toRate, err := p.rate(to)
+	if err != nil {
+		return 0, err
+	}
+
+	return toRate / fromRate, nil
+}
+
+// rate returns the price of one unit of the base currency in the given currency
+func (p *StaticRates) rate(currency string) (float64, error) {
+	if currency == p.base {
+		return 1, nil
+	}
+
+	rate, ok := p.rates[currency]
+	if !ok {
+		return 0, fmt.Errorf("unsupported currency %s", currency)
+	}
+	return rate, nil
+}
+
+// NewStaticRates validates the rates, they are the price of one unit of the base currency in every other currency
+func NewStaticRates(base string, rates map[string]float64) (*StaticRates, error) {
+	p := &StaticRates{
+		base:  strings.ToUpper(base),
+		rates: make(map[string]float64, len(rates)),
+	}
+	if len(p.base) != 3 {
+		return nil, errors.New("base currency must be an ISO 4217 code")
+	}
+
+	for currency, rate := range rates {
+		currency = strings.ToUpper(currency)
+		if len(currency) != 3 {
+			return nil, fmt.Errorf("currency %s must be an ISO 4217 code", currency)
+		}
+		if rate <= 0 {
+			return nil, fmt.Errorf("rate of %s must be positive", currency)
+		}
+		p.rates[currency] = rate
+	}
+
+	return p, nil
+}
+
+// LoadStaticRates reads the rates from a JSON file, the built-in rates are used when path is empty
+func LoadStaticRates(path string) (*StaticRates, error) {
+	bts := defaultRates
+	if path != "" {
+		var err error
+		bts, err = os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var file struct {
+		Base  string             `json:"base"`
+		Rates map[string]float64 `json:"rates"`
+	}
+	if err := json.Unmarshal(bts, &file); err != nil {
+		return nil, errors.New("invalid exchange rates file: " + err.Error())
+	}
+
+	return NewStaticRates(file.Base, file.Rates)
+}
@@ -0,0 +1,46 @@
+package exchange_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"graphql-backend/pkg/exchange"
+)
+
+func TestStaticRates(t *testing.T) {
+	rates, err := exchange.NewStaticRates("usd", map[string]float64{"eur": 0.8, "GBP": 0.5})
+	require.NoError(t, err)
+
+	rate, err := rates.Rate(context.TODO(), "USD", "EUR")
+	require.NoError(t, err)
+	require.Equal(t, 0.8, rate)
+
+	rate, err = rates.Rate(context.TODO(), "GBP", "USD")
+	require.NoError(t, err)
+	require.Equal(t, 2.0, rate)
+
+	// Cross rates go through the base currency
+	rate, err = rates.Rate(context.TODO(), "GBP", "EUR")
+	require.NoError(t, err)
+	require.InDelta(t, 1.6, rate, 1e-9)
+
+	_, err = rates.Rate(context.TODO(), "USD", "XXX")
+	require.Error(t, err)
+}
+
+func TestInvalidRates(t *testing.T) {
+	_, err := exchange.NewStaticRates("USD", map[string]float64{"EUR": 0})
+	require.Error(t, err)
+
+	_, err = exchange.NewStaticRates("dollar", nil)
+	require.Error(t, err)
+}
+
+func TestLoadDefaultRates(t *testing.T) {
+	rates, err := exchange.LoadStaticRates("")
+	require.NoError(t, err)
+
+	_, err = rates.Rate(context.TODO(), "USD", "EUR")
+	require.NoError(t, err)
+}
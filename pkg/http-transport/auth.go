@@ -8,26 +8,71 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"graphql-backend/graph/model"
 	"net/http"
+	"slices"
 	"strings"
 )
 
 type contextKey string
 
 const (
-	UserContextKey contextKey = "user"
+	UserContextKey    contextKey = "user"
+	ServiceContextKey contextKey = "service"
+
+	APIKeyHeader = "X-API-Key"
 )
 
 // UserClaims represents the JWT claims
 type UserClaims struct {
 	UserID string `json:"userId"`
 	Role   string `json:"role"`
+	// ActorID is the admin acting as UserID when the token was issued for impersonation
+	ActorID string `json:"actorId,omitempty"`
 	*jwt.RegisteredClaims
 }
 
+func (c *UserClaims) IsImpersonated() bool {
+	return c.ActorID != ""
+}
+
+// ServicePrincipal represents a machine client authenticated with an API key,
+// it is never a user and has no role, only the scopes granted to its key
+type ServicePrincipal struct {
+	KeyID  string
+	Name   string
+	Scopes []string
+}
+
+func (p *ServicePrincipal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// APIKeyAuthenticator resolves a plain API key to its service principal
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*ServicePrincipal, error)
+}
+
+// SessionValidator checks that the session a token was issued for has not been revoked
+type SessionValidator interface {
+	ValidateSession(ctx context.Context, claims *UserClaims) error
+}
+
 // AuthMiddleware is a middleware for authentication
-func AuthMiddleware(jwtHandler JwtHandler) func(http.Handler) http.Handler {
+func AuthMiddleware(jwtHandler JwtHandler, apiKeys APIKeyAuthenticator, sessions SessionValidator) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Machine clients authenticate with an API key instead of a user token
+			if apiKey := r.Header.Get(APIKeyHeader); apiKey != "" {
+				principal, err := apiKeys.AuthenticateAPIKey(r.Context(), apiKey)
+				if err != nil {
+					next.ServeHTTP(w, r)
+					return
+				}
+
+				ctx := context.WithValue(r.Context(), ServiceContextKey, principal)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
 			// Get the Authorization header
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
@@ -69,6 +114,12 @@ func AuthMiddleware(jwtHandler JwtHandler) func(http.Handler) http.Handler {
 			}
 
 			if claims, ok := token.Claims.(*UserClaims); ok && token.Valid {
+				// Tokens of revoked sessions or deactivated users are ignored
+				if err := sessions.ValidateSession(r.Context(), claims); err != nil {
+					next.ServeHTTP(w, r)
+					return
+				}
+
 				// Add the user to the context
 				ctx := context.WithValue(r.Context(), UserContextKey, claims)
 				next.ServeHTTP(w, r.WithContext(ctx))
@@ -89,7 +140,26 @@ func GetUserFromContext(ctx context.Context) *UserClaims {
 	return user
 }
 
-var HasRole = func(ctx context.Context, obj interface{}, next graphql.Resolver, role model.Role) (interface{}, error) {
+// GetServiceFromContext gets the API key service principal from the context
+func GetServiceFromContext(ctx context.Context) *ServicePrincipal {
+	service, ok := ctx.Value(ServiceContextKey).(*ServicePrincipal)
+	if !ok {
+		return nil
+	}
+	return service
+}
+
+// hasServiceScope reports whether the request comes from an API key granted the given scope
+func hasServiceScope(ctx context.Context, scope *model.APIKeyScope) bool {
+	service := GetServiceFromContext(ctx)
+	return service != nil && scope != nil && service.HasScope(string(*scope))
+}
+
+var HasRole = func(ctx context.Context, obj interface{}, next graphql.Resolver, role model.Role, scope *model.APIKeyScope) (interface{}, error) {
+	if hasServiceScope(ctx, scope) {
+		return next(ctx)
+	}
+
 	user := GetUserFromContext(ctx)
 	if user == nil {
 		return nil, errors.New("unauthorized")
@@ -103,7 +173,11 @@ var HasRole = func(ctx context.Context, obj interface{}, next graphql.Resolver,
 	return next(ctx)
 }
 
-var HasAuthenticated = func(ctx context.Context, obj interface{}, next graphql.Resolver) (interface{}, error) {
+var HasAuthenticated = func(ctx context.Context, obj interface{}, next graphql.Resolver, scope *model.APIKeyScope) (interface{}, error) {
+	if hasServiceScope(ctx, scope) {
+		return next(ctx)
+	}
+
 	user := GetUserFromContext(ctx)
 	if user == nil {
 		return nil, errors.New("unauthorized")
@@ -112,3 +186,17 @@ var HasAuthenticated = func(ctx context.Context, obj interface{}, next graphql.R
 	// or let it pass through
 	return next(ctx)
 }
+
+// NotImpersonated blocks sensitive operations, e.g. credential changes, for impersonation tokens
+var NotImpersonated = func(ctx context.Context, obj interface{}, next graphql.Resolver) (interface{}, error) {
+	user := GetUserFromContext(ctx)
+	if user == nil {
+		return nil, errors.New("unauthorized")
+	}
+
+	if user.IsImpersonated() {
+		return nil, errors.New("operation is not allowed while impersonating a user")
+	}
+
+	return next(ctx)
+}
@@ -0,0 +1,225 @@
+package http_transport
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+	"io"
+	"mime"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	maxIdempotentRequestBytes = 1 << 20
+)
+
+// IdempotencyRecord is the first request sent with an idempotency key, and its response once completed
+type IdempotencyRecord struct {
+	// Fingerprint is the hash of the request body, retries must send the same body
+	Fingerprint string
+	Completed   bool
+	StatusCode  int
+	ContentType string
+	Body        []byte
+	ExpiresAt   time.Time
+}
+
+// IdempotencyStore keeps the responses of the requests sent with an idempotency key until they expire
+type IdempotencyStore interface {
+	// Reserve claims the key for a new request, it returns the record of the key instead when it is already taken
+	Reserve(ctx context.Context, key string, record IdempotencyRecord) (IdempotencyRecord, bool, error)
+	Complete(ctx context.Context, key string, record IdempotencyRecord) error
+	// Release frees the key of a request that failed, so that it can be retried
+	Release(ctx context.Context, key string) error
+}
+
+// IdempotencyMiddleware executes the GraphQL mutations sent with an Idempotency-Key header once per key and caller.
+// Retries with the same body get the first response replayed, reusing the key with another body is rejected.
+// It has to be wrapped with the auth middleware, keys are scoped to the caller and anonymous requests are not deduplicated.
+// Only JSON requests are deduplicated, multipart uploads are passed through without reading them.
+func IdempotencyMiddleware(store IdempotencyStore, window time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			scope := callerScope(r.Context())
+			if key == "" || scope == "" || r.Method != http.MethodPost || !isJSON(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				writeGraphQLError(w, http.StatusBadRequest, "idempotency key is too long")
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentRequestBytes))
+			if err != nil {
+				writeGraphQLError(w, http.StatusRequestEntityTooLarge, "request body is too large")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			if !isMutation(body) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			sum := sha256.Sum256(body)
+			scopedKey := scope + ":" + key
+			record, reserved, err := store.Reserve(r.Context(), scopedKey, IdempotencyRecord{
+				Fingerprint: hex.EncodeToString(sum[:]),
+				ExpiresAt:   time.Now().Add(window),
+			})
+			if err != nil {
+				writeGraphQLError(w, http.StatusInternalServerError, "failed to check the idempotency key")
+				return
+			}
+			if !reserved {
+				switch {
+				case record.Fingerprint != hex.EncodeToString(sum[:]):
+					writeGraphQLError(w, http.StatusUnprocessableEntity, "idempotency key was already used with a different request")
+				case !record.Completed:
+					writeGraphQLError(w, http.StatusConflict, "a request with this idempotency key is in progress")
+				default:
+					w.Header().Set("Content-Type", record.ContentType)
+					w.Header().Set(IdempotentReplayedHeader, "true")
+					w.WriteHeader(record.StatusCode)
+					_, _ = w.Write(record.Body)
+				}
+				return
+			}
+
+			recorder := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+
+			// server errors are not replayed, the request may succeed when retried
+			if recorder.statusCode >= http.StatusInternalServerError {
+				_ = store.Release(r.Context(), scopedKey)
+				return
+			}
+			record.Completed = true
+			record.StatusCode = recorder.statusCode
+			record.ContentType = recorder.Header().Get("Content-Type")
+			record.Body = recorder.body.Bytes()
+			_ = store.Complete(r.Context(), scopedKey, record)
+		})
+	}
+}
+
+// callerScope identifies the user or the API key of the request, impersonated requests are kept apart from the user's own
+func callerScope(ctx context.Context) string {
+	if claims := GetUserFromContext(ctx); claims != nil {
+		return "user:" + claims.UserID + ":" + claims.ActorID
+	}
+	if principal := GetServiceFromContext(ctx); principal != nil {
+		return "service:" + principal.KeyID
+	}
+	return ""
+}
+
+func isJSON(r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType == "application/json"
+}
+
+// isMutation reports whether the JSON GraphQL request runs a mutation
+func isMutation(body []byte) bool {
+	var params struct {
+		Query         string `json:"query"`
+		OperationName string `json:"operationName"`
+	}
+	if err := json.Unmarshal(body, &params); err != nil {
+		return false
+	}
+	doc, err := parser.ParseQuery(&ast.Source{Input: params.Query})
+	if err != nil {
+		return false
+	}
+
+	operation := doc.Operations.ForName(params.OperationName)
+	if params.OperationName == "" && len(doc.Operations) == 1 {
+		operation = doc.Operations[0]
+	}
+	return operation != nil && operation.Operation == ast.Mutation
+}
+
+func writeGraphQLError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"errors": []map[string]string{{"message": message}},
+	})
+}
+
+// responseRecorder copies the response it writes
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	r.statusCode = statusCode
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// MemoryIdempotencyStore is an IdempotencyStore in memory, keys are forgotten when the server restarts
+type MemoryIdempotencyStore struct {
+	mu        sync.Mutex
+	records   map[string]IdempotencyRecord
+	lastSweep time.Time
+}
+
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{records: map[string]IdempotencyRecord{}}
+}
+
+func (s *MemoryIdempotencyStore) Reserve(ctx context.Context, key string, record IdempotencyRecord) (IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) > time.Minute {
+		for k, r := range s.records {
+			if now.After(r.ExpiresAt) {
+				delete(s.records, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	if existing, ok := s.records[key]; ok && now.Before(existing.ExpiresAt) {
+		return existing, false, nil
+	}
+
+	s.records[key] = record
+	return record, true, nil
+}
+
+func (s *MemoryIdempotencyStore) Complete(ctx context.Context, key string, record IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[key] = record
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
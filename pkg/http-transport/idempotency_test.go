@@ -0,0 +1,144 @@
+package http_transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const placeOrderBody = `{"query":"mutation { placeOrder(productIds: [\"1\"]) { id } }"}`
+
+type idempotencyTest struct {
+	calls   atomic.Int32
+	handler http.Handler
+}
+
+// newIdempotencyTest serves a handler that counts its calls, behind the middleware and a fake authentication
+func newIdempotencyTest(window time.Duration) *idempotencyTest {
+	test := &idempotencyTest{}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := test.calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"call":` + strconv.Itoa(int(n)) + `}}`))
+	})
+
+	handler := IdempotencyMiddleware(NewMemoryIdempotencyStore(), window)(next)
+	test.handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if userID := r.Header.Get("X-Test-User"); userID != "" {
+			r = r.WithContext(context.WithValue(r.Context(), UserContextKey, &UserClaims{UserID: userID}))
+		}
+		handler.ServeHTTP(w, r)
+	})
+	return test
+}
+
+func (test *idempotencyTest) send(userID string, key string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Test-User", userID)
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+
+	rec := httptest.NewRecorder()
+	test.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotentRetryIsReplayed(t *testing.T) {
+	test := newIdempotencyTest(time.Hour)
+
+	first := test.send("u1", "key-1", placeOrderBody)
+	require.Equal(t, http.StatusOK, first.Code)
+	require.Empty(t, first.Header().Get(IdempotentReplayedHeader))
+
+	retry := test.send("u1", "key-1", placeOrderBody)
+	require.Equal(t, http.StatusOK, retry.Code)
+	require.Equal(t, "true", retry.Header().Get(IdempotentReplayedHeader))
+	require.Equal(t, "application/json", retry.Header().Get("Content-Type"))
+	require.Equal(t, first.Body.String(), retry.Body.String())
+	require.EqualValues(t, 1, test.calls.Load())
+
+	// Another key or another user executes the mutation again
+	test.send("u1", "key-2", placeOrderBody)
+	test.send("u2", "key-1", placeOrderBody)
+	require.EqualValues(t, 3, test.calls.Load())
+}
+
+func TestIdempotencyKeyReusedWithAnotherPayload(t *testing.T) {
+	test := newIdempotencyTest(time.Hour)
+
+	test.send("u1", "key-1", placeOrderBody)
+	rec := test.send("u1", "key-1", `{"query":"mutation { placeOrder(productIds: [\"2\"]) { id } }"}`)
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	require.Contains(t, rec.Body.String(), "different request")
+	require.EqualValues(t, 1, test.calls.Load())
+}
+
+func TestIdempotencyOnlyAppliesToMutations(t *testing.T) {
+	test := newIdempotencyTest(time.Hour)
+	query := `{"query":"query Products { products { id } } mutation Order { placeOrder(productIds: []) { id } }","operationName":"Products"}`
+
+	test.send("u1", "key-1", query)
+	test.send("u1", "key-1", query)
+	require.EqualValues(t, 2, test.calls.Load())
+
+	// Requests without a key or of anonymous users are not deduplicated
+	test.send("u1", "", placeOrderBody)
+	test.send("u1", "", placeOrderBody)
+	test.send("", "key-1", placeOrderBody)
+	test.send("", "key-1", placeOrderBody)
+	require.EqualValues(t, 6, test.calls.Load())
+}
+
+func TestIdempotencyKeyExpires(t *testing.T) {
+	test := newIdempotencyTest(20 * time.Millisecond)
+
+	test.send("u1", "key-1", placeOrderBody)
+	time.Sleep(30 * time.Millisecond)
+	rec := test.send("u1", "key-1", placeOrderBody)
+	require.Empty(t, rec.Header().Get(IdempotentReplayedHeader))
+	require.EqualValues(t, 2, test.calls.Load())
+}
+
+func TestIdempotencyKeyInProgress(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+	_, reserved, err := store.Reserve(context.TODO(), "k", IdempotencyRecord{Fingerprint: "a", ExpiresAt: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	require.True(t, reserved)
+
+	record, reserved, err := store.Reserve(context.TODO(), "k", IdempotencyRecord{Fingerprint: "a", ExpiresAt: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	require.False(t, reserved)
+	require.False(t, record.Completed)
+
+	// Released keys can be used again
+	require.NoError(t, store.Release(context.TODO(), "k"))
+	_, reserved, err = store.Reserve(context.TODO(), "k", IdempotencyRecord{Fingerprint: "b", ExpiresAt: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	require.True(t, reserved)
+}
+
+func TestIdempotencySkipsUploads(t *testing.T) {
+	test := newIdempotencyTest(time.Hour)
+	// uploads are larger than the JSON requests the middleware reads
+	body := strings.Repeat("x", maxIdempotentRequestBytes+1)
+
+	for range 2 {
+		req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+		req.Header.Set("Content-Type", "multipart/form-data; boundary=test")
+		req.Header.Set("X-Test-User", "u1")
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+		rec := httptest.NewRecorder()
+		test.handler.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+	}
+	require.EqualValues(t, 2, test.calls.Load())
+}
@@ -0,0 +1,63 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+)
+
+// Thumbnail scales the image down to fit in a square of the size, keeping its aspect ratio.
+// Each pixel of the thumbnail is the average of the pixels it covers, images that already fit are copied as they are.
+func Thumbnail(src image.Image, size int) *image.NRGBA {
+	bounds := src.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	dstW, dstH := fit(srcW, srcH, size)
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dstW, dstH))
+	for y := 0; y < dstH; y++ {
+		y0 := bounds.Min.Y + y*srcH/dstH
+		y1 := max(bounds.Min.Y+(y+1)*srcH/dstH, y0+1)
+		for x := 0; x < dstW; x++ {
+			x0 := bounds.Min.X + x*srcW/dstW
+			x1 := max(bounds.Min.X+(x+1)*srcW/dstW, x0+1)
+			dst.SetNRGBA(x, y, average(src, x0, y0, x1, y1))
+		}
+	}
+	return dst
+}
+
+// fit returns the dimensions of the thumbnail, at least one pixel wide and high
+func fit(w, h, size int) (int, int) {
+	if w <= size && h <= size {
+		return max(w, 1), max(h, 1)
+	}
+	if w >= h {
+		return size, max(h*size/w, 1)
+	}
+	return max(w*size/h, 1), size
+}
+
+// average blends the pixels of the rectangle, weighting the colors by their alpha so transparent pixels don't darken the edges
+func average(src image.Image, x0, y0, x1, y1 int) color.NRGBA {
+	var r, g, b, a uint64
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			// RGBA returns alpha-premultiplied 16-bit values
+			pr, pg, pb, pa := src.At(x, y).RGBA()
+			r += uint64(pr)
+			g += uint64(pg)
+			b += uint64(pb)
+			a += uint64(pa)
+		}
+	}
+	if a == 0 {
+		return color.NRGBA{}
+	}
+
+	n := uint64((x1 - x0) * (y1 - y0))
+	return color.NRGBA{
+		R: uint8(r * 0xff / a),
+		G: uint8(g * 0xff / a),
+		B: uint8(b * 0xff / a),
+		A: uint8(a / n >> 8),
+	}
+}
@@ -0,0 +1,32 @@
+package imaging_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"graphql-backend/pkg/imaging"
+)
+
+func TestThumbnail(t *testing.T) {
+	// left half red, right half transparent
+	src := image.NewNRGBA(image.Rect(0, 0, 400, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 200; x++ {
+			src.SetNRGBA(x, y, color.NRGBA{R: 255, A: 255})
+		}
+	}
+
+	thumb := imaging.Thumbnail(src, 100)
+	require.Equal(t, image.Rect(0, 0, 100, 50), thumb.Bounds())
+	require.Equal(t, color.NRGBA{R: 255, A: 255}, thumb.NRGBAAt(10, 10))
+	require.Equal(t, color.NRGBA{}, thumb.NRGBAAt(90, 10))
+
+	// Images that fit are not scaled up
+	small := imaging.Thumbnail(image.NewNRGBA(image.Rect(5, 5, 25, 65)), 100)
+	require.Equal(t, image.Rect(0, 0, 20, 60), small.Bounds())
+
+	tall := imaging.Thumbnail(image.NewGray(image.Rect(0, 0, 10, 1000)), 100)
+	require.Equal(t, image.Rect(0, 0, 1, 100), tall.Bounds())
+}
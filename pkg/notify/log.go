@@ -0,0 +1,20 @@
+package notify
+
+import (
+	"context"
+	"graphql-backend/entity"
+	"log"
+)
+
+// LogNotifier writes notifications to the application log instead of sending them,
+// for local development where no mail server is available
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(ctx context.Context, n entity.Notification) error {
+	log.Printf("notification to %s (user %s): %s\n%s", n.To, n.UserID, n.Subject, n.Body)
+	return nil
+}
+
+func NewLogNotifier() LogNotifier {
+	return LogNotifier{}
+}
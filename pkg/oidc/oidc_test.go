@@ -0,0 +1,101 @@
+package oidc_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"graphql-backend/pkg/oidc"
+	"graphql-backend/pkg/oidc/oidctest"
+)
+
+const redirectURL = "http://localhost:8080/auth/oidc/callback"
+
+func authorize(t *testing.T, provider *oidc.Provider, state, nonce, verifier string) string {
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Get(provider.AuthCodeURL(state, nonce, verifier))
+	require.NoError(t, err)
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	require.Equal(t, state, location.Query().Get("state"))
+	return location.Query().Get("code")
+}
+
+func TestAuthorizationCodeFlowWithPKCE(t *testing.T) {
+	issuer, err := oidctest.NewServer("graphql-backend", oidctest.User{
+		Subject:       "user-123",
+		Email:         "oidc@example.com",
+		EmailVerified: true,
+		Name:          "OIDC User",
+	})
+	require.NoError(t, err)
+	defer issuer.Close()
+
+	provider, err := oidc.Discover(context.TODO(), oidc.Config{
+		IssuerURL:   issuer.URL,
+		ClientID:    "graphql-backend",
+		RedirectURL: redirectURL,
+	}, nil)
+	require.NoError(t, err)
+
+	verifier, err := oidc.RandomString()
+	require.NoError(t, err)
+	code := authorize(t, provider, "state-1", "nonce-1", verifier)
+
+	claims, err := provider.Exchange(context.TODO(), code, verifier, "nonce-1")
+	require.NoError(t, err)
+	require.Equal(t, "user-123", claims.Subject)
+	require.Equal(t, "oidc@example.com", claims.Email)
+	require.True(t, claims.EmailVerified)
+
+	// codes are single use
+	_, err = provider.Exchange(context.TODO(), code, verifier, "nonce-1")
+	require.Error(t, err)
+}
+
+func TestExchangeRejectsWrongVerifierAndNonce(t *testing.T) {
+	issuer, err := oidctest.NewServer("graphql-backend", oidctest.User{Subject: "user-123"})
+	require.NoError(t, err)
+	defer issuer.Close()
+
+	provider, err := oidc.Discover(context.TODO(), oidc.Config{
+		IssuerURL:   issuer.URL,
+		ClientID:    "graphql-backend",
+		RedirectURL: redirectURL,
+	}, nil)
+	require.NoError(t, err)
+
+	verifier, err := oidc.RandomString()
+	require.NoError(t, err)
+
+	code := authorize(t, provider, "state-1", "nonce-1", verifier)
+	_, err = provider.Exchange(context.TODO(), code, "another-verifier", "nonce-1")
+	require.Error(t, err)
+
+	code = authorize(t, provider, "state-2", "nonce-2", verifier)
+	_, err = provider.Exchange(context.TODO(), code, verifier, "another-nonce")
+	require.Error(t, err)
+}
+
+func TestDiscoverRejectsIssuerMismatch(t *testing.T) {
+	issuer, err := oidctest.NewServer("graphql-backend", oidctest.User{Subject: "user-123"})
+	require.NoError(t, err)
+	defer issuer.Close()
+
+	_, err = oidc.Discover(context.TODO(), oidc.Config{
+		IssuerURL: issuer.URL + "/",
+		ClientID:  "graphql-backend",
+	}, nil)
+	require.Error(t, err)
+}
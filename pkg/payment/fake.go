@@ -0,0 +1,303 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"graphql-backend/entity"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Test cards of the fake gateway, any other valid card number is approved
+const (
+	CardApproved          = "4242424242424242"
+	CardDeclined          = "4000000000000002"
+	CardInsufficientFunds = "4000000000009995"
+	// CardAsyncApproved and CardAsyncDeclined stay pending until the gateway posts its decision to the webhook
+	CardAsyncApproved = "4000000000003220"
+	CardAsyncDeclined = "4000000000000341"
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of the webhook payload with the webhook secret
+const SignatureHeader = "X-Webhook-Signature"
+
+const defaultWebhookDelay = 500 * time.Millisecond
+
+type FakeConfig struct {
+	// WebhookURL receives the asynchronous decisions, they are dropped when it is empty
+	WebhookURL    string
+	WebhookSecret string
+	WebhookDelay  time.Duration
+	HTTPClient    *http.Client
+}
+
+// FakeGateway is a PaymentGateway that decides by card number, for local use and tests. It keeps its transactions in memory,
+// the ones it lost on a restart are picked up from the stored payment.
+type FakeGateway struct {
+	config FakeConfig
+
+	mu           sync.Mutex
+	transactions map[string]*transaction
+}
+
+type transaction struct {
+	amount   entity.Money
+	refunded entity.Money
+	status   entity.PaymentStatus
+}
+
+// webhookEvent is the payload posted to the webhook
+type webhookEvent struct {
+	Reference     string               `json:"reference"`
+	Status        entity.PaymentStatus `json:"status"`
+	FailureReason string               `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time            `json:"created_at"`
+}
+
+func NewFakeGateway(config FakeConfig) *FakeGateway {
+	if config.WebhookDelay <= 0 {
+		config.WebhookDelay = defaultWebhookDelay
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 5 * time.Second}
+	}
+
+	return &FakeGateway{config: config, transactions: map[string]*transaction{}}
+}
+
+func (g *FakeGateway) Name() string {
+	return "fake"
+}
+
+func (g *FakeGateway) Authorize(ctx context.Context, req entity.PaymentAuthorization) (entity.PaymentResult, error) {
+	card := strings.NewReplacer(" ", "", "-", "").Replace(req.PaymentMethod)
+	result := entity.PaymentResult{Reference: "fake_" + uuid.NewString()}
+	if len(card) >= 4 {
+		result.CardLast4 = card[len(card)-4:]
+	}
+
+	var decision *webhookEvent
+	switch {
+	case !validCardNumber(card):
+		result.Status = entity.PaymentStatusFailed
+		result.FailureReason = "invalid_card_number"
+	case card == CardDeclined:
+		result.Status = entity.PaymentStatusFailed
+		result.FailureReason = "card_declined"
+	case card == CardInsufficientFunds:
+		result.Status = entity.PaymentStatusFailed
+		result.FailureReason = "insufficient_funds"
+	case card == CardAsyncApproved:
+		result.Status = entity.PaymentStatusPending
+		decision = &webhookEvent{Reference: result.Reference, Status: entity.PaymentStatusAuthorized}
+	case card == CardAsyncDeclined:
+		result.Status = entity.PaymentStatusPending
+		decision = &webhookEvent{Reference: result.Reference, Status: entity.PaymentStatusFailed, FailureReason: "card_declined"}
+	default:
+		result.Status = entity.PaymentStatusAuthorized
+	}
+
+	g.mu.Lock()
+	g.transactions[result.Reference] = &transaction{
+		amount:   req.Amount,
+		refunded: entity.Money{Currency: req.Amount.Currency},
+		status:   result.Status,
+	}
+	g.mu.Unlock()
+
+	if decision != nil {
+		g.decideLater(*decision)
+	}
+	return result, nil
+}
+
+func (g *FakeGateway) Capture(ctx context.Context, payment entity.Payment, amount entity.Money) (entity.PaymentResult, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	reference := payment.Reference
+	txn, err := g.transaction(payment)
+	if err != nil {
+		return entity.PaymentResult{}, err
+	}
+	if txn.status != entity.PaymentStatusAuthorized {
+		return entity.PaymentResult{}, fmt.Errorf("cannot capture a %s transaction", txn.status)
+	}
+	if txn.amount.LessThan(amount) || amount.Currency != txn.amount.Currency {
+		return entity.PaymentResult{}, errors.New("capture exceeds the authorized amount")
+	}
+
+	txn.amount = amount
+	txn.status = entity.PaymentStatusCaptured
+	return g.result(reference, txn), nil
+}
+
+func (g *FakeGateway) Void(ctx context.Context, payment entity.Payment) (entity.PaymentResult, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	reference := payment.Reference
+	txn, err := g.transaction(payment)
+	if err != nil {
+		return entity.PaymentResult{}, err
+	}
+	if txn.status != entity.PaymentStatusAuthorized && txn.status != entity.PaymentStatusPending {
+		return entity.PaymentResult{}, fmt.Errorf("cannot void a %s transaction", txn.status)
+	}
+
+	txn.status = entity.PaymentStatusVoided
+	return g.result(reference, txn), nil
+}
+
+// Refund refunds a part or all of a captured transaction, it is Refunded once refunded in full
+func (g *FakeGateway) Refund(ctx context.Context, payment entity.Payment, amount entity.Money) (entity.PaymentResult, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	reference := payment.Reference
+	txn, err := g.transaction(payment)
+	if err != nil {
+		return entity.PaymentResult{}, err
+	}
+	if txn.status != entity.PaymentStatusCaptured {
+		return entity.PaymentResult{}, fmt.Errorf("cannot refund a %s transaction", txn.status)
+	}
+	if amount.IsZero() || amount.IsNegative() || amount.Currency != txn.amount.Currency {
+		return entity.PaymentResult{}, errors.New("invalid refund amount")
+	}
+	if txn.amount.Sub(txn.refunded).LessThan(amount) {
+		return entity.PaymentResult{}, errors.New("refund exceeds the captured amount")
+	}
+
+	txn.refunded = txn.refunded.Add(amount)
+	if txn.refunded == txn.amount {
+		txn.status = entity.PaymentStatusRefunded
+	}
+	return g.result(reference, txn), nil
+}
+
+func (g *FakeGateway) ParseWebhook(payload []byte, header http.Header) (entity.PaymentResult, error) {
+	signature, err := hex.DecodeString(header.Get(SignatureHeader))
+	if err != nil || !hmac.Equal(signature, g.sign(payload)) {
+		return entity.PaymentResult{}, entity.ErrInvalidWebhookSignature
+	}
+
+	var event webhookEvent
+	err = json.Unmarshal(payload, &event)
+	if err != nil {
+		return entity.PaymentResult{}, err
+	}
+
+	return entity.PaymentResult{Reference: event.Reference, Status: event.Status, FailureReason: event.FailureReason}, nil
+}
+
+// decideLater settles a pending transaction after the webhook delay and posts the decision to the webhook
+func (g *FakeGateway) decideLater(event webhookEvent) {
+	time.AfterFunc(g.config.WebhookDelay, func() {
+		g.mu.Lock()
+		txn, ok := g.transactions[event.Reference]
+		if !ok || txn.status != entity.PaymentStatusPending {
+			g.mu.Unlock()
+			return
+		}
+		txn.status = event.Status
+		g.mu.Unlock()
+
+		if g.config.WebhookURL == "" {
+			return
+		}
+		event.CreatedAt = time.Now()
+		err := g.postWebhook(event)
+		if err != nil {
+			log.Printf("fake payment gateway: failed to post webhook for %s: %v", event.Reference, err)
+		}
+	})
+}
+
+func (g *FakeGateway) postWebhook(event webhookEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, g.config.WebhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, hex.EncodeToString(g.sign(payload)))
+
+	resp, err := g.config.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (g *FakeGateway) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(g.config.WebhookSecret))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// transaction returns the transaction of the payment, it is restored from the payment when the gateway doesn't know it
+func (g *FakeGateway) transaction(payment entity.Payment) (*transaction, error) {
+	if txn, ok := g.transactions[payment.Reference]; ok {
+		return txn, nil
+	}
+	if payment.Gateway != g.Name() || payment.Reference == "" {
+		return nil, errors.New("transaction not found")
+	}
+
+	txn := &transaction{
+		amount:   payment.Amount,
+		refunded: payment.RefundedAmount,
+		status:   payment.Status,
+	}
+	g.transactions[payment.Reference] = txn
+	return txn, nil
+}
+
+func (g *FakeGateway) result(reference string, txn *transaction) entity.PaymentResult {
+	return entity.PaymentResult{Reference: reference, Status: txn.status, RefundedAmount: txn.refunded}
+}
+
+// validCardNumber checks the length and the Luhn checksum of a card number
+func validCardNumber(card string) bool {
+	if len(card) < 12 || len(card) > 19 {
+		return false
+	}
+
+	sum := 0
+	for i := 0; i < len(card); i++ {
+		c := card[len(card)-1-i]
+		if c < '0' || c > '9' {
+			return false
+		}
+		digit := int(c - '0')
+		if i%2 == 1 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+	}
+
+	return sum%10 == 0
+}
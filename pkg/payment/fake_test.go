@@ -0,0 +1,132 @@
+package payment_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"graphql-backend/entity"
+	"graphql-backend/pkg/payment"
+)
+
+func TestFakeGatewayTestCards(t *testing.T) {
+	gateway := payment.NewFakeGateway(payment.FakeConfig{})
+	amount := entity.NewMoney(1999, "USD")
+
+	cases := map[string]struct {
+		status entity.PaymentStatus
+		reason string
+	}{
+		payment.CardApproved:          {status: entity.PaymentStatusAuthorized},
+		"5555 5555 5555 4444":         {status: entity.PaymentStatusAuthorized},
+		payment.CardDeclined:          {status: entity.PaymentStatusFailed, reason: "card_declined"},
+		payment.CardInsufficientFunds: {status: entity.PaymentStatusFailed, reason: "insufficient_funds"},
+		payment.CardAsyncApproved:     {status: entity.PaymentStatusPending},
+		"4242424242424241":            {status: entity.PaymentStatusFailed, reason: "invalid_card_number"},
+	}
+	for card, want := range cases {
+		result, err := gateway.Authorize(context.TODO(), entity.PaymentAuthorization{PaymentID: "p", Amount: amount, PaymentMethod: card})
+		require.NoError(t, err, card)
+		require.Equal(t, want.status, result.Status, card)
+		require.Equal(t, want.reason, result.FailureReason, card)
+		require.NotEmpty(t, result.Reference, card)
+	}
+}
+
+func TestFakeGatewayCaptureAndRefund(t *testing.T) {
+	gateway := payment.NewFakeGateway(payment.FakeConfig{})
+	amount := entity.NewMoney(1000, "USD")
+
+	authorized, err := gateway.Authorize(context.TODO(), entity.PaymentAuthorization{Amount: amount, PaymentMethod: payment.CardApproved})
+	require.NoError(t, err)
+	stored := entity.Payment{Gateway: gateway.Name(), Reference: authorized.Reference, Amount: amount}
+
+	_, err = gateway.Capture(context.TODO(), stored, entity.NewMoney(1001, "USD"))
+	require.Error(t, err)
+
+	captured, err := gateway.Capture(context.TODO(), stored, amount)
+	require.NoError(t, err)
+	require.Equal(t, entity.PaymentStatusCaptured, captured.Status)
+
+	_, err = gateway.Void(context.TODO(), stored)
+	require.Error(t, err)
+
+	refunded, err := gateway.Refund(context.TODO(), stored, entity.NewMoney(400, "USD"))
+	require.NoError(t, err)
+	require.Equal(t, entity.PaymentStatusCaptured, refunded.Status)
+	require.Equal(t, entity.NewMoney(400, "USD"), refunded.RefundedAmount)
+
+	_, err = gateway.Refund(context.TODO(), stored, entity.NewMoney(601, "USD"))
+	require.Error(t, err)
+
+	refunded, err = gateway.Refund(context.TODO(), stored, entity.NewMoney(600, "USD"))
+	require.NoError(t, err)
+	require.Equal(t, entity.PaymentStatusRefunded, refunded.Status)
+}
+
+func TestFakeGatewayRestoresTransactionsFromPayments(t *testing.T) {
+	// a payment captured before the gateway restarted
+	stored := entity.Payment{
+		Gateway:        "fake",
+		Reference:      "fake_before_restart",
+		Amount:         entity.NewMoney(1000, "USD"),
+		RefundedAmount: entity.NewMoney(300, "USD"),
+		Status:         entity.PaymentStatusCaptured,
+	}
+	gateway := payment.NewFakeGateway(payment.FakeConfig{})
+
+	_, err := gateway.Refund(context.TODO(), stored, entity.NewMoney(701, "USD"))
+	require.Error(t, err)
+
+	refunded, err := gateway.Refund(context.TODO(), stored, entity.NewMoney(700, "USD"))
+	require.NoError(t, err)
+	require.Equal(t, entity.PaymentStatusRefunded, refunded.Status)
+	require.Equal(t, entity.NewMoney(1000, "USD"), refunded.RefundedAmount)
+
+	// payments of other gateways are unknown
+	stored.Gateway = "other"
+	stored.Reference = "other_reference"
+	_, err = gateway.Void(context.TODO(), stored)
+	require.Error(t, err)
+}
+
+func TestFakeGatewayWebhook(t *testing.T) {
+	received := make(chan entity.PaymentResult, 1)
+	var gateway *payment.FakeGateway
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, _ := io.ReadAll(r.Body)
+		result, err := gateway.ParseWebhook(payload, r.Header)
+		require.NoError(t, err)
+		received <- result
+	}))
+	defer server.Close()
+
+	gateway = payment.NewFakeGateway(payment.FakeConfig{
+		WebhookURL:    server.URL,
+		WebhookSecret: "secret",
+		WebhookDelay:  10 * time.Millisecond,
+	})
+
+	pending, err := gateway.Authorize(context.TODO(), entity.PaymentAuthorization{Amount: entity.NewMoney(500, "EUR"), PaymentMethod: payment.CardAsyncDeclined})
+	require.NoError(t, err)
+	require.Equal(t, entity.PaymentStatusPending, pending.Status)
+
+	select {
+	case result := <-received:
+		require.Equal(t, pending.Reference, result.Reference)
+		require.Equal(t, entity.PaymentStatusFailed, result.Status)
+		require.Equal(t, "card_declined", result.FailureReason)
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook was not posted")
+	}
+
+	// Payloads signed with another secret are rejected
+	header := http.Header{}
+	header.Set(payment.SignatureHeader, "deadbeef")
+	_, err = gateway.ParseWebhook([]byte(`{"reference":"x","status":"Authorized"}`), header)
+	require.ErrorIs(t, err, entity.ErrInvalidWebhookSignature)
+}
@@ -0,0 +1,171 @@
+package tax
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"graphql-backend/entity"
+	"os"
+	"slices"
+	"strings"
+)
+
+// defaultRules is the rate table used when no rates file is configured
+//
+//go:embed rates.json
+var defaultRules []byte
+
+// Rule is the tax rate of the products of a category shipped to a country or one of its regions.
+// The category is the ID or slug of a category, the rule applies to its subcategories too. An empty region or category matches any.
+type Rule struct {
+	Country  string `json:"country"`
+	Region   string `json:"region,omitempty"`
+	Category string `json:"category,omitempty"`
+	Name     string `json:"name"`
+	// Rate is a percentage, products with a zero rate are exempt
+	Rate float64 `json:"rate"`
+}
+
+func (r Rule) matches(address *entity.OrderAddress) bool {
+	return r.Country == address.Country && (r.Region == "" || strings.EqualFold(r.Region, address.Region))
+}
+
+// categoryDepth returns how far up the categories of a product the category of the rule is, or -1 when it is none of them
+func (r Rule) categoryDepth(categories []entity.Category) int {
+	if r.Category == "" {
+		return len(categories)
+	}
+	return slices.IndexFunc(categories, func(category entity.Category) bool {
+		return r.Category == category.ID || r.Category == category.Slug
+	})
+}
+
+// specificity ranks a region over a category, the most specific matching rule applies and then the one of the closest category
+func (r Rule) specificity() int {
+	var n int
+	if r.Region != "" {
+		n += 2
+	}
+	if r.Category != "" {
+		n++
+	}
+	return n
+}
+
+// Origin is where the store is, orders without a shipping address are picked up there and taxed by its rules
+type Origin struct {
+	Country string `json:"country"`
+	Region  string `json:"region,omitempty"`
+}
+
+// RuleTable is a TaxCalculator looking up the rate of every order item by the shipping destination and product category
+type RuleTable struct {
+	origin entity.OrderAddress
+	rules  []Rule
+}
+
+func (t *RuleTable) CalculateTax(ctx context.Context, req entity.TaxRequest) ([]entity.TaxLine, error) {
+	address := req.Address
+	if address == nil {
+		address = &t.origin
+	}
+
+	var lines []entity.TaxLine
+	lineIndex := map[int]int{}
+	for _, item := range req.Lines {
+		rule := t.match(address, item.Categories)
+		if rule < 0 || t.rules[rule].Rate == 0 {
+			continue
+		}
+
+		i, ok := lineIndex[rule]
+		if !ok {
+			i = len(lines)
+			lineIndex[rule] = i
+			lines = append(lines, entity.TaxLine{
+				Name: t.rules[rule].Name,
+				Rate: t.rules[rule].Rate,
+			})
+		}
+		lines[i].TaxableAmount = lines[i].TaxableAmount.Add(item.Amount)
+	}
+
+	for i := range lines {
+		lines[i].Amount = lines[i].TaxableAmount.Percent(lines[i].Rate)
+	}
+
+	return lines, nil
+}
+
+// match returns the index of the most specific rule for the destination and categories, or -1
+func (t *RuleTable) match(address *entity.OrderAddress, categories []entity.Category) int {
+	best, bestDepth := -1, 0
+	for i, rule := range t.rules {
+		depth := rule.categoryDepth(categories)
+		if !rule.matches(address) || depth < 0 {
+			continue
+		}
+		if best < 0 || rule.specificity() > t.rules[best].specificity() ||
+			rule.specificity() == t.rules[best].specificity() && depth < bestDepth {
+			best, bestDepth = i, depth
+		}
+	}
+	return best
+}
+
+// NewRuleTable validates the origin and the rules, countries and regions are upper-cased
+func NewRuleTable(origin Origin, rules []Rule) (*RuleTable, error) {
+	table := &RuleTable{
+		origin: entity.OrderAddress{
+			Country: strings.ToUpper(strings.TrimSpace(origin.Country)),
+			Region:  strings.ToUpper(strings.TrimSpace(origin.Region)),
+		},
+		rules: make([]Rule, len(rules)),
+	}
+	if len(table.origin.Country) != 2 {
+		return nil, errors.New("tax origin: country must be an ISO 3166-1 alpha-2 code")
+	}
+	for i, rule := range rules {
+		rule.Country = strings.ToUpper(strings.TrimSpace(rule.Country))
+		rule.Region = strings.ToUpper(strings.TrimSpace(rule.Region))
+		rule.Category = strings.ToLower(strings.TrimSpace(rule.Category))
+		rule.Name = strings.TrimSpace(rule.Name)
+
+		if len(rule.Country) != 2 {
+			return nil, fmt.Errorf("tax rule %d: country must be an ISO 3166-1 alpha-2 code", i)
+		}
+		if rule.Name == "" {
+			return nil, fmt.Errorf("tax rule %d: name cannot be empty", i)
+		}
+		if rule.Rate < 0 || rule.Rate > 100 {
+			return nil, fmt.Errorf("tax rule %d: rate must be between 0 and 100", i)
+		}
+		table.rules[i] = rule
+	}
+
+	return table, nil
+}
+
+// LoadRuleTable reads the rules from a JSON file, the built-in rates are used when path is empty
+func LoadRuleTable(path string) (*RuleTable, error) {
+	bts := defaultRules
+	if path != "" {
+		var err error
+		bts, err = os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var file struct {
+		Origin Origin `json:"origin"`
+		Rules  []Rule `json:"rules"`
+	}
+	if err := json.Unmarshal(bts, &file); err != nil {
+		return nil, errors.New("invalid tax rates file: " + err.Error())
+	}
+
+	return NewRuleTable(file.Origin, file.Rules)
+}
@@ -0,0 +1,129 @@
+package tax_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"graphql-backend/entity"
+	"graphql-backend/pkg/tax"
+)
+
+func usd(amount float64) entity.Money {
+	return entity.NewMoneyFromFloat(amount, "USD")
+}
+
+var (
+	books   = entity.Category{ID: "c1", Name: "Books", Slug: "books"}
+	comics  = entity.Category{ID: "c2", Name: "Comics", Slug: "comics", ParentID: "c1"}
+	games   = entity.Category{ID: "c3", Name: "Games", Slug: "games"}
+	renamed = entity.Category{ID: "c1", Name: "Literature", Slug: "books"}
+)
+
+func TestMostSpecificRuleApplies(t *testing.T) {
+	table, err := tax.NewRuleTable(tax.Origin{Country: "CA"}, []tax.Rule{
+		{Country: "ca", Name: "GST", Rate: 5},
+		{Country: "CA", Region: "on", Name: "HST", Rate: 13},
+		{Country: "CA", Category: "Books", Name: "Books", Rate: 0},
+	})
+	require.NoError(t, err)
+
+	lines := []entity.TaxableLine{
+		{ProductID: "p1", Categories: []entity.Category{games}, Amount: usd(100)},
+		{ProductID: "p2", Categories: []entity.Category{games}, Amount: usd(50)},
+		{ProductID: "p3", Categories: []entity.Category{comics, books}, Amount: usd(20)},
+	}
+
+	taxLines, err := table.CalculateTax(context.TODO(), entity.TaxRequest{
+		Address: &entity.OrderAddress{Country: "CA", Region: "QC"},
+		Lines:   lines,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []entity.TaxLine{{Name: "GST", Rate: 5, TaxableAmount: usd(150), Amount: usd(7.5)}}, taxLines)
+
+	// The region wins over the category
+	taxLines, err = table.CalculateTax(context.TODO(), entity.TaxRequest{
+		Address: &entity.OrderAddress{Country: "CA", Region: "ON"},
+		Lines:   lines,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []entity.TaxLine{{Name: "HST", Rate: 13, TaxableAmount: usd(170), Amount: usd(22.1)}}, taxLines)
+}
+
+func TestCategoryRulesMatchByIDOrSlug(t *testing.T) {
+	table, err := tax.NewRuleTable(tax.Origin{Country: "DE"}, []tax.Rule{
+		{Country: "DE", Name: "MwSt 19%", Rate: 19},
+		{Country: "DE", Category: "books", Name: "MwSt 7%", Rate: 7},
+		{Country: "DE", Category: "c2", Name: "Comics", Rate: 5},
+	})
+	require.NoError(t, err)
+
+	calculate := func(categories ...entity.Category) []entity.TaxLine {
+		taxLines, err := table.CalculateTax(context.TODO(), entity.TaxRequest{
+			Address: &entity.OrderAddress{Country: "DE"},
+			Lines:   []entity.TaxableLine{{ProductID: "p1", Categories: categories, Amount: usd(100)}},
+		})
+		require.NoError(t, err)
+		return taxLines
+	}
+
+	// A renamed category keeps its slug and its rules
+	require.Equal(t, "MwSt 7%", calculate(renamed)[0].Name)
+	// Subcategories get the rule of their closest category
+	require.Equal(t, "Comics", calculate(comics, books)[0].Name)
+	require.Equal(t, "MwSt 7%", calculate(entity.Category{ID: "c4", Slug: "novels", ParentID: "c1"}, books)[0].Name)
+	require.Equal(t, "MwSt 19%", calculate(games)[0].Name)
+}
+
+func TestNoTaxWithoutRule(t *testing.T) {
+	table, err := tax.NewRuleTable(tax.Origin{Country: "DE"}, []tax.Rule{{Country: "DE", Name: "MwSt", Rate: 19}})
+	require.NoError(t, err)
+
+	taxLines, err := table.CalculateTax(context.TODO(), entity.TaxRequest{
+		Address: &entity.OrderAddress{Country: "US"},
+		Lines:   []entity.TaxableLine{{ProductID: "p1", Categories: []entity.Category{games}, Amount: usd(10)}},
+	})
+	require.NoError(t, err)
+	require.Empty(t, taxLines)
+}
+
+func TestOrdersWithoutAddressAreTaxedAtTheOrigin(t *testing.T) {
+	table, err := tax.NewRuleTable(tax.Origin{Country: "us", Region: "ny"}, []tax.Rule{
+		{Country: "US", Region: "NY", Name: "New York Sales Tax", Rate: 4},
+		{Country: "US", Region: "TX", Name: "Texas Sales Tax", Rate: 6.25},
+	})
+	require.NoError(t, err)
+
+	taxLines, err := table.CalculateTax(context.TODO(), entity.TaxRequest{
+		Lines: []entity.TaxableLine{{ProductID: "p1", Categories: []entity.Category{games}, Amount: usd(10)}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []entity.TaxLine{{Name: "New York Sales Tax", Rate: 4, TaxableAmount: usd(10), Amount: usd(0.4)}}, taxLines)
+}
+
+func TestInvalidRules(t *testing.T) {
+	origin := tax.Origin{Country: "DE"}
+	_, err := tax.NewRuleTable(origin, []tax.Rule{{Country: "Germany", Name: "MwSt", Rate: 19}})
+	require.Error(t, err)
+
+	_, err = tax.NewRuleTable(origin, []tax.Rule{{Country: "DE", Name: "MwSt", Rate: 190}})
+	require.Error(t, err)
+
+	_, err = tax.NewRuleTable(tax.Origin{}, []tax.Rule{{Country: "DE", Name: "MwSt", Rate: 19}})
+	require.Error(t, err)
+}
+
+func TestLoadDefaultRates(t *testing.T) {
+	table, err := tax.LoadRuleTable("")
+	require.NoError(t, err)
+
+	taxLines, err := table.CalculateTax(context.TODO(), entity.TaxRequest{
+		Address: &entity.OrderAddress{Country: "DE"},
+		Lines: []entity.TaxableLine{
+			{ProductID: "p1", Categories: []entity.Category{books}, Amount: usd(10)},
+			{ProductID: "p2", Categories: []entity.Category{games}, Amount: usd(10)},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, taxLines, 2)
+}
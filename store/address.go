@@ -0,0 +1,124 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"graphql-backend/entity"
+	"sort"
+)
+
+func (r *repo) GetAddressByID(ctx context.Context, id string) (entity.Address, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	address, ok := r.addressMap[id]
+	if !ok {
+		return entity.Address{}, errors.New("address not found")
+	}
+
+	return address, nil
+}
+
+func (r *repo) GetAddressesByUserID(ctx context.Context, userID string) ([]entity.Address, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	addresses := []entity.Address{}
+	for _, address := range r.addressMap {
+		if address.UserID == userID {
+			addresses = append(addresses, address)
+		}
+	}
+
+	sort.Slice(addresses, func(i, j int) bool {
+		if addresses[i].CreatedAt.Equal(addresses[j].CreatedAt) {
+			return addresses[i].ID < addresses[j].ID
+		}
+		return addresses[i].CreatedAt.Before(addresses[j].CreatedAt)
+	})
+
+	return addresses, nil
+}
+
+func (r *repo) CreateAddress(ctx context.Context, e entity.Address) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.addressMap[e.ID]; exists {
+		return errors.New("address with the given ID already exists")
+	}
+
+	r.clearDefaultAddresses(e)
+	r.addressMap[e.ID] = e
+	return nil
+}
+
+func (r *repo) UpdateAddress(ctx context.Context, e entity.Address) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.addressMap[e.ID]; !exists {
+		return errors.New("address not found")
+	}
+
+	r.clearDefaultAddresses(e)
+	r.addressMap[e.ID] = e
+	return nil
+}
+
+func (r *repo) DeleteAddress(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted, exists := r.addressMap[id]
+	if !exists {
+		return errors.New("address not found")
+	}
+
+	delete(r.addressMap, id)
+	r.promoteDefaultAddress(deleted)
+	return nil
+}
+
+// promoteDefaultAddress makes the oldest remaining address of the user take over the defaults of a deleted address
+func (r *repo) promoteDefaultAddress(deleted entity.Address) {
+	if !deleted.IsDefaultShipping && !deleted.IsDefaultBilling {
+		return
+	}
+
+	var oldest *entity.Address
+	for _, address := range r.addressMap {
+		if address.UserID != deleted.UserID {
+			continue
+		}
+		if oldest == nil || address.CreatedAt.Before(oldest.CreatedAt) ||
+			(address.CreatedAt.Equal(oldest.CreatedAt) && address.ID < oldest.ID) {
+			oldest = &address
+		}
+	}
+	if oldest == nil {
+		return
+	}
+
+	promoted := *oldest
+	promoted.IsDefaultShipping = promoted.IsDefaultShipping || deleted.IsDefaultShipping
+	promoted.IsDefaultBilling = promoted.IsDefaultBilling || deleted.IsDefaultBilling
+	r.addressMap[promoted.ID] = promoted
+}
+
+// clearDefaultAddresses keeps a single default shipping and billing address per user,
+// the given address takes over the defaults it is flagged with
+func (r *repo) clearDefaultAddresses(e entity.Address) {
+	for id, address := range r.addressMap {
+		if id == e.ID || address.UserID != e.UserID {
+			continue
+		}
+		if e.IsDefaultShipping {
+			address.IsDefaultShipping = false
+		}
+		if e.IsDefaultBilling {
+			address.IsDefaultBilling = false
+		}
+		r.addressMap[id] = address
+	}
+}
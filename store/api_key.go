@@ -0,0 +1,89 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"graphql-backend/entity"
+	"sort"
+	"time"
+)
+
+func (r *repo) GetAPIKeys(ctx context.Context) ([]entity.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	apiKeys := make([]entity.APIKey, 0, len(r.apiKeyMap))
+	for _, apiKey := range r.apiKeyMap {
+		apiKeys = append(apiKeys, apiKey)
+	}
+
+	sort.Slice(apiKeys, func(i, j int) bool {
+		return apiKeys[i].CreatedAt.After(apiKeys[j].CreatedAt)
+	})
+
+	return apiKeys, nil
+}
+
+func (r *repo) GetAPIKeyByID(ctx context.Context, id string) (entity.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	apiKey, ok := r.apiKeyMap[id]
+	if !ok {
+		return entity.APIKey{}, errors.New("api key not found")
+	}
+
+	return apiKey, nil
+}
+
+func (r *repo) GetAPIKeyByHash(ctx context.Context, hash string) (entity.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, apiKey := range r.apiKeyMap {
+		if apiKey.Hash == hash {
+			return apiKey, nil
+		}
+	}
+
+	return entity.APIKey{}, errors.New("api key not found")
+}
+
+func (r *repo) CreateAPIKey(ctx context.Context, e entity.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.apiKeyMap[e.ID]; exists {
+		return errors.New("api key with the given ID already exists")
+	}
+
+	r.apiKeyMap[e.ID] = e
+	return nil
+}
+
+// TouchAPIKey sets only when the key was last used, so that a concurrent revocation is kept
+func (r *repo) TouchAPIKey(ctx context.Context, id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	apiKey, exists := r.apiKeyMap[id]
+	if !exists {
+		return errors.New("api key not found")
+	}
+
+	apiKey.LastUsedAt = &at
+	r.apiKeyMap[id] = apiKey
+	return nil
+}
+
+func (r *repo) UpdateAPIKey(ctx context.Context, e entity.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.apiKeyMap[e.ID]; !exists {
+		return errors.New("api key not found")
+	}
+
+	r.apiKeyMap[e.ID] = e
+	return nil
+}
@@ -0,0 +1,56 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"graphql-backend/app"
+	"graphql-backend/entity"
+	"sort"
+)
+
+func (r *repo) CreateAuditEntry(ctx context.Context, e entity.AuditEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.auditMap[e.ID]; exists {
+		return errors.New("audit entry with the given ID already exists")
+	}
+
+	r.auditMap[e.ID] = e
+	return nil
+}
+
+func (r *repo) GetAuditEntries(ctx context.Context, prs app.AuditEntriesParams) ([]entity.AuditEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	limit := *prs.Limit
+	offset := *prs.Offset
+
+	var entries []entity.AuditEntry
+	for _, entry := range r.auditMap {
+		if prs.ActorID != nil && *prs.ActorID != "" && entry.ActorID != *prs.ActorID {
+			continue
+		}
+		if prs.UserID != nil && *prs.UserID != "" && entry.UserID != *prs.UserID {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	// newest first
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.After(entries[j].CreatedAt)
+	})
+
+	start := offset
+	end := offset + limit
+	if int(start) > len(entries) {
+		return []entity.AuditEntry{}, nil
+	}
+	if int(end) > len(entries) {
+		end = int32(len(entries))
+	}
+
+	return entries[start:end], nil
+}
@@ -0,0 +1,165 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"graphql-backend/entity"
+	"time"
+)
+
+func (r *repo) GetCartByUserID(ctx context.Context, userID string) (entity.Cart, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, cart := range r.cartMap {
+		if !cart.IsGuest() && cart.UserID == userID {
+			return cart, nil
+		}
+	}
+
+	return entity.Cart{}, errors.New("cart not found")
+}
+
+func (r *repo) GetCartByTokenHash(ctx context.Context, tokenHash string) (entity.Cart, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, cart := range r.cartMap {
+		if cart.IsGuest() && cart.TokenHash == tokenHash {
+			return cart, nil
+		}
+	}
+
+	return entity.Cart{}, errors.New("cart not found")
+}
+
+func (r *repo) DeleteCart(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.cartMap[id]; !exists {
+		return errors.New("cart not found")
+	}
+
+	delete(r.cartMap, id)
+	return nil
+}
+
+// SaveCart creates or replaces a cart
+func (r *repo) SaveCart(ctx context.Context, e entity.Cart) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cartMap[e.ID] = e
+	return nil
+}
+
+func (r *repo) DeleteExpiredCarts(ctx context.Context, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, cart := range r.cartMap {
+		if cart.IsExpired(now) {
+			delete(r.cartMap, id)
+		}
+	}
+
+	return nil
+}
+
+// CheckoutCart places the order of a cart in one step: the stock of every item is reserved,
+// the coupons redeemed, the order created and the cart emptied. Nothing is changed when any of it fails.
+func (r *repo) CheckoutCart(ctx context.Context, cart entity.Cart, order entity.Order) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// the order was built from this version of the cart
+	stored, exists := r.cartMap[cart.ID]
+	if !exists {
+		return errors.New("cart not found")
+	}
+	if !stored.UpdatedAt.Equal(cart.UpdatedAt) {
+		return errors.New("cart was changed during checkout, try again")
+	}
+	if _, exists := r.orderMap[order.ID]; exists {
+		return errors.New("order with the given ID already exists")
+	}
+	if err := r.checkPromotionLimits(order); err != nil {
+		return err
+	}
+
+	if err := r.checkStock(order); err != nil {
+		return err
+	}
+	for _, item := range order.Items {
+		product := r.productMap[item.ProductID]
+		if product.UnitPrice(item.VariantID).Convert(order.Currency, order.ExchangeRate) != item.UnitPrice {
+			return fmt.Errorf("price of %s changed during checkout, try again", product.Name)
+		}
+	}
+
+	r.reserveStock(order)
+	r.redeemPromotions(order)
+	r.orderMap[order.ID] = order
+
+	cart.Items = []entity.CartItem{}
+	cart.UpdatedAt = order.CreatedAt
+	r.cartMap[cart.ID] = cart
+
+	return nil
+}
+
+// checkStock checks that every item of the order has a positive quantity, can still be ordered and is in stock. It has to be called under the lock.
+func (r *repo) checkStock(order entity.Order) error {
+	for _, item := range order.Items {
+		if item.Quantity <= 0 {
+			return fmt.Errorf("quantity of %s must be positive", item.ProductID)
+		}
+		product, ok := r.productMap[item.ProductID]
+		if !ok || product.CheckAvailable() != nil || product.CheckVariant(item.VariantID) != nil {
+			return fmt.Errorf("product %s is no longer available", item.ProductID)
+		}
+		if stock := product.Stock(item.VariantID); stock < item.Quantity {
+			return fmt.Errorf("only %d of %s left in stock", stock, product.Name)
+		}
+	}
+	return nil
+}
+
+// reserveStock takes the items of an order out of stock once checkStock passed. It has to be called under the write lock.
+func (r *repo) reserveStock(order entity.Order) {
+	for _, item := range order.Items {
+		product := r.productMap[item.ProductID]
+		product.AddStock(item.VariantID, -item.Quantity)
+		r.productMap[item.ProductID] = product
+	}
+}
+
+// releaseStock puts the items of an order that is being cancelled back in stock, except the ones already
+// restocked by a return. It has to be called under the write lock.
+func (r *repo) releaseStock(stored entity.Order, status entity.OrderStatus) {
+	if !stored.StockReserved || stored.Status == entity.OrderStatusCancelled || status != entity.OrderStatusCancelled {
+		return
+	}
+
+	restocked := map[entity.ItemKey]int32{}
+	for _, request := range r.returnMap {
+		if request.OrderID != stored.ID || !request.Restocked {
+			continue
+		}
+		for _, item := range request.Items {
+			restocked[item.Key()] += item.Quantity
+		}
+	}
+
+	for _, item := range stored.Items {
+		quantity := item.Quantity - restocked[item.Key()]
+		product, ok := r.productMap[item.ProductID]
+		if !ok || quantity <= 0 {
+			continue
+		}
+		product.AddStock(item.VariantID, quantity)
+		r.productMap[item.ProductID] = product
+	}
+}
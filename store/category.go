@@ -0,0 +1,193 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"graphql-backend/entity"
+	"strings"
+	"time"
+)
+
+func (r *repo) GetCategories(ctx context.Context) ([]entity.Category, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	categories := make([]entity.Category, 0, len(r.categoryMap))
+	for _, category := range r.categoryMap {
+		categories = append(categories, category)
+	}
+	return categories, nil
+}
+
+func (r *repo) GetCategoryByID(ctx context.Context, id string) (entity.Category, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	category, ok := r.categoryMap[id]
+	if !ok {
+		return entity.Category{}, errors.New("category not found")
+	}
+
+	return category, nil
+}
+
+func (r *repo) GetCategoryBySlug(ctx context.Context, slug string) (entity.Category, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, category := range r.categoryMap {
+		if category.Slug == slug {
+			return category, nil
+		}
+	}
+
+	return entity.Category{}, errors.New("category not found")
+}
+
+func (r *repo) CreateCategory(ctx context.Context, e entity.Category) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.categoryMap[e.ID]; exists {
+		return errors.New("category with the given ID already exists")
+	}
+	if err := r.checkCategory(e); err != nil {
+		return err
+	}
+
+	r.categoryMap[e.ID] = e
+	return nil
+}
+
+// UpdateCategory stores the category, the products of a renamed category get the new name
+func (r *repo) UpdateCategory(ctx context.Context, e entity.Category) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, exists := r.categoryMap[e.ID]
+	if !exists {
+		return errors.New("category not found")
+	}
+	if err := r.checkCategory(e); err != nil {
+		return err
+	}
+
+	if stored.Name != e.Name {
+		for id, product := range r.productMap {
+			if product.CategoryID == e.ID {
+				product.Category = e.Name
+				r.productMap[id] = product
+			}
+		}
+	}
+	r.categoryMap[e.ID] = e
+	return nil
+}
+
+// DeleteCategory deletes a category without subcategories or products
+func (r *repo) DeleteCategory(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.categoryMap[id]; !exists {
+		return errors.New("category not found")
+	}
+	for _, category := range r.categoryMap {
+		if category.ParentID == id {
+			return errors.New("category has subcategories, move or delete them first")
+		}
+	}
+	for _, product := range r.productMap {
+		if product.CategoryID == id {
+			return errors.New("category has products, move them to another category first")
+		}
+	}
+
+	delete(r.categoryMap, id)
+	return nil
+}
+
+// checkCategory checks that the slug is not taken and that the parent exists and is not the category or one of its descendants
+func (r *repo) checkCategory(e entity.Category) error {
+	for _, category := range r.categoryMap {
+		if category.ID != e.ID && category.Slug == e.Slug {
+			return fmt.Errorf("slug %s is used by another category", e.Slug)
+		}
+	}
+
+	for parentID := e.ParentID; parentID != ""; parentID = r.categoryMap[parentID].ParentID {
+		if parentID == e.ID {
+			return errors.New("a category can't be moved under itself or one of its subcategories")
+		}
+		if _, ok := r.categoryMap[parentID]; !ok {
+			return errors.New("parent category not found")
+		}
+	}
+
+	return nil
+}
+
+// categoryDescendants returns the IDs of the category and of all the categories below it
+func (r *repo) categoryDescendants(id string) map[string]bool {
+	children := make(map[string][]string, len(r.categoryMap))
+	for _, category := range r.categoryMap {
+		children[category.ParentID] = append(children[category.ParentID], category.ID)
+	}
+
+	ids := map[string]bool{id: true}
+	queue := []string{id}
+	for len(queue) > 0 {
+		for _, child := range children[queue[0]] {
+			ids[child] = true
+			queue = append(queue, child)
+		}
+		queue = queue[1:]
+	}
+	return ids
+}
+
+// setProductCategory puts a product given only a category name in the top-level category of the name, which is created
+// when missing. It has to be called under the write lock once the product is sure to be stored, so that no category is left empty.
+func (r *repo) setProductCategory(e *entity.Product) {
+	if e.CategoryID != "" {
+		return
+	}
+	category := getOrCreateCategory(r.categoryMap, e.Category)
+	e.CategoryID = category.ID
+	e.Category = category.Name
+}
+
+// getOrCreateCategory finds the top-level category of the name regardless of case, or adds it with a free slug
+func getOrCreateCategory(categoryMap CategoryMap, name string) entity.Category {
+	for _, category := range categoryMap {
+		if category.IsTopLevel() && strings.EqualFold(category.Name, name) {
+			return category
+		}
+	}
+
+	slugs := make(map[string]bool, len(categoryMap))
+	for _, category := range categoryMap {
+		slugs[category.Slug] = true
+	}
+	base := entity.Slugify(name)
+	if base == "" {
+		base = "category"
+	}
+	slug := base
+	for i := 2; slugs[slug]; i++ {
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+
+	now := time.Now()
+	category := entity.Category{
+		ID:        uuid.NewString(),
+		Name:      name,
+		Slug:      slug,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	categoryMap[category.ID] = category
+	return category
+}
@@ -0,0 +1,74 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"graphql-backend/entity"
+	"os"
+	"path/filepath"
+)
+
+func dataExportPath(id string) string {
+	return filepath.Join("store", "data", "exports", id+".json")
+}
+
+// CreateDataExport stores the archive content in its own file, it is only read when downloaded
+func (r *repo) CreateDataExport(ctx context.Context, e entity.DataExport, content []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.dataExportMap[e.ID]; exists {
+		return errors.New("data export with the given ID already exists")
+	}
+
+	fpath := dataExportPath(e.ID)
+	if err := os.MkdirAll(filepath.Dir(fpath), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(fpath, content, 0600); err != nil {
+		return err
+	}
+
+	r.dataExportMap[e.ID] = e
+	return nil
+}
+
+func (r *repo) GetDataExport(ctx context.Context, id string) (entity.DataExport, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	export, ok := r.dataExportMap[id]
+	if !ok {
+		return entity.DataExport{}, errors.New("data export not found")
+	}
+
+	return export, nil
+}
+
+func (r *repo) GetDataExportContent(ctx context.Context, id string) ([]byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.dataExportMap[id]; !ok {
+		return nil, errors.New("data export not found")
+	}
+
+	return os.ReadFile(dataExportPath(id))
+}
+
+func (r *repo) DeleteUserDataExports(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, export := range r.dataExportMap {
+		if export.UserID != userID {
+			continue
+		}
+		if err := os.Remove(dataExportPath(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		delete(r.dataExportMap, id)
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"graphql-backend/entity"
+	"slices"
+)
+
+// AddProductImage appends the image to the product when it has less than limit images, it returns the updated product
+func (r *repo) AddProductImage(ctx context.Context, productID string, img entity.ProductImage, limit int) (entity.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	product, ok := r.productMap[productID]
+	if !ok {
+		return entity.Product{}, errors.New("product not found")
+	}
+	if len(product.Images) >= limit {
+		return entity.Product{}, fmt.Errorf("a product cannot have more than %d images", limit)
+	}
+
+	product.Images = append(slices.Clone(product.Images), img)
+	r.productMap[productID] = product
+	return product, nil
+}
+
+// RemoveProductImage removes the image from the product, it returns the updated product and the removed image
+func (r *repo) RemoveProductImage(ctx context.Context, productID string, id string) (entity.Product, entity.ProductImage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	product, ok := r.productMap[productID]
+	if !ok {
+		return entity.Product{}, entity.ProductImage{}, errors.New("product not found")
+	}
+	i := product.Image(id)
+	if i < 0 {
+		return entity.Product{}, entity.ProductImage{}, errors.New("image not found")
+	}
+
+	img := product.Images[i]
+	product.Images = slices.Delete(slices.Clone(product.Images), i, i+1)
+	r.productMap[productID] = product
+	return product, img, nil
+}
@@ -0,0 +1,129 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"graphql-backend/entity"
+	"slices"
+	"sort"
+)
+
+func (r *repo) GetPaymentByID(ctx context.Context, id string) (entity.Payment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	payment, ok := r.paymentMap[id]
+	if !ok {
+		return entity.Payment{}, errors.New("payment not found")
+	}
+
+	return payment, nil
+}
+
+func (r *repo) GetPaymentByReference(ctx context.Context, gateway string, reference string) (entity.Payment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, payment := range r.paymentMap {
+		if payment.Gateway == gateway && payment.Reference == reference {
+			return payment, nil
+		}
+	}
+
+	return entity.Payment{}, errors.New("payment not found")
+}
+
+// GetPaymentsByOrderIDs returns the payments of every order, oldest first
+func (r *repo) GetPaymentsByOrderIDs(ctx context.Context, orderIDs []string) (map[string][]entity.Payment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	wanted := make(map[string]bool, len(orderIDs))
+	for _, id := range orderIDs {
+		wanted[id] = true
+	}
+
+	payments := make(map[string][]entity.Payment, len(orderIDs))
+	for _, payment := range r.paymentMap {
+		if wanted[payment.OrderID] {
+			payments[payment.OrderID] = append(payments[payment.OrderID], payment)
+		}
+	}
+	for _, orderPayments := range payments {
+		sort.Slice(orderPayments, func(i, j int) bool {
+			return orderPayments[i].CreatedAt.Before(orderPayments[j].CreatedAt)
+		})
+	}
+
+	return payments, nil
+}
+
+// CreatePayment stores a new payment of the order, the order can only have one open payment at a time
+func (r *repo) CreatePayment(ctx context.Context, e entity.Payment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.paymentMap[e.ID]; exists {
+		return errors.New("payment with the given ID already exists")
+	}
+	for _, payment := range r.paymentMap {
+		if payment.OrderID == e.OrderID && payment.IsOpen() {
+			return errors.New("order already has a payment in progress")
+		}
+	}
+
+	r.paymentMap[e.ID] = e
+	return nil
+}
+
+func (r *repo) UpdatePayment(ctx context.Context, e entity.Payment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.paymentMap[e.ID]; !exists {
+		return errors.New("payment not found")
+	}
+
+	r.paymentMap[e.ID] = e
+	return nil
+}
+
+func (r *repo) UpdateOrderStatus(ctx context.Context, id string, status entity.OrderStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	order, exists := r.orderMap[id]
+	if !exists {
+		return errors.New("order not found")
+	}
+	if !order.Status.CanChangeTo(status) {
+		return fmt.Errorf("order cannot change from %s to %s", order.Status, status)
+	}
+
+	r.releasePromotions(order, status)
+	r.releaseStock(order, status)
+	order.Status = status
+	r.orderMap[id] = order
+	return nil
+}
+
+func (r *repo) ClaimOrder(ctx context.Context, id string, from []entity.OrderStatus, to entity.OrderStatus) (entity.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	order, exists := r.orderMap[id]
+	if !exists {
+		return entity.Order{}, errors.New("order not found")
+	}
+	if !slices.Contains(from, order.Status) {
+		return entity.Order{}, fmt.Errorf("order is %s", order.Status)
+	}
+
+	claimed := order
+	r.releasePromotions(claimed, to)
+	r.releaseStock(claimed, to)
+	claimed.Status = to
+	r.orderMap[id] = claimed
+	return order, nil
+}
@@ -0,0 +1,213 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"graphql-backend/app"
+	"graphql-backend/entity"
+	"sort"
+	"time"
+)
+
+// GetPriceChangesByProductIDs returns the price history of every product, newest first
+func (r *repo) GetPriceChangesByProductIDs(ctx context.Context, productIDs []string) (map[string][]entity.PriceChange, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	wanted := make(map[string]bool, len(productIDs))
+	for _, id := range productIDs {
+		wanted[id] = true
+	}
+
+	changes := make(map[string][]entity.PriceChange, len(productIDs))
+	for _, change := range r.priceChangeMap {
+		if wanted[change.ProductID] {
+			changes[change.ProductID] = append(changes[change.ProductID], change)
+		}
+	}
+	for _, productChanges := range changes {
+		sort.Slice(productChanges, func(i, j int) bool {
+			if productChanges[i].ChangedAt.Equal(productChanges[j].ChangedAt) {
+				return productChanges[i].ID > productChanges[j].ID
+			}
+			return productChanges[i].ChangedAt.After(productChanges[j].ChangedAt)
+		})
+	}
+
+	return changes, nil
+}
+
+func (r *repo) CreatePriceChange(ctx context.Context, e entity.PriceChange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.priceChangeMap[e.ID]; exists {
+		return errors.New("price change with the given ID already exists")
+	}
+	if _, exists := r.productMap[e.ProductID]; !exists {
+		return errors.New("product not found")
+	}
+
+	r.priceChangeMap[e.ID] = e
+	return nil
+}
+
+// SetProductPrice changes the price of the product only when it is still from, so that concurrent changes of the
+// product are kept
+func (r *repo) SetProductPrice(ctx context.Context, productID string, from, to entity.Money) (entity.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	product, ok := r.productMap[productID]
+	if !ok {
+		return entity.Product{}, errors.New("product not found")
+	}
+	if product.Price != from {
+		return entity.Product{}, app.ErrPriceChanged
+	}
+
+	product.Price = to
+	r.productMap[productID] = product
+	return product, nil
+}
+
+func (r *repo) GetScheduledPriceByID(ctx context.Context, id string) (entity.ScheduledPrice, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	scheduled, ok := r.scheduledPriceMap[id]
+	if !ok {
+		return entity.ScheduledPrice{}, errors.New("scheduled price not found")
+	}
+
+	return scheduled, nil
+}
+
+// GetScheduledPrices returns the scheduled prices in the order they start
+func (r *repo) GetScheduledPrices(ctx context.Context, prs app.ScheduledPricesParams) ([]entity.ScheduledPrice, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	limit := *prs.Limit
+	offset := *prs.Offset
+
+	var scheduledPrices []entity.ScheduledPrice
+	for _, scheduled := range r.scheduledPriceMap {
+		if prs.Status != nil && scheduled.Status != *prs.Status {
+			continue
+		}
+		if prs.ProductID != "" && scheduled.ProductID != prs.ProductID {
+			continue
+		}
+		scheduledPrices = append(scheduledPrices, scheduled)
+	}
+
+	sortScheduledPrices(scheduledPrices)
+
+	start := offset
+	end := offset + limit
+	if int(start) > len(scheduledPrices) {
+		return []entity.ScheduledPrice{}, nil
+	}
+	if int(end) > len(scheduledPrices) {
+		end = int32(len(scheduledPrices))
+	}
+
+	return scheduledPrices[start:end], nil
+}
+
+// GetDueScheduledPrices returns the scheduled prices that start or end by the given time, in the order they are due
+func (r *repo) GetDueScheduledPrices(ctx context.Context, now time.Time) ([]entity.ScheduledPrice, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var due []entity.ScheduledPrice
+	for _, scheduled := range r.scheduledPriceMap {
+		if dueAt, ok := scheduledPriceDueAt(scheduled); ok && !dueAt.After(now) {
+			due = append(due, scheduled)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		a, _ := scheduledPriceDueAt(due[i])
+		b, _ := scheduledPriceDueAt(due[j])
+		if a.Equal(b) {
+			// a sale ending makes way for the next one starting at the same time
+			return due[i].Status == entity.ScheduledPriceStatusActive && due[j].Status != entity.ScheduledPriceStatusActive
+		}
+		return a.Before(b)
+	})
+
+	return due, nil
+}
+
+// CreateScheduledPrice stores a new scheduled price, it cannot overlap the pending ones of the product
+func (r *repo) CreateScheduledPrice(ctx context.Context, e entity.ScheduledPrice) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.scheduledPriceMap[e.ID]; exists {
+		return errors.New("scheduled price with the given ID already exists")
+	}
+	if _, exists := r.productMap[e.ProductID]; !exists {
+		return errors.New("product not found")
+	}
+	for _, scheduled := range r.scheduledPriceMap {
+		if scheduled.ProductID == e.ProductID && scheduled.IsPending() && scheduled.Overlaps(e) {
+			return errors.New("product already has a scheduled price at that time")
+		}
+	}
+
+	r.scheduledPriceMap[e.ID] = e
+	return nil
+}
+
+func (r *repo) UpdateScheduledPrice(ctx context.Context, e entity.ScheduledPrice) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.scheduledPriceMap[e.ID]; !exists {
+		return errors.New("scheduled price not found")
+	}
+
+	r.scheduledPriceMap[e.ID] = e
+	return nil
+}
+
+func (r *repo) ClaimScheduledPrice(ctx context.Context, id string, from, to entity.ScheduledPriceStatus) (entity.ScheduledPrice, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	scheduled, ok := r.scheduledPriceMap[id]
+	if !ok {
+		return entity.ScheduledPrice{}, errors.New("scheduled price not found")
+	}
+	if scheduled.Status != from {
+		return entity.ScheduledPrice{}, fmt.Errorf("scheduled price is %s", scheduled.Status)
+	}
+
+	scheduled.Status = to
+	r.scheduledPriceMap[id] = scheduled
+	return scheduled, nil
+}
+
+// scheduledPriceDueAt returns when the scheduled price is next due to start or end, if ever
+func scheduledPriceDueAt(e entity.ScheduledPrice) (time.Time, bool) {
+	switch {
+	case e.Status == entity.ScheduledPriceStatusScheduled:
+		return e.StartsAt, true
+	case e.Status == entity.ScheduledPriceStatusActive && e.EndsAt != nil:
+		return *e.EndsAt, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func sortScheduledPrices(scheduledPrices []entity.ScheduledPrice) {
+	sort.Slice(scheduledPrices, func(i, j int) bool {
+		if scheduledPrices[i].StartsAt.Equal(scheduledPrices[j].StartsAt) {
+			return scheduledPrices[i].ID < scheduledPrices[j].ID
+		}
+		return scheduledPrices[i].StartsAt.Before(scheduledPrices[j].StartsAt)
+	})
+}
@@ -0,0 +1,164 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"graphql-backend/app"
+	"graphql-backend/entity"
+	"sort"
+	"strings"
+)
+
+func (r *repo) GetPromotions(ctx context.Context, prs app.PromotionsParams) ([]entity.Promotion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	limit := *prs.Limit
+	offset := *prs.Offset
+
+	var promotions []entity.Promotion
+	for _, promotion := range r.promotionMap {
+		if prs.Active != nil && promotion.Active != *prs.Active {
+			continue
+		}
+		promotions = append(promotions, promotion)
+	}
+
+	sort.Slice(promotions, func(i, j int) bool {
+		if promotions[i].CreatedAt.Equal(promotions[j].CreatedAt) {
+			return promotions[i].ID < promotions[j].ID
+		}
+		return promotions[i].CreatedAt.After(promotions[j].CreatedAt)
+	})
+
+	start := offset
+	end := offset + limit
+	if int(start) > len(promotions) {
+		return []entity.Promotion{}, nil
+	}
+	if int(end) > len(promotions) {
+		end = int32(len(promotions))
+	}
+
+	return promotions[start:end], nil
+}
+
+func (r *repo) GetPromotionByID(ctx context.Context, id string) (entity.Promotion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	promotion, ok := r.promotionMap[id]
+	if !ok {
+		return entity.Promotion{}, errors.New("promotion not found")
+	}
+
+	return promotion, nil
+}
+
+func (r *repo) GetPromotionByCode(ctx context.Context, code string) (entity.Promotion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, promotion := range r.promotionMap {
+		if strings.EqualFold(promotion.Code, code) {
+			return promotion, nil
+		}
+	}
+
+	return entity.Promotion{}, errors.New("promotion not found")
+}
+
+func (r *repo) CreatePromotion(ctx context.Context, e entity.Promotion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.promotionMap[e.ID]; exists {
+		return errors.New("promotion with the given ID already exists")
+	}
+	if err := r.checkPromotionCode(e); err != nil {
+		return err
+	}
+
+	r.promotionMap[e.ID] = e
+	return nil
+}
+
+func (r *repo) UpdatePromotion(ctx context.Context, e entity.Promotion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, exists := r.promotionMap[e.ID]
+	if !exists {
+		return errors.New("promotion not found")
+	}
+	if err := r.checkPromotionCode(e); err != nil {
+		return err
+	}
+
+	// the usage is only counted by placed orders
+	e.UsageCount = stored.UsageCount
+	r.promotionMap[e.ID] = e
+	return nil
+}
+
+func (r *repo) checkPromotionCode(e entity.Promotion) error {
+	for _, promotion := range r.promotionMap {
+		if promotion.ID != e.ID && strings.EqualFold(promotion.Code, e.Code) {
+			return errors.New("promotion with the given code already exists")
+		}
+	}
+	return nil
+}
+
+// checkPromotionLimits checks the usage limits of the promotions applied to an order, cancelled orders don't count.
+// It has to be called under the write lock, right before redeemPromotions
+func (r *repo) checkPromotionLimits(order entity.Order) error {
+	for _, discount := range order.Discounts {
+		promotion, ok := r.promotionMap[discount.PromotionID]
+		if !ok {
+			return errors.New("promotion not found")
+		}
+
+		if promotion.UsageLimit != nil && promotion.UsageCount >= *promotion.UsageLimit {
+			return errors.New("coupon usage limit has been reached")
+		}
+
+		if promotion.UsageLimitPerUser != nil {
+			var used int32
+			for _, o := range r.orderMap {
+				if o.UserID == order.UserID && o.Status != entity.OrderStatusCancelled && o.HasPromotion(promotion.ID) {
+					used++
+				}
+			}
+			if used >= *promotion.UsageLimitPerUser {
+				return errors.New("coupon has already been used")
+			}
+		}
+	}
+	return nil
+}
+
+func (r *repo) redeemPromotions(order entity.Order) {
+	for _, discount := range order.Discounts {
+		promotion := r.promotionMap[discount.PromotionID]
+		promotion.UsageCount++
+		r.promotionMap[discount.PromotionID] = promotion
+	}
+}
+
+// releasePromotions gives back the uses of the promotions of an order that is being cancelled,
+// it has to be called under the write lock
+func (r *repo) releasePromotions(stored entity.Order, status entity.OrderStatus) {
+	if stored.Status == entity.OrderStatusCancelled || status != entity.OrderStatusCancelled {
+		return
+	}
+
+	for _, discount := range stored.Discounts {
+		promotion, ok := r.promotionMap[discount.PromotionID]
+		if !ok || promotion.UsageCount == 0 {
+			continue
+		}
+		promotion.UsageCount--
+		r.promotionMap[discount.PromotionID] = promotion
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"graphql-backend/entity"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -21,22 +23,70 @@ type ProductMap map[string]entity.Product
 
 type OrderMap map[string]entity.Order
 
+type APIKeyMap map[string]entity.APIKey
+
+type AuditMap map[string]entity.AuditEntry
+
+type SessionMap map[string]entity.Session
+
+type DataExportMap map[string]entity.DataExport
+
+type AddressMap map[string]entity.Address
+
+type CartMap map[string]entity.Cart
+
+type PromotionMap map[string]entity.Promotion
+
+type PaymentMap map[string]entity.Payment
+
+type ReturnMap map[string]entity.ReturnRequest
+
+type CategoryMap map[string]entity.Category
+
+type ReviewMap map[string]entity.Review
+
+// WishlistMap is keyed by user ID, users have a single wishlist
+type WishlistMap map[string]entity.Wishlist
+
+type PriceChangeMap map[string]entity.PriceChange
+
+type ScheduledPriceMap map[string]entity.ScheduledPrice
+
 // this repo implements the app.Repo interface
 // we will use in-memory data for simplicity, and interval update it to json file
 type repo struct {
 	mu sync.RWMutex
+	// fileMu keeps the periodic writes and Flush from writing the files at the same time
+	fileMu sync.Mutex
 
 	userMap    UserMap
 	productMap ProductMap
 	orderMap   OrderMap
+	apiKeyMap  APIKeyMap
+	auditMap   AuditMap
+	sessionMap SessionMap
+
+	dataExportMap DataExportMap
+	addressMap    AddressMap
+	cartMap       CartMap
+	promotionMap  PromotionMap
+	paymentMap    PaymentMap
+	returnMap     ReturnMap
+	categoryMap   CategoryMap
+	reviewMap     ReviewMap
+	wishlistMap   WishlistMap
+
+	priceChangeMap    PriceChangeMap
+	scheduledPriceMap ScheduledPriceMap
 }
 
+// GetUserByEmail ignores the case of the email, users don't always type it the same way
 func (r *repo) GetUserByEmail(ctx context.Context, email string) (entity.User, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	for _, user := range r.userMap {
-		if user.Email == email {
+		if strings.EqualFold(user.Email, email) {
 			return user, nil
 		}
 	}
@@ -44,6 +94,62 @@ func (r *repo) GetUserByEmail(ctx context.Context, email string) (entity.User, e
 	return entity.User{}, errors.New("user not found")
 }
 
+func (r *repo) GetUserByIdentity(ctx context.Context, issuer, subject string) (entity.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.userMap {
+		for _, identity := range user.Identities {
+			if identity.Issuer == issuer && identity.Subject == subject {
+				return user, nil
+			}
+		}
+	}
+
+	return entity.User{}, errors.New("user not found")
+}
+
+func (r *repo) CreateUser(ctx context.Context, e entity.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.userMap[e.ID]; exists {
+		return errors.New("user with the given ID already exists")
+	}
+
+	if r.emailTaken(e) {
+		return errors.New("user with the given email already exists")
+	}
+
+	r.userMap[e.ID] = e
+	return nil
+}
+
+func (r *repo) UpdateUser(ctx context.Context, e entity.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.userMap[e.ID]; !exists {
+		return errors.New("user not found")
+	}
+	if r.emailTaken(e) {
+		return errors.New("user with the given email already exists")
+	}
+
+	r.userMap[e.ID] = e
+	return nil
+}
+
+// emailTaken reports whether another user has the email of the user, emails are compared case-insensitively
+func (r *repo) emailTaken(e entity.User) bool {
+	for _, user := range r.userMap {
+		if user.ID != e.ID && strings.EqualFold(user.Email, e.Email) {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *repo) CreateProduct(ctx context.Context, e entity.Product) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -51,20 +157,115 @@ func (r *repo) CreateProduct(ctx context.Context, e entity.Product) error {
 	if _, exists := r.productMap[e.ID]; exists {
 		return errors.New("product with the given ID already exists")
 	}
+	if err := r.checkSKUs(e); err != nil {
+		return err
+	}
 
+	r.setProductCategory(&e)
 	r.productMap[e.ID] = e
 	return nil
 }
 
-func (r *repo) UpdateProduct(ctx context.Context, e entity.Product) error {
+func (r *repo) SaveProduct(ctx context.Context, update app.ProductUpdate) (entity.Product, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.productMap[e.ID]; !exists {
-		return errors.New("product not found")
+	stored, exists := r.productMap[update.Product.ID]
+	if !exists {
+		return entity.Product{}, errors.New("product not found")
+	}
+	e, err := update.Apply(stored)
+	if err != nil {
+		return entity.Product{}, err
+	}
+	err = r.checkSKUs(e)
+	if err != nil {
+		return entity.Product{}, err
 	}
 
+	r.setProductCategory(&e)
 	r.productMap[e.ID] = e
+	return e, nil
+}
+
+func (r *repo) SetProductArchived(ctx context.Context, id string, archivedAt *time.Time) (entity.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	product, exists := r.productMap[id]
+	if !exists {
+		return entity.Product{}, errors.New("product not found")
+	}
+
+	product.ArchivedAt = archivedAt
+	r.productMap[id] = product
+	return product, nil
+}
+
+// DeleteProduct deletes a product no order or promotion refers to, it is removed from the carts and wishlists that have it.
+// Its price history and scheduled prices go with it.
+func (r *repo) DeleteProduct(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.productMap[id]; !exists {
+		return errors.New("product not found")
+	}
+	for _, order := range r.orderMap {
+		if slices.Contains(order.ProductIDs, id) {
+			return errors.New("product has orders, archive it instead")
+		}
+	}
+	for _, promotion := range r.promotionMap {
+		if slices.Contains(promotion.ProductIDs, id) {
+			return fmt.Errorf("product is used by promotion %s", promotion.Code)
+		}
+	}
+
+	for cartID, cart := range r.cartMap {
+		items := slices.DeleteFunc(slices.Clone(cart.Items), func(item entity.CartItem) bool { return item.ProductID == id })
+		if len(items) != len(cart.Items) {
+			cart.Items = items
+			r.cartMap[cartID] = cart
+		}
+	}
+	for userID, wishlist := range r.wishlistMap {
+		if i := wishlist.Item(id); i >= 0 {
+			wishlist.Items = slices.Delete(slices.Clone(wishlist.Items), i, i+1)
+			r.wishlistMap[userID] = wishlist
+		}
+	}
+	for changeID, change := range r.priceChangeMap {
+		if change.ProductID == id {
+			delete(r.priceChangeMap, changeID)
+		}
+	}
+	for scheduledID, scheduled := range r.scheduledPriceMap {
+		if scheduled.ProductID == id {
+			delete(r.scheduledPriceMap, scheduledID)
+		}
+	}
+	delete(r.productMap, id)
+	return nil
+}
+
+// checkSKUs checks that the SKUs of the product and its variants are not used by other products
+func (r *repo) checkSKUs(e entity.Product) error {
+	skus := e.SKUs()
+	if len(skus) == 0 {
+		return nil
+	}
+
+	for _, product := range r.productMap {
+		if product.ID == e.ID {
+			continue
+		}
+		for _, sku := range product.SKUs() {
+			if slices.Contains(skus, sku) {
+				return fmt.Errorf("SKU %s is used by another product", sku)
+			}
+		}
+	}
 	return nil
 }
 
@@ -75,7 +276,32 @@ func (r *repo) CreateOrder(ctx context.Context, e entity.Order) error {
 	if _, exists := r.orderMap[e.ID]; exists {
 		return errors.New("order with the given ID already exists")
 	}
+	if err := r.checkPromotionLimits(e); err != nil {
+		return err
+	}
+	if e.StockReserved {
+		if err := r.checkStock(e); err != nil {
+			return err
+		}
+		r.reserveStock(e)
+	}
+
+	r.redeemPromotions(e)
+	r.orderMap[e.ID] = e
+	return nil
+}
+
+func (r *repo) UpdateOrder(ctx context.Context, e entity.Order) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
+	stored, exists := r.orderMap[e.ID]
+	if !exists {
+		return errors.New("order not found")
+	}
+
+	r.releasePromotions(stored, e.Status)
+	r.releaseStock(stored, e.Status)
 	r.orderMap[e.ID] = e
 	return nil
 }
@@ -93,6 +319,59 @@ func (r *repo) GetProductsByIDs(ctx context.Context, ids []string) ([]entity.Pro
 	return products, nil
 }
 
+func (r *repo) GetAllProducts(ctx context.Context) ([]entity.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	products := make([]entity.Product, 0, len(r.productMap))
+	for _, product := range r.productMap {
+		products = append(products, product)
+	}
+	return products, nil
+}
+
+// SaveProducts creates the new products and merges the updates onto the stored products at once,
+// none is saved when a product no longer exists or the SKUs of any are taken
+func (r *repo) SaveProducts(ctx context.Context, updates []app.ProductUpdate) ([]entity.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	es := make([]entity.Product, len(updates))
+	for i, update := range updates {
+		stored, exists := r.productMap[update.Product.ID]
+		switch {
+		case update.Created && exists:
+			return nil, errors.New("product with the given ID already exists")
+		case update.Created:
+			es[i] = update.Product
+		case !exists:
+			return nil, fmt.Errorf("product %s was deleted during the import", update.Product.Name)
+		default:
+			es[i] = update.Merge(stored)
+		}
+	}
+
+	skus := make(map[string]string)
+	for _, e := range es {
+		if err := r.checkSKUs(e); err != nil {
+			return nil, err
+		}
+		for _, sku := range e.SKUs() {
+			if id, taken := skus[sku]; taken && id != e.ID {
+				return nil, fmt.Errorf("SKU %s is used by another product", sku)
+			}
+			skus[sku] = e.ID
+		}
+	}
+
+	for i := range es {
+		keepRating(&es[i], r.productMap[es[i].ID])
+		r.setProductCategory(&es[i])
+		r.productMap[es[i].ID] = es[i]
+	}
+	return es, nil
+}
+
 func (r *repo) GetOrders(ctx context.Context, prs app.OrdersParams) ([]entity.Order, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -106,6 +385,7 @@ func (r *repo) GetOrders(ctx context.Context, prs app.OrdersParams) ([]entity.Or
 			orders = append(orders, order)
 		}
 	}
+	sortOrders(orders)
 
 	start := offset
 	end := offset + limit
@@ -119,13 +399,46 @@ func (r *repo) GetOrders(ctx context.Context, prs app.OrdersParams) ([]entity.Or
 	return orders[start:end], nil
 }
 
+func (r *repo) GetOrdersByUserIDs(ctx context.Context, userIDs []string) (map[string][]entity.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	orders := make(map[string][]entity.Order, len(userIDs))
+	for _, id := range userIDs {
+		orders[id] = []entity.Order{}
+	}
+	for _, order := range r.orderMap {
+		if userOrders, ok := orders[order.UserID]; ok {
+			orders[order.UserID] = append(userOrders, order)
+		}
+	}
+	for _, userOrders := range orders {
+		sortOrders(userOrders)
+	}
+
+	return orders, nil
+}
+
+// sortOrders sorts the most recent orders first
+func sortOrders(orders []entity.Order) {
+	sort.Slice(orders, func(i, j int) bool {
+		if orders[i].CreatedAt.Equal(orders[j].CreatedAt) {
+			return orders[i].ID < orders[j].ID
+		}
+		return orders[i].CreatedAt.After(orders[j].CreatedAt)
+	})
+}
+
 func (r *repo) GetOrder(ctx context.Context, prs app.OrderParams) (entity.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	order, ok := r.orderMap[prs.ID]
 	if !ok {
 		return entity.Order{}, errors.New("order not found")
 	}
 
-	if prs.UserID != order.UserID {
+	if prs.UserID != "" && prs.UserID != order.UserID {
 		return entity.Order{}, errors.New("order not found")
 	}
 
@@ -148,8 +461,23 @@ func (r *repo) GetProducts(ctx context.Context, prs app.ProductsParams) ([]entit
 	limit := *prs.Limit
 	offset := *prs.Offset
 
+	var categoryIDs map[string]bool
+	if prs.CategoryID != nil && *prs.CategoryID != "" {
+		if _, ok := r.categoryMap[*prs.CategoryID]; !ok {
+			return nil, errors.New("category not found")
+		}
+		categoryIDs = r.categoryDescendants(*prs.CategoryID)
+	}
+
 	var products []entity.Product
 	for _, product := range r.productMap {
+		if product.IsArchived() != prs.Archived {
+			continue
+		}
+		if categoryIDs != nil && !categoryIDs[product.CategoryID] {
+			continue
+		}
+
 		if prs.Category == nil || *prs.Category == "" {
 			products = append(products, product)
 			continue
@@ -186,7 +514,54 @@ func (r *repo) GetUsersByIDs(ctx context.Context, userIDs []string) ([]entity.Us
 	return users, nil
 }
 
+func (r *repo) GetUsers(ctx context.Context, prs app.UsersParams) ([]entity.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	limit := *prs.Limit
+	offset := *prs.Offset
+	search := strings.ToLower(*prs.Search)
+
+	var users []entity.User
+	for _, user := range r.userMap {
+		if prs.Role != nil && user.Role != *prs.Role {
+			continue
+		}
+		if prs.Status != nil && user.GetStatus() != *prs.Status {
+			continue
+		}
+		if search != "" &&
+			!strings.Contains(strings.ToLower(user.Name), search) &&
+			!strings.Contains(strings.ToLower(user.Email), search) {
+			continue
+		}
+		users = append(users, user)
+	}
+
+	// stable pages, oldest first
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].CreatedAt.Equal(users[j].CreatedAt) {
+			return users[i].ID < users[j].ID
+		}
+		return users[i].CreatedAt.Before(users[j].CreatedAt)
+	})
+
+	start := offset
+	end := offset + limit
+	if int(start) > len(users) {
+		return []entity.User{}, nil
+	}
+	if int(end) > len(users) {
+		end = int32(len(users))
+	}
+
+	return users[start:end], nil
+}
+
 func (r *repo) GetUserByID(ctx context.Context, userID string) (entity.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	user, ok := r.userMap[userID]
 	if !ok {
 		return entity.User{}, errors.New("user not found")
@@ -215,33 +590,102 @@ func NewRepo(ctx context.Context) app.Repo {
 	usersPath := filepath.Join(dir, "users.json")
 	productsPath := filepath.Join(dir, "products.json")
 	ordersPath := filepath.Join(dir, "orders.json")
+	apiKeysPath := filepath.Join(dir, "api_keys.json")
+	auditPath := filepath.Join(dir, "audit_log.json")
+	sessionsPath := filepath.Join(dir, "sessions.json")
+	dataExportsPath := filepath.Join(dir, "data_exports.json")
+	addressesPath := filepath.Join(dir, "addresses.json")
+	cartsPath := filepath.Join(dir, "carts.json")
+	promotionsPath := filepath.Join(dir, "promotions.json")
+	paymentsPath := filepath.Join(dir, "payments.json")
+	returnsPath := filepath.Join(dir, "returns.json")
+	categoriesPath := filepath.Join(dir, "categories.json")
+	reviewsPath := filepath.Join(dir, "reviews.json")
+	wishlistsPath := filepath.Join(dir, "wishlists.json")
+	priceChangesPath := filepath.Join(dir, "price_changes.json")
+	scheduledPricesPath := filepath.Join(dir, "scheduled_prices.json")
 
 	userMap := UserMap{}
 	productMap := ProductMap{}
 	orderMap := OrderMap{}
+	apiKeyMap := APIKeyMap{}
+	auditMap := AuditMap{}
+	sessionMap := SessionMap{}
+	dataExportMap := DataExportMap{}
+	addressMap := AddressMap{}
+	cartMap := CartMap{}
+	promotionMap := PromotionMap{}
+	paymentMap := PaymentMap{}
+	returnMap := ReturnMap{}
+	categoryMap := CategoryMap{}
+	reviewMap := ReviewMap{}
+	wishlistMap := WishlistMap{}
+	priceChangeMap := PriceChangeMap{}
+	scheduledPriceMap := ScheduledPriceMap{}
 
 	// Try to load from files, fallback to seed if not found
 	_ = loadMapFromFile(usersPath, (*map[string]entity.User)(&userMap))
 	_ = loadMapFromFile(productsPath, (*map[string]entity.Product)(&productMap))
 	_ = loadMapFromFile(ordersPath, (*map[string]entity.Order)(&orderMap))
+	_ = loadMapFromFile(apiKeysPath, (*map[string]entity.APIKey)(&apiKeyMap))
+	_ = loadMapFromFile(auditPath, (*map[string]entity.AuditEntry)(&auditMap))
+	_ = loadMapFromFile(sessionsPath, (*map[string]entity.Session)(&sessionMap))
+	_ = loadMapFromFile(dataExportsPath, (*map[string]entity.DataExport)(&dataExportMap))
+	_ = loadMapFromFile(addressesPath, (*map[string]entity.Address)(&addressMap))
+	_ = loadMapFromFile(cartsPath, (*map[string]entity.Cart)(&cartMap))
+	_ = loadMapFromFile(promotionsPath, (*map[string]entity.Promotion)(&promotionMap))
+	_ = loadMapFromFile(paymentsPath, (*map[string]entity.Payment)(&paymentMap))
+	_ = loadMapFromFile(returnsPath, (*map[string]entity.ReturnRequest)(&returnMap))
+	_ = loadMapFromFile(categoriesPath, (*map[string]entity.Category)(&categoryMap))
+	_ = loadMapFromFile(reviewsPath, (*map[string]entity.Review)(&reviewMap))
+	_ = loadMapFromFile(wishlistsPath, (*map[string]entity.Wishlist)(&wishlistMap))
+	_ = loadMapFromFile(priceChangesPath, (*map[string]entity.PriceChange)(&priceChangeMap))
+	_ = loadMapFromFile(scheduledPricesPath, (*map[string]entity.ScheduledPrice)(&scheduledPriceMap))
+
+	// Amounts stored as plain numbers are read as money of the default currency, see entity.Money.
+	// Orders stored before subtotals and currencies were recorded only have a total in the base currency.
+	for id, order := range orderMap {
+		if order.Subtotal.Currency == "" && len(order.Discounts) == 0 {
+			order.Subtotal = order.Total
+		}
+		if order.Currency == "" {
+			order.Currency = entity.DefaultCurrency
+			order.ExchangeRate = 1
+		}
+		orderMap[id] = order
+	}
+
+	// Products stored before categories were entities only have a category name, it becomes a top-level category
+	for id, product := range productMap {
+		if product.CategoryID == "" && product.Category != "" {
+			product.CategoryID = getOrCreateCategory(categoryMap, product.Category).ID
+			productMap[id] = product
+		}
+	}
 
 	// If userMap is empty, seed data for testing purposes
 	if len(userMap) == 0 {
 		adminID := uuid.NewString()
 		customerID := uuid.NewString()
 		userMap[adminID] = entity.User{
-			ID:       adminID,
-			Name:     "Admin User",
-			Email:    "admin@example.com",
-			Password: "secret",
-			Role:     "Admin",
+			ID:        adminID,
+			Name:      "Admin User",
+			Email:     "admin@example.com",
+			Password:  "secret",
+			Role:      entity.RoleAdmin,
+			Status:    entity.UserStatusActive,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
 		}
 		userMap[customerID] = entity.User{
-			ID:       customerID,
-			Name:     "Customer User",
-			Email:    "customer@example.com",
-			Password: "secret",
-			Role:     "Customer",
+			ID:        customerID,
+			Name:      "Customer User",
+			Email:     "customer@example.com",
+			Password:  "secret",
+			Role:      entity.RoleCustomer,
+			Status:    entity.UserStatusActive,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
 		}
 	}
 
@@ -250,6 +694,22 @@ func NewRepo(ctx context.Context) app.Repo {
 		userMap:    userMap,
 		productMap: productMap,
 		orderMap:   orderMap,
+		apiKeyMap:  apiKeyMap,
+		auditMap:   auditMap,
+		sessionMap: sessionMap,
+
+		dataExportMap: dataExportMap,
+		addressMap:    addressMap,
+		cartMap:       cartMap,
+		promotionMap:  promotionMap,
+		paymentMap:    paymentMap,
+		returnMap:     returnMap,
+		categoryMap:   categoryMap,
+		reviewMap:     reviewMap,
+		wishlistMap:   wishlistMap,
+
+		priceChangeMap:    priceChangeMap,
+		scheduledPriceMap: scheduledPriceMap,
 	}
 
 	// write data to file in a separate goroutine and periodically update it
@@ -259,47 +719,83 @@ func NewRepo(ctx context.Context) app.Repo {
 }
 
 func (r *repo) WriteDataToFile(ctx context.Context) {
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Println("Stop writing data to file due to context cancellation")
+			return
+		case <-ticker.C:
+			r.writeDataFiles()
+		}
+	}
+}
+
+// Flush writes the data of the repo to its files right away, e.g. before a command line tool exits
+func Flush(repository app.Repo) error {
+	r, ok := repository.(*repo)
+	if !ok {
+		return errors.New("repo is not stored in files")
+	}
+	return r.writeDataFiles()
+}
+
+func (r *repo) writeDataFiles() error {
+	r.fileMu.Lock()
+	defer r.fileMu.Unlock()
+
 	dir := filepath.Join("store", "data")
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		fmt.Println("Failed to create data directory:", err)
-		return
+		return err
 	}
 
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
-
+	var errs []error
 	write := func(filename string, data any) {
 		fpath := filepath.Join(dir, filename)
 		f, err := os.Create(fpath)
 		if err != nil {
 			fmt.Println("Failed to create file", fpath, ":", err)
+			errs = append(errs, err)
 			return
 		}
 		defer func(f *os.File) {
 			err := f.Close()
 			if err != nil {
 				fmt.Println("Failed to close file", fpath, ":", err)
+				errs = append(errs, err)
 			}
 		}(f)
 		enc := json.NewEncoder(f)
 		enc.SetIndent("", "  ")
 		if err := enc.Encode(data); err != nil {
 			fmt.Println("Failed to write data to", fpath, ":", err)
+			errs = append(errs, err)
 		}
 	}
 
-	for {
-		select {
-		case <-ctx.Done():
-			fmt.Println("Stop writing data to file due to context cancellation")
-			return
-		case <-ticker.C:
-			r.mu.RLock()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 
-			write("users.json", r.userMap)
-			write("products.json", r.productMap)
-			write("orders.json", r.orderMap)
-			r.mu.RUnlock()
-		}
-	}
+	write("users.json", r.userMap)
+	write("products.json", r.productMap)
+	write("orders.json", r.orderMap)
+	write("api_keys.json", r.apiKeyMap)
+	write("audit_log.json", r.auditMap)
+	write("sessions.json", r.sessionMap)
+	write("data_exports.json", r.dataExportMap)
+	write("addresses.json", r.addressMap)
+	write("carts.json", r.cartMap)
+	write("promotions.json", r.promotionMap)
+	write("payments.json", r.paymentMap)
+	write("returns.json", r.returnMap)
+	write("categories.json", r.categoryMap)
+	write("reviews.json", r.reviewMap)
+	write("wishlists.json", r.wishlistMap)
+	write("price_changes.json", r.priceChangeMap)
+	write("scheduled_prices.json", r.scheduledPriceMap)
+
+	return errors.Join(errs...)
 }
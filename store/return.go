@@ -0,0 +1,166 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"graphql-backend/app"
+	"graphql-backend/entity"
+	"sort"
+)
+
+func (r *repo) GetReturnByID(ctx context.Context, id string) (entity.ReturnRequest, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	request, ok := r.returnMap[id]
+	if !ok {
+		return entity.ReturnRequest{}, errors.New("return request not found")
+	}
+
+	return request, nil
+}
+
+// GetReturns returns the return requests oldest first, the order in which they are reviewed
+func (r *repo) GetReturns(ctx context.Context, prs app.ReturnsParams) ([]entity.ReturnRequest, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	limit := *prs.Limit
+	offset := *prs.Offset
+
+	var requests []entity.ReturnRequest
+	for _, request := range r.returnMap {
+		if prs.Status != nil && request.Status != *prs.Status {
+			continue
+		}
+		requests = append(requests, request)
+	}
+
+	sortReturns(requests)
+
+	start := offset
+	end := offset + limit
+	if int(start) > len(requests) {
+		return []entity.ReturnRequest{}, nil
+	}
+	if int(end) > len(requests) {
+		end = int32(len(requests))
+	}
+
+	return requests[start:end], nil
+}
+
+// GetReturnsByOrderIDs returns the return requests of every order, oldest first
+func (r *repo) GetReturnsByOrderIDs(ctx context.Context, orderIDs []string) (map[string][]entity.ReturnRequest, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	wanted := make(map[string]bool, len(orderIDs))
+	for _, id := range orderIDs {
+		wanted[id] = true
+	}
+
+	requests := make(map[string][]entity.ReturnRequest, len(orderIDs))
+	for _, request := range r.returnMap {
+		if wanted[request.OrderID] {
+			requests[request.OrderID] = append(requests[request.OrderID], request)
+		}
+	}
+	for _, orderRequests := range requests {
+		sortReturns(orderRequests)
+	}
+
+	return requests, nil
+}
+
+// CreateReturn stores a new return request, the quantities of the open and approved requests of the order
+// can't exceed the ordered ones
+func (r *repo) CreateReturn(ctx context.Context, e entity.ReturnRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.returnMap[e.ID]; exists {
+		return errors.New("return request with the given ID already exists")
+	}
+	order, ok := r.orderMap[e.OrderID]
+	if !ok {
+		return errors.New("order not found")
+	}
+
+	returnable := map[entity.ItemKey]int32{}
+	for _, item := range order.Items {
+		returnable[item.Key()] += item.Quantity
+	}
+	for _, request := range r.returnMap {
+		if request.OrderID != e.OrderID || request.Status == entity.ReturnStatusRejected {
+			continue
+		}
+		for _, item := range request.Items {
+			returnable[item.Key()] -= item.Quantity
+		}
+	}
+	for _, item := range e.Items {
+		if item.Quantity > returnable[item.Key()] {
+			return fmt.Errorf("only %d of product %s can still be returned", max(returnable[item.Key()], 0), item.ProductID)
+		}
+	}
+
+	r.returnMap[e.ID] = e
+	return nil
+}
+
+func (r *repo) ClaimReturn(ctx context.Context, id string, from, to entity.ReturnStatus) (entity.ReturnRequest, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	request, ok := r.returnMap[id]
+	if !ok {
+		return entity.ReturnRequest{}, errors.New("return request not found")
+	}
+	if request.Status != from {
+		return entity.ReturnRequest{}, fmt.Errorf("return request is %s", request.Status)
+	}
+
+	request.Status = to
+	r.returnMap[id] = request
+	return request, nil
+}
+
+func (r *repo) UpdateReturn(ctx context.Context, e entity.ReturnRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.returnMap[e.ID]; !exists {
+		return errors.New("return request not found")
+	}
+
+	r.returnMap[e.ID] = e
+	return nil
+}
+
+// RestockProducts adds the quantities back to the stock of the products and variants, the ones deleted since are skipped
+func (r *repo) RestockProducts(ctx context.Context, items []entity.ReturnItem) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, item := range items {
+		product, ok := r.productMap[item.ProductID]
+		if !ok {
+			continue
+		}
+		product.AddStock(item.VariantID, item.Quantity)
+		r.productMap[item.ProductID] = product
+	}
+
+	return nil
+}
+
+func sortReturns(requests []entity.ReturnRequest) {
+	sort.Slice(requests, func(i, j int) bool {
+		if requests[i].CreatedAt.Equal(requests[j].CreatedAt) {
+			return requests[i].ID < requests[j].ID
+		}
+		return requests[i].CreatedAt.Before(requests[j].CreatedAt)
+	})
+}
@@ -0,0 +1,174 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"graphql-backend/app"
+	"graphql-backend/entity"
+	"slices"
+	"sort"
+)
+
+func (r *repo) GetReviewByID(ctx context.Context, id string) (entity.Review, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	review, ok := r.reviewMap[id]
+	if !ok {
+		return entity.Review{}, errors.New("review not found")
+	}
+
+	return review, nil
+}
+
+// GetReviews returns the reviews oldest first, the order in which they are moderated
+func (r *repo) GetReviews(ctx context.Context, prs app.ReviewsParams) ([]entity.Review, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	limit := *prs.Limit
+	offset := *prs.Offset
+
+	var reviews []entity.Review
+	for _, review := range r.reviewMap {
+		if prs.Status != nil && review.Status != *prs.Status {
+			continue
+		}
+		if prs.ProductID != "" && review.ProductID != prs.ProductID {
+			continue
+		}
+		reviews = append(reviews, review)
+	}
+
+	sortReviews(reviews)
+
+	start := offset
+	end := offset + limit
+	if int(start) > len(reviews) {
+		return []entity.Review{}, nil
+	}
+	if int(end) > len(reviews) {
+		end = int32(len(reviews))
+	}
+
+	return reviews[start:end], nil
+}
+
+func (r *repo) GetApprovedReviewsByProductIDs(ctx context.Context, productIDs []string) (map[string][]entity.Review, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	wanted := make(map[string]bool, len(productIDs))
+	for _, id := range productIDs {
+		wanted[id] = true
+	}
+
+	reviews := make(map[string][]entity.Review, len(productIDs))
+	for _, review := range r.reviewMap {
+		if wanted[review.ProductID] && review.Status == entity.ReviewStatusApproved {
+			reviews[review.ProductID] = append(reviews[review.ProductID], review)
+		}
+	}
+	for _, productReviews := range reviews {
+		sortReviews(productReviews)
+		slices.Reverse(productReviews)
+	}
+
+	return reviews, nil
+}
+
+func (r *repo) GetReviewsByUserID(ctx context.Context, userID string) ([]entity.Review, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	reviews := []entity.Review{}
+	for _, review := range r.reviewMap {
+		if review.UserID == userID {
+			reviews = append(reviews, review)
+		}
+	}
+	sortReviews(reviews)
+
+	return reviews, nil
+}
+
+// CreateReview stores a new review, customers review a product once
+func (r *repo) CreateReview(ctx context.Context, e entity.Review) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.reviewMap[e.ID]; exists {
+		return errors.New("review with the given ID already exists")
+	}
+	if _, exists := r.productMap[e.ProductID]; !exists {
+		return errors.New("product not found")
+	}
+	for _, review := range r.reviewMap {
+		if review.UserID == e.UserID && review.ProductID == e.ProductID {
+			return errors.New("product was already reviewed")
+		}
+	}
+
+	r.reviewMap[e.ID] = e
+	r.countRating(e, 1)
+	return nil
+}
+
+func (r *repo) UpdateReview(ctx context.Context, e entity.Review) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, exists := r.reviewMap[e.ID]
+	if !exists {
+		return errors.New("review not found")
+	}
+
+	r.countRating(stored, -1)
+	r.reviewMap[e.ID] = e
+	r.countRating(e, 1)
+	return nil
+}
+
+// DeleteUserReviews deletes the reviews of the user, the approved ones are taken out of the product ratings
+func (r *repo) DeleteUserReviews(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, review := range r.reviewMap {
+		if review.UserID == userID {
+			r.countRating(review, -1)
+			delete(r.reviewMap, id)
+		}
+	}
+	return nil
+}
+
+// countRating adds an approved review to the rating of its product, or takes it out with a sign of -1.
+// The caller must hold the write lock.
+func (r *repo) countRating(review entity.Review, sign int32) {
+	if review.Status != entity.ReviewStatusApproved {
+		return
+	}
+	product, ok := r.productMap[review.ProductID]
+	if !ok {
+		return
+	}
+	product.RatingTotal += int64(sign * review.Rating)
+	product.ReviewCount += sign
+	r.productMap[review.ProductID] = product
+}
+
+// keepRating carries the rating over to a new version of the product, it is only changed along with the reviews
+func keepRating(e *entity.Product, stored entity.Product) {
+	e.RatingTotal = stored.RatingTotal
+	e.ReviewCount = stored.ReviewCount
+}
+
+func sortReviews(reviews []entity.Review) {
+	sort.Slice(reviews, func(i, j int) bool {
+		if reviews[i].CreatedAt.Equal(reviews[j].CreatedAt) {
+			return reviews[i].ID < reviews[j].ID
+		}
+		return reviews[i].CreatedAt.Before(reviews[j].CreatedAt)
+	})
+}
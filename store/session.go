@@ -0,0 +1,66 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"graphql-backend/entity"
+	"sort"
+	"time"
+)
+
+func (r *repo) CreateSession(ctx context.Context, e entity.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.sessionMap[e.ID]; exists {
+		return errors.New("session with the given ID already exists")
+	}
+
+	r.sessionMap[e.ID] = e
+	return nil
+}
+
+func (r *repo) GetSession(ctx context.Context, id string) (entity.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	session, ok := r.sessionMap[id]
+	if !ok {
+		return entity.Session{}, errors.New("session not found")
+	}
+
+	return session, nil
+}
+
+func (r *repo) RevokeUserSessions(ctx context.Context, userID string, revokedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, session := range r.sessionMap {
+		if (session.UserID != userID && session.ActorID != userID) || session.RevokedAt != nil {
+			continue
+		}
+		session.RevokedAt = &revokedAt
+		r.sessionMap[id] = session
+	}
+
+	return nil
+}
+
+func (r *repo) GetSessionsByUserID(ctx context.Context, userID string) ([]entity.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var sessions []entity.Session
+	for _, session := range r.sessionMap {
+		if session.UserID == userID {
+			sessions = append(sessions, session)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.Before(sessions[j].CreatedAt)
+	})
+
+	return sessions, nil
+}
@@ -0,0 +1,75 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"graphql-backend/entity"
+	"slices"
+	"time"
+)
+
+func (r *repo) GetWishlist(ctx context.Context, userID string) (entity.Wishlist, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	wishlist, ok := r.wishlistMap[userID]
+	if !ok {
+		return entity.Wishlist{UserID: userID, Items: []entity.WishlistItem{}}, nil
+	}
+
+	return wishlist, nil
+}
+
+func (r *repo) GetWishlistsByProductID(ctx context.Context, productID string) ([]entity.Wishlist, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var wishlists []entity.Wishlist
+	for _, wishlist := range r.wishlistMap {
+		if wishlist.Item(productID) >= 0 {
+			wishlists = append(wishlists, wishlist)
+		}
+	}
+
+	return wishlists, nil
+}
+
+func (r *repo) SaveWishlist(ctx context.Context, e entity.Wishlist) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.userMap[e.UserID]; !exists {
+		return errors.New("user not found")
+	}
+
+	r.wishlistMap[e.UserID] = e
+	return nil
+}
+
+func (r *repo) SetWishlistNotified(ctx context.Context, userID string, productID string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	wishlist, ok := r.wishlistMap[userID]
+	if !ok {
+		return errors.New("wishlist not found")
+	}
+	// the product could have been removed from the wishlist in the meantime
+	i := wishlist.Item(productID)
+	if i < 0 {
+		return nil
+	}
+
+	wishlist.Items = slices.Clone(wishlist.Items)
+	wishlist.Items[i].NotifiedAt = &at
+	r.wishlistMap[userID] = wishlist
+	return nil
+}
+
+func (r *repo) DeleteWishlist(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.wishlistMap, userID)
+	return nil
+}
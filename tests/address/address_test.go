@@ -0,0 +1,195 @@
+package address
+
+import (
+	"context"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/require"
+	"graphql-backend/tests"
+)
+
+type address struct {
+	ID                string
+	FullName          string
+	PostalCode        string
+	Country           string
+	IsDefaultShipping bool
+	IsDefaultBilling  bool
+}
+
+func createAddress(client *graphql.Client, token string, input map[string]interface{}) (address, error) {
+	req := graphql.NewRequest(`mutation($input: CreateAddressInput!) { createAddress(input: $input) { id fullName postalCode country isDefaultShipping isDefaultBilling } }`)
+	req.Var("input", input)
+	tests.AuthRequest(req, token)
+	var resp struct{ CreateAddress address }
+	err := client.Run(context.TODO(), req, &resp)
+	return resp.CreateAddress, err
+}
+
+func TestCreateAddressValidation(t *testing.T) {
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+
+	_, err := createAddress(client, customerToken, map[string]interface{}{
+		"fullName":   "Customer User",
+		"line1":      "1 Main Street",
+		"city":       "Springfield",
+		"postalCode": "ABCDE",
+		"country":    "US",
+	})
+	require.Error(t, err)
+
+	_, err = createAddress(client, customerToken, map[string]interface{}{
+		"fullName":   "Customer User",
+		"line1":      "1 Main Street",
+		"city":       "Springfield",
+		"postalCode": "12345",
+		"country":    "Narnia",
+	})
+	require.Error(t, err)
+
+	created, err := createAddress(client, customerToken, map[string]interface{}{
+		"fullName":   "Customer User",
+		"line1":      "10 Downing Street",
+		"city":       "London",
+		"postalCode": "sw1a 2aa",
+		"country":    "gb",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "SW1A 2AA", created.PostalCode)
+	require.Equal(t, "GB", created.Country)
+}
+
+func TestPlaceOrderSnapshotsAddress(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+
+	createReq := graphql.NewRequest(`mutation($input: CreateProductInput!) { createProduct(input: $input) { id } }`)
+	createReq.Var("input", map[string]interface{}{
+		"name":     "AddressProduct",
+		"price":    15.0,
+		"inStock":  10,
+		"category": "AddressCat",
+	})
+	tests.AuthRequest(createReq, adminToken)
+	var createResp struct {
+		CreateProduct struct{ ID string }
+	}
+	err := client.Run(context.TODO(), createReq, &createResp)
+	require.NoError(t, err)
+
+	shipping, err := createAddress(client, customerToken, map[string]interface{}{
+		"fullName":          "Customer User",
+		"line1":             "1 Main Street",
+		"city":              "Springfield",
+		"postalCode":        "12345",
+		"country":           "US",
+		"isDefaultShipping": true,
+	})
+	require.NoError(t, err)
+	require.True(t, shipping.IsDefaultShipping)
+
+	orderReq := graphql.NewRequest(`mutation($ids: [ID!]!, $addressId: ID) { placeOrder(productIds: $ids, addressId: $addressId) { id shippingAddress { fullName postalCode country } billingAddress { country } } }`)
+	orderReq.Var("ids", []string{createResp.CreateProduct.ID})
+	orderReq.Var("addressId", shipping.ID)
+	tests.AuthRequest(orderReq, customerToken)
+	var orderResp struct {
+		PlaceOrder struct {
+			ID              string
+			ShippingAddress struct {
+				FullName   string
+				PostalCode string
+				Country    string
+			}
+			BillingAddress *struct{ Country string }
+		}
+	}
+	err = client.Run(context.TODO(), orderReq, &orderResp)
+	require.NoError(t, err)
+	require.Equal(t, "12345", orderResp.PlaceOrder.ShippingAddress.PostalCode)
+	require.NotNil(t, orderResp.PlaceOrder.BillingAddress)
+
+	// Editing the address doesn't change the order
+	updateReq := graphql.NewRequest(`mutation($input: UpdateAddressInput!) { updateAddress(input: $input) { id postalCode } }`)
+	updateReq.Var("input", map[string]interface{}{
+		"id":         shipping.ID,
+		"postalCode": "54321-1234",
+	})
+	tests.AuthRequest(updateReq, customerToken)
+	var updateResp struct {
+		UpdateAddress struct{ PostalCode string }
+	}
+	err = client.Run(context.TODO(), updateReq, &updateResp)
+	require.NoError(t, err)
+	require.Equal(t, "54321-1234", updateResp.UpdateAddress.PostalCode)
+
+	getReq := graphql.NewRequest(`query($id: ID!) { order(id: $id) { shippingAddress { postalCode } } }`)
+	getReq.Var("id", orderResp.PlaceOrder.ID)
+	tests.AuthRequest(getReq, customerToken)
+	var getResp struct {
+		Order struct {
+			ShippingAddress struct{ PostalCode string }
+		}
+	}
+	err = client.Run(context.TODO(), getReq, &getResp)
+	require.NoError(t, err)
+	require.Equal(t, "12345", getResp.Order.ShippingAddress.PostalCode)
+
+	// Other users can't use or change the address
+	tests.AuthRequest(orderReq, adminToken)
+	err = client.Run(context.TODO(), orderReq, &struct{}{})
+	require.Error(t, err)
+
+	deleteReq := graphql.NewRequest(`mutation($id: ID!) { deleteAddress(id: $id) }`)
+	deleteReq.Var("id", shipping.ID)
+	tests.AuthRequest(deleteReq, adminToken)
+	err = client.Run(context.TODO(), deleteReq, &struct{ DeleteAddress bool }{})
+	require.Error(t, err)
+
+	tests.AuthRequest(deleteReq, customerToken)
+	var deleteResp struct{ DeleteAddress bool }
+	err = client.Run(context.TODO(), deleteReq, &deleteResp)
+	require.NoError(t, err)
+	require.True(t, deleteResp.DeleteAddress)
+}
+
+func TestDeleteDefaultAddress(t *testing.T) {
+	token := tests.Login(t, tests.CreateUser(t, "Customer"), tests.UserPassword)
+	client := tests.NewGraphQLClient()
+
+	var created []address
+	for _, city := range []string{"Springfield", "Shelbyville", "Ogdenville"} {
+		a, err := createAddress(client, token, map[string]interface{}{
+			"fullName":   "Test User",
+			"line1":      "1 Main Street",
+			"city":       city,
+			"postalCode": "12345",
+			"country":    "US",
+		})
+		require.NoError(t, err)
+		created = append(created, a)
+	}
+	require.True(t, created[0].IsDefaultShipping)
+	require.True(t, created[0].IsDefaultBilling)
+
+	deleteReq := graphql.NewRequest(`mutation($id: ID!) { deleteAddress(id: $id) }`)
+	deleteReq.Var("id", created[0].ID)
+	tests.AuthRequest(deleteReq, token)
+	err := client.Run(context.TODO(), deleteReq, &struct{ DeleteAddress bool }{})
+	require.NoError(t, err)
+
+	// The oldest remaining address becomes the default
+	req := graphql.NewRequest(`query { addresses { id isDefaultShipping isDefaultBilling } }`)
+	tests.AuthRequest(req, token)
+	var resp struct{ Addresses []address }
+	err = client.Run(context.TODO(), req, &resp)
+	require.NoError(t, err)
+	require.Len(t, resp.Addresses, 2)
+	require.Equal(t, created[1].ID, resp.Addresses[0].ID)
+	require.True(t, resp.Addresses[0].IsDefaultShipping)
+	require.True(t, resp.Addresses[0].IsDefaultBilling)
+	require.False(t, resp.Addresses[1].IsDefaultShipping)
+	require.False(t, resp.Addresses[1].IsDefaultBilling)
+}
@@ -0,0 +1,122 @@
+package apikey
+
+import (
+	"context"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/require"
+	"graphql-backend/tests"
+)
+
+func createAPIKey(t *testing.T, adminToken string, scopes []string) (string, string) {
+	client := tests.NewGraphQLClient()
+	req := graphql.NewRequest(`mutation($input: CreateApiKeyInput!) { createApiKey(input: $input) { key apiKey { id prefix scopes } } }`)
+	req.Var("input", map[string]interface{}{
+		"name":   "Warehouse integration",
+		"scopes": scopes,
+	})
+	tests.AuthRequest(req, adminToken)
+	var resp struct {
+		CreateApiKey struct {
+			Key    string
+			ApiKey struct {
+				ID     string
+				Prefix string
+				Scopes []string
+			}
+		}
+	}
+	err := client.Run(context.TODO(), req, &resp)
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.CreateApiKey.Key)
+	require.Contains(t, resp.CreateApiKey.Key, resp.CreateApiKey.ApiKey.Prefix)
+	return resp.CreateApiKey.ApiKey.ID, resp.CreateApiKey.Key
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	client := tests.NewGraphQLClient()
+	keyID, key := createAPIKey(t, adminToken, []string{"ReadProducts"})
+
+	// The key can read products
+	listReq := graphql.NewRequest(`query { products(limit: 1) { id } }`)
+	listReq.Header.Set("X-API-Key", key)
+	err := client.Run(context.TODO(), listReq, &struct{ Products []struct{ ID string } }{})
+	require.NoError(t, err)
+
+	// but is not granted to write them
+	createReq := graphql.NewRequest(`mutation($input: CreateProductInput!) { createProduct(input: $input) { id } }`)
+	createReq.Var("input", map[string]interface{}{
+		"name":     "ApiKeyProduct",
+		"price":    1.0,
+		"inStock":  1,
+		"category": "ApiKeyCat",
+	})
+	createReq.Header.Set("X-API-Key", key)
+	err = client.Run(context.TODO(), createReq, &struct{ CreateProduct struct{ ID string } }{})
+	require.Error(t, err)
+
+	// nor to act as a user
+	meReq := graphql.NewRequest(`query { me { id } }`)
+	meReq.Header.Set("X-API-Key", key)
+	err = client.Run(context.TODO(), meReq, &struct{ Me struct{ ID string } }{})
+	require.Error(t, err)
+
+	// Last used timestamp is recorded
+	keysReq := graphql.NewRequest(`query { apiKeys { id lastUsedAt } }`)
+	tests.AuthRequest(keysReq, adminToken)
+	var keysResp struct {
+		ApiKeys []struct {
+			ID         string
+			LastUsedAt *string
+		}
+	}
+	err = client.Run(context.TODO(), keysReq, &keysResp)
+	require.NoError(t, err)
+	var found bool
+	for _, k := range keysResp.ApiKeys {
+		if k.ID == keyID {
+			found = true
+			require.NotNil(t, k.LastUsedAt)
+		}
+	}
+	require.True(t, found)
+}
+
+func TestRevokedAPIKeyIsRejected(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	client := tests.NewGraphQLClient()
+	keyID, key := createAPIKey(t, adminToken, []string{"ReadProducts", "WriteProducts"})
+
+	revokeReq := graphql.NewRequest(`mutation($id: ID!) { revokeApiKey(id: $id) { id revokedAt } }`)
+	revokeReq.Var("id", keyID)
+	tests.AuthRequest(revokeReq, adminToken)
+	var revokeResp struct {
+		RevokeApiKey struct {
+			ID        string
+			RevokedAt *string
+		}
+	}
+	err := client.Run(context.TODO(), revokeReq, &revokeResp)
+	require.NoError(t, err)
+	require.NotNil(t, revokeResp.RevokeApiKey.RevokedAt)
+
+	listReq := graphql.NewRequest(`query { products(limit: 1) { id } }`)
+	listReq.Header.Set("X-API-Key", key)
+	err = client.Run(context.TODO(), listReq, &struct{ Products []struct{ ID string } }{})
+	require.Error(t, err)
+}
+
+func TestCustomerCannotCreateAPIKey(t *testing.T) {
+	token := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+	req := graphql.NewRequest(`mutation($input: CreateApiKeyInput!) { createApiKey(input: $input) { key } }`)
+	req.Var("input", map[string]interface{}{
+		"name":   "ShouldFail",
+		"scopes": []string{"ReadProducts"},
+	})
+	tests.AuthRequest(req, token)
+	err := client.Run(context.TODO(), req, &struct{ CreateApiKey struct{ Key string } }{})
+	require.Error(t, err)
+}
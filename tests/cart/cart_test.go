@@ -0,0 +1,259 @@
+package cart
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/require"
+	"graphql-backend/tests"
+)
+
+const cartFields = `{ id itemCount subtotal orderable items { productId quantity unitPrice addedPrice lineTotal issues product { id name } } }`
+
+type cart struct {
+	ID        string
+	ItemCount int32
+	Subtotal  float64
+	Orderable bool
+	Items     []struct {
+		ProductID  string
+		Quantity   int32
+		UnitPrice  float64
+		AddedPrice float64
+		LineTotal  float64
+		Issues     []string
+		Product    *struct {
+			ID   string
+			Name string
+		}
+	}
+}
+
+func runCart(t *testing.T, token, query, field string, vars map[string]interface{}) (cart, error) {
+	client := tests.NewGraphQLClient()
+	req := graphql.NewRequest(query)
+	for k, v := range vars {
+		req.Var(k, v)
+	}
+	tests.AuthRequest(req, token)
+	var resp map[string]cart
+	err := client.Run(context.TODO(), req, &resp)
+	return resp[field], err
+}
+
+func TestCartCheckout(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	productID := tests.CreateProduct(t, adminToken, map[string]interface{}{"price": 10, "inStock": 3, "category": "CartCat"})
+
+	_, err := runCart(t, customerToken, `mutation { clearCart `+cartFields+` }`, "clearCart", nil)
+	require.NoError(t, err)
+
+	c, err := runCart(t, customerToken, `mutation($id: ID!, $qty: Int!) { addToCart(productId: $id, quantity: $qty) `+cartFields+` }`, "addToCart",
+		map[string]interface{}{"id": productID, "qty": 2})
+	require.NoError(t, err)
+	require.Equal(t, int32(2), c.ItemCount)
+	require.Equal(t, 20.0, c.Subtotal)
+	require.True(t, c.Orderable)
+
+	// Can't add more than in stock
+	_, err = runCart(t, customerToken, `mutation($id: ID!, $qty: Int!) { addToCart(productId: $id, quantity: $qty) `+cartFields+` }`, "addToCart",
+		map[string]interface{}{"id": productID, "qty": 2})
+	require.Error(t, err)
+	// nor so many that the quantity overflows
+	_, err = runCart(t, customerToken, `mutation($id: ID!, $qty: Int!) { addToCart(productId: $id, quantity: $qty) `+cartFields+` }`, "addToCart",
+		map[string]interface{}{"id": productID, "qty": math.MaxInt32})
+	require.Error(t, err)
+	c, err = runCart(t, customerToken, `query { cart `+cartFields+` }`, "cart", nil)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), c.ItemCount)
+
+	// Price changes show up when the cart is read
+	client := tests.NewGraphQLClient()
+	updateReq := graphql.NewRequest(`mutation($input: UpdateProductInput!) { updateProduct(input: $input) { id } }`)
+	updateReq.Var("input", map[string]interface{}{"id": productID, "price": 12.5})
+	tests.AuthRequest(updateReq, adminToken)
+	err = client.Run(context.TODO(), updateReq, &struct{}{})
+	require.NoError(t, err)
+
+	c, err = runCart(t, customerToken, `query { cart `+cartFields+` }`, "cart", nil)
+	require.NoError(t, err)
+	require.Len(t, c.Items, 1)
+	require.Equal(t, 12.5, c.Items[0].UnitPrice)
+	require.Equal(t, 10.0, c.Items[0].AddedPrice)
+	require.Contains(t, c.Items[0].Issues, "PriceChanged")
+	require.Equal(t, 25.0, c.Subtotal)
+
+	c, err = runCart(t, customerToken, `mutation($id: ID!, $qty: Int!) { updateCartItem(productId: $id, quantity: $qty) `+cartFields+` }`, "updateCartItem",
+		map[string]interface{}{"id": productID, "qty": 3})
+	require.NoError(t, err)
+	require.Empty(t, c.Items[0].Issues)
+
+	// Texas taxes 6.25%
+	addressID := tests.CreateAddress(t, customerToken, map[string]interface{}{
+		"city":       "Austin",
+		"region":     "TX",
+		"postalCode": "73301",
+		"country":    "US",
+	})
+	checkoutReq := graphql.NewRequest(`mutation($addressId: ID) { checkout(addressId: $addressId) { id subtotal taxTotal total items { quantity unitPrice product { id } } } }`)
+	checkoutReq.Var("addressId", addressID)
+	tests.AuthRequest(checkoutReq, customerToken)
+	var checkoutResp struct {
+		Checkout struct {
+			ID       string
+			Subtotal float64
+			TaxTotal float64
+			Total    float64
+			Items    []struct {
+				Quantity  int32
+				UnitPrice float64
+				Product   struct{ ID string }
+			}
+		}
+	}
+	err = client.Run(context.TODO(), checkoutReq, &checkoutResp)
+	require.NoError(t, err)
+	require.Equal(t, 37.5, checkoutResp.Checkout.Subtotal)
+	require.Equal(t, 2.34, checkoutResp.Checkout.TaxTotal)
+	require.Equal(t, 39.84, checkoutResp.Checkout.Total)
+	require.Len(t, checkoutResp.Checkout.Items, 1)
+	require.Equal(t, int32(3), checkoutResp.Checkout.Items[0].Quantity)
+	require.Equal(t, productID, checkoutResp.Checkout.Items[0].Product.ID)
+
+	// The stock is reserved and the cart emptied
+	productReq := graphql.NewRequest(`query($id: ID!) { product(id: $id) { inStock } }`)
+	productReq.Var("id", productID)
+	tests.AuthRequest(productReq, customerToken)
+	var productResp struct {
+		Product struct{ InStock int32 }
+	}
+	err = client.Run(context.TODO(), productReq, &productResp)
+	require.NoError(t, err)
+	require.Equal(t, int32(0), productResp.Product.InStock)
+
+	c, err = runCart(t, customerToken, `query { cart `+cartFields+` }`, "cart", nil)
+	require.NoError(t, err)
+	require.Empty(t, c.Items)
+
+	err = client.Run(context.TODO(), checkoutReq, &struct{}{})
+	require.Error(t, err)
+}
+
+func TestCheckoutRejectsOutOfStockItems(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	productID := tests.CreateProduct(t, adminToken, map[string]interface{}{"price": 5, "inStock": 1, "category": "CartCat"})
+
+	_, err := runCart(t, customerToken, `mutation { clearCart `+cartFields+` }`, "clearCart", nil)
+	require.NoError(t, err)
+	_, err = runCart(t, customerToken, `mutation($id: ID!) { addToCart(productId: $id) `+cartFields+` }`, "addToCart",
+		map[string]interface{}{"id": productID})
+	require.NoError(t, err)
+
+	client := tests.NewGraphQLClient()
+	updateReq := graphql.NewRequest(`mutation($input: UpdateProductInput!) { updateProduct(input: $input) { id } }`)
+	updateReq.Var("input", map[string]interface{}{"id": productID, "inStock": 0})
+	tests.AuthRequest(updateReq, adminToken)
+	err = client.Run(context.TODO(), updateReq, &struct{}{})
+	require.NoError(t, err)
+
+	c, err := runCart(t, customerToken, `query { cart `+cartFields+` }`, "cart", nil)
+	require.NoError(t, err)
+	require.False(t, c.Orderable)
+	require.Equal(t, []string{"OutOfStock"}, c.Items[0].Issues)
+
+	checkoutReq := graphql.NewRequest(`mutation { checkout { id } }`)
+	tests.AuthRequest(checkoutReq, customerToken)
+	err = client.Run(context.TODO(), checkoutReq, &struct{}{})
+	require.Error(t, err)
+
+	c, err = runCart(t, customerToken, `mutation($id: ID!) { removeFromCart(productId: $id) `+cartFields+` }`, "removeFromCart",
+		map[string]interface{}{"id": productID})
+	require.NoError(t, err)
+	require.Empty(t, c.Items)
+}
+
+func TestCartInCurrency(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CreateUser(t, "Customer"), tests.UserPassword)
+	productID := tests.CreateProduct(t, adminToken, map[string]interface{}{"price": 10, "category": "CartCat"})
+
+	_, err := runCart(t, customerToken, `mutation($id: ID!, $qty: Int!) { addToCart(productId: $id, quantity: $qty) `+cartFields+` }`, "addToCart",
+		map[string]interface{}{"id": productID, "qty": 2})
+	require.NoError(t, err)
+
+	client := tests.NewGraphQLClient()
+	req := graphql.NewRequest(`query { cart(currency: "eur") { currency subtotal items { unitPrice addedPrice lineTotal issues product { price } } } }`)
+	tests.AuthRequest(req, customerToken)
+	var resp struct {
+		Cart struct {
+			Currency string
+			Subtotal float64
+			Items    []struct {
+				UnitPrice  float64
+				AddedPrice float64
+				LineTotal  float64
+				Issues     []string
+				Product    struct{ Price float64 }
+			}
+		}
+	}
+	err = client.Run(context.TODO(), req, &resp)
+	require.NoError(t, err)
+	require.Equal(t, "EUR", resp.Cart.Currency)
+	require.Equal(t, 18.4, resp.Cart.Subtotal)
+	require.Len(t, resp.Cart.Items, 1)
+	require.Equal(t, 9.2, resp.Cart.Items[0].UnitPrice)
+	require.Equal(t, 9.2, resp.Cart.Items[0].AddedPrice)
+	require.Equal(t, 18.4, resp.Cart.Items[0].LineTotal)
+	require.Empty(t, resp.Cart.Items[0].Issues)
+	require.Equal(t, 9.2, resp.Cart.Items[0].Product.Price)
+
+	req = graphql.NewRequest(`query { cart(currency: "XYZ") { currency } }`)
+	tests.AuthRequest(req, customerToken)
+	err = client.Run(context.TODO(), req, &map[string]interface{}{})
+	require.Error(t, err)
+}
+
+func TestCancelledCheckoutIsRestocked(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CreateUser(t, "Customer"), tests.UserPassword)
+	productID := tests.CreateProduct(t, adminToken, map[string]interface{}{"price": 10, "inStock": 3, "category": "CartCat"})
+
+	_, err := runCart(t, customerToken, `mutation($id: ID!, $qty: Int!) { addToCart(productId: $id, quantity: $qty) `+cartFields+` }`, "addToCart",
+		map[string]interface{}{"id": productID, "qty": 2})
+	require.NoError(t, err)
+
+	client := tests.NewGraphQLClient()
+	checkoutReq := graphql.NewRequest(`mutation { checkout { id } }`)
+	tests.AuthRequest(checkoutReq, customerToken)
+	var checkoutResp struct {
+		Checkout struct{ ID string }
+	}
+	require.NoError(t, client.Run(context.TODO(), checkoutReq, &checkoutResp))
+
+	getStock := func() int32 {
+		req := graphql.NewRequest(`query($id: ID!) { product(id: $id) { inStock } }`)
+		req.Var("id", productID)
+		tests.AuthRequest(req, adminToken)
+		var resp struct {
+			Product struct{ InStock int32 }
+		}
+		require.NoError(t, client.Run(context.TODO(), req, &resp))
+		return resp.Product.InStock
+	}
+	require.Equal(t, int32(1), getStock())
+
+	cancelReq := graphql.NewRequest(`mutation($id: ID!) { updateOrderStatus(id: $id, status: "Cancelled") { status } }`)
+	cancelReq.Var("id", checkoutResp.Checkout.ID)
+	tests.AuthRequest(cancelReq, adminToken)
+	require.NoError(t, client.Run(context.TODO(), cancelReq, &map[string]interface{}{}))
+	require.Equal(t, int32(3), getStock())
+
+	// Cancelling again doesn't restock twice
+	require.Error(t, client.Run(context.TODO(), cancelReq, &map[string]interface{}{}))
+	require.Equal(t, int32(3), getStock())
+}
@@ -0,0 +1,80 @@
+package cart
+
+import (
+	"context"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/require"
+	"graphql-backend/tests"
+)
+
+func TestGuestCartMergedOnLogin(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	productID := tests.CreateProduct(t, adminToken, map[string]interface{}{"price": 4, "inStock": 5, "category": "CartCat"})
+	otherProductID := tests.CreateProduct(t, adminToken, map[string]interface{}{"price": 6, "inStock": 10, "category": "CartCat"})
+	client := tests.NewGraphQLClient()
+
+	// Anonymous visitors can't browse the catalog, but they can start a guest cart
+	productReq := graphql.NewRequest(`query($id: ID!) { product(id: $id) { id } }`)
+	productReq.Var("id", productID)
+	err := client.Run(context.TODO(), productReq, &struct{}{})
+	require.Error(t, err)
+
+	guestReq := graphql.NewRequest(`mutation($id: ID!, $qty: Int!, $token: String) { addToCart(productId: $id, quantity: $qty, cartToken: $token) { token itemCount } }`)
+	guestReq.Var("id", productID)
+	guestReq.Var("qty", 3)
+	var guestResp struct {
+		AddToCart struct {
+			Token     *string
+			ItemCount int32
+		}
+	}
+	err = client.Run(context.TODO(), guestReq, &guestResp)
+	require.NoError(t, err)
+	require.NotNil(t, guestResp.AddToCart.Token)
+	cartToken := *guestResp.AddToCart.Token
+
+	guestReq.Var("id", otherProductID)
+	guestReq.Var("qty", 1)
+	guestReq.Var("token", cartToken)
+	err = client.Run(context.TODO(), guestReq, &guestResp)
+	require.NoError(t, err)
+	require.Equal(t, int32(4), guestResp.AddToCart.ItemCount)
+	require.Equal(t, cartToken, *guestResp.AddToCart.Token)
+
+	// The customer already has some of the product in their cart
+	_, err = runCart(t, customerToken, `mutation { clearCart `+cartFields+` }`, "clearCart", nil)
+	require.NoError(t, err)
+	_, err = runCart(t, customerToken, `mutation($id: ID!, $qty: Int!) { addToCart(productId: $id, quantity: $qty) `+cartFields+` }`, "addToCart",
+		map[string]interface{}{"id": productID, "qty": 4})
+	require.NoError(t, err)
+
+	loginReq := graphql.NewRequest(`mutation($input: LoginInput!) { login(input: $input) { accessToken } }`)
+	loginReq.Var("input", map[string]interface{}{
+		"email":     tests.CustomerEmail,
+		"password":  tests.CustomerPassword,
+		"cartToken": cartToken,
+	})
+	var loginResp struct {
+		Login struct{ AccessToken string }
+	}
+	err = client.Run(context.TODO(), loginReq, &loginResp)
+	require.NoError(t, err)
+
+	// Quantities are summed and capped at the stock
+	c, err := runCart(t, loginResp.Login.AccessToken, `query { cart `+cartFields+` }`, "cart", nil)
+	require.NoError(t, err)
+	quantities := map[string]int32{}
+	for _, item := range c.Items {
+		quantities[item.ProductID] = item.Quantity
+	}
+	require.Equal(t, map[string]int32{productID: 5, otherProductID: 1}, quantities)
+
+	// The guest cart is gone
+	cartReq := graphql.NewRequest(`query($token: String) { cart(cartToken: $token) { id } }`)
+	cartReq.Var("token", cartToken)
+	err = client.Run(context.TODO(), cartReq, &struct{}{})
+	require.Error(t, err)
+}
@@ -0,0 +1,148 @@
+package cart
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/require"
+	"graphql-backend/tests"
+)
+
+// createVariantProduct creates a product in sizes S and M, M costs more than the product price
+func createVariantProduct(t *testing.T, adminToken string) (string, []string) {
+	client := tests.NewGraphQLClient()
+	req := graphql.NewRequest(`mutation($input: CreateProductInput!) { createProduct(input: $input) { id } }`)
+	req.Var("input", map[string]interface{}{
+		"name":     "CartShirt",
+		"price":    10,
+		"inStock":  0,
+		"category": "CartCat",
+		"options":  []map[string]interface{}{{"name": "Size", "values": []string{"S", "M"}}},
+	})
+	tests.AuthRequest(req, adminToken)
+	var resp struct {
+		CreateProduct struct{ ID string }
+	}
+	err := client.Run(context.TODO(), req, &resp)
+	require.NoError(t, err)
+
+	sku := "CART-" + uuid.NewString()[:8]
+	var variantIDs []string
+	for _, variant := range []map[string]interface{}{
+		{"sku": sku + "-S", "options": []map[string]interface{}{{"name": "Size", "value": "S"}}, "inStock": 2},
+		{"sku": sku + "-M", "options": []map[string]interface{}{{"name": "Size", "value": "M"}}, "inStock": 5, "price": 15},
+	} {
+		variant["productId"] = resp.CreateProduct.ID
+		req := graphql.NewRequest(`mutation($input: CreateProductVariantInput!) { createProductVariant(input: $input) { variants { id } } }`)
+		req.Var("input", variant)
+		tests.AuthRequest(req, adminToken)
+		var variantResp struct {
+			CreateProductVariant struct {
+				Variants []struct{ ID string }
+			}
+		}
+		err := client.Run(context.TODO(), req, &variantResp)
+		require.NoError(t, err)
+		variants := variantResp.CreateProductVariant.Variants
+		variantIDs = append(variantIDs, variants[len(variants)-1].ID)
+	}
+
+	return resp.CreateProduct.ID, variantIDs
+}
+
+const variantCartFields = `{ itemCount subtotal items { productId variantId quantity unitPrice variant { sku inStock } } }`
+
+type variantCart struct {
+	ItemCount int32
+	Subtotal  float64
+	Items     []struct {
+		ProductID string
+		VariantID *string
+		Quantity  int32
+		UnitPrice float64
+		Variant   *struct {
+			Sku     string
+			InStock int32
+		}
+	}
+}
+
+func TestCheckoutVariants(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+	productID, variantIDs := createVariantProduct(t, adminToken)
+
+	_, err := runCart(t, customerToken, `mutation { clearCart `+cartFields+` }`, "clearCart", nil)
+	require.NoError(t, err)
+
+	addToCart := func(variantID interface{}, quantity int) (variantCart, error) {
+		req := graphql.NewRequest(`mutation($id: ID!, $variantId: ID, $qty: Int!) { addToCart(productId: $id, variantId: $variantId, quantity: $qty) ` + variantCartFields + ` }`)
+		req.Var("id", productID)
+		req.Var("variantId", variantID)
+		req.Var("qty", quantity)
+		tests.AuthRequest(req, customerToken)
+		var resp struct {
+			AddToCart variantCart
+		}
+		err := client.Run(context.TODO(), req, &resp)
+		return resp.AddToCart, err
+	}
+
+	// Products with variants are added through one of them, within the stock of the variant
+	_, err = addToCart(nil, 1)
+	require.Error(t, err)
+	_, err = addToCart(variantIDs[0], 3)
+	require.Error(t, err)
+
+	_, err = addToCart(variantIDs[0], 2)
+	require.NoError(t, err)
+	c, err := addToCart(variantIDs[1], 1)
+	require.NoError(t, err)
+	require.Len(t, c.Items, 2)
+	require.Equal(t, int32(3), c.ItemCount)
+	require.Equal(t, 35.0, c.Subtotal)
+	require.Equal(t, variantIDs[1], *c.Items[1].VariantID)
+	require.Equal(t, 15.0, c.Items[1].UnitPrice)
+	require.Equal(t, int32(5), c.Items[1].Variant.InStock)
+
+	checkoutReq := graphql.NewRequest(`mutation { checkout { subtotal items { variantId sku options { name value } quantity unitPrice } } }`)
+	tests.AuthRequest(checkoutReq, customerToken)
+	var checkoutResp struct {
+		Checkout struct {
+			Subtotal float64
+			Items    []struct {
+				VariantID string
+				Sku       string
+				Options   []struct{ Name, Value string }
+				Quantity  int32
+				UnitPrice float64
+			}
+		}
+	}
+	err = client.Run(context.TODO(), checkoutReq, &checkoutResp)
+	require.NoError(t, err)
+	require.Equal(t, 35.0, checkoutResp.Checkout.Subtotal)
+	require.Len(t, checkoutResp.Checkout.Items, 2)
+	require.Equal(t, variantIDs[0], checkoutResp.Checkout.Items[0].VariantID)
+	require.Equal(t, "S", checkoutResp.Checkout.Items[0].Options[0].Value)
+	require.Equal(t, 15.0, checkoutResp.Checkout.Items[1].UnitPrice)
+
+	// The stock of each variant is reserved
+	productReq := graphql.NewRequest(`query($id: ID!) { product(id: $id) { inStock variants { inStock } } }`)
+	productReq.Var("id", productID)
+	tests.AuthRequest(productReq, adminToken)
+	var productResp struct {
+		Product struct {
+			InStock  int32
+			Variants []struct{ InStock int32 }
+		}
+	}
+	err = client.Run(context.TODO(), productReq, &productResp)
+	require.NoError(t, err)
+	require.Equal(t, int32(4), productResp.Product.InStock)
+	require.Equal(t, int32(0), productResp.Product.Variants[0].InStock)
+	require.Equal(t, int32(4), productResp.Product.Variants[1].InStock)
+}
@@ -64,3 +64,87 @@ func TestGetOrder(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, orderID, getResp.Order.ID)
 }
+
+func TestAdminCanGetCustomerOrder(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	client := tests.NewGraphQLClient()
+	createReq := graphql.NewRequest(`mutation($input: CreateProductInput!) { createProduct(input: $input) { id } }`)
+	input := map[string]interface{}{
+		"name":        "OrderOwnerProduct",
+		"price":       12.0,
+		"inStock":     4,
+		"description": "desc",
+		"category":    "OrderOwnerCat",
+	}
+	createReq.Var("input", input)
+	tests.AuthRequest(createReq, adminToken)
+	var createResp struct {
+		CreateProduct struct{ ID string }
+	}
+	err := client.Run(context.TODO(), createReq, &createResp)
+	require.NoError(t, err)
+
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	orderReq := graphql.NewRequest(`mutation($ids: [ID!]!) { placeOrder(productIds: $ids) { id } }`)
+	orderReq.Var("ids", []string{createResp.CreateProduct.ID})
+	tests.AuthRequest(orderReq, customerToken)
+	var orderResp struct {
+		PlaceOrder struct{ ID string }
+	}
+	err = client.Run(context.TODO(), orderReq, &orderResp)
+	require.NoError(t, err)
+
+	// Admin overrides the ownership check
+	getReq := graphql.NewRequest(`query($id: ID!) { order(id: $id) { id user { id } } }`)
+	getReq.Var("id", orderResp.PlaceOrder.ID)
+	tests.AuthRequest(getReq, adminToken)
+	var getResp struct {
+		Order struct {
+			ID   string
+			User struct{ ID string }
+		}
+	}
+	err = client.Run(context.TODO(), getReq, &getResp)
+	require.NoError(t, err)
+	require.Equal(t, orderResp.PlaceOrder.ID, getResp.Order.ID)
+
+	// Anonymous callers are rejected
+	anonReq := graphql.NewRequest(`query($id: ID!) { order(id: $id) { id } }`)
+	anonReq.Var("id", orderResp.PlaceOrder.ID)
+	err = client.Run(context.TODO(), anonReq, &getResp)
+	require.Error(t, err)
+}
+
+func TestOtherUsersOrderLooksMissing(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+	createReq := graphql.NewRequest(`mutation($input: CreateProductInput!) { createProduct(input: $input) { id } }`)
+	createReq.Var("input", map[string]interface{}{"name": "OrderOwnerProduct", "price": 12.0, "inStock": 4, "category": "OrderOwnerCat"})
+	tests.AuthRequest(createReq, adminToken)
+	var createResp struct {
+		CreateProduct struct{ ID string }
+	}
+	require.NoError(t, client.Run(context.TODO(), createReq, &createResp))
+
+	orderReq := graphql.NewRequest(`mutation($ids: [ID!]!) { placeOrder(productIds: $ids) { id } }`)
+	orderReq.Var("ids", []string{createResp.CreateProduct.ID})
+	tests.AuthRequest(orderReq, adminToken)
+	var orderResp struct {
+		PlaceOrder struct{ ID string }
+	}
+	require.NoError(t, client.Run(context.TODO(), orderReq, &orderResp))
+
+	// The order of another user fails like an order that doesn't exist
+	getOrder := func(id string) error {
+		req := graphql.NewRequest(`query($id: ID!) { order(id: $id) { id } }`)
+		req.Var("id", id)
+		tests.AuthRequest(req, customerToken)
+		return client.Run(context.TODO(), req, &map[string]interface{}{})
+	}
+	otherErr := getOrder(orderResp.PlaceOrder.ID)
+	missingErr := getOrder("missing-order-id")
+	require.Error(t, otherErr)
+	require.Error(t, missingErr)
+	require.Equal(t, missingErr.Error(), otherErr.Error())
+}
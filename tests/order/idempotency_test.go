@@ -0,0 +1,71 @@
+package order
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/require"
+	"graphql-backend/tests"
+)
+
+func postWithIdempotencyKey(t *testing.T, token string, key string, body map[string]interface{}) (*http.Response, string) {
+	payload, err := json.Marshal(body)
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodPost, tests.URL("/query"), bytes.NewReader(payload))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Idempotency-Key", key)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var res struct {
+		Data struct {
+			PlaceOrder struct{ ID string }
+		}
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&res)
+	return resp, res.Data.PlaceOrder.ID
+}
+
+func TestPlaceOrderRetryWithIdempotencyKey(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+
+	createReq := graphql.NewRequest(`mutation($input: CreateProductInput!) { createProduct(input: $input) { id } }`)
+	createReq.Var("input", map[string]interface{}{"name": "RetriedProduct", "price": 5, "inStock": 10, "category": "OrderCat"})
+	tests.AuthRequest(createReq, adminToken)
+	var createResp struct {
+		CreateProduct struct{ ID string }
+	}
+	err := client.Run(context.TODO(), createReq, &createResp)
+	require.NoError(t, err)
+
+	key := uuid.NewString()
+	body := map[string]interface{}{
+		"query":     `mutation($ids: [ID!]!) { placeOrder(productIds: $ids) { id } }`,
+		"variables": map[string]interface{}{"ids": []string{createResp.CreateProduct.ID}},
+	}
+
+	resp, orderID := postWithIdempotencyKey(t, customerToken, key, body)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NotEmpty(t, orderID)
+
+	// The retry gets the first order instead of placing a second one
+	resp, retriedID := postWithIdempotencyKey(t, customerToken, key, body)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "true", resp.Header.Get("Idempotent-Replayed"))
+	require.Equal(t, orderID, retriedID)
+
+	body["variables"] = map[string]interface{}{"ids": []string{createResp.CreateProduct.ID, createResp.CreateProduct.ID}}
+	resp, _ = postWithIdempotencyKey(t, customerToken, key, body)
+	require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+}
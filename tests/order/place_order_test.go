@@ -2,6 +2,7 @@ package order
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 
 	"github.com/machinebox/graphql"
@@ -54,3 +55,222 @@ func TestPlaceOrder(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, 1, len(orderResp.PlaceOrder.Products))
 }
+
+func TestPlaceOrderTotalIsExact(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+
+	createProduct := func(price float64) (string, error) {
+		req := graphql.NewRequest(`mutation($input: CreateProductInput!) { createProduct(input: $input) { id price } }`)
+		req.Var("input", map[string]interface{}{
+			"name":     "CentsProduct",
+			"price":    price,
+			"inStock":  10,
+			"category": "OrderCat",
+		})
+		tests.AuthRequest(req, adminToken)
+		var resp struct {
+			CreateProduct struct {
+				ID    string
+				Price json.Number
+			}
+		}
+		err := client.Run(context.TODO(), req, &resp)
+		return resp.CreateProduct.ID, err
+	}
+
+	firstID, err := createProduct(0.1)
+	require.NoError(t, err)
+	secondID, err := createProduct(0.2)
+	require.NoError(t, err)
+
+	// Prices are in cents
+	_, err = createProduct(1.234)
+	require.Error(t, err)
+
+	orderReq := graphql.NewRequest(`mutation($ids: [ID!]!) { placeOrder(productIds: $ids) { id subtotal items { unitPrice } } }`)
+	orderReq.Var("ids", []string{firstID, secondID})
+	tests.AuthRequest(orderReq, customerToken)
+	var orderResp struct {
+		PlaceOrder struct {
+			ID       string
+			Subtotal json.Number
+			Items    []struct {
+				UnitPrice json.Number
+			}
+		}
+	}
+	err = client.Run(context.TODO(), orderReq, &orderResp)
+	require.NoError(t, err)
+	require.Equal(t, json.Number("0.30"), orderResp.PlaceOrder.Subtotal)
+	require.Len(t, orderResp.PlaceOrder.Items, 2)
+}
+
+func TestPlaceOrderWithVariants(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+
+	createReq := graphql.NewRequest(`mutation($input: CreateProductInput!) { createProduct(input: $input) { id } }`)
+	createReq.Var("input", map[string]interface{}{
+		"name":     "OrderShirt",
+		"price":    8,
+		"inStock":  0,
+		"category": "OrderCat",
+		"options":  []map[string]interface{}{{"name": "Color", "values": []string{"Red"}}},
+	})
+	tests.AuthRequest(createReq, adminToken)
+	var createResp struct {
+		CreateProduct struct{ ID string }
+	}
+	err := client.Run(context.TODO(), createReq, &createResp)
+	require.NoError(t, err)
+	productID := createResp.CreateProduct.ID
+
+	variantReq := graphql.NewRequest(`mutation($input: CreateProductVariantInput!) { createProductVariant(input: $input) { variants { id sku } } }`)
+	variantReq.Var("input", map[string]interface{}{
+		"productId": productID,
+		"sku":       "ORDER-" + productID,
+		"options":   []map[string]interface{}{{"name": "Color", "value": "Red"}},
+		"price":     9,
+		"inStock":   5,
+	})
+	tests.AuthRequest(variantReq, adminToken)
+	var variantResp struct {
+		CreateProductVariant struct {
+			Variants []struct{ ID, Sku string }
+		}
+	}
+	err = client.Run(context.TODO(), variantReq, &variantResp)
+	require.NoError(t, err)
+	variant := variantResp.CreateProductVariant.Variants[0]
+
+	// Products with variants can't be ordered by product ID only
+	orderReq := graphql.NewRequest(`mutation($ids: [ID!]!) { placeOrder(productIds: $ids) { id } }`)
+	orderReq.Var("ids", []string{productID})
+	tests.AuthRequest(orderReq, customerToken)
+	err = client.Run(context.TODO(), orderReq, &struct{}{})
+	require.Error(t, err)
+
+	orderReq = graphql.NewRequest(`mutation($items: [PlaceOrderItemInput!]) { placeOrder(items: $items) { subtotal items { variantId sku quantity unitPrice } } }`)
+	orderReq.Var("items", []map[string]interface{}{{"productId": productID, "variantId": variant.ID, "quantity": 2}})
+	tests.AuthRequest(orderReq, customerToken)
+	var orderResp struct {
+		PlaceOrder struct {
+			Subtotal float64
+			Items    []struct {
+				VariantID string
+				Sku       string
+				Quantity  int32
+				UnitPrice float64
+			}
+		}
+	}
+	err = client.Run(context.TODO(), orderReq, &orderResp)
+	require.NoError(t, err)
+	require.Equal(t, 18.0, orderResp.PlaceOrder.Subtotal)
+	require.Len(t, orderResp.PlaceOrder.Items, 1)
+	require.Equal(t, variant.ID, orderResp.PlaceOrder.Items[0].VariantID)
+	require.Equal(t, variant.Sku, orderResp.PlaceOrder.Items[0].Sku)
+	require.Equal(t, int32(2), orderResp.PlaceOrder.Items[0].Quantity)
+}
+
+func TestPlaceOrderInCurrency(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+	productID := tests.CreateProduct(t, adminToken, map[string]interface{}{"price": 10, "category": "TaxCat"})
+
+	// Oregon has no sales tax
+	addressID := tests.CreateAddress(t, customerToken, map[string]interface{}{
+		"city":       "Portland",
+		"region":     "OR",
+		"postalCode": "97201",
+		"country":    "US",
+	})
+
+	req := graphql.NewRequest(`mutation($ids: [ID!]!, $addressId: ID) { placeOrder(productIds: $ids, addressId: $addressId, currency: "EUR") { id currency exchangeRate subtotal total items { unitPrice } } }`)
+	req.Var("ids", []string{productID, productID})
+	req.Var("addressId", addressID)
+	tests.AuthRequest(req, customerToken)
+	var resp struct {
+		PlaceOrder struct {
+			ID           string
+			Currency     string
+			ExchangeRate float64
+			Subtotal     float64
+			Total        float64
+			Items        []struct{ UnitPrice float64 }
+		}
+	}
+	err := client.Run(context.TODO(), req, &resp)
+	require.NoError(t, err)
+	require.Equal(t, "EUR", resp.PlaceOrder.Currency)
+	require.Equal(t, 0.92, resp.PlaceOrder.ExchangeRate)
+	require.Equal(t, 9.2, resp.PlaceOrder.Items[0].UnitPrice)
+	require.Equal(t, 18.4, resp.PlaceOrder.Subtotal)
+	require.Equal(t, 18.4, resp.PlaceOrder.Total)
+
+	req = graphql.NewRequest(`mutation($ids: [ID!]!) { placeOrder(productIds: $ids, currency: "XYZ") { id } }`)
+	req.Var("ids", []string{productID})
+	tests.AuthRequest(req, customerToken)
+	err = client.Run(context.TODO(), req, &struct{ PlaceOrder struct{ ID string } }{})
+	require.Error(t, err)
+}
+
+func TestPlaceOrderReservesStock(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CreateUser(t, "Customer"), tests.UserPassword)
+	client := tests.NewGraphQLClient()
+	productID := tests.CreateProduct(t, adminToken, map[string]interface{}{"price": 10, "inStock": 3, "category": "OrderCat"})
+
+	placeOrder := func(items ...map[string]interface{}) (string, error) {
+		req := graphql.NewRequest(`mutation($items: [PlaceOrderItemInput!]) { placeOrder(items: $items) { id products { id } } }`)
+		req.Var("items", items)
+		tests.AuthRequest(req, customerToken)
+		var resp struct {
+			PlaceOrder struct {
+				ID       string
+				Products []struct{ ID string }
+			}
+		}
+		err := client.Run(context.TODO(), req, &resp)
+		if err == nil {
+			// the products are listed once, not once per unit
+			require.Len(t, resp.PlaceOrder.Products, 1)
+		}
+		return resp.PlaceOrder.ID, err
+	}
+	getStock := func() int32 {
+		req := graphql.NewRequest(`query($id: ID!) { product(id: $id) { inStock } }`)
+		req.Var("id", productID)
+		tests.AuthRequest(req, adminToken)
+		var resp struct {
+			Product struct{ InStock int32 }
+		}
+		require.NoError(t, client.Run(context.TODO(), req, &resp))
+		return resp.Product.InStock
+	}
+
+	// Quantities are capped like in the cart, and can't exceed the stock
+	_, err := placeOrder(map[string]interface{}{"productId": productID, "quantity": 2000000000})
+	require.Error(t, err)
+	_, err = placeOrder(map[string]interface{}{"productId": productID, "quantity": 2}, map[string]interface{}{"productId": productID, "quantity": 2})
+	require.Error(t, err)
+	require.Equal(t, int32(3), getStock())
+
+	orderID, err := placeOrder(map[string]interface{}{"productId": productID, "quantity": 2}, map[string]interface{}{"productId": productID, "quantity": 1})
+	require.NoError(t, err)
+	require.Equal(t, int32(0), getStock())
+
+	_, err = placeOrder(map[string]interface{}{"productId": productID, "quantity": 1})
+	require.Error(t, err)
+
+	// Cancelling the order puts the items back in stock
+	cancelReq := graphql.NewRequest(`mutation($id: ID!) { updateOrderStatus(id: $id, status: "Cancelled") { status } }`)
+	cancelReq.Var("id", orderID)
+	tests.AuthRequest(cancelReq, adminToken)
+	require.NoError(t, client.Run(context.TODO(), cancelReq, &map[string]interface{}{}))
+	require.Equal(t, int32(3), getStock())
+}
@@ -0,0 +1,150 @@
+package order
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/require"
+	"graphql-backend/tests"
+)
+
+type taxedOrder struct {
+	ID       string
+	Subtotal float64
+	TaxTotal float64
+	Total    float64
+	TaxLines []struct {
+		Name          string
+		Rate          float64
+		TaxableAmount float64
+		Amount        float64
+	}
+}
+
+func placeTaxedOrder(t *testing.T, client *graphql.Client, token string, productIDs []string, addressID string) taxedOrder {
+	req := graphql.NewRequest(`mutation($ids: [ID!]!, $addressId: ID) { placeOrder(productIds: $ids, addressId: $addressId) { id subtotal taxTotal total taxLines { name rate taxableAmount amount } } }`)
+	req.Var("ids", productIDs)
+	req.Var("addressId", addressID)
+	tests.AuthRequest(req, token)
+	var resp struct {
+		PlaceOrder taxedOrder
+	}
+	err := client.Run(context.TODO(), req, &resp)
+	require.NoError(t, err)
+	return resp.PlaceOrder
+}
+
+func TestOrderTaxByCategory(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+	bookID := tests.CreateProduct(t, adminToken, map[string]interface{}{"price": 10, "category": "Books"})
+	gameID := tests.CreateProduct(t, adminToken, map[string]interface{}{"price": 20, "category": "TaxCat"})
+
+	addressID := tests.CreateAddress(t, customerToken, map[string]interface{}{
+		"city":       "Berlin",
+		"postalCode": "10115",
+		"country":    "DE",
+	})
+
+	o := placeTaxedOrder(t, client, customerToken, []string{bookID, gameID}, addressID)
+	require.Equal(t, 30.0, o.Subtotal)
+	require.Len(t, o.TaxLines, 2)
+	require.Equal(t, 7.0, o.TaxLines[0].Rate)
+	require.Equal(t, 10.0, o.TaxLines[0].TaxableAmount)
+	require.Equal(t, 0.7, o.TaxLines[0].Amount)
+	require.Equal(t, 19.0, o.TaxLines[1].Rate)
+	require.Equal(t, 3.8, o.TaxLines[1].Amount)
+	require.Equal(t, 4.5, o.TaxTotal)
+	require.Equal(t, 34.5, o.Total)
+
+	// Tax lines are stored with the order
+	getReq := graphql.NewRequest(`query($id: ID!) { order(id: $id) { id subtotal taxTotal total taxLines { name rate taxableAmount amount } } }`)
+	getReq.Var("id", o.ID)
+	tests.AuthRequest(getReq, customerToken)
+	var getResp struct {
+		Order taxedOrder
+	}
+	err := client.Run(context.TODO(), getReq, &getResp)
+	require.NoError(t, err)
+	require.Equal(t, o, getResp.Order)
+}
+
+func TestSubcategoryTaxedByTheRuleOfItsCategory(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CreateUser(t, "Customer"), tests.UserPassword)
+	client := tests.NewGraphQLClient()
+	// the product makes sure the Books category of the built-in rules exists
+	tests.CreateProduct(t, adminToken, map[string]interface{}{"category": "Books"})
+
+	req := graphql.NewRequest(`query { category(slug: "books") { id } }`)
+	tests.AuthRequest(req, adminToken)
+	var resp struct {
+		Category struct{ ID string }
+	}
+	require.NoError(t, client.Run(context.TODO(), req, &resp))
+	comicsID := tests.CreateCategory(t, adminToken, "Comics-"+uuid.NewString()[:8], resp.Category.ID)
+	comicID := tests.CreateProduct(t, adminToken, map[string]interface{}{"price": 10, "categoryId": comicsID})
+
+	addressID := tests.CreateAddress(t, customerToken, map[string]interface{}{
+		"city":       "Berlin",
+		"postalCode": "10115",
+		"country":    "DE",
+	})
+	o := placeTaxedOrder(t, client, customerToken, []string{comicID}, addressID)
+	require.Len(t, o.TaxLines, 1)
+	require.Equal(t, 7.0, o.TaxLines[0].Rate)
+	require.Equal(t, 10.7, o.Total)
+}
+
+func TestOrderTaxByRegion(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+	productID := tests.CreateProduct(t, adminToken, map[string]interface{}{"price": 30, "category": "TaxCat"})
+
+	newYorkID := tests.CreateAddress(t, customerToken, map[string]interface{}{
+		"city":       "New York",
+		"region":     "NY",
+		"postalCode": "10001",
+		"country":    "US",
+	})
+	o := placeTaxedOrder(t, client, customerToken, []string{productID}, newYorkID)
+	require.Len(t, o.TaxLines, 1)
+	require.Equal(t, 1.2, o.TaxTotal)
+	require.Equal(t, 31.2, o.Total)
+
+	// States without a rate are not taxed
+	oregonID := tests.CreateAddress(t, customerToken, map[string]interface{}{
+		"city":       "Portland",
+		"region":     "OR",
+		"postalCode": "97201",
+		"country":    "US",
+	})
+	o = placeTaxedOrder(t, client, customerToken, []string{productID}, oregonID)
+	require.Empty(t, o.TaxLines)
+	require.Equal(t, 30.0, o.Total)
+}
+
+func TestPickupOrderTaxedAtTheStore(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CreateUser(t, "Customer"), tests.UserPassword)
+	client := tests.NewGraphQLClient()
+	productID := tests.CreateProduct(t, adminToken, map[string]interface{}{"price": 30, "category": "TaxCat"})
+
+	// The built-in rates put the store in New York
+	req := graphql.NewRequest(`mutation($ids: [ID!]!) { placeOrder(productIds: $ids) { id subtotal taxTotal total taxLines { name rate taxableAmount amount } } }`)
+	req.Var("ids", []string{productID})
+	tests.AuthRequest(req, customerToken)
+	var resp struct {
+		PlaceOrder taxedOrder
+	}
+	err := client.Run(context.TODO(), req, &resp)
+	require.NoError(t, err)
+	require.Len(t, resp.PlaceOrder.TaxLines, 1)
+	require.Equal(t, "New York Sales Tax", resp.PlaceOrder.TaxLines[0].Name)
+	require.Equal(t, 1.2, resp.PlaceOrder.TaxTotal)
+	require.Equal(t, 31.2, resp.PlaceOrder.Total)
+}
@@ -0,0 +1,305 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/require"
+	"graphql-backend/pkg/payment"
+	"graphql-backend/tests"
+)
+
+type paymentRes struct {
+	ID             string
+	OrderID        string
+	Amount         float64
+	Status         string
+	CardLast4      *string
+	FailureReason  *string
+	RefundedAmount float64
+}
+
+func placeOrder(t *testing.T, client *graphql.Client, adminToken string, token string) (string, float64) {
+	req := graphql.NewRequest(`mutation($input: CreateProductInput!) { createProduct(input: $input) { id } }`)
+	req.Var("input", map[string]interface{}{
+		"name":     "PaidProduct",
+		"price":    12.5,
+		"inStock":  10,
+		"category": "Payments",
+	})
+	tests.AuthRequest(req, adminToken)
+	var productResp struct {
+		CreateProduct struct{ ID string }
+	}
+	err := client.Run(context.TODO(), req, &productResp)
+	require.NoError(t, err)
+
+	req = graphql.NewRequest(`mutation($ids: [ID!]!) { placeOrder(productIds: $ids) { id status total } }`)
+	req.Var("ids", []string{productResp.CreateProduct.ID})
+	tests.AuthRequest(req, token)
+	var orderResp struct {
+		PlaceOrder struct {
+			ID     string
+			Status string
+			Total  float64
+		}
+	}
+	err = client.Run(context.TODO(), req, &orderResp)
+	require.NoError(t, err)
+	require.Equal(t, "AwaitingPayment", orderResp.PlaceOrder.Status)
+
+	return orderResp.PlaceOrder.ID, orderResp.PlaceOrder.Total
+}
+
+func payOrder(client *graphql.Client, token string, orderID string, card string) (paymentRes, error) {
+	req := graphql.NewRequest(`mutation($orderId: ID!, $paymentMethod: String!) {
+		payOrder(orderId: $orderId, paymentMethod: $paymentMethod) { id orderId amount status cardLast4 failureReason refundedAmount }
+	}`)
+	req.Var("orderId", orderID)
+	req.Var("paymentMethod", card)
+	tests.AuthRequest(req, token)
+	var resp struct {
+		PayOrder paymentRes
+	}
+	err := client.Run(context.TODO(), req, &resp)
+	return resp.PayOrder, err
+}
+
+type orderPayments struct {
+	Status   string
+	Payments []paymentRes
+}
+
+func getOrder(t *testing.T, client *graphql.Client, token string, orderID string) orderPayments {
+	req := graphql.NewRequest(`query($id: ID!) { order(id: $id) { status payments { id orderId amount status cardLast4 failureReason refundedAmount } } }`)
+	req.Var("id", orderID)
+	tests.AuthRequest(req, token)
+	var resp struct {
+		Order orderPayments
+	}
+	err := client.Run(context.TODO(), req, &resp)
+	require.NoError(t, err)
+	return resp.Order
+}
+
+func TestPayOrder(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+	orderID, total := placeOrder(t, client, adminToken, customerToken)
+
+	paid, err := payOrder(client, customerToken, orderID, "4242 4242 4242 4242")
+	require.NoError(t, err)
+	require.Equal(t, "Captured", paid.Status)
+	require.Equal(t, total, paid.Amount)
+	require.Equal(t, "4242", *paid.CardLast4)
+
+	order := getOrder(t, client, customerToken, orderID)
+	require.Equal(t, "Paid", order.Status)
+	require.Len(t, order.Payments, 1)
+	require.Equal(t, paid.ID, order.Payments[0].ID)
+
+	// Paid orders can't be paid twice
+	_, err = payOrder(client, customerToken, orderID, payment.CardApproved)
+	require.Error(t, err)
+}
+
+func TestPayOrderDeclinedThenRetried(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+	orderID, _ := placeOrder(t, client, adminToken, customerToken)
+
+	declined, err := payOrder(client, customerToken, orderID, payment.CardInsufficientFunds)
+	require.NoError(t, err)
+	require.Equal(t, "Failed", declined.Status)
+	require.Equal(t, "insufficient_funds", *declined.FailureReason)
+	require.Equal(t, "AwaitingPayment", getOrder(t, client, customerToken, orderID).Status)
+
+	invalid, err := payOrder(client, customerToken, orderID, "4242424242424241")
+	require.NoError(t, err)
+	require.Equal(t, "Failed", invalid.Status)
+	require.Equal(t, "invalid_card_number", *invalid.FailureReason)
+
+	paid, err := payOrder(client, customerToken, orderID, payment.CardApproved)
+	require.NoError(t, err)
+	require.Equal(t, "Captured", paid.Status)
+
+	order := getOrder(t, client, customerToken, orderID)
+	require.Equal(t, "Paid", order.Status)
+	require.Len(t, order.Payments, 3)
+	require.Equal(t, declined.ID, order.Payments[0].ID)
+}
+
+// requireWebhook skips the test when the server runs without PAYMENT_WEBHOOK_SECRET, the webhook is disabled then
+func requireWebhook(t *testing.T) {
+	resp, err := http.Post(tests.URL("/payments/webhook"), "application/json", bytes.NewReader([]byte(`{}`)))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		t.Skip("the payment webhook is disabled, start the server with PAYMENT_WEBHOOK_SECRET")
+	}
+}
+
+func TestPayOrderConfirmedByWebhook(t *testing.T) {
+	requireWebhook(t)
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+	approvedOrderID, _ := placeOrder(t, client, adminToken, customerToken)
+	declinedOrderID, _ := placeOrder(t, client, adminToken, customerToken)
+
+	pending, err := payOrder(client, customerToken, approvedOrderID, payment.CardAsyncApproved)
+	require.NoError(t, err)
+	require.Equal(t, "Pending", pending.Status)
+	require.Equal(t, "AwaitingPayment", getOrder(t, client, customerToken, approvedOrderID).Status)
+
+	// A second payment can't be started while one is pending
+	_, err = payOrder(client, customerToken, approvedOrderID, payment.CardApproved)
+	require.Error(t, err)
+
+	pending, err = payOrder(client, customerToken, declinedOrderID, payment.CardAsyncDeclined)
+	require.NoError(t, err)
+	require.Equal(t, "Pending", pending.Status)
+
+	require.Eventually(t, func() bool {
+		return getOrder(t, client, customerToken, approvedOrderID).Status == "Paid"
+	}, 5*time.Second, 100*time.Millisecond)
+	require.Equal(t, "Captured", getOrder(t, client, customerToken, approvedOrderID).Payments[0].Status)
+
+	require.Eventually(t, func() bool {
+		return getOrder(t, client, customerToken, declinedOrderID).Payments[0].Status == "Failed"
+	}, 5*time.Second, 100*time.Millisecond)
+	require.Equal(t, "AwaitingPayment", getOrder(t, client, customerToken, declinedOrderID).Status)
+}
+
+func TestForgedWebhookIsRejected(t *testing.T) {
+	requireWebhook(t)
+	body := []byte(`{"reference":"fake_forged","status":"Authorized"}`)
+	req, err := http.NewRequest(http.MethodPost, tests.URL("/payments/webhook"), bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set(payment.SignatureHeader, "00ff")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestUnpaidOrderCannotBeCompleted(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+	orderID, _ := placeOrder(t, client, adminToken, customerToken)
+
+	req := graphql.NewRequest(`mutation($id: ID!, $status: String!) { updateOrderStatus(id: $id, status: $status) { status } }`)
+	req.Var("id", orderID)
+	req.Var("status", "Completed")
+	tests.AuthRequest(req, adminToken)
+	var resp struct {
+		UpdateOrderStatus struct{ Status string }
+	}
+	err := client.Run(context.TODO(), req, &resp)
+	require.Error(t, err)
+
+	_, err = payOrder(client, customerToken, orderID, payment.CardApproved)
+	require.NoError(t, err)
+	err = client.Run(context.TODO(), req, &resp)
+	require.NoError(t, err)
+	require.Equal(t, "Completed", resp.UpdateOrderStatus.Status)
+}
+
+func TestPayOrderOfAnotherUser(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+	orderID, _ := placeOrder(t, client, adminToken, adminToken)
+
+	_, err := payOrder(client, customerToken, orderID, payment.CardApproved)
+	require.Error(t, err)
+}
+
+func TestCancelPaidOrderRefundsPayment(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+	orderID, total := placeOrder(t, client, adminToken, customerToken)
+
+	_, err := payOrder(client, customerToken, orderID, payment.CardApproved)
+	require.NoError(t, err)
+
+	req := graphql.NewRequest(`mutation($id: ID!, $status: String!) { updateOrderStatus(id: $id, status: $status) { status } }`)
+	req.Var("id", orderID)
+	req.Var("status", "Cancelled")
+
+	// Only admins change order statuses
+	tests.AuthRequest(req, customerToken)
+	var resp struct {
+		UpdateOrderStatus struct{ Status string }
+	}
+	err = client.Run(context.TODO(), req, &resp)
+	require.Error(t, err)
+
+	req.Header.Del("Authorization")
+	tests.AuthRequest(req, adminToken)
+	err = client.Run(context.TODO(), req, &resp)
+	require.NoError(t, err)
+	require.Equal(t, "Cancelled", resp.UpdateOrderStatus.Status)
+
+	order := getOrder(t, client, customerToken, orderID)
+	require.Equal(t, "Cancelled", order.Status)
+	require.Equal(t, "Refunded", order.Payments[0].Status)
+	require.Equal(t, total, order.Payments[0].RefundedAmount)
+
+	// Cancelled orders can't be completed
+	req.Var("status", "Completed")
+	err = client.Run(context.TODO(), req, &resp)
+	require.Error(t, err)
+}
+
+func TestConcurrentCancelsRefundOnce(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+	orderID, total := placeOrder(t, client, adminToken, customerToken)
+	_, err := payOrder(client, customerToken, orderID, payment.CardApproved)
+	require.NoError(t, err)
+
+	// cancellations and refunds of the order race each other, only one of them gets to refund the payment
+	var wg sync.WaitGroup
+	errs := make(chan error, 6)
+	for i := range 6 {
+		req := graphql.NewRequest(`mutation($id: ID!) { updateOrderStatus(id: $id, status: "Cancelled") { status } }`)
+		if i%2 == 1 {
+			req = graphql.NewRequest(`mutation($id: ID!) { refundOrder(orderId: $id, reason: "Changed mind") { status } }`)
+		}
+		req.Var("id", orderID)
+		tests.AuthRequest(req, adminToken)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- client.Run(context.TODO(), req, &map[string]interface{}{})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+		}
+	}
+	require.Equal(t, 1, succeeded)
+
+	order := getOrder(t, client, customerToken, orderID)
+	require.Contains(t, []string{"Cancelled", "Refunded"}, order.Status)
+	require.Len(t, order.Payments, 1)
+	require.Equal(t, "Refunded", order.Payments[0].Status)
+	require.Equal(t, total, order.Payments[0].RefundedAmount)
+}
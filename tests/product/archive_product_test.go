@@ -0,0 +1,146 @@
+package product
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/require"
+	"graphql-backend/tests"
+)
+
+type archivedProduct struct {
+	ID         string
+	ArchivedAt *string
+}
+
+func runProductMutation(client *graphql.Client, token string, mutation string, id string) (archivedProduct, error) {
+	req := graphql.NewRequest(mutation)
+	req.Var("id", id)
+	tests.AuthRequest(req, token)
+	var resp map[string]archivedProduct
+	err := client.Run(context.TODO(), req, &resp)
+	for _, product := range resp {
+		return product, err
+	}
+	return archivedProduct{}, err
+}
+
+const archiveProduct = `mutation($id: ID!) { archiveProduct(id: $id) { id archivedAt } }`
+const restoreProduct = `mutation($id: ID!) { restoreProduct(id: $id) { id archivedAt } }`
+
+func listProductIDs(t *testing.T, client *graphql.Client, token string, field string, category string) []string {
+	req := graphql.NewRequest(`query($category: String) { ` + field + `(category: $category, limit: 50) { id } }`)
+	req.Var("category", category)
+	tests.AuthRequest(req, token)
+	var resp map[string][]struct{ ID string }
+	err := client.Run(context.TODO(), req, &resp)
+	require.NoError(t, err)
+
+	var ids []string
+	for _, product := range resp[field] {
+		ids = append(ids, product.ID)
+	}
+	return ids
+}
+
+func TestArchiveProduct(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+	category := "Archive " + uuid.NewString()[:8]
+
+	product := createCategoryProduct(t, client, adminToken, map[string]interface{}{"category": category})
+	orderReq := graphql.NewRequest(`mutation($ids: [ID!]!) { placeOrder(productIds: $ids) { id } }`)
+	orderReq.Var("ids", []string{product.ID})
+	tests.AuthRequest(orderReq, customerToken)
+	var orderResp struct {
+		PlaceOrder struct{ ID string }
+	}
+	err := client.Run(context.TODO(), orderReq, &orderResp)
+	require.NoError(t, err)
+
+	_, err = runProductMutation(client, customerToken, archiveProduct, product.ID)
+	require.Error(t, err)
+	archived, err := runProductMutation(client, adminToken, archiveProduct, product.ID)
+	require.NoError(t, err)
+	require.NotNil(t, archived.ArchivedAt)
+
+	// Archived products are only listed for admins
+	require.Empty(t, listProductIDs(t, client, customerToken, "products", category))
+	require.Equal(t, []string{product.ID}, listProductIDs(t, client, adminToken, "archivedProducts", category))
+	require.Error(t, client.Run(context.TODO(), graphql.NewRequest(`query { archivedProducts { id } }`), &struct{}{}))
+
+	// They can't be bought anymore
+	err = client.Run(context.TODO(), orderReq, &orderResp)
+	require.Error(t, err)
+	cartReq := graphql.NewRequest(`mutation($productId: ID!) { addToCart(productId: $productId) { token } }`)
+	cartReq.Var("productId", product.ID)
+	err = client.Run(context.TODO(), cartReq, &struct{}{})
+	require.Error(t, err)
+
+	// but still resolve in the orders that have them
+	getOrderReq := graphql.NewRequest(`query($id: ID!) { order(id: $id) { products { id archivedAt } } }`)
+	getOrderReq.Var("id", orderResp.PlaceOrder.ID)
+	tests.AuthRequest(getOrderReq, customerToken)
+	var getOrderResp struct {
+		Order struct {
+			Products []archivedProduct
+		}
+	}
+	err = client.Run(context.TODO(), getOrderReq, &getOrderResp)
+	require.NoError(t, err)
+	require.Len(t, getOrderResp.Order.Products, 1)
+	require.NotNil(t, getOrderResp.Order.Products[0].ArchivedAt)
+
+	// Ordered products can't be deleted
+	deleteReq := graphql.NewRequest(`mutation($id: ID!) { deleteProduct(id: $id) }`)
+	deleteReq.Var("id", product.ID)
+	tests.AuthRequest(deleteReq, adminToken)
+	err = client.Run(context.TODO(), deleteReq, &struct{}{})
+	require.Error(t, err)
+
+	restored, err := runProductMutation(client, adminToken, restoreProduct, product.ID)
+	require.NoError(t, err)
+	require.Nil(t, restored.ArchivedAt)
+	require.Equal(t, []string{product.ID}, listProductIDs(t, client, customerToken, "products", category))
+}
+
+func TestDeleteProduct(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	client := tests.NewGraphQLClient()
+
+	product := createCategoryProduct(t, client, adminToken, map[string]interface{}{"category": "Delete " + uuid.NewString()[:8]})
+
+	// A guest cart having the product doesn't prevent its deletion, the item is removed from it
+	cartReq := graphql.NewRequest(`mutation($productId: ID!) { addToCart(productId: $productId) { token } }`)
+	cartReq.Var("productId", product.ID)
+	var cartResp struct {
+		AddToCart struct{ Token string }
+	}
+	err := client.Run(context.TODO(), cartReq, &cartResp)
+	require.NoError(t, err)
+
+	deleteReq := graphql.NewRequest(`mutation($id: ID!) { deleteProduct(id: $id) }`)
+	deleteReq.Var("id", product.ID)
+	tests.AuthRequest(deleteReq, adminToken)
+	err = client.Run(context.TODO(), deleteReq, &struct{}{})
+	require.NoError(t, err)
+
+	getReq := graphql.NewRequest(`query($id: ID!) { product(id: $id) { id } }`)
+	getReq.Var("id", product.ID)
+	err = client.Run(context.TODO(), getReq, &struct{}{})
+	require.Error(t, err)
+
+	cartQuery := graphql.NewRequest(`query($token: String) { cart(cartToken: $token) { items { productId } } }`)
+	cartQuery.Var("token", cartResp.AddToCart.Token)
+	var cartQueryResp struct {
+		Cart struct {
+			Items []struct{ ProductID string }
+		}
+	}
+	err = client.Run(context.TODO(), cartQuery, &cartQueryResp)
+	require.NoError(t, err)
+	require.Empty(t, cartQueryResp.Cart.Items)
+}
@@ -0,0 +1,178 @@
+package product
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/require"
+	"graphql-backend/tests"
+)
+
+type categoryRes struct {
+	ID       string
+	Name     string
+	Slug     string
+	ParentID *string
+	Children []categoryRes
+}
+
+const categoryFields = `{ id name slug parentId children { id name slug parentId children { id name } } }`
+
+func runCategoryMutation(client *graphql.Client, token string, mutation string, input map[string]interface{}) (categoryRes, error) {
+	req := graphql.NewRequest(mutation)
+	req.Var("input", input)
+	tests.AuthRequest(req, token)
+	var resp map[string]categoryRes
+	err := client.Run(context.TODO(), req, &resp)
+	for _, category := range resp {
+		return category, err
+	}
+	return categoryRes{}, err
+}
+
+const createCategory = `mutation($input: CreateCategoryInput!) { createCategory(input: $input) ` + categoryFields + ` }`
+const updateCategory = `mutation($input: UpdateCategoryInput!) { updateCategory(input: $input) ` + categoryFields + ` }`
+
+type categoryProduct struct {
+	ID         string
+	Category   string
+	CategoryID *string
+}
+
+func createCategoryProduct(t *testing.T, client *graphql.Client, token string, input map[string]interface{}) categoryProduct {
+	input["name"] = "CategorizedProduct"
+	input["price"] = 5
+	input["inStock"] = 1
+	req := graphql.NewRequest(`mutation($input: CreateProductInput!) { createProduct(input: $input) { id category categoryId } }`)
+	req.Var("input", input)
+	tests.AuthRequest(req, token)
+	var resp struct {
+		CreateProduct categoryProduct
+	}
+	err := client.Run(context.TODO(), req, &resp)
+	require.NoError(t, err)
+	return resp.CreateProduct
+}
+
+func TestCategoryTree(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+	suffix := uuid.NewString()[:8]
+
+	clothing, err := runCategoryMutation(client, adminToken, createCategory, map[string]interface{}{"name": "Clothing " + suffix})
+	require.NoError(t, err)
+	require.Equal(t, "clothing-"+suffix, clothing.Slug)
+	require.Nil(t, clothing.ParentID)
+
+	shirts, err := runCategoryMutation(client, adminToken, createCategory, map[string]interface{}{"name": "Shirts", "slug": "shirts-" + suffix, "parentId": clothing.ID})
+	require.NoError(t, err)
+	require.Equal(t, clothing.ID, *shirts.ParentID)
+
+	_, err = runCategoryMutation(client, adminToken, createCategory, map[string]interface{}{"name": "Shirts", "slug": "shirts-" + suffix})
+	require.Error(t, err)
+	_, err = runCategoryMutation(client, customerToken, createCategory, map[string]interface{}{"name": "Customer " + suffix})
+	require.Error(t, err)
+
+	// A category can't be moved below one of its subcategories
+	_, err = runCategoryMutation(client, adminToken, updateCategory, map[string]interface{}{"id": clothing.ID, "parentId": shirts.ID})
+	require.Error(t, err)
+
+	shirt := createCategoryProduct(t, client, adminToken, map[string]interface{}{"categoryId": shirts.ID})
+	require.Equal(t, "Shirts", shirt.Category)
+
+	// Category names still work, they are top-level categories created when missing
+	legacy := createCategoryProduct(t, client, adminToken, map[string]interface{}{"category": "Legacy " + suffix})
+	require.NotNil(t, legacy.CategoryID)
+	again := createCategoryProduct(t, client, adminToken, map[string]interface{}{"category": "legacy " + suffix})
+	require.Equal(t, *legacy.CategoryID, *again.CategoryID)
+
+	req := graphql.NewRequest(`query { categories ` + categoryFields + ` }`)
+	var treeResp struct {
+		Categories []categoryRes
+	}
+	err = client.Run(context.TODO(), req, &treeResp)
+	require.NoError(t, err)
+	var found bool
+	for _, category := range treeResp.Categories {
+		if category.ID == clothing.ID {
+			found = true
+			require.Len(t, category.Children, 1)
+			require.Equal(t, shirts.ID, category.Children[0].ID)
+		}
+	}
+	require.True(t, found)
+
+	// Products of the subcategories are listed under their ancestors
+	listReq := graphql.NewRequest(`query($categoryId: ID) { products(categoryId: $categoryId, limit: 50) { id } }`)
+	listReq.Var("categoryId", clothing.ID)
+	tests.AuthRequest(listReq, customerToken)
+	var listResp struct {
+		Products []struct{ ID string }
+	}
+	err = client.Run(context.TODO(), listReq, &listResp)
+	require.NoError(t, err)
+	require.Len(t, listResp.Products, 1)
+	require.Equal(t, shirt.ID, listResp.Products[0].ID)
+
+	renamed, err := runCategoryMutation(client, adminToken, updateCategory, map[string]interface{}{"id": shirts.ID, "name": "T-Shirts", "moveToTop": true})
+	require.NoError(t, err)
+	require.Nil(t, renamed.ParentID)
+	productReq := graphql.NewRequest(`query($id: ID!) { product(id: $id) { id category categoryId } }`)
+	productReq.Var("id", shirt.ID)
+	tests.AuthRequest(productReq, customerToken)
+	var productResp struct {
+		Product categoryProduct
+	}
+	err = client.Run(context.TODO(), productReq, &productResp)
+	require.NoError(t, err)
+	require.Equal(t, "T-Shirts", productResp.Product.Category)
+
+	getReq := graphql.NewRequest(`query($slug: String) { category(slug: $slug) ` + categoryFields + ` }`)
+	getReq.Var("slug", "clothing-"+suffix)
+	var getResp struct {
+		Category categoryRes
+	}
+	err = client.Run(context.TODO(), getReq, &getResp)
+	require.NoError(t, err)
+	require.Equal(t, clothing.ID, getResp.Category.ID)
+	require.Empty(t, getResp.Category.Children)
+
+	// Categories with products can't be deleted
+	deleteReq := graphql.NewRequest(`mutation($id: ID!) { deleteCategory(id: $id) }`)
+	deleteReq.Var("id", shirts.ID)
+	tests.AuthRequest(deleteReq, adminToken)
+	err = client.Run(context.TODO(), deleteReq, &struct{}{})
+	require.Error(t, err)
+
+	deleteReq.Var("id", clothing.ID)
+	err = client.Run(context.TODO(), deleteReq, &struct{}{})
+	require.NoError(t, err)
+}
+
+func TestFailedCreateLeavesNoCategory(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	client := tests.NewGraphQLClient()
+	suffix := uuid.NewString()[:8]
+	sku := "CAT-" + suffix
+	tests.CreateProduct(t, adminToken, map[string]interface{}{"sku": sku})
+
+	// the SKU is taken, the category named by the product is not created
+	req := graphql.NewRequest(`mutation($input: CreateProductInput!) { createProduct(input: $input) { id } }`)
+	req.Var("input", map[string]interface{}{
+		"name":        "Duplicate",
+		"description": "Created by the tests",
+		"price":       5,
+		"inStock":     1,
+		"sku":         sku,
+		"category":    "Orphan " + suffix,
+	})
+	tests.AuthRequest(req, adminToken)
+	require.Error(t, client.Run(context.TODO(), req, &map[string]interface{}{}))
+
+	getReq := graphql.NewRequest(`query($slug: String) { category(slug: $slug) { id } }`)
+	getReq.Var("slug", "orphan-"+suffix)
+	require.Error(t, client.Run(context.TODO(), getReq, &map[string]interface{}{}))
+}
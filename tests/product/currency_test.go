@@ -0,0 +1,68 @@
+package product
+
+import (
+	"context"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/require"
+	"graphql-backend/tests"
+)
+
+func TestProductPriceInCurrency(t *testing.T) {
+	token := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	client := tests.NewGraphQLClient()
+	createReq := graphql.NewRequest(`mutation($input: CreateProductInput!) { createProduct(input: $input) { id price currency } }`)
+	createReq.Var("input", map[string]interface{}{
+		"name":     "CurrencyProduct",
+		"price":    10.0,
+		"inStock":  5,
+		"category": "CurrencyCat",
+	})
+	tests.AuthRequest(createReq, token)
+	var createResp struct {
+		CreateProduct struct {
+			ID       string
+			Price    float64
+			Currency string
+		}
+	}
+	err := client.Run(context.TODO(), createReq, &createResp)
+	require.NoError(t, err)
+	require.Equal(t, "USD", createResp.CreateProduct.Currency)
+
+	// Prices are converted with the built-in rates
+	getReq := graphql.NewRequest(`query($id: ID!, $currency: String) { product(id: $id, currency: $currency) { id price currency } }`)
+	getReq.Var("id", createResp.CreateProduct.ID)
+	getReq.Var("currency", "eur")
+	tests.AuthRequest(getReq, token)
+	var getResp struct {
+		Product struct {
+			ID       string
+			Price    float64
+			Currency string
+		}
+	}
+	err = client.Run(context.TODO(), getReq, &getResp)
+	require.NoError(t, err)
+	require.Equal(t, "EUR", getResp.Product.Currency)
+	require.Equal(t, 9.2, getResp.Product.Price)
+
+	listReq := graphql.NewRequest(`query { products(limit: 5, currency: "JPY") { price currency } }`)
+	tests.AuthRequest(listReq, token)
+	var listResp struct {
+		Products []struct {
+			Price    float64
+			Currency string
+		}
+	}
+	err = client.Run(context.TODO(), listReq, &listResp)
+	require.NoError(t, err)
+	for _, product := range listResp.Products {
+		require.Equal(t, "JPY", product.Currency)
+	}
+
+	getReq.Var("currency", "XYZ")
+	err = client.Run(context.TODO(), getReq, &getResp)
+	require.Error(t, err)
+}
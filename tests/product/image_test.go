@@ -0,0 +1,189 @@
+package product
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/require"
+	"graphql-backend/tests"
+)
+
+type productImage struct {
+	ID           string
+	URL          string
+	ThumbnailURL string
+	ContentType  string
+	Width        int32
+	Height       int32
+	Size         int32
+}
+
+type uploadResponse struct {
+	Data struct {
+		UploadProductImage struct {
+			ID     string
+			Images []productImage
+		}
+	}
+	Errors []struct{ Message string }
+}
+
+func uploadImage(t *testing.T, token string, productID string, filename string, content []byte) uploadResponse {
+	var res uploadResponse
+	postUpload(t, token, `mutation($productId: ID!, $file: Upload!) { uploadProductImage(productId: $productId, file: $file) { id images { id url thumbnailUrl contentType width height size } } }`,
+		map[string]interface{}{"productId": productID}, filename, content, &res)
+	return res
+}
+
+// postUpload sends the file as the $file variable of a GraphQL multipart request, which the GraphQL client doesn't support
+func postUpload(t *testing.T, token string, query string, variables map[string]interface{}, filename string, content []byte, res interface{}) {
+	variables["file"] = nil
+	operations, err := json.Marshal(map[string]interface{}{
+		"query":     query,
+		"variables": variables,
+	})
+	require.NoError(t, err)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	require.NoError(t, form.WriteField("operations", string(operations)))
+	require.NoError(t, form.WriteField("map", `{"0": ["variables.file"]}`))
+	file, err := form.CreateFormFile("0", filename)
+	require.NoError(t, err)
+	_, err = file.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	req, err := http.NewRequest(http.MethodPost, tests.URL("/query"), &body)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(res))
+}
+
+func getImage(t *testing.T, url string) (*http.Response, []byte) {
+	resp, err := http.Get(tests.URL(url))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	content, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, content
+}
+
+func TestUploadProductImage(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+	product := createCategoryProduct(t, client, adminToken, map[string]interface{}{"category": "Images"})
+
+	var pngFile bytes.Buffer
+	img := image.NewNRGBA(image.Rect(0, 0, 800, 400))
+	img.SetNRGBA(0, 0, color.NRGBA{R: 255, A: 255})
+	require.NoError(t, png.Encode(&pngFile, img))
+
+	res := uploadImage(t, customerToken, product.ID, "photo.png", pngFile.Bytes())
+	require.NotEmpty(t, res.Errors)
+
+	res = uploadImage(t, adminToken, product.ID, "photo.png", pngFile.Bytes())
+	require.Empty(t, res.Errors)
+	require.Len(t, res.Data.UploadProductImage.Images, 1)
+	uploaded := res.Data.UploadProductImage.Images[0]
+	require.Equal(t, "image/png", uploaded.ContentType)
+	require.Equal(t, int32(800), uploaded.Width)
+	require.Equal(t, int32(400), uploaded.Height)
+	require.Equal(t, int32(pngFile.Len()), uploaded.Size)
+
+	resp, content := getImage(t, uploaded.URL)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "image/png", resp.Header.Get("Content-Type"))
+	require.Equal(t, pngFile.Bytes(), content)
+
+	resp, content = getImage(t, uploaded.ThumbnailURL)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	thumbnail, _, err := image.DecodeConfig(bytes.NewReader(content))
+	require.NoError(t, err)
+	require.Equal(t, 256, thumbnail.Width)
+	require.Equal(t, 128, thumbnail.Height)
+
+	// The type is detected from the content, not from the file name
+	res = uploadImage(t, adminToken, product.ID, "photo.png", []byte("<html>not an image</html>"))
+	require.NotEmpty(t, res.Errors)
+	res = uploadImage(t, adminToken, product.ID, "photo.jpg", append([]byte{0xff, 0xd8, 0xff}, make([]byte, 100)...))
+	require.NotEmpty(t, res.Errors)
+	res = uploadImage(t, adminToken, product.ID, "large.png", append(pngFile.Bytes(), make([]byte, 5<<20)...))
+	require.NotEmpty(t, res.Errors)
+
+	var jpegFile bytes.Buffer
+	require.NoError(t, jpeg.Encode(&jpegFile, image.NewGray(image.Rect(0, 0, 100, 300)), nil))
+	res = uploadImage(t, adminToken, product.ID, "upload", jpegFile.Bytes())
+	require.Empty(t, res.Errors)
+	require.Len(t, res.Data.UploadProductImage.Images, 2)
+	require.Equal(t, "image/jpeg", res.Data.UploadProductImage.Images[1].ContentType)
+
+	deleteReq := graphql.NewRequest(`mutation($productId: ID!, $id: ID!) { deleteProductImage(productId: $productId, id: $id) { images { id } } }`)
+	deleteReq.Var("productId", product.ID)
+	deleteReq.Var("id", uploaded.ID)
+	tests.AuthRequest(deleteReq, adminToken)
+	var deleteResp struct {
+		DeleteProductImage struct {
+			Images []struct{ ID string }
+		}
+	}
+	err = client.Run(context.TODO(), deleteReq, &deleteResp)
+	require.NoError(t, err)
+	require.Len(t, deleteResp.DeleteProductImage.Images, 1)
+
+	resp, _ = getImage(t, uploaded.URL)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp, _ = getImage(t, "/images/..%2F..%2Fstore%2Fdata%2Fexample.png")
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestConcurrentImageUploads(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	client := tests.NewGraphQLClient()
+	product := createCategoryProduct(t, client, adminToken, map[string]interface{}{"category": "Images"})
+
+	var pngFile bytes.Buffer
+	require.NoError(t, png.Encode(&pngFile, image.NewGray(image.Rect(0, 0, 40, 40))))
+
+	// the uploads run in parallel subtests, the group returns once they are all done
+	t.Run("uploads", func(t *testing.T) {
+		for range 5 {
+			t.Run("upload", func(t *testing.T) {
+				t.Parallel()
+				require.Empty(t, uploadImage(t, adminToken, product.ID, "photo.png", pngFile.Bytes()).Errors)
+			})
+		}
+	})
+
+	req := graphql.NewRequest(`query($id: ID!) { product(id: $id) { images { id } } }`)
+	req.Var("id", product.ID)
+	tests.AuthRequest(req, adminToken)
+	var resp struct {
+		Product struct {
+			Images []struct{ ID string }
+		}
+	}
+	require.NoError(t, client.Run(context.TODO(), req, &resp))
+	require.Len(t, resp.Product.Images, 5)
+
+	// Images too large to decode safely are refused
+	var largeFile bytes.Buffer
+	require.NoError(t, png.Encode(&largeFile, image.NewGray(image.Rect(0, 0, 5000, 4000))))
+	require.NotEmpty(t, uploadImage(t, adminToken, product.ID, "large.png", largeFile.Bytes()).Errors)
+}
@@ -0,0 +1,206 @@
+package product
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/require"
+	"graphql-backend/tests"
+)
+
+const importProducts = `mutation($file: Upload!, $format: CatalogFormat, $dryRun: Boolean!) {
+	importProducts(file: $file, format: $format, dryRun: $dryRun) { dryRun applied created updated errors { line sku message } }
+}`
+
+type importResponse struct {
+	Data struct {
+		ImportProducts struct {
+			DryRun  bool
+			Applied bool
+			Created int32
+			Updated int32
+			Errors  []struct {
+				Line    int32
+				Sku     *string
+				Message string
+			}
+		}
+	}
+	Errors []struct{ Message string }
+}
+
+func uploadCatalog(t *testing.T, token string, filename string, content string, dryRun bool) importResponse {
+	var res importResponse
+	postUpload(t, token, importProducts, map[string]interface{}{"dryRun": dryRun}, filename, []byte(content), &res)
+	return res
+}
+
+type importedProduct struct {
+	ID          string
+	Name        string
+	Sku         *string
+	Price       float64
+	InStock     int32
+	Description *string
+	Variants    []struct {
+		Sku             string
+		Price           float64
+		PriceOverridden bool
+		InStock         int32
+	}
+}
+
+func getImportedProducts(t *testing.T, client *graphql.Client, token string, category string) []importedProduct {
+	req := graphql.NewRequest(`query($category: String) { products(category: $category, limit: 50) { id name sku price inStock description variants { sku price priceOverridden inStock } } }`)
+	req.Var("category", category)
+	tests.AuthRequest(req, token)
+	var resp struct {
+		Products []importedProduct
+	}
+	err := client.Run(context.TODO(), req, &resp)
+	require.NoError(t, err)
+	return resp.Products
+}
+
+func TestImportProducts(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+	suffix := uuid.NewString()[:8]
+	category := "Import " + suffix
+	shirt := "SHIRT-" + suffix
+	mug := "MUG-" + suffix
+
+	csv := fmt.Sprintf(`sku,parent_sku,name,description,price,category,in_stock,options
+%[1]s,,Shirt,Cotton,20,%[3]s,,Size=S|M
+%[1]s-S,%[1]s,,,,,3,Size=S
+%[1]s-M,%[1]s,,,22.50,,4,Size=M
+%[2]s,,Mug,,8,%[3]s,10,
+`, shirt, mug, category)
+
+	res := uploadCatalog(t, customerToken, "catalog.csv", csv, false)
+	require.NotEmpty(t, res.Errors)
+
+	// A dry run validates the rows without saving them
+	res = uploadCatalog(t, adminToken, "catalog.csv", csv, true)
+	require.Empty(t, res.Errors)
+	require.True(t, res.Data.ImportProducts.DryRun)
+	require.False(t, res.Data.ImportProducts.Applied)
+	require.Equal(t, int32(4), res.Data.ImportProducts.Created)
+	require.Empty(t, getImportedProducts(t, client, adminToken, category))
+
+	res = uploadCatalog(t, adminToken, "catalog.csv", csv, false)
+	require.Empty(t, res.Errors)
+	require.True(t, res.Data.ImportProducts.Applied)
+	require.Equal(t, int32(4), res.Data.ImportProducts.Created)
+	require.Equal(t, int32(0), res.Data.ImportProducts.Updated)
+
+	products := getImportedProducts(t, client, adminToken, category)
+	require.Len(t, products, 2)
+	byName := map[string]importedProduct{}
+	for _, product := range products {
+		byName[product.Name] = product
+	}
+	require.Equal(t, shirt, *byName["Shirt"].Sku)
+	require.Equal(t, int32(7), byName["Shirt"].InStock)
+	require.Len(t, byName["Shirt"].Variants, 2)
+	require.Equal(t, 20.0, byName["Shirt"].Variants[0].Price)
+	require.False(t, byName["Shirt"].Variants[0].PriceOverridden)
+	require.Equal(t, 22.5, byName["Shirt"].Variants[1].Price)
+	require.Equal(t, int32(10), byName["Mug"].InStock)
+
+	// Rows are matched by SKU, and nothing is saved when any row fails
+	invalid := fmt.Sprintf(`sku,price,in_stock
+%[1]s,9,
+%[2]s-M,,-1
+NEW-%[3]s,5,1
+`, mug, shirt, suffix)
+	res = uploadCatalog(t, adminToken, "update.csv", invalid, false)
+	require.Empty(t, res.Errors)
+	require.False(t, res.Data.ImportProducts.Applied)
+	require.Len(t, res.Data.ImportProducts.Errors, 2)
+	require.Equal(t, int32(3), res.Data.ImportProducts.Errors[0].Line)
+	require.Equal(t, shirt+"-M", *res.Data.ImportProducts.Errors[0].Sku)
+	require.Equal(t, "stock cannot be negative", res.Data.ImportProducts.Errors[0].Message)
+	require.Equal(t, int32(4), res.Data.ImportProducts.Errors[1].Line)
+	products = getImportedProducts(t, client, adminToken, category)
+	require.Len(t, products, 2)
+	for _, product := range products {
+		if product.Name == "Mug" {
+			require.Equal(t, 8.0, product.Price)
+		}
+	}
+
+	jsonl := fmt.Sprintf(`{"sku": %[1]q, "price": 9, "description": "Stoneware"}
+{"sku": %[2]q, "in_stock": 5}
+`, mug, shirt+"-S")
+	res = uploadCatalog(t, adminToken, "update.jsonl", jsonl, false)
+	require.Empty(t, res.Errors)
+	require.Empty(t, res.Data.ImportProducts.Errors)
+	require.Equal(t, int32(0), res.Data.ImportProducts.Created)
+	require.Equal(t, int32(2), res.Data.ImportProducts.Updated)
+
+	products = getImportedProducts(t, client, adminToken, category)
+	require.Len(t, products, 2)
+	for _, product := range products {
+		if product.Name == "Mug" {
+			require.Equal(t, 9.0, product.Price)
+			require.Equal(t, "Stoneware", *product.Description)
+		} else {
+			require.Equal(t, int32(9), product.InStock)
+		}
+	}
+
+	// The format is required for other file names
+	res = uploadCatalog(t, adminToken, "catalog.txt", csv, true)
+	require.NotEmpty(t, res.Errors)
+}
+
+func TestExportProducts(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+	suffix := uuid.NewString()[:8]
+	category := "Export " + suffix
+
+	res := uploadCatalog(t, adminToken, "catalog.jsonl", fmt.Sprintf(`{"sku": "LAMP-%[1]s", "name": "Lamp", "price": 30, "category": %[2]q, "in_stock": 2}
+{"sku": "OLD-%[1]s", "name": "Old lamp", "price": 10, "category": %[2]q, "in_stock": 1}
+`, suffix, category), false)
+	require.Empty(t, res.Errors)
+	require.True(t, res.Data.ImportProducts.Applied)
+
+	ids := listProductIDs(t, client, adminToken, "products", category)
+	require.Len(t, ids, 2)
+	for _, id := range ids {
+		req := graphql.NewRequest(`query($id: ID!) { product(id: $id) { name } }`)
+		req.Var("id", id)
+		tests.AuthRequest(req, adminToken)
+		var resp struct{ Product struct{ Name string } }
+		require.NoError(t, client.Run(context.TODO(), req, &resp))
+		if resp.Product.Name == "Old lamp" {
+			archiveReq := graphql.NewRequest(`mutation($id: ID!) { archiveProduct(id: $id) { id } }`)
+			archiveReq.Var("id", id)
+			tests.AuthRequest(archiveReq, adminToken)
+			require.NoError(t, client.Run(context.TODO(), archiveReq, &map[string]interface{}{}))
+		}
+	}
+
+	exportReq := graphql.NewRequest(`query($format: CatalogFormat!) { exportProducts(format: $format) }`)
+	exportReq.Var("format", "CSV")
+	tests.AuthRequest(exportReq, customerToken)
+	var exportResp struct{ ExportProducts string }
+	require.Error(t, client.Run(context.TODO(), exportReq, &exportResp))
+
+	tests.AuthRequest(exportReq, adminToken)
+	require.NoError(t, client.Run(context.TODO(), exportReq, &exportResp))
+	require.Contains(t, exportResp.ExportProducts, "sku,parent_sku,name,description,price,category,in_stock,options\n")
+	require.Contains(t, exportResp.ExportProducts, fmt.Sprintf("LAMP-%s,,Lamp,,30.00,%s,2,\n", suffix, category))
+	require.NotContains(t, exportResp.ExportProducts, "OLD-"+suffix)
+
+	exportReq.Var("format", "JSONL")
+	require.NoError(t, client.Run(context.TODO(), exportReq, &exportResp))
+	require.Contains(t, exportResp.ExportProducts, fmt.Sprintf(`{"sku":"LAMP-%s","name":"Lamp","price":30.00,"category":%q,"in_stock":2}`, suffix, category))
+}
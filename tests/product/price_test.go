@@ -0,0 +1,296 @@
+package product
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/require"
+	"graphql-backend/tests"
+)
+
+type priceChange struct {
+	VariantID        *string
+	OldPrice         float64
+	NewPrice         float64
+	ActorID          *string
+	Source           string
+	ScheduledPriceID *string
+}
+
+type scheduledPrice struct {
+	ID            string
+	Price         float64
+	Status        string
+	PreviousPrice *float64
+}
+
+type pricedProduct struct {
+	Price        float64
+	PriceHistory []priceChange
+}
+
+const scheduledPriceFields = `{ id price status previousPrice }`
+
+func updatePrice(t *testing.T, client *graphql.Client, token string, id string, input map[string]interface{}) {
+	input["id"] = id
+	req := graphql.NewRequest(`mutation($input: UpdateProductInput!) { updateProduct(input: $input) { id } }`)
+	req.Var("input", input)
+	tests.AuthRequest(req, token)
+	require.NoError(t, client.Run(context.TODO(), req, &map[string]interface{}{}))
+}
+
+func getPricedProduct(client *graphql.Client, token string, id string) (pricedProduct, error) {
+	req := graphql.NewRequest(`query($id: ID!) { product(id: $id) { price priceHistory { variantId oldPrice newPrice actorId source scheduledPriceId } } }`)
+	req.Var("id", id)
+	tests.AuthRequest(req, token)
+	var resp struct {
+		Product pricedProduct
+	}
+	err := client.Run(context.TODO(), req, &resp)
+	return resp.Product, err
+}
+
+func schedulePrice(client *graphql.Client, token string, productID string, price float64, startsAt time.Time, endsAt *time.Time) (scheduledPrice, error) {
+	input := map[string]interface{}{
+		"productId": productID,
+		"price":     price,
+		"startsAt":  startsAt.Format(time.RFC3339),
+	}
+	if endsAt != nil {
+		input["endsAt"] = endsAt.Format(time.RFC3339)
+	}
+	req := graphql.NewRequest(`mutation($input: SchedulePriceInput!) { schedulePrice(input: $input) ` + scheduledPriceFields + ` }`)
+	req.Var("input", input)
+	tests.AuthRequest(req, token)
+	var resp struct {
+		SchedulePrice scheduledPrice
+	}
+	err := client.Run(context.TODO(), req, &resp)
+	return resp.SchedulePrice, err
+}
+
+func getScheduledPrice(t *testing.T, client *graphql.Client, token string, productID string, id string) scheduledPrice {
+	req := graphql.NewRequest(`query($productId: ID) { scheduledPrices(productId: $productId) ` + scheduledPriceFields + ` }`)
+	req.Var("productId", productID)
+	tests.AuthRequest(req, token)
+	var resp struct {
+		ScheduledPrices []scheduledPrice
+	}
+	require.NoError(t, client.Run(context.TODO(), req, &resp))
+	for _, scheduled := range resp.ScheduledPrices {
+		if scheduled.ID == id {
+			return scheduled
+		}
+	}
+	t.Fatalf("scheduled price %s not found", id)
+	return scheduledPrice{}
+}
+
+func cancelScheduledPrice(client *graphql.Client, token string, id string) (scheduledPrice, error) {
+	req := graphql.NewRequest(`mutation($id: ID!) { cancelScheduledPrice(id: $id) ` + scheduledPriceFields + ` }`)
+	req.Var("id", id)
+	tests.AuthRequest(req, token)
+	var resp struct {
+		CancelScheduledPrice scheduledPrice
+	}
+	err := client.Run(context.TODO(), req, &resp)
+	return resp.CancelScheduledPrice, err
+}
+
+func TestPriceHistory(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+	productID := tests.CreateProduct(t, adminToken, map[string]interface{}{"price": 10, "inStock": 1, "category": "Prices"})
+
+	product, err := getPricedProduct(client, adminToken, productID)
+	require.NoError(t, err)
+	require.Empty(t, product.PriceHistory)
+
+	// Only changes of the price are recorded
+	updatePrice(t, client, adminToken, productID, map[string]interface{}{"price": 12.5})
+	updatePrice(t, client, adminToken, productID, map[string]interface{}{"name": "RenamedPricedProduct"})
+	updatePrice(t, client, adminToken, productID, map[string]interface{}{"price": 12.5})
+	updatePrice(t, client, adminToken, productID, map[string]interface{}{"price": 11})
+
+	product, err = getPricedProduct(client, adminToken, productID)
+	require.NoError(t, err)
+	require.Len(t, product.PriceHistory, 2)
+	require.Equal(t, 12.5, product.PriceHistory[0].OldPrice)
+	require.Equal(t, 11.0, product.PriceHistory[0].NewPrice)
+	require.Equal(t, 10.0, product.PriceHistory[1].OldPrice)
+	require.Equal(t, 12.5, product.PriceHistory[1].NewPrice)
+	require.Equal(t, "Manual", product.PriceHistory[1].Source)
+	require.NotNil(t, product.PriceHistory[1].ActorID)
+	require.Nil(t, product.PriceHistory[1].ScheduledPriceID)
+
+	_, err = getPricedProduct(client, customerToken, productID)
+	require.Error(t, err)
+}
+
+func TestVariantPriceHistory(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	client := tests.NewGraphQLClient()
+	sku := "CAP-" + uuid.NewString()[:8]
+	productID := tests.CreateProduct(t, adminToken, map[string]interface{}{
+		"price":    10,
+		"inStock":  0,
+		"category": "Prices",
+		"options":  []map[string]interface{}{{"name": "Size", "values": []string{"S", "M"}}},
+	})
+
+	product, err := runVariantMutation(client, adminToken, createVariant, map[string]interface{}{
+		"input": map[string]interface{}{
+			"productId": productID,
+			"sku":       sku + "-S",
+			"options":   []map[string]interface{}{{"name": "Size", "value": "S"}},
+			"price":     12,
+			"inStock":   1,
+		},
+	})
+	require.NoError(t, err)
+	small := product.Variants[0].ID
+	// variants at the product price have no changes of their own
+	product, err = runVariantMutation(client, adminToken, createVariant, map[string]interface{}{
+		"input": map[string]interface{}{
+			"productId": productID,
+			"sku":       sku + "-M",
+			"options":   []map[string]interface{}{{"name": "Size", "value": "M"}},
+			"inStock":   1,
+		},
+	})
+	require.NoError(t, err)
+	medium := product.Variants[1].ID
+
+	for _, input := range []map[string]interface{}{{"price": 13}, {"clearPrice": true}} {
+		input["productId"] = productID
+		input["id"] = small
+		_, err = runVariantMutation(client, adminToken, updateVariant, map[string]interface{}{"input": input})
+		require.NoError(t, err)
+	}
+	res := uploadCatalog(t, adminToken, "prices.jsonl", fmt.Sprintf(`{"sku": %q, "price": 11}`+"\n", sku+"-M"), false)
+	require.Empty(t, res.Errors)
+	require.True(t, res.Data.ImportProducts.Applied)
+
+	priced, err := getPricedProduct(client, adminToken, productID)
+	require.NoError(t, err)
+	require.Equal(t, 10.0, priced.Price)
+	require.Len(t, priced.PriceHistory, 4)
+	expected := []struct {
+		variantID string
+		oldPrice  float64
+		newPrice  float64
+		source    string
+	}{
+		{medium, 10, 11, "Import"},
+		{small, 13, 10, "Manual"},
+		{small, 12, 13, "Manual"},
+		{small, 10, 12, "Manual"},
+	}
+	for i, change := range priced.PriceHistory {
+		require.NotNil(t, change.VariantID)
+		require.Equal(t, expected[i].variantID, *change.VariantID)
+		require.Equal(t, expected[i].oldPrice, change.OldPrice)
+		require.Equal(t, expected[i].newPrice, change.NewPrice)
+		require.Equal(t, expected[i].source, change.Source)
+		require.NotNil(t, change.ActorID)
+	}
+}
+
+func TestScheduledPrices(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+	reverted := tests.CreateProduct(t, adminToken, map[string]interface{}{"price": 20, "inStock": 1, "category": "Prices"})
+	repriced := tests.CreateProduct(t, adminToken, map[string]interface{}{"price": 20, "inStock": 1, "category": "Prices"})
+
+	now := time.Now()
+	startsAt := now.Add(2 * time.Second)
+	endsAt := now.Add(6 * time.Second)
+	_, err := schedulePrice(client, customerToken, reverted, 15, startsAt, &endsAt)
+	require.Error(t, err)
+	_, err = schedulePrice(client, adminToken, reverted, 15, now.Add(-time.Minute), nil)
+	require.Error(t, err)
+	_, err = schedulePrice(client, adminToken, reverted, 15, endsAt, &startsAt)
+	require.Error(t, err)
+
+	sale, err := schedulePrice(client, adminToken, reverted, 15, startsAt, &endsAt)
+	require.NoError(t, err)
+	require.Equal(t, "Scheduled", sale.Status)
+	require.Nil(t, sale.PreviousPrice)
+	otherSale, err := schedulePrice(client, adminToken, repriced, 15, startsAt, &endsAt)
+	require.NoError(t, err)
+
+	// Scheduled prices of a product can't overlap
+	_, err = schedulePrice(client, adminToken, reverted, 10, startsAt.Add(time.Second), nil)
+	require.Error(t, err)
+	later, err := schedulePrice(client, adminToken, reverted, 10, endsAt.Add(time.Hour), nil)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return getScheduledPrice(t, client, adminToken, reverted, sale.ID).Status == "Active" &&
+			getScheduledPrice(t, client, adminToken, repriced, otherSale.ID).Status == "Active"
+	}, 10*time.Second, 100*time.Millisecond)
+	product, err := getPricedProduct(client, adminToken, reverted)
+	require.NoError(t, err)
+	require.Equal(t, 15.0, product.Price)
+	require.Equal(t, 20.0, *getScheduledPrice(t, client, adminToken, reverted, sale.ID).PreviousPrice)
+
+	// A price changed during the sale is kept when it ends
+	updatePrice(t, client, adminToken, repriced, map[string]interface{}{"price": 17})
+
+	require.Eventually(t, func() bool {
+		return getScheduledPrice(t, client, adminToken, reverted, sale.ID).Status == "Completed" &&
+			getScheduledPrice(t, client, adminToken, repriced, otherSale.ID).Status == "Completed"
+	}, 10*time.Second, 100*time.Millisecond)
+
+	product, err = getPricedProduct(client, adminToken, reverted)
+	require.NoError(t, err)
+	require.Equal(t, 20.0, product.Price)
+	require.Len(t, product.PriceHistory, 2)
+	for _, change := range product.PriceHistory {
+		require.Equal(t, "Schedule", change.Source)
+		require.Equal(t, sale.ID, *change.ScheduledPriceID)
+	}
+	require.Equal(t, 15.0, product.PriceHistory[0].OldPrice)
+	require.Equal(t, 20.0, product.PriceHistory[0].NewPrice)
+
+	product, err = getPricedProduct(client, adminToken, repriced)
+	require.NoError(t, err)
+	require.Equal(t, 17.0, product.Price)
+	require.Len(t, product.PriceHistory, 2)
+
+	cancelled, err := cancelScheduledPrice(client, adminToken, later.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Cancelled", cancelled.Status)
+	_, err = cancelScheduledPrice(client, adminToken, later.ID)
+	require.Error(t, err)
+	_, err = cancelScheduledPrice(client, adminToken, sale.ID)
+	require.Error(t, err)
+}
+
+func TestCancelActiveSale(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	client := tests.NewGraphQLClient()
+	productID := tests.CreateProduct(t, adminToken, map[string]interface{}{"price": 30, "inStock": 1, "category": "Prices"})
+
+	endsAt := time.Now().Add(time.Hour)
+	sale, err := schedulePrice(client, adminToken, productID, 25, time.Now().Add(2*time.Second), &endsAt)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return getScheduledPrice(t, client, adminToken, productID, sale.ID).Status == "Active"
+	}, 10*time.Second, 100*time.Millisecond)
+
+	cancelled, err := cancelScheduledPrice(client, adminToken, sale.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Cancelled", cancelled.Status)
+
+	product, err := getPricedProduct(client, adminToken, productID)
+	require.NoError(t, err)
+	require.Equal(t, 30.0, product.Price)
+	require.Len(t, product.PriceHistory, 2)
+}
@@ -0,0 +1,168 @@
+package product
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/require"
+	"graphql-backend/tests"
+)
+
+const variantFields = `{ id inStock options { name values } variants { id sku price priceOverridden inStock options { name value } } }`
+
+type variantProduct struct {
+	ID      string
+	InStock int32
+	Options []struct {
+		Name   string
+		Values []string
+	}
+	Variants []struct {
+		ID              string
+		Sku             string
+		Price           float64
+		PriceOverridden bool
+		InStock         int32
+		Options         []struct {
+			Name  string
+			Value string
+		}
+	}
+}
+
+func runVariantMutation(client *graphql.Client, token string, mutation string, vars map[string]interface{}) (variantProduct, error) {
+	req := graphql.NewRequest(mutation)
+	for k, v := range vars {
+		req.Var(k, v)
+	}
+	tests.AuthRequest(req, token)
+	var resp map[string]variantProduct
+	err := client.Run(context.TODO(), req, &resp)
+	for _, product := range resp {
+		return product, err
+	}
+	return variantProduct{}, err
+}
+
+const createVariant = `mutation($input: CreateProductVariantInput!) { createProductVariant(input: $input) ` + variantFields + ` }`
+const updateVariant = `mutation($input: UpdateProductVariantInput!) { updateProductVariant(input: $input) ` + variantFields + ` }`
+
+func TestProductVariants(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+	sku := "TEE-" + uuid.NewString()[:8]
+
+	product, err := runVariantMutation(client, adminToken, `mutation($input: CreateProductInput!) { createProduct(input: $input) `+variantFields+` }`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"name":     "T-Shirt",
+			"price":    10,
+			"inStock":  0,
+			"category": "Apparel",
+			"options": []map[string]interface{}{
+				{"name": "Size", "values": []string{"S", "M"}},
+				{"name": "Color", "values": []string{"Blue"}},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, product.Options, 2)
+	require.Empty(t, product.Variants)
+
+	// Options are matched by name, the values are listed in the order of the product options
+	product, err = runVariantMutation(client, adminToken, createVariant, map[string]interface{}{
+		"input": map[string]interface{}{
+			"productId": product.ID,
+			"sku":       sku + "-S",
+			"options":   []map[string]interface{}{{"name": "color", "value": "Blue"}, {"name": "Size", "value": "S"}},
+			"inStock":   3,
+		},
+	})
+	require.NoError(t, err)
+	product, err = runVariantMutation(client, adminToken, createVariant, map[string]interface{}{
+		"input": map[string]interface{}{
+			"productId": product.ID,
+			"sku":       sku + "-M",
+			"options":   []map[string]interface{}{{"name": "Size", "value": "M"}, {"name": "Color", "value": "Blue"}},
+			"price":     12.5,
+			"inStock":   2,
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, product.Variants, 2)
+	require.Equal(t, int32(5), product.InStock)
+	require.Equal(t, "Size", product.Variants[0].Options[0].Name)
+	require.Equal(t, "S", product.Variants[0].Options[0].Value)
+	require.Equal(t, 10.0, product.Variants[0].Price)
+	require.False(t, product.Variants[0].PriceOverridden)
+	require.Equal(t, 12.5, product.Variants[1].Price)
+	require.True(t, product.Variants[1].PriceOverridden)
+
+	invalid := []map[string]interface{}{
+		// same options as another variant
+		{"sku": sku + "-X", "options": []map[string]interface{}{{"name": "Size", "value": "S"}, {"name": "Color", "value": "Blue"}}},
+		// SKU already used
+		{"sku": sku + "-S", "options": []map[string]interface{}{{"name": "Size", "value": "M"}, {"name": "Color", "value": "Blue"}}},
+		// not a value of the option
+		{"sku": sku + "-L", "options": []map[string]interface{}{{"name": "Size", "value": "L"}, {"name": "Color", "value": "Blue"}}},
+		// a value is missing
+		{"sku": sku + "-Y", "options": []map[string]interface{}{{"name": "Size", "value": "M"}}},
+	}
+	for _, input := range invalid {
+		input["productId"] = product.ID
+		input["inStock"] = 1
+		_, err = runVariantMutation(client, adminToken, createVariant, map[string]interface{}{"input": input})
+		require.Error(t, err, input["sku"])
+	}
+
+	_, err = runVariantMutation(client, customerToken, createVariant, map[string]interface{}{
+		"input": map[string]interface{}{
+			"productId": product.ID,
+			"sku":       sku + "-Z",
+			"options":   []map[string]interface{}{{"name": "Size", "value": "M"}, {"name": "Color", "value": "Blue"}},
+			"inStock":   1,
+		},
+	})
+	require.Error(t, err)
+
+	// The stock of a product with variants is the total of its variants
+	_, err = runVariantMutation(client, adminToken, `mutation($input: UpdateProductInput!) { updateProduct(input: $input) `+variantFields+` }`, map[string]interface{}{
+		"input": map[string]interface{}{"id": product.ID, "inStock": 10},
+	})
+	require.Error(t, err)
+
+	product, err = runVariantMutation(client, adminToken, updateVariant, map[string]interface{}{
+		"input": map[string]interface{}{"productId": product.ID, "id": product.Variants[1].ID, "clearPrice": true, "inStock": 6},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 10.0, product.Variants[1].Price)
+	require.False(t, product.Variants[1].PriceOverridden)
+	require.Equal(t, int32(9), product.InStock)
+
+	// Prices overrides are converted along with the product price
+	product, err = runVariantMutation(client, adminToken, updateVariant, map[string]interface{}{
+		"input": map[string]interface{}{"productId": product.ID, "id": product.Variants[1].ID, "price": 20},
+	})
+	require.NoError(t, err)
+	getReq := graphql.NewRequest(`query($id: ID!) { product(id: $id, currency: "EUR") ` + variantFields + ` }`)
+	getReq.Var("id", product.ID)
+	tests.AuthRequest(getReq, adminToken)
+	var getResp struct {
+		Product variantProduct
+	}
+	err = client.Run(context.TODO(), getReq, &getResp)
+	require.NoError(t, err)
+	require.Equal(t, 9.2, getResp.Product.Variants[0].Price)
+	require.Equal(t, 18.4, getResp.Product.Variants[1].Price)
+
+	product, err = runVariantMutation(client, adminToken, `mutation($productId: ID!, $id: ID!) { deleteProductVariant(productId: $productId, id: $id) `+variantFields+` }`, map[string]interface{}{
+		"productId": product.ID,
+		"id":        product.Variants[0].ID,
+	})
+	require.NoError(t, err)
+	require.Len(t, product.Variants, 1)
+	require.Equal(t, sku+"-M", product.Variants[0].Sku)
+	require.Equal(t, int32(6), product.InStock)
+}
@@ -0,0 +1,337 @@
+package promotion
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/require"
+	"graphql-backend/tests"
+)
+
+type order struct {
+	ID        string
+	Subtotal  float64
+	TaxTotal  float64
+	Total     float64
+	Discounts []struct {
+		Code   string
+		Amount float64
+	}
+}
+
+func uniqueCode(prefix string) string {
+	return prefix + "-" + strings.ToUpper(uuid.NewString()[:8])
+}
+
+func createPromotion(t *testing.T, token string, input map[string]interface{}) (string, error) {
+	client := tests.NewGraphQLClient()
+	req := graphql.NewRequest(`mutation($input: CreatePromotionInput!) { createPromotion(input: $input) { id code usageCount } }`)
+	req.Var("input", input)
+	tests.AuthRequest(req, token)
+	var resp struct {
+		CreatePromotion struct {
+			ID   string
+			Code string
+		}
+	}
+	err := client.Run(context.TODO(), req, &resp)
+	return resp.CreatePromotion.ID, err
+}
+
+func placeOrder(t *testing.T, token string, productIDs []string, couponCode string) (order, error) {
+	client := tests.NewGraphQLClient()
+	req := graphql.NewRequest(`mutation($ids: [ID!]!, $code: String) { placeOrder(productIds: $ids, couponCode: $code) { id subtotal taxTotal total discounts { code amount } } }`)
+	req.Var("ids", productIDs)
+	req.Var("code", couponCode)
+	tests.AuthRequest(req, token)
+	var resp struct {
+		PlaceOrder order
+	}
+	err := client.Run(context.TODO(), req, &resp)
+	return resp.PlaceOrder, err
+}
+
+// newYorkCustomer logs in a new customer shipping to New York, where taxes are 4%
+func newYorkCustomer(t *testing.T) string {
+	token := tests.Login(t, tests.CreateUser(t, "Customer"), tests.UserPassword)
+	tests.CreateAddress(t, token, map[string]interface{}{
+		"city":       "New York",
+		"region":     "NY",
+		"postalCode": "10001",
+		"country":    "US",
+	})
+	return token
+}
+
+func TestCategoryCouponWithPerUserLimit(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := newYorkCustomer(t)
+	categoryID := tests.CreateCategory(t, adminToken, "PromoCat-"+uuid.NewString()[:8], "")
+	// products of subcategories are discounted too
+	subcategoryID := tests.CreateCategory(t, adminToken, "PromoSubcat-"+uuid.NewString()[:8], categoryID)
+	bookID := tests.CreateProduct(t, adminToken, map[string]interface{}{"price": 40, "categoryId": subcategoryID})
+	otherID := tests.CreateProduct(t, adminToken, map[string]interface{}{"price": 10, "category": "PromoOther"})
+
+	code := uniqueCode("BOOKS")
+	promotionID, err := createPromotion(t, adminToken, map[string]interface{}{
+		"code":              strings.ToLower(code),
+		"type":              "Percentage",
+		"percentage":        25,
+		"scope":             "Category",
+		"categoryIds":       []string{categoryID},
+		"usageLimitPerUser": 1,
+	})
+	require.NoError(t, err)
+
+	// Renaming the category keeps the promotion on it
+	client := tests.NewGraphQLClient()
+	renameReq := graphql.NewRequest(`mutation($input: UpdateCategoryInput!) { updateCategory(input: $input) { id } }`)
+	renameReq.Var("input", map[string]interface{}{"id": categoryID, "name": "Renamed-" + uuid.NewString()[:8]})
+	tests.AuthRequest(renameReq, adminToken)
+	require.NoError(t, client.Run(context.TODO(), renameReq, &map[string]interface{}{}))
+
+	// The code is matched case-insensitively and only discounts the category
+	o, err := placeOrder(t, customerToken, []string{bookID, otherID}, code)
+	require.NoError(t, err)
+	require.Equal(t, 50.0, o.Subtotal)
+	require.Equal(t, 1.6, o.TaxTotal)
+	require.Equal(t, 41.6, o.Total)
+	require.Len(t, o.Discounts, 1)
+	require.Equal(t, code, o.Discounts[0].Code)
+	require.Equal(t, 10.0, o.Discounts[0].Amount)
+
+	// The customer can only use it once
+	_, err = placeOrder(t, customerToken, []string{bookID}, code)
+	require.Error(t, err)
+
+	req := graphql.NewRequest(`query($id: ID!) { promotion(id: $id) { id usageCount } }`)
+	req.Var("id", promotionID)
+	tests.AuthRequest(req, adminToken)
+	var resp struct {
+		Promotion struct {
+			ID         string
+			UsageCount int32
+		}
+	}
+	err = client.Run(context.TODO(), req, &resp)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), resp.Promotion.UsageCount)
+
+	// Customers can't see promotions
+	req = graphql.NewRequest(`query { promotions { id } }`)
+	tests.AuthRequest(req, customerToken)
+	err = client.Run(context.TODO(), req, &struct{ Promotions []struct{ ID string } }{})
+	require.Error(t, err)
+}
+
+func TestFixedAmountCouponWithMinimumOrderValue(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := newYorkCustomer(t)
+	cheapID := tests.CreateProduct(t, adminToken, map[string]interface{}{"price": 20, "category": "PromoFixed"})
+	expensiveID := tests.CreateProduct(t, adminToken, map[string]interface{}{"price": 80, "category": "PromoFixed"})
+
+	code := uniqueCode("TENOFF")
+	_, err := createPromotion(t, adminToken, map[string]interface{}{
+		"code":          code,
+		"type":          "FixedAmount",
+		"amount":        10,
+		"scope":         "Order",
+		"minOrderValue": 50,
+	})
+	require.NoError(t, err)
+
+	_, err = placeOrder(t, customerToken, []string{cheapID}, code)
+	require.Error(t, err)
+
+	o, err := placeOrder(t, customerToken, []string{cheapID, expensiveID}, code)
+	require.NoError(t, err)
+	require.Equal(t, 100.0, o.Subtotal)
+	require.Equal(t, 3.6, o.TaxTotal)
+	require.Equal(t, 93.6, o.Total)
+}
+
+func TestInvalidCoupons(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	productID := tests.CreateProduct(t, adminToken, map[string]interface{}{"price": 10, "category": "PromoInvalid"})
+
+	_, err := placeOrder(t, customerToken, []string{productID}, "DOES-NOT-EXIST")
+	require.Error(t, err)
+
+	// Inactive promotions are not redeemable
+	code := uniqueCode("OFF")
+	_, err = createPromotion(t, adminToken, map[string]interface{}{
+		"code":       code,
+		"type":       "Percentage",
+		"percentage": 10,
+		"scope":      "Order",
+		"active":     false,
+	})
+	require.NoError(t, err)
+	_, err = placeOrder(t, customerToken, []string{productID}, code)
+	require.Error(t, err)
+
+	// Invalid promotions are rejected
+	_, err = createPromotion(t, adminToken, map[string]interface{}{
+		"code":       uniqueCode("BAD"),
+		"type":       "Percentage",
+		"percentage": 150,
+		"scope":      "Order",
+	})
+	require.Error(t, err)
+
+	// Categories must exist
+	_, err = createPromotion(t, adminToken, map[string]interface{}{
+		"code":        uniqueCode("BAD"),
+		"type":        "Percentage",
+		"percentage":  10,
+		"scope":       "Category",
+		"categoryIds": []string{"missing-category"},
+	})
+	require.Error(t, err)
+
+	// Fixed amounts can't be more precise than a cent
+	_, err = createPromotion(t, adminToken, map[string]interface{}{
+		"code":   uniqueCode("BAD"),
+		"type":   "FixedAmount",
+		"amount": 10.005,
+		"scope":  "Order",
+	})
+	require.Error(t, err)
+
+	_, err = createPromotion(t, customerToken, map[string]interface{}{
+		"code":       uniqueCode("CUST"),
+		"type":       "Percentage",
+		"percentage": 10,
+		"scope":      "Order",
+	})
+	require.Error(t, err)
+}
+
+func updatePromotion(t *testing.T, token string, input map[string]interface{}) {
+	client := tests.NewGraphQLClient()
+	req := graphql.NewRequest(`mutation($input: UpdatePromotionInput!) { updatePromotion(input: $input) { id } }`)
+	req.Var("input", input)
+	tests.AuthRequest(req, token)
+	require.NoError(t, client.Run(context.TODO(), req, &map[string]interface{}{}))
+}
+
+func TestGlobalUsageLimit(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	firstToken := tests.Login(t, tests.CreateUser(t, "Customer"), tests.UserPassword)
+	secondToken := tests.Login(t, tests.CreateUser(t, "Customer"), tests.UserPassword)
+	productID := tests.CreateProduct(t, adminToken, map[string]interface{}{"price": 20, "category": "PromoGlobal"})
+
+	code := uniqueCode("ONCE")
+	promotionID, err := createPromotion(t, adminToken, map[string]interface{}{
+		"code":       code,
+		"type":       "Percentage",
+		"percentage": 10,
+		"scope":      "Order",
+		"usageLimit": 1,
+	})
+	require.NoError(t, err)
+
+	o, err := placeOrder(t, firstToken, []string{productID}, code)
+	require.NoError(t, err)
+	require.Len(t, o.Discounts, 1)
+	require.Equal(t, 2.0, o.Discounts[0].Amount)
+
+	// The limit is shared by all the customers
+	_, err = placeOrder(t, secondToken, []string{productID}, code)
+	require.Error(t, err)
+
+	// Cancelling the order gives the use back
+	client := tests.NewGraphQLClient()
+	req := graphql.NewRequest(`mutation($id: ID!) { updateOrderStatus(id: $id, status: "Cancelled") { id } }`)
+	req.Var("id", o.ID)
+	tests.AuthRequest(req, adminToken)
+	require.NoError(t, client.Run(context.TODO(), req, &map[string]interface{}{}))
+	_, err = placeOrder(t, secondToken, []string{productID}, code)
+	require.NoError(t, err)
+
+	// and clearing the limit lets everyone use it
+	_, err = placeOrder(t, firstToken, []string{productID}, code)
+	require.Error(t, err)
+	updatePromotion(t, adminToken, map[string]interface{}{"id": promotionID, "clearUsageLimit": true})
+	_, err = placeOrder(t, firstToken, []string{productID}, code)
+	require.NoError(t, err)
+}
+
+func TestPromotionDateWindow(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CreateUser(t, "Customer"), tests.UserPassword)
+	productID := tests.CreateProduct(t, adminToken, map[string]interface{}{"price": 20, "category": "PromoWindow"})
+
+	code := uniqueCode("SOON")
+	promotionID, err := createPromotion(t, adminToken, map[string]interface{}{
+		"code":       code,
+		"type":       "Percentage",
+		"percentage": 10,
+		"scope":      "Order",
+		"startsAt":   time.Now().Add(24 * time.Hour).Format(time.RFC3339),
+	})
+	require.NoError(t, err)
+
+	// The promotion hasn't started yet
+	_, err = placeOrder(t, customerToken, []string{productID}, code)
+	require.Error(t, err)
+
+	updatePromotion(t, adminToken, map[string]interface{}{"id": promotionID, "clearStartsAt": true})
+	_, err = placeOrder(t, customerToken, []string{productID}, code)
+	require.NoError(t, err)
+
+	// An ended promotion is refused until its end is cleared
+	updatePromotion(t, adminToken, map[string]interface{}{"id": promotionID, "endsAt": time.Now().Add(-time.Hour).Format(time.RFC3339)})
+	_, err = placeOrder(t, customerToken, []string{productID}, code)
+	require.Error(t, err)
+
+	updatePromotion(t, adminToken, map[string]interface{}{"id": promotionID, "clearEndsAt": true})
+	_, err = placeOrder(t, customerToken, []string{productID}, code)
+	require.NoError(t, err)
+}
+
+func TestProductCouponAtCheckout(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CreateUser(t, "Customer"), tests.UserPassword)
+	discountedID := tests.CreateProduct(t, adminToken, map[string]interface{}{"price": 30, "category": "PromoProduct"})
+	otherID := tests.CreateProduct(t, adminToken, map[string]interface{}{"price": 10, "category": "PromoProduct"})
+
+	code := uniqueCode("MUG")
+	_, err := createPromotion(t, adminToken, map[string]interface{}{
+		"code":       code,
+		"type":       "Percentage",
+		"percentage": 50,
+		"scope":      "Product",
+		"productIds": []string{discountedID},
+	})
+	require.NoError(t, err)
+
+	client := tests.NewGraphQLClient()
+	for _, productID := range []string{discountedID, otherID} {
+		req := graphql.NewRequest(`mutation($id: ID!) { addToCart(productId: $id, quantity: 2) { id } }`)
+		req.Var("id", productID)
+		tests.AuthRequest(req, customerToken)
+		require.NoError(t, client.Run(context.TODO(), req, &map[string]interface{}{}))
+	}
+
+	// Only the product in the promotion is discounted
+	req := graphql.NewRequest(`mutation($code: String) { checkout(couponCode: $code) { id subtotal taxTotal total discounts { code amount } } }`)
+	req.Var("code", code)
+	tests.AuthRequest(req, customerToken)
+	var resp struct {
+		Checkout order
+	}
+	require.NoError(t, client.Run(context.TODO(), req, &resp))
+	require.Equal(t, 80.0, resp.Checkout.Subtotal)
+	require.Len(t, resp.Checkout.Discounts, 1)
+	require.Equal(t, code, resp.Checkout.Discounts[0].Code)
+	require.Equal(t, 30.0, resp.Checkout.Discounts[0].Amount)
+	// The cart is picked up at the store, in New York
+	require.Equal(t, 2.0, resp.Checkout.TaxTotal)
+	require.Equal(t, 52.0, resp.Checkout.Total)
+}
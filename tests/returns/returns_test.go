@@ -0,0 +1,271 @@
+package returns
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/require"
+	"graphql-backend/tests"
+)
+
+type returnRes struct {
+	ID        string
+	Status    string
+	RefundID  *string
+	Restocked bool
+	Items     []struct {
+		ProductID string
+		Quantity  int32
+	}
+}
+
+type orderRes struct {
+	Status        string
+	Total         float64
+	RefundedTotal float64
+	Refunds       []struct {
+		Amount   float64
+		Reason   string
+		ReturnID *string
+	}
+	Returns []returnRes
+}
+
+func getOrder(t *testing.T, client *graphql.Client, token string, orderID string) orderRes {
+	req := graphql.NewRequest(`query($id: ID!) { order(id: $id) {
+		status total refundedTotal
+		refunds { amount reason returnId }
+		returns { id status refundId restocked items { productId quantity } }
+	} }`)
+	req.Var("id", orderID)
+	tests.AuthRequest(req, token)
+	var resp struct {
+		Order orderRes
+	}
+	err := client.Run(context.TODO(), req, &resp)
+	require.NoError(t, err)
+	return resp.Order
+}
+
+func getStock(t *testing.T, client *graphql.Client, token string, productID string) int32 {
+	req := graphql.NewRequest(`query($id: ID!) { product(id: $id) { inStock } }`)
+	req.Var("id", productID)
+	tests.AuthRequest(req, token)
+	var resp struct {
+		Product struct{ InStock int32 }
+	}
+	err := client.Run(context.TODO(), req, &resp)
+	require.NoError(t, err)
+	return resp.Product.InStock
+}
+
+func requestReturn(client *graphql.Client, token string, orderID string, productID string, quantity int) (returnRes, error) {
+	req := graphql.NewRequest(`mutation($input: RequestReturnInput!) { requestReturn(input: $input) { id status refundId restocked items { productId quantity } } }`)
+	req.Var("input", map[string]interface{}{
+		"orderId": orderID,
+		"items":   []map[string]interface{}{{"productId": productID, "quantity": quantity}},
+		"reason":  "Damaged",
+	})
+	tests.AuthRequest(req, token)
+	var resp struct {
+		RequestReturn returnRes
+	}
+	err := client.Run(context.TODO(), req, &resp)
+	return resp.RequestReturn, err
+}
+
+func reviewReturn(client *graphql.Client, token string, mutation string, id string, vars map[string]interface{}) (returnRes, error) {
+	req := graphql.NewRequest(mutation)
+	req.Var("id", id)
+	for k, v := range vars {
+		req.Var(k, v)
+	}
+	tests.AuthRequest(req, token)
+	var resp map[string]returnRes
+	err := client.Run(context.TODO(), req, &resp)
+	for _, res := range resp {
+		return res, err
+	}
+	return returnRes{}, err
+}
+
+const approveReturn = `mutation($id: ID!, $restock: Boolean, $note: String) {
+	approveReturn(id: $id, restock: $restock, note: $note) { id status refundId restocked }
+}`
+const rejectReturn = `mutation($id: ID!, $note: String) { rejectReturn(id: $id, note: $note) { id status refundId restocked } }`
+
+func TestReturnWorkflow(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+	productID := tests.CreateProduct(t, adminToken, map[string]interface{}{"category": "Returns"})
+	orderID := tests.PlacePaidOrder(t, customerToken, productID, productID, productID)
+	total := getOrder(t, client, customerToken, orderID).Total
+	stock := getStock(t, client, adminToken, productID)
+
+	requested, err := requestReturn(client, customerToken, orderID, productID, 1)
+	require.NoError(t, err)
+	require.Equal(t, "Requested", requested.Status)
+
+	// Only the quantities not already being returned can be returned
+	_, err = requestReturn(client, customerToken, orderID, productID, 3)
+	require.Error(t, err)
+
+	// Customers can't review returns
+	_, err = reviewReturn(client, customerToken, approveReturn, requested.ID, nil)
+	require.Error(t, err)
+
+	approved, err := reviewReturn(client, adminToken, approveReturn, requested.ID, map[string]interface{}{"note": "Sorry"})
+	require.NoError(t, err)
+	require.Equal(t, "Approved", approved.Status)
+	require.True(t, approved.Restocked)
+	require.NotNil(t, approved.RefundID)
+	require.Equal(t, stock+1, getStock(t, client, adminToken, productID))
+
+	// The refund is the share of the total paid for the item, taxes included
+	order := getOrder(t, client, customerToken, orderID)
+	require.Equal(t, "PartiallyRefunded", order.Status)
+	require.Len(t, order.Refunds, 1)
+	require.InDelta(t, math.Round(total*100/3)/100, order.Refunds[0].Amount, 0.001)
+	require.Equal(t, requested.ID, *order.Refunds[0].ReturnID)
+	require.InDelta(t, order.Refunds[0].Amount, order.RefundedTotal, 0.001)
+
+	_, err = reviewReturn(client, adminToken, rejectReturn, requested.ID, nil)
+	require.Error(t, err)
+
+	rejected, err := requestReturn(client, customerToken, orderID, productID, 2)
+	require.NoError(t, err)
+	rejected, err = reviewReturn(client, adminToken, rejectReturn, rejected.ID, map[string]interface{}{"note": "Used"})
+	require.NoError(t, err)
+	require.Equal(t, "Rejected", rejected.Status)
+	require.Nil(t, rejected.RefundID)
+
+	// Rejected quantities can be requested again, the last items get what is left of the total
+	last, err := requestReturn(client, customerToken, orderID, productID, 2)
+	require.NoError(t, err)
+	last, err = reviewReturn(client, adminToken, approveReturn, last.ID, map[string]interface{}{"restock": false})
+	require.NoError(t, err)
+	require.False(t, last.Restocked)
+	require.Equal(t, stock+1, getStock(t, client, adminToken, productID))
+
+	order = getOrder(t, client, customerToken, orderID)
+	require.Equal(t, "Refunded", order.Status)
+	require.InDelta(t, total, order.RefundedTotal, 0.001)
+	require.Len(t, order.Returns, 3)
+
+	_, err = requestReturn(client, customerToken, orderID, productID, 1)
+	require.Error(t, err)
+}
+
+func TestReturnOfUnpaidOrder(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+	productID := tests.CreateProduct(t, adminToken, map[string]interface{}{"category": "Returns"})
+
+	req := graphql.NewRequest(`mutation($ids: [ID!]!) { placeOrder(productIds: $ids) { id } }`)
+	req.Var("ids", []string{productID})
+	tests.AuthRequest(req, customerToken)
+	var resp struct {
+		PlaceOrder struct{ ID string }
+	}
+	err := client.Run(context.TODO(), req, &resp)
+	require.NoError(t, err)
+
+	_, err = requestReturn(client, customerToken, resp.PlaceOrder.ID, productID, 1)
+	require.Error(t, err)
+}
+
+func TestRefundOrder(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+	productID := tests.CreateProduct(t, adminToken, map[string]interface{}{"category": "Returns"})
+	orderID := tests.PlacePaidOrder(t, customerToken, productID)
+
+	refund := func(amount interface{}) (orderRes, error) {
+		req := graphql.NewRequest(`mutation($orderId: ID!, $amount: Money) {
+			refundOrder(orderId: $orderId, amount: $amount, reason: "Late delivery") { status total refundedTotal refunds { amount reason } }
+		}`)
+		req.Var("orderId", orderID)
+		req.Var("amount", amount)
+		tests.AuthRequest(req, adminToken)
+		var resp struct {
+			RefundOrder orderRes
+		}
+		err := client.Run(context.TODO(), req, &resp)
+		return resp.RefundOrder, err
+	}
+
+	order, err := refund(1.5)
+	require.NoError(t, err)
+	require.Equal(t, "PartiallyRefunded", order.Status)
+	require.Equal(t, 1.5, order.RefundedTotal)
+	require.Equal(t, "Late delivery", order.Refunds[0].Reason)
+
+	_, err = refund(order.Total)
+	require.Error(t, err)
+
+	// Everything left is refunded without an amount
+	order, err = refund(nil)
+	require.NoError(t, err)
+	require.Equal(t, "Refunded", order.Status)
+	require.Equal(t, order.Total, order.RefundedTotal)
+
+	_, err = refund(nil)
+	require.Error(t, err)
+}
+
+func TestConcurrentRefundsRefundOnce(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+	productID := tests.CreateProduct(t, adminToken, map[string]interface{}{"category": "Returns"})
+	orderID := tests.PlacePaidOrder(t, customerToken, productID, productID)
+
+	// concurrentSuccesses runs the call a few times at once and counts the ones that succeeded
+	concurrentSuccesses := func(call func() error) int {
+		var wg sync.WaitGroup
+		errs := make(chan error, 5)
+		for range 5 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- call()
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		succeeded := 0
+		for err := range errs {
+			if err == nil {
+				succeeded++
+			}
+		}
+		return succeeded
+	}
+
+	requested, err := requestReturn(client, customerToken, orderID, productID, 1)
+	require.NoError(t, err)
+	require.Equal(t, 1, concurrentSuccesses(func() error {
+		_, err := reviewReturn(client, adminToken, approveReturn, requested.ID, nil)
+		return err
+	}))
+
+	require.Equal(t, 1, concurrentSuccesses(func() error {
+		req := graphql.NewRequest(`mutation($orderId: ID!) { refundOrder(orderId: $orderId, reason: "Late delivery") { status } }`)
+		req.Var("orderId", orderID)
+		tests.AuthRequest(req, adminToken)
+		return client.Run(context.TODO(), req, &map[string]interface{}{})
+	}))
+
+	order := getOrder(t, client, customerToken, orderID)
+	require.Equal(t, "Refunded", order.Status)
+	require.Len(t, order.Refunds, 2)
+	require.Equal(t, order.Total, order.RefundedTotal)
+	require.Equal(t, "Approved", order.Returns[0].Status)
+}
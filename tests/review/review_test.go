@@ -0,0 +1,184 @@
+package review
+
+import (
+	"context"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/require"
+	"graphql-backend/tests"
+)
+
+type reviewRes struct {
+	ID         string
+	ProductID  string
+	AuthorName string
+	Rating     int32
+	Body       *string
+	Status     string
+}
+
+type productReviews struct {
+	AverageRating *float64
+	ReviewCount   int32
+	Reviews       []reviewRes
+}
+
+const reviewFields = `{ id productId authorName rating body status }`
+
+func completeOrder(t *testing.T, client *graphql.Client, adminToken string, orderID string) {
+	req := graphql.NewRequest(`mutation($id: ID!) { updateOrderStatus(id: $id, status: "Completed") { status } }`)
+	req.Var("id", orderID)
+	tests.AuthRequest(req, adminToken)
+	err := client.Run(context.TODO(), req, &map[string]interface{}{})
+	require.NoError(t, err)
+}
+
+func createReview(client *graphql.Client, token string, productID string, rating int, body string) (reviewRes, error) {
+	req := graphql.NewRequest(`mutation($input: CreateReviewInput!) { createReview(input: $input) ` + reviewFields + ` }`)
+	req.Var("input", map[string]interface{}{"productId": productID, "rating": rating, "body": body})
+	tests.AuthRequest(req, token)
+	var resp struct {
+		CreateReview reviewRes
+	}
+	err := client.Run(context.TODO(), req, &resp)
+	return resp.CreateReview, err
+}
+
+func moderateReview(t *testing.T, client *graphql.Client, adminToken string, mutation string, id string) reviewRes {
+	req := graphql.NewRequest(`mutation($id: ID!) { ` + mutation + `(id: $id) ` + reviewFields + ` }`)
+	req.Var("id", id)
+	tests.AuthRequest(req, adminToken)
+	var resp map[string]reviewRes
+	err := client.Run(context.TODO(), req, &resp)
+	require.NoError(t, err)
+	return resp[mutation]
+}
+
+func getProductReviews(t *testing.T, client *graphql.Client, token string, productID string, limit int, offset int) productReviews {
+	req := graphql.NewRequest(`query($id: ID!, $limit: Int, $offset: Int) { product(id: $id) { averageRating reviewCount reviews(limit: $limit, offset: $offset) ` + reviewFields + ` } }`)
+	req.Var("id", productID)
+	req.Var("limit", limit)
+	req.Var("offset", offset)
+	tests.AuthRequest(req, token)
+	var resp struct {
+		Product productReviews
+	}
+	err := client.Run(context.TODO(), req, &resp)
+	require.NoError(t, err)
+	return resp.Product
+}
+
+func TestProductReviews(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+	productID := tests.CreateProduct(t, adminToken, map[string]interface{}{"category": "Reviews"})
+
+	// Only products of completed orders can be reviewed
+	_, err := createReview(client, customerToken, productID, 4, "Great")
+	require.Error(t, err)
+	orderID := tests.PlacePaidOrder(t, customerToken, productID)
+	_, err = createReview(client, customerToken, productID, 4, "Great")
+	require.Error(t, err)
+	completeOrder(t, client, adminToken, orderID)
+
+	_, err = createReview(client, customerToken, productID, 6, "Great")
+	require.Error(t, err)
+	customerReview, err := createReview(client, customerToken, productID, 4, "  Great  ")
+	require.NoError(t, err)
+	require.Equal(t, "Pending", customerReview.Status)
+	require.Equal(t, "Customer User", customerReview.AuthorName)
+	require.Equal(t, "Great", *customerReview.Body)
+	_, err = createReview(client, customerToken, productID, 5, "Even better")
+	require.Error(t, err)
+
+	// Pending reviews are not published
+	product := getProductReviews(t, client, customerToken, productID, 10, 0)
+	require.Nil(t, product.AverageRating)
+	require.Equal(t, int32(0), product.ReviewCount)
+	require.Empty(t, product.Reviews)
+
+	pendingReq := graphql.NewRequest(`query($productId: ID) { reviews(status: Pending, productId: $productId) ` + reviewFields + ` }`)
+	pendingReq.Var("productId", productID)
+	tests.AuthRequest(pendingReq, customerToken)
+	var pendingResp struct {
+		Reviews []reviewRes
+	}
+	require.Error(t, client.Run(context.TODO(), pendingReq, &pendingResp))
+	tests.AuthRequest(pendingReq, adminToken)
+	require.NoError(t, client.Run(context.TODO(), pendingReq, &pendingResp))
+	require.Len(t, pendingResp.Reviews, 1)
+	require.Equal(t, customerReview.ID, pendingResp.Reviews[0].ID)
+
+	approved := moderateReview(t, client, adminToken, "approveReview", customerReview.ID)
+	require.Equal(t, "Approved", approved.Status)
+	product = getProductReviews(t, client, customerToken, productID, 10, 0)
+	require.Equal(t, 4.0, *product.AverageRating)
+	require.Equal(t, int32(1), product.ReviewCount)
+	require.Len(t, product.Reviews, 1)
+
+	orderID = tests.PlacePaidOrder(t, adminToken, productID)
+	completeOrder(t, client, adminToken, orderID)
+	adminReview, err := createReview(client, adminToken, productID, 1, "")
+	require.NoError(t, err)
+	require.Nil(t, adminReview.Body)
+	moderateReview(t, client, adminToken, "approveReview", adminReview.ID)
+
+	product = getProductReviews(t, client, customerToken, productID, 1, 0)
+	require.Equal(t, 2.5, *product.AverageRating)
+	require.Equal(t, int32(2), product.ReviewCount)
+	require.Len(t, product.Reviews, 1)
+	require.Equal(t, adminReview.ID, product.Reviews[0].ID)
+	product = getProductReviews(t, client, customerToken, productID, 1, 1)
+	require.Len(t, product.Reviews, 1)
+	require.Equal(t, customerReview.ID, product.Reviews[0].ID)
+
+	// Hidden reviews are taken out of the rating until they are approved again
+	hidden := moderateReview(t, client, adminToken, "hideReview", customerReview.ID)
+	require.Equal(t, "Hidden", hidden.Status)
+	product = getProductReviews(t, client, customerToken, productID, 10, 0)
+	require.Equal(t, 1.0, *product.AverageRating)
+	require.Equal(t, int32(1), product.ReviewCount)
+	require.Len(t, product.Reviews, 1)
+	moderateReview(t, client, adminToken, "hideReview", customerReview.ID)
+	moderateReview(t, client, adminToken, "approveReview", customerReview.ID)
+
+	// Updating the product keeps its rating
+	updateReq := graphql.NewRequest(`mutation($input: UpdateProductInput!) { updateProduct(input: $input) { id } }`)
+	updateReq.Var("input", map[string]interface{}{"id": productID, "name": "RenamedReviewedProduct"})
+	tests.AuthRequest(updateReq, adminToken)
+	require.NoError(t, client.Run(context.TODO(), updateReq, &map[string]interface{}{}))
+
+	product = getProductReviews(t, client, customerToken, productID, 10, 0)
+	require.Equal(t, 2.5, *product.AverageRating)
+	require.Equal(t, int32(2), product.ReviewCount)
+	require.Len(t, product.Reviews, 2)
+}
+
+func TestProductReviewsAreBatched(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	client := tests.NewGraphQLClient()
+	first := tests.CreateProduct(t, adminToken, map[string]interface{}{"category": "Reviews"})
+	second := tests.CreateProduct(t, adminToken, map[string]interface{}{"category": "Reviews"})
+
+	orderID := tests.PlacePaidOrder(t, adminToken, first)
+	completeOrder(t, client, adminToken, orderID)
+	review, err := createReview(client, adminToken, first, 5, "Works")
+	require.NoError(t, err)
+	moderateReview(t, client, adminToken, "approveReview", review.ID)
+
+	req := graphql.NewRequest(`query($first: ID!, $second: ID!) {
+		first: product(id: $first) { reviews ` + reviewFields + ` }
+		second: product(id: $second) { reviews ` + reviewFields + ` }
+	}`)
+	req.Var("first", first)
+	req.Var("second", second)
+	tests.AuthRequest(req, adminToken)
+	var resp map[string]productReviews
+	err = client.Run(context.TODO(), req, &resp)
+	require.NoError(t, err)
+	require.Len(t, resp["first"].Reviews, 1)
+	require.Equal(t, int32(5), resp["first"].Reviews[0].Rating)
+	require.Empty(t, resp["second"].Reviews)
+}
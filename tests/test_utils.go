@@ -3,7 +3,11 @@ package tests
 import (
 	"context"
 	"fmt"
+	"github.com/google/uuid"
 	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/require"
+	"graphql-backend/pkg/payment"
+	"maps"
 	"os"
 	"testing"
 )
@@ -15,12 +19,19 @@ const (
 	AdminPassword    = "secret"
 	CustomerEmail    = "customer@example.com"
 	CustomerPassword = "secret"
+	// UserPassword is the password of the users created by CreateUser
+	UserPassword = "secret"
 )
 
 func NewGraphQLClient() *graphql.Client {
 	return graphql.NewClient(fmt.Sprintf("%s/query", serverURL))
 }
 
+// URL returns the address of a plain HTTP route of the server
+func URL(path string) string {
+	return serverURL + path
+}
+
 func Login(t *testing.T, email, password string) string {
 	client := NewGraphQLClient()
 	request := graphql.NewRequest(`mutation($input: LoginInput!) {  login(input: $input) {    accessToken  }}`)
@@ -44,6 +55,107 @@ func AuthRequest(req *graphql.Request, token string) {
 	req.Header.Set("Authorization", "Bearer "+token)
 }
 
+// CreateUser creates a user of the role with a unique email as the admin, it returns the email
+func CreateUser(t *testing.T, role string) string {
+	email := "user-" + uuid.NewString()[:8] + "@example.com"
+	req := graphql.NewRequest(`mutation($input: CreateUserInput!) { createUser(input: $input) { id } }`)
+	req.Var("input", map[string]interface{}{
+		"name":     "Test User",
+		"email":    email,
+		"password": UserPassword,
+		"role":     role,
+	})
+	AuthRequest(req, Login(t, AdminEmail, AdminPassword))
+	err := NewGraphQLClient().Run(context.Background(), req, &map[string]interface{}{})
+	require.NoError(t, err)
+	return email
+}
+
+// CreateProduct creates a product in stock as the admin, the input overrides the defaults
+func CreateProduct(t *testing.T, adminToken string, input map[string]interface{}) string {
+	product := map[string]interface{}{
+		"name":        "TestProduct",
+		"description": "Created by the tests",
+		"price":       10,
+		"inStock":     10,
+		"category":    "Tests",
+	}
+	maps.Copy(product, input)
+
+	req := graphql.NewRequest(`mutation($input: CreateProductInput!) { createProduct(input: $input) { id } }`)
+	req.Var("input", product)
+	AuthRequest(req, adminToken)
+	var resp struct {
+		CreateProduct struct{ ID string }
+	}
+	err := NewGraphQLClient().Run(context.Background(), req, &resp)
+	require.NoError(t, err)
+	return resp.CreateProduct.ID
+}
+
+// CreateCategory creates a category under the parent, or a top-level one when parentID is empty, it returns the category ID
+func CreateCategory(t *testing.T, adminToken string, name string, parentID string) string {
+	input := map[string]interface{}{"name": name}
+	if parentID != "" {
+		input["parentId"] = parentID
+	}
+
+	req := graphql.NewRequest(`mutation($input: CreateCategoryInput!) { createCategory(input: $input) { id } }`)
+	req.Var("input", input)
+	AuthRequest(req, adminToken)
+	var resp struct {
+		CreateCategory struct{ ID string }
+	}
+	err := NewGraphQLClient().Run(context.Background(), req, &resp)
+	require.NoError(t, err)
+	return resp.CreateCategory.ID
+}
+
+// CreateAddress creates an address of the user, the input sets the location, it returns the address ID
+func CreateAddress(t *testing.T, token string, input map[string]interface{}) string {
+	address := map[string]interface{}{
+		"fullName": "Test User",
+		"line1":    "1 Main Street",
+	}
+	maps.Copy(address, input)
+
+	req := graphql.NewRequest(`mutation($input: CreateAddressInput!) { createAddress(input: $input) { id } }`)
+	req.Var("input", address)
+	AuthRequest(req, token)
+	var resp struct {
+		CreateAddress struct{ ID string }
+	}
+	err := NewGraphQLClient().Run(context.Background(), req, &resp)
+	require.NoError(t, err)
+	return resp.CreateAddress.ID
+}
+
+// PlacePaidOrder places an order of the products and pays it with a card the payment gateway approves
+func PlacePaidOrder(t *testing.T, token string, productIDs ...string) string {
+	client := NewGraphQLClient()
+	req := graphql.NewRequest(`mutation($ids: [ID!]!) { placeOrder(productIds: $ids) { id } }`)
+	req.Var("ids", productIDs)
+	AuthRequest(req, token)
+	var orderResp struct {
+		PlaceOrder struct{ ID string }
+	}
+	err := client.Run(context.Background(), req, &orderResp)
+	require.NoError(t, err)
+
+	req = graphql.NewRequest(`mutation($orderId: ID!, $card: String!) { payOrder(orderId: $orderId, paymentMethod: $card) { status } }`)
+	req.Var("orderId", orderResp.PlaceOrder.ID)
+	req.Var("card", payment.CardApproved)
+	AuthRequest(req, token)
+	var payResp struct {
+		PayOrder struct{ Status string }
+	}
+	err = client.Run(context.Background(), req, &payResp)
+	require.NoError(t, err)
+	require.Equal(t, "Captured", payResp.PayOrder.Status)
+
+	return orderResp.PlaceOrder.ID
+}
+
 func init() {
 	if v := os.Getenv("SERVER_URL"); v != "" {
 		serverURL = v
@@ -0,0 +1,160 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/require"
+	"graphql-backend/tests"
+)
+
+func downloadExport(t *testing.T, url, token string) *http.Response {
+	req, err := http.NewRequest(http.MethodGet, tests.URL(url), nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	return resp
+}
+
+func TestRequestMyDataExport(t *testing.T) {
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+
+	req := graphql.NewRequest(`mutation { requestMyDataExport { id createdAt expiresAt downloadUrl } }`)
+	tests.AuthRequest(req, customerToken)
+	var resp struct {
+		RequestMyDataExport struct {
+			ID          string
+			ExpiresAt   string
+			DownloadURL string `json:"downloadUrl"`
+		}
+	}
+	err := client.Run(context.TODO(), req, &resp)
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.RequestMyDataExport.ExpiresAt)
+
+	download := downloadExport(t, resp.RequestMyDataExport.DownloadURL, customerToken)
+	require.Equal(t, http.StatusOK, download.StatusCode)
+
+	var archive struct {
+		Profile  map[string]interface{}
+		Orders   []map[string]interface{}
+		Sessions []map[string]interface{}
+	}
+	err = json.NewDecoder(download.Body).Decode(&archive)
+	require.NoError(t, err)
+	require.Equal(t, tests.CustomerEmail, archive.Profile["email"])
+	require.NotContains(t, archive.Profile, "password")
+	require.NotEmpty(t, archive.Sessions)
+
+	// Other customers can't download it
+	otherToken := tests.Login(t, tests.CreateUser(t, "Customer"), tests.UserPassword)
+	download = downloadExport(t, resp.RequestMyDataExport.DownloadURL, otherToken)
+	require.Equal(t, http.StatusNotFound, download.StatusCode)
+}
+
+func TestDeleteUserAccount(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	erasableEmail := tests.CreateUser(t, "Customer")
+	erasableToken := tests.Login(t, erasableEmail, tests.UserPassword)
+	erasableID := getMeID(t, erasableToken)
+	client := tests.NewGraphQLClient()
+
+	productID := tests.CreateProduct(t, adminToken, map[string]interface{}{"category": "DataRequests"})
+	orderID := tests.PlacePaidOrder(t, erasableToken, productID)
+	returnReq := graphql.NewRequest(`mutation($input: RequestReturnInput!) { requestReturn(input: $input) { id } }`)
+	returnReq.Var("input", map[string]interface{}{
+		"orderId": orderID,
+		"items":   []map[string]interface{}{{"productId": productID, "quantity": 1}},
+		"reason":  "Too small",
+	})
+	tests.AuthRequest(returnReq, erasableToken)
+	err := client.Run(context.TODO(), returnReq, &map[string]interface{}{})
+	require.NoError(t, err)
+
+	// The export has the payments and returns of the orders
+	exportReq := graphql.NewRequest(`mutation { requestMyDataExport { downloadUrl } }`)
+	tests.AuthRequest(exportReq, erasableToken)
+	var exportResp struct {
+		RequestMyDataExport struct {
+			DownloadURL string `json:"downloadUrl"`
+		}
+	}
+	err = client.Run(context.TODO(), exportReq, &exportResp)
+	require.NoError(t, err)
+	var archive struct {
+		Payments []struct {
+			OrderID   string `json:"order_id"`
+			CardLast4 string `json:"card_last4"`
+		}
+		Returns []struct {
+			OrderID string `json:"order_id"`
+			Reason  string
+		}
+	}
+	err = json.NewDecoder(downloadExport(t, exportResp.RequestMyDataExport.DownloadURL, erasableToken).Body).Decode(&archive)
+	require.NoError(t, err)
+	require.Len(t, archive.Payments, 1)
+	require.Equal(t, orderID, archive.Payments[0].OrderID)
+	require.NotEmpty(t, archive.Payments[0].CardLast4)
+	require.Len(t, archive.Returns, 1)
+	require.Equal(t, orderID, archive.Returns[0].OrderID)
+	require.Equal(t, "Too small", archive.Returns[0].Reason)
+
+	// Customers can only delete their own account
+	req := graphql.NewRequest(`mutation($userId: ID!) { deleteUserAccount(userId: $userId) { id } }`)
+	req.Var("userId", erasableID)
+	tests.AuthRequest(req, tests.Login(t, tests.CustomerEmail, tests.CustomerPassword))
+	err = client.Run(context.TODO(), req, &struct{ DeleteUserAccount struct{ ID string } }{})
+	require.Error(t, err)
+
+	req = graphql.NewRequest(`mutation($userId: ID!) { deleteUserAccount(userId: $userId) { id name email status } }`)
+	req.Var("userId", erasableID)
+	tests.AuthRequest(req, adminToken)
+	var resp struct {
+		DeleteUserAccount struct {
+			ID     string
+			Name   string
+			Email  string
+			Status string
+		}
+	}
+	err = client.Run(context.TODO(), req, &resp)
+	require.NoError(t, err)
+	require.Equal(t, "Deleted", resp.DeleteUserAccount.Status)
+	require.NotEqual(t, erasableEmail, resp.DeleteUserAccount.Email)
+
+	// Existing tokens are revoked and the account can't be reactivated
+	meReq := graphql.NewRequest(`query { me { id } }`)
+	tests.AuthRequest(meReq, erasableToken)
+	err = client.Run(context.TODO(), meReq, &struct{ Me struct{ ID string } }{})
+	require.Error(t, err)
+
+	reactivateReq := graphql.NewRequest(`mutation($id: ID!) { reactivateUser(id: $id) { id } }`)
+	reactivateReq.Var("id", erasableID)
+	tests.AuthRequest(reactivateReq, adminToken)
+	err = client.Run(context.TODO(), reactivateReq, &struct{ ReactivateUser struct{ ID string } }{})
+	require.Error(t, err)
+
+	// The payments and returns are kept for accounting without the card digits and the reason
+	orderReq := graphql.NewRequest(`query($id: ID!) { order(id: $id) { payments { cardLast4 } returns { reason } } }`)
+	orderReq.Var("id", orderID)
+	tests.AuthRequest(orderReq, adminToken)
+	var orderResp struct {
+		Order struct {
+			Payments []struct{ CardLast4 *string }
+			Returns  []struct{ Reason string }
+		}
+	}
+	err = client.Run(context.TODO(), orderReq, &orderResp)
+	require.NoError(t, err)
+	require.Len(t, orderResp.Order.Payments, 1)
+	require.Nil(t, orderResp.Order.Payments[0].CardLast4)
+	require.Len(t, orderResp.Order.Returns, 1)
+	require.Empty(t, orderResp.Order.Returns[0].Reason)
+}
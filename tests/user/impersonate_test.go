@@ -0,0 +1,154 @@
+package user
+
+import (
+	"context"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/require"
+	"graphql-backend/tests"
+)
+
+func getMeID(t *testing.T, token string) string {
+	client := tests.NewGraphQLClient()
+	req := graphql.NewRequest(`query { me { id } }`)
+	tests.AuthRequest(req, token)
+	var resp struct {
+		Me struct{ ID string }
+	}
+	err := client.Run(context.TODO(), req, &resp)
+	require.NoError(t, err)
+	return resp.Me.ID
+}
+
+func TestImpersonate(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	adminID := getMeID(t, adminToken)
+	customerID := getMeID(t, customerToken)
+	client := tests.NewGraphQLClient()
+
+	req := graphql.NewRequest(`mutation($userId: ID!) { impersonate(userId: $userId) { accessToken expiresAt user { id } } }`)
+	req.Var("userId", customerID)
+	tests.AuthRequest(req, adminToken)
+	var resp struct {
+		Impersonate struct {
+			AccessToken string
+			ExpiresAt   string
+			User        struct{ ID string }
+		}
+	}
+	err := client.Run(context.TODO(), req, &resp)
+	require.NoError(t, err)
+	require.Equal(t, customerID, resp.Impersonate.User.ID)
+	impersonationToken := resp.Impersonate.AccessToken
+
+	// The token acts as the customer
+	require.Equal(t, customerID, getMeID(t, impersonationToken))
+
+	// Mutations under impersonation are audited
+	createReq := graphql.NewRequest(`mutation($input: CreateProductInput!) { createProduct(input: $input) { id } }`)
+	createReq.Var("input", map[string]interface{}{
+		"name":     "ImpersonationProduct",
+		"price":    3.0,
+		"inStock":  3,
+		"category": "ImpersonationCat",
+	})
+	tests.AuthRequest(createReq, adminToken)
+	var createResp struct {
+		CreateProduct struct{ ID string }
+	}
+	err = client.Run(context.TODO(), createReq, &createResp)
+	require.NoError(t, err)
+
+	orderReq := graphql.NewRequest(`mutation($ids: [ID!]!) { placeOrder(productIds: $ids) { id } }`)
+	orderReq.Var("ids", []string{createResp.CreateProduct.ID})
+	tests.AuthRequest(orderReq, impersonationToken)
+	err = client.Run(context.TODO(), orderReq, &struct{ PlaceOrder struct{ ID string } }{})
+	require.NoError(t, err)
+
+	auditReq := graphql.NewRequest(`query($actorId: ID) { auditLog(limit: 5, actorId: $actorId) { action operation succeeded actor { id } user { id } } }`)
+	auditReq.Var("actorId", adminID)
+	tests.AuthRequest(auditReq, adminToken)
+	var auditResp struct {
+		AuditLog []struct {
+			Action    string
+			Operation string
+			Succeeded bool
+			Actor     struct{ ID string }
+			User      struct{ ID string }
+		}
+	}
+	err = client.Run(context.TODO(), auditReq, &auditResp)
+	require.NoError(t, err)
+	require.NotEmpty(t, auditResp.AuditLog)
+	require.Equal(t, "placeOrder", auditResp.AuditLog[0].Operation)
+	require.True(t, auditResp.AuditLog[0].Succeeded)
+	require.Equal(t, adminID, auditResp.AuditLog[0].Actor.ID)
+	require.Equal(t, customerID, auditResp.AuditLog[0].User.ID)
+
+	// Impersonation tokens can't impersonate again
+	nestedReq := graphql.NewRequest(`mutation($userId: ID!) { impersonate(userId: $userId) { accessToken } }`)
+	nestedReq.Var("userId", customerID)
+	tests.AuthRequest(nestedReq, impersonationToken)
+	err = client.Run(context.TODO(), nestedReq, &struct{ Impersonate struct{ AccessToken string } }{})
+	require.Error(t, err)
+
+	// Nor change the customer's profile
+	profileReq := graphql.NewRequest(`mutation($input: UpdateProfileInput!) { updateProfile(input: $input) { id } }`)
+	profileReq.Var("input", map[string]interface{}{"name": "Impersonated"})
+	tests.AuthRequest(profileReq, impersonationToken)
+	err = client.Run(context.TODO(), profileReq, &map[string]interface{}{})
+	require.Error(t, err)
+}
+
+func TestCustomerCannotImpersonate(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+
+	req := graphql.NewRequest(`mutation($userId: ID!) { impersonate(userId: $userId) { accessToken } }`)
+	req.Var("userId", getMeID(t, adminToken))
+	tests.AuthRequest(req, customerToken)
+	err := client.Run(context.TODO(), req, &struct{ Impersonate struct{ AccessToken string } }{})
+	require.Error(t, err)
+}
+
+func TestImpersonationEndsWithTheAdmin(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerID := getMeID(t, tests.Login(t, tests.CreateUser(t, "Customer"), tests.UserPassword))
+	client := tests.NewGraphQLClient()
+
+	impersonate := func(token string) string {
+		req := graphql.NewRequest(`mutation($userId: ID!) { impersonate(userId: $userId) { accessToken } }`)
+		req.Var("userId", customerID)
+		tests.AuthRequest(req, token)
+		var resp struct {
+			Impersonate struct{ AccessToken string }
+		}
+		require.NoError(t, client.Run(context.TODO(), req, &resp))
+		require.Equal(t, customerID, getMeID(t, resp.Impersonate.AccessToken))
+		return resp.Impersonate.AccessToken
+	}
+	requireRevoked := func(token string) {
+		req := graphql.NewRequest(`query { me { id } }`)
+		tests.AuthRequest(req, token)
+		require.Error(t, client.Run(context.TODO(), req, &struct{ Me struct{ ID string } }{}))
+	}
+
+	// A demoted admin loses the impersonation sessions they started
+	demotedToken := tests.Login(t, tests.CreateUser(t, "Admin"), tests.UserPassword)
+	impersonationToken := impersonate(demotedToken)
+
+	req := graphql.NewRequest(`mutation($id: ID!) { updateUserRole(id: $id, role: Customer) { role } }`)
+	req.Var("id", getMeID(t, demotedToken))
+	tests.AuthRequest(req, adminToken)
+	require.NoError(t, client.Run(context.TODO(), req, &map[string]interface{}{}))
+	requireRevoked(impersonationToken)
+
+	// and so does a deactivated one
+	deactivatedToken := tests.Login(t, tests.CreateUser(t, "Admin"), tests.UserPassword)
+	impersonationToken = impersonate(deactivatedToken)
+	require.Equal(t, "Deactivated", setUserStatus(t, adminToken, "deactivateUser", getMeID(t, deactivatedToken)))
+	requireRevoked(impersonationToken)
+}
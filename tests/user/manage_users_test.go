@@ -0,0 +1,128 @@
+package user
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/require"
+	"graphql-backend/tests"
+)
+
+func setUserStatus(t *testing.T, adminToken, mutation, userID string) string {
+	client := tests.NewGraphQLClient()
+	req := graphql.NewRequest(`mutation($id: ID!) { ` + mutation + `(id: $id) { id status } }`)
+	req.Var("id", userID)
+	tests.AuthRequest(req, adminToken)
+	var resp map[string]struct {
+		ID     string
+		Status string
+	}
+	err := client.Run(context.TODO(), req, &resp)
+	require.NoError(t, err)
+	return resp[mutation].Status
+}
+
+func TestDeactivateUser(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	managedEmail := tests.CreateUser(t, "Customer")
+	managedToken := tests.Login(t, managedEmail, tests.UserPassword)
+	managedID := getMeID(t, managedToken)
+	client := tests.NewGraphQLClient()
+
+	require.Equal(t, "Deactivated", setUserStatus(t, adminToken, "deactivateUser", managedID))
+
+	// Existing tokens are revoked
+	meReq := graphql.NewRequest(`query { me { id } }`)
+	tests.AuthRequest(meReq, managedToken)
+	err := client.Run(context.TODO(), meReq, &struct{ Me struct{ ID string } }{})
+	require.Error(t, err)
+
+	// and the user can't log in
+	loginReq := graphql.NewRequest(`mutation($input: LoginInput!) { login(input: $input) { accessToken } }`)
+	loginReq.Var("input", map[string]interface{}{
+		"email":    managedEmail,
+		"password": tests.UserPassword,
+	})
+	err = client.Run(context.TODO(), loginReq, &struct{ Login struct{ AccessToken string } }{})
+	require.Error(t, err)
+
+	require.Equal(t, "Active", setUserStatus(t, adminToken, "reactivateUser", managedID))
+	require.NotEmpty(t, tests.Login(t, managedEmail, tests.UserPassword))
+}
+
+func TestUsersFilter(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	client := tests.NewGraphQLClient()
+
+	req := graphql.NewRequest(`query($filter: UsersFilter) { users(filter: $filter, limit: 10) { id email role status createdAt } }`)
+	req.Var("filter", map[string]interface{}{
+		"role":   "Admin",
+		"search": "ADMIN@",
+	})
+	tests.AuthRequest(req, adminToken)
+	var resp struct {
+		Users []struct {
+			ID     string
+			Email  string
+			Role   string
+			Status string
+		}
+	}
+	err := client.Run(context.TODO(), req, &resp)
+	require.NoError(t, err)
+	require.Len(t, resp.Users, 1)
+	require.Equal(t, tests.AdminEmail, resp.Users[0].Email)
+
+	userReq := graphql.NewRequest(`query($id: ID!) { user(id: $id) { id email } }`)
+	userReq.Var("id", resp.Users[0].ID)
+	tests.AuthRequest(userReq, adminToken)
+	var userResp struct {
+		User struct {
+			ID    string
+			Email string
+		}
+	}
+	err = client.Run(context.TODO(), userReq, &userResp)
+	require.NoError(t, err)
+	require.Equal(t, tests.AdminEmail, userResp.User.Email)
+}
+
+func TestUpdateUserRole(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	client := tests.NewGraphQLClient()
+
+	// Admins can't demote themselves
+	req := graphql.NewRequest(`mutation($id: ID!, $role: Role!) { updateUserRole(id: $id, role: $role) { id role } }`)
+	req.Var("id", getMeID(t, adminToken))
+	req.Var("role", "Customer")
+	tests.AuthRequest(req, adminToken)
+	err := client.Run(context.TODO(), req, &struct{ UpdateUserRole struct{ ID string } }{})
+	require.Error(t, err)
+
+	// Customers can't manage users
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	listReq := graphql.NewRequest(`query { users { id } }`)
+	tests.AuthRequest(listReq, customerToken)
+	err = client.Run(context.TODO(), listReq, &struct{ Users []struct{ ID string } }{})
+	require.Error(t, err)
+}
+
+func TestCreateUser(t *testing.T) {
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	client := tests.NewGraphQLClient()
+
+	createUser := func(token string, email string) error {
+		req := graphql.NewRequest(`mutation($input: CreateUserInput!) { createUser(input: $input) { id } }`)
+		req.Var("input", map[string]interface{}{"name": "New User", "email": email, "password": "secret"})
+		tests.AuthRequest(req, token)
+		return client.Run(context.TODO(), req, &map[string]interface{}{})
+	}
+
+	require.Error(t, createUser(customerToken, "created-by-customer@example.com"))
+	require.Error(t, createUser(adminToken, tests.CustomerEmail))
+	require.Error(t, createUser(adminToken, strings.ToUpper(tests.CustomerEmail)))
+	require.Error(t, createUser(adminToken, "not an email"))
+}
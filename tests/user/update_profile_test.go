@@ -0,0 +1,107 @@
+package user
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/require"
+	"graphql-backend/tests"
+)
+
+func TestUpdateProfile(t *testing.T) {
+	email := tests.CreateUser(t, "Customer")
+	token := tests.Login(t, email, tests.UserPassword)
+	client := tests.NewGraphQLClient()
+
+	req := graphql.NewRequest(`mutation($input: UpdateProfileInput!) { updateProfile(input: $input) { id name email pendingEmail role createdAt } }`)
+	req.Var("input", map[string]interface{}{
+		"name":  "Renamed User",
+		"email": "new-" + email,
+	})
+	tests.AuthRequest(req, token)
+	var resp struct {
+		UpdateProfile struct {
+			ID           string
+			Name         string
+			Email        string
+			PendingEmail *string
+			Role         string
+		}
+	}
+	err := client.Run(context.TODO(), req, &resp)
+	require.NoError(t, err)
+	require.Equal(t, "Renamed User", resp.UpdateProfile.Name)
+	require.Equal(t, "Customer", resp.UpdateProfile.Role)
+
+	// The email only changes once verified
+	require.Equal(t, email, resp.UpdateProfile.Email)
+	require.NotNil(t, resp.UpdateProfile.PendingEmail)
+	require.Equal(t, "new-"+email, *resp.UpdateProfile.PendingEmail)
+
+	verifyReq := graphql.NewRequest(`mutation($token: String!) { verifyEmail(token: $token) { email } }`)
+	verifyReq.Var("token", "wrong-token")
+	tests.AuthRequest(verifyReq, token)
+	err = client.Run(context.TODO(), verifyReq, &struct{ VerifyEmail struct{ Email string } }{})
+	require.Error(t, err)
+}
+
+func TestUpdateProfileRejectsTakenEmail(t *testing.T) {
+	token := tests.Login(t, tests.CreateUser(t, "Customer"), tests.UserPassword)
+	client := tests.NewGraphQLClient()
+
+	for _, email := range []string{tests.AdminEmail, strings.ToUpper(tests.AdminEmail)} {
+		req := graphql.NewRequest(`mutation($input: UpdateProfileInput!) { updateProfile(input: $input) { id } }`)
+		req.Var("input", map[string]interface{}{
+			"email": email,
+		})
+		tests.AuthRequest(req, token)
+		err := client.Run(context.TODO(), req, &struct{ UpdateProfile struct{ ID string } }{})
+		require.Error(t, err)
+	}
+}
+
+func TestMeWithOrders(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+
+	createReq := graphql.NewRequest(`mutation($input: CreateProductInput!) { createProduct(input: $input) { id } }`)
+	createReq.Var("input", map[string]interface{}{
+		"name":     "MeOrdersProduct",
+		"price":    4.0,
+		"inStock":  2,
+		"category": "MeOrdersCat",
+	})
+	tests.AuthRequest(createReq, adminToken)
+	var createResp struct {
+		CreateProduct struct{ ID string }
+	}
+	err := client.Run(context.TODO(), createReq, &createResp)
+	require.NoError(t, err)
+
+	orderReq := graphql.NewRequest(`mutation($ids: [ID!]!) { placeOrder(productIds: $ids) { id } }`)
+	orderReq.Var("ids", []string{createResp.CreateProduct.ID})
+	tests.AuthRequest(orderReq, customerToken)
+	var orderResp struct {
+		PlaceOrder struct{ ID string }
+	}
+	err = client.Run(context.TODO(), orderReq, &orderResp)
+	require.NoError(t, err)
+
+	meReq := graphql.NewRequest(`query { me { id orders(limit: 1) { id products { id } } } }`)
+	tests.AuthRequest(meReq, customerToken)
+	var meResp struct {
+		Me struct {
+			ID     string
+			Orders []struct {
+				ID       string
+				Products []struct{ ID string }
+			}
+		}
+	}
+	err = client.Run(context.TODO(), meReq, &meResp)
+	require.NoError(t, err)
+	require.Len(t, meResp.Me.Orders, 1)
+}
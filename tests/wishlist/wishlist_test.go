@@ -0,0 +1,159 @@
+package wishlist
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/require"
+	"graphql-backend/tests"
+)
+
+type wishlistItem struct {
+	ProductID  string
+	Product    *struct{ Name string }
+	AddedAt    string
+	NotifiedAt *string
+}
+
+const wishlistFields = `{ productId product { name } addedAt notifiedAt }`
+
+func createProduct(t *testing.T, client *graphql.Client, adminToken string, input map[string]interface{}) string {
+	input["name"] = "WishedProduct"
+	input["price"] = 10
+	input["category"] = "Wishlist"
+	req := graphql.NewRequest(`mutation($input: CreateProductInput!) { createProduct(input: $input) { id } }`)
+	req.Var("input", input)
+	tests.AuthRequest(req, adminToken)
+	var resp struct {
+		CreateProduct struct{ ID string }
+	}
+	err := client.Run(context.TODO(), req, &resp)
+	require.NoError(t, err)
+	return resp.CreateProduct.ID
+}
+
+func runWishlistMutation(client *graphql.Client, token string, mutation string, productID string) ([]wishlistItem, error) {
+	req := graphql.NewRequest(`mutation($productId: ID!) { ` + mutation + `(productId: $productId) ` + wishlistFields + ` }`)
+	req.Var("productId", productID)
+	tests.AuthRequest(req, token)
+	var resp map[string][]wishlistItem
+	err := client.Run(context.TODO(), req, &resp)
+	return resp[mutation], err
+}
+
+// getWishlistItem returns the item of the product in the user's wishlist, nil when it is not in it
+func getWishlistItem(t *testing.T, client *graphql.Client, token string, productID string) *wishlistItem {
+	req := graphql.NewRequest(`query { wishlist ` + wishlistFields + ` }`)
+	tests.AuthRequest(req, token)
+	var resp struct {
+		Wishlist []wishlistItem
+	}
+	err := client.Run(context.TODO(), req, &resp)
+	require.NoError(t, err)
+	for _, item := range resp.Wishlist {
+		if item.ProductID == productID {
+			return &item
+		}
+	}
+	return nil
+}
+
+func updateProduct(t *testing.T, client *graphql.Client, adminToken string, input map[string]interface{}) {
+	req := graphql.NewRequest(`mutation($input: UpdateProductInput!) { updateProduct(input: $input) { id } }`)
+	req.Var("input", input)
+	tests.AuthRequest(req, adminToken)
+	err := client.Run(context.TODO(), req, &map[string]interface{}{})
+	require.NoError(t, err)
+}
+
+func TestWishlist(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+	soldOut := createProduct(t, client, adminToken, map[string]interface{}{"inStock": 0})
+	inStock := createProduct(t, client, adminToken, map[string]interface{}{"inStock": 3})
+
+	_, err := runWishlistMutation(client, "", "addToWishlist", soldOut)
+	require.Error(t, err)
+
+	_, err = runWishlistMutation(client, customerToken, "addToWishlist", soldOut)
+	require.NoError(t, err)
+	items, err := runWishlistMutation(client, customerToken, "addToWishlist", soldOut)
+	require.NoError(t, err)
+	count := 0
+	for _, item := range items {
+		if item.ProductID == soldOut {
+			count++
+		}
+	}
+	require.Equal(t, 1, count)
+	_, err = runWishlistMutation(client, customerToken, "addToWishlist", inStock)
+	require.NoError(t, err)
+
+	item := getWishlistItem(t, client, customerToken, soldOut)
+	require.NotNil(t, item)
+	require.Equal(t, "WishedProduct", item.Product.Name)
+	require.NotEmpty(t, item.AddedAt)
+	require.Nil(t, item.NotifiedAt)
+
+	// Only products coming back from zero stock are notified
+	updateProduct(t, client, adminToken, map[string]interface{}{"id": inStock, "inStock": 5})
+	require.Nil(t, getWishlistItem(t, client, customerToken, inStock).NotifiedAt)
+	updateProduct(t, client, adminToken, map[string]interface{}{"id": soldOut, "name": "WishedProduct"})
+	require.Nil(t, getWishlistItem(t, client, customerToken, soldOut).NotifiedAt)
+
+	updateProduct(t, client, adminToken, map[string]interface{}{"id": soldOut, "inStock": 2})
+	require.NotNil(t, getWishlistItem(t, client, customerToken, soldOut).NotifiedAt)
+
+	items, err = runWishlistMutation(client, customerToken, "removeFromWishlist", soldOut)
+	require.NoError(t, err)
+	for _, item := range items {
+		require.NotEqual(t, soldOut, item.ProductID)
+	}
+	_, err = runWishlistMutation(client, customerToken, "removeFromWishlist", soldOut)
+	require.Error(t, err)
+
+	// Deleted products leave the wishlists, archived ones can't be added
+	deleteReq := graphql.NewRequest(`mutation($id: ID!) { deleteProduct(id: $id) }`)
+	deleteReq.Var("id", inStock)
+	tests.AuthRequest(deleteReq, adminToken)
+	require.NoError(t, client.Run(context.TODO(), deleteReq, &map[string]interface{}{}))
+	require.Nil(t, getWishlistItem(t, client, customerToken, inStock))
+
+	archiveReq := graphql.NewRequest(`mutation($id: ID!) { archiveProduct(id: $id) { id } }`)
+	archiveReq.Var("id", soldOut)
+	tests.AuthRequest(archiveReq, adminToken)
+	require.NoError(t, client.Run(context.TODO(), archiveReq, &map[string]interface{}{}))
+	_, err = runWishlistMutation(client, customerToken, "addToWishlist", soldOut)
+	require.Error(t, err)
+}
+
+func TestWishlistVariantsBackInStock(t *testing.T) {
+	adminToken := tests.Login(t, tests.AdminEmail, tests.AdminPassword)
+	customerToken := tests.Login(t, tests.CustomerEmail, tests.CustomerPassword)
+	client := tests.NewGraphQLClient()
+	productID := createProduct(t, client, adminToken, map[string]interface{}{
+		"inStock": 0,
+		"options": []map[string]interface{}{{"name": "Size", "values": []string{"S"}}},
+	})
+
+	_, err := runWishlistMutation(client, customerToken, "addToWishlist", productID)
+	require.NoError(t, err)
+
+	req := graphql.NewRequest(`mutation($input: CreateProductVariantInput!) { createProductVariant(input: $input) { inStock } }`)
+	req.Var("input", map[string]interface{}{
+		"productId": productID,
+		"sku":       "WISH-" + uuid.NewString()[:8],
+		"options":   []map[string]interface{}{{"name": "Size", "value": "S"}},
+		"inStock":   4,
+	})
+	tests.AuthRequest(req, adminToken)
+	require.NoError(t, client.Run(context.TODO(), req, &map[string]interface{}{}))
+
+	require.NotNil(t, getWishlistItem(t, client, customerToken, productID).NotifiedAt)
+
+	_, err = runWishlistMutation(client, customerToken, "removeFromWishlist", productID)
+	require.NoError(t, err)
+}
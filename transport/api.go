@@ -2,23 +2,101 @@ package transport
 
 import (
 	"context"
+	"errors"
 	"graphql-backend/app"
+	"graphql-backend/entity"
 	"graphql-backend/graph/model"
 	httptrans "graphql-backend/pkg/http-transport"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
 )
 
 type API interface {
 	CreateProduct(ctx context.Context, input model.CreateProductInput) (*model.Product, error)
 	UpdateProduct(ctx context.Context, input model.UpdateProductInput) (*model.Product, error)
-	Product(ctx context.Context, id string) (*model.Product, error)
-	Products(ctx context.Context, limit *int32, offset *int32, category *string) ([]*model.Product, error)
+	CreateProductVariant(ctx context.Context, input model.CreateProductVariantInput) (*model.Product, error)
+	UpdateProductVariant(ctx context.Context, input model.UpdateProductVariantInput) (*model.Product, error)
+	DeleteProductVariant(ctx context.Context, productID string, id string) (*model.Product, error)
+	ArchiveProduct(ctx context.Context, id string) (*model.Product, error)
+	RestoreProduct(ctx context.Context, id string) (*model.Product, error)
+	DeleteProduct(ctx context.Context, id string) (bool, error)
+	UploadProductImage(ctx context.Context, productID string, file graphql.Upload) (*model.Product, error)
+	ImportProducts(ctx context.Context, file graphql.Upload, format *model.CatalogFormat, dryRun bool) (*model.ProductImportResult, error)
+	ExportProducts(ctx context.Context, format model.CatalogFormat) (string, error)
+	DeleteProductImage(ctx context.Context, productID string, id string) (*model.Product, error)
+	Product(ctx context.Context, id string, currency *string) (*model.Product, error)
+	Categories(ctx context.Context) ([]*model.Category, error)
+	Category(ctx context.Context, id *string, slug *string) (*model.Category, error)
+	CreateCategory(ctx context.Context, input model.CreateCategoryInput) (*model.Category, error)
+	UpdateCategory(ctx context.Context, input model.UpdateCategoryInput) (*model.Category, error)
+	DeleteCategory(ctx context.Context, id string) (bool, error)
+	Products(ctx context.Context, limit *int32, offset *int32, category *string, categoryID *string, currency *string) ([]*model.Product, error)
+	ArchivedProducts(ctx context.Context, limit *int32, offset *int32, category *string, categoryID *string) ([]*model.Product, error)
 
-	PlaceOrder(ctx context.Context, productIds []string) (*model.Order, error)
+	PlaceOrder(ctx context.Context, productIds []string, items []*model.PlaceOrderItemInput, addressID *string, billingAddressID *string, couponCode *string, currency *string) (*model.Order, error)
 	Orders(ctx context.Context, limit *int32, offset *int32) ([]*model.Order, error)
 	Order(ctx context.Context, id string) (*model.Order, error)
 
 	Me(ctx context.Context) (*model.User, error)
 	Login(ctx context.Context, input model.LoginInput) (*model.AuthPayload, error)
+
+	APIKeys(ctx context.Context) ([]*model.APIKey, error)
+	CreateAPIKey(ctx context.Context, input model.CreateAPIKeyInput) (*model.CreateAPIKeyPayload, error)
+	RevokeAPIKey(ctx context.Context, id string) (*model.APIKey, error)
+
+	Impersonate(ctx context.Context, userID string) (*model.ImpersonationPayload, error)
+	AuditLog(ctx context.Context, limit *int32, offset *int32, actorID *string, userID *string) ([]*model.AuditEntry, error)
+
+	Users(ctx context.Context, filter *model.UsersFilter, limit *int32, offset *int32) ([]*model.User, error)
+	User(ctx context.Context, id string) (*model.User, error)
+	CreateUser(ctx context.Context, input model.CreateUserInput) (*model.User, error)
+	UpdateUserRole(ctx context.Context, id string, role model.Role) (*model.User, error)
+	DeactivateUser(ctx context.Context, id string) (*model.User, error)
+	ReactivateUser(ctx context.Context, id string) (*model.User, error)
+	UpdateProfile(ctx context.Context, input model.UpdateProfileInput) (*model.User, error)
+	VerifyEmail(ctx context.Context, token string) (*model.User, error)
+
+	RequestMyDataExport(ctx context.Context) (*model.DataExport, error)
+	DeleteMyAccount(ctx context.Context) (*model.User, error)
+	RequestUserDataExport(ctx context.Context, userID string) (*model.DataExport, error)
+	DeleteUserAccount(ctx context.Context, userID string) (*model.User, error)
+
+	Addresses(ctx context.Context) ([]*model.Address, error)
+	CreateAddress(ctx context.Context, input model.CreateAddressInput) (*model.Address, error)
+	UpdateAddress(ctx context.Context, input model.UpdateAddressInput) (*model.Address, error)
+	DeleteAddress(ctx context.Context, id string) (bool, error)
+
+	Cart(ctx context.Context, cartToken *string, currency *string) (*model.Cart, error)
+	AddToCart(ctx context.Context, productID string, variantID *string, quantity int32, cartToken *string) (*model.Cart, error)
+	UpdateCartItem(ctx context.Context, productID string, variantID *string, quantity int32, cartToken *string) (*model.Cart, error)
+	RemoveFromCart(ctx context.Context, productID string, variantID *string, cartToken *string) (*model.Cart, error)
+	ClearCart(ctx context.Context, cartToken *string) (*model.Cart, error)
+	Checkout(ctx context.Context, addressID *string, billingAddressID *string, couponCode *string, currency *string) (*model.Order, error)
+
+	Promotions(ctx context.Context, limit *int32, offset *int32, active *bool) ([]*model.Promotion, error)
+	Promotion(ctx context.Context, id string) (*model.Promotion, error)
+	CreatePromotion(ctx context.Context, input model.CreatePromotionInput) (*model.Promotion, error)
+	UpdatePromotion(ctx context.Context, input model.UpdatePromotionInput) (*model.Promotion, error)
+
+	PayOrder(ctx context.Context, orderID string, paymentMethod string) (*model.Payment, error)
+	UpdateOrderStatus(ctx context.Context, id string, status string) (*model.Order, error)
+
+	ReturnRequests(ctx context.Context, status *model.ReturnStatus, limit *int32, offset *int32) ([]*model.ReturnRequest, error)
+	RequestReturn(ctx context.Context, input model.RequestReturnInput) (*model.ReturnRequest, error)
+	ApproveReturn(ctx context.Context, id string, restock *bool, note *string) (*model.ReturnRequest, error)
+	RejectReturn(ctx context.Context, id string, note *string) (*model.ReturnRequest, error)
+	Reviews(ctx context.Context, status *model.ReviewStatus, productID *string, limit *int32, offset *int32) ([]*model.Review, error)
+	CreateReview(ctx context.Context, input model.CreateReviewInput) (*model.Review, error)
+	ApproveReview(ctx context.Context, id string) (*model.Review, error)
+	HideReview(ctx context.Context, id string) (*model.Review, error)
+	Wishlist(ctx context.Context) ([]*model.WishlistItem, error)
+	AddToWishlist(ctx context.Context, productID string) ([]*model.WishlistItem, error)
+	RemoveFromWishlist(ctx context.Context, productID string) ([]*model.WishlistItem, error)
+	RefundOrder(ctx context.Context, orderID string, amount *entity.Money, reason string) (*model.Order, error)
+	ScheduledPrices(ctx context.Context, status *model.ScheduledPriceStatus, productID *string, limit *int32, offset *int32) ([]*model.ScheduledPrice, error)
+	SchedulePrice(ctx context.Context, input model.SchedulePriceInput) (*model.ScheduledPrice, error)
+	CancelScheduledPrice(ctx context.Context, id string) (*model.ScheduledPrice, error)
 }
 
 type api struct {
@@ -41,8 +119,9 @@ func (a api) Me(ctx context.Context) (*model.User, error) {
 
 func (a api) Login(ctx context.Context, input model.LoginInput) (*model.AuthPayload, error) {
 	result, err := a.service.Login(ctx, app.LoginParams{
-		Email:    input.Email,
-		Password: input.Password,
+		Email:     input.Email,
+		Password:  input.Password,
+		CartToken: StringV(input.CartToken),
 	})
 	if err != nil {
 		return nil, err
@@ -57,10 +136,13 @@ func (a api) Login(ctx context.Context, input model.LoginInput) (*model.AuthPayl
 func (a api) CreateProduct(ctx context.Context, input model.CreateProductInput) (*model.Product, error) {
 	product, err := a.service.CreateProduct(ctx, app.CreateProductParams{
 		Name:        input.Name,
-		Description: *input.Description,
+		SKU:         StringV(input.Sku),
+		Description: StringV(input.Description),
 		Price:       input.Price,
 		InStock:     input.InStock,
-		Category:    input.Category,
+		Category:    StringV(input.Category),
+		CategoryID:  StringV(input.CategoryID),
+		Options:     productOptions(input.Options),
 	})
 	if err != nil {
 		return nil, err
@@ -76,10 +158,168 @@ func (a api) UpdateProduct(ctx context.Context, input model.UpdateProductInput)
 	product, err := a.service.UpdateProduct(ctx, app.UpdateProductParams{
 		ID:          input.ID,
 		Name:        input.Name,
+		SKU:         input.Sku,
 		Description: input.Description,
 		Price:       input.Price,
 		InStock:     input.InStock,
 		Category:    input.Category,
+		CategoryID:  input.CategoryID,
+		Options:     productOptions(input.Options),
+		ActorID:     actorID(ctx),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := ProductRes{}
+	res.Bind(product)
+
+	return res.Res, nil
+}
+
+func (a api) ArchiveProduct(ctx context.Context, id string) (*model.Product, error) {
+	product, err := a.service.ArchiveProduct(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	res := ProductRes{}
+	res.Bind(product)
+
+	return res.Res, nil
+}
+
+func (a api) RestoreProduct(ctx context.Context, id string) (*model.Product, error) {
+	product, err := a.service.RestoreProduct(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	res := ProductRes{}
+	res.Bind(product)
+
+	return res.Res, nil
+}
+
+func (a api) DeleteProduct(ctx context.Context, id string) (bool, error) {
+	err := a.service.DeleteProduct(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (a api) UploadProductImage(ctx context.Context, productID string, file graphql.Upload) (*model.Product, error) {
+	product, err := a.service.UploadProductImage(ctx, app.UploadProductImageParams{
+		ProductID: productID,
+		Content:   file.File,
+		Size:      file.Size,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := ProductRes{}
+	res.Bind(product)
+
+	return res.Res, nil
+}
+
+func (a api) DeleteProductImage(ctx context.Context, productID string, id string) (*model.Product, error) {
+	product, err := a.service.DeleteProductImage(ctx, app.DeleteProductImageParams{
+		ProductID: productID,
+		ID:        id,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := ProductRes{}
+	res.Bind(product)
+
+	return res.Res, nil
+}
+
+func (a api) ImportProducts(ctx context.Context, file graphql.Upload, format *model.CatalogFormat, dryRun bool) (*model.ProductImportResult, error) {
+	catalogFormat, err := app.CatalogFormatOf(file.Filename)
+	if format != nil {
+		catalogFormat, err = app.CatalogFormat(*format), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := a.service.ImportProducts(ctx, app.ImportProductsParams{
+		Format:  catalogFormat,
+		Content: file.File,
+		DryRun:  dryRun,
+		ActorID: actorID(ctx),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := ProductImportResultRes{}
+	res.Bind(result)
+
+	return res.Res, nil
+}
+
+func (a api) ExportProducts(ctx context.Context, format model.CatalogFormat) (string, error) {
+	var b strings.Builder
+	err := a.query.ExportProducts(ctx, app.ExportProductsParams{Format: app.CatalogFormat(format)}, &b)
+	if err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+func (a api) CreateProductVariant(ctx context.Context, input model.CreateProductVariantInput) (*model.Product, error) {
+	product, err := a.service.CreateProductVariant(ctx, app.CreateProductVariantParams{
+		ProductID: input.ProductID,
+		SKU:       input.Sku,
+		Options:   variantOptions(input.Options),
+		Price:     input.Price,
+		InStock:   input.InStock,
+		ActorID:   actorID(ctx),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := ProductRes{}
+	res.Bind(product)
+
+	return res.Res, nil
+}
+
+func (a api) UpdateProductVariant(ctx context.Context, input model.UpdateProductVariantInput) (*model.Product, error) {
+	product, err := a.service.UpdateProductVariant(ctx, app.UpdateProductVariantParams{
+		ProductID:  input.ProductID,
+		ID:         input.ID,
+		SKU:        input.Sku,
+		Options:    variantOptions(input.Options),
+		Price:      input.Price,
+		ClearPrice: input.ClearPrice != nil && *input.ClearPrice,
+		InStock:    input.InStock,
+		ActorID:    actorID(ctx),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := ProductRes{}
+	res.Bind(product)
+
+	return res.Res, nil
+}
+
+func (a api) DeleteProductVariant(ctx context.Context, productID string, id string) (*model.Product, error) {
+	product, err := a.service.DeleteProductVariant(ctx, app.DeleteProductVariantParams{
+		ProductID: productID,
+		ID:        id,
 	})
 	if err != nil {
 		return nil, err
@@ -91,8 +331,33 @@ func (a api) UpdateProduct(ctx context.Context, input model.UpdateProductInput)
 	return res.Res, nil
 }
 
-func (a api) Product(ctx context.Context, id string) (*model.Product, error) {
-	product, err := a.query.GetProduct(ctx, id)
+func productOptions(input []*model.ProductOptionInput) []entity.ProductOption {
+	if input == nil {
+		return nil
+	}
+	options := make([]entity.ProductOption, len(input))
+	for i, option := range input {
+		options[i] = entity.ProductOption{Name: option.Name, Values: option.Values}
+	}
+	return options
+}
+
+func variantOptions(input []*model.VariantOptionInput) []entity.VariantOption {
+	if input == nil {
+		return nil
+	}
+	options := make([]entity.VariantOption, len(input))
+	for i, option := range input {
+		options[i] = entity.VariantOption{Name: option.Name, Value: option.Value}
+	}
+	return options
+}
+
+func (a api) Product(ctx context.Context, id string, currency *string) (*model.Product, error) {
+	product, err := a.query.GetProduct(ctx, app.ProductParams{
+		ID:       id,
+		Currency: StringV(currency),
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -103,11 +368,31 @@ func (a api) Product(ctx context.Context, id string) (*model.Product, error) {
 	return res.Res, nil
 }
 
-func (a api) Products(ctx context.Context, limit *int32, offset *int32, category *string) ([]*model.Product, error) {
+func (a api) Products(ctx context.Context, limit *int32, offset *int32, category *string, categoryID *string, currency *string) ([]*model.Product, error) {
+	es, err := a.query.GetProducts(ctx, app.ProductsParams{
+		Limit:      limit,
+		Offset:     offset,
+		Category:   category,
+		CategoryID: categoryID,
+		Currency:   StringV(currency),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := ProductsRes{}
+	res.Bind(es)
+
+	return res.Res, nil
+}
+
+func (a api) ArchivedProducts(ctx context.Context, limit *int32, offset *int32, category *string, categoryID *string) ([]*model.Product, error) {
 	es, err := a.query.GetProducts(ctx, app.ProductsParams{
-		Limit:    limit,
-		Offset:   offset,
-		Category: category,
+		Limit:      limit,
+		Offset:     offset,
+		Category:   category,
+		CategoryID: categoryID,
+		Archived:   true,
 	})
 	if err != nil {
 		return nil, err
@@ -119,11 +404,88 @@ func (a api) Products(ctx context.Context, limit *int32, offset *int32, category
 	return res.Res, nil
 }
 
-func (a api) PlaceOrder(ctx context.Context, productIds []string) (*model.Order, error) {
+func (a api) Categories(ctx context.Context) ([]*model.Category, error) {
+	trees, err := a.query.GetCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := CategoriesRes{}
+	res.Bind(trees)
+
+	return res.Res, nil
+}
+
+func (a api) Category(ctx context.Context, id *string, slug *string) (*model.Category, error) {
+	tree, err := a.query.GetCategory(ctx, app.CategoryParams{
+		ID:   StringV(id),
+		Slug: StringV(slug),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := CategoryRes{}
+	res.Bind(tree)
+
+	return res.Res, nil
+}
+
+func (a api) CreateCategory(ctx context.Context, input model.CreateCategoryInput) (*model.Category, error) {
+	category, err := a.service.CreateCategory(ctx, app.CreateCategoryParams{
+		Name:     input.Name,
+		Slug:     StringV(input.Slug),
+		ParentID: StringV(input.ParentID),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := CategoryRes{}
+	res.Bind(app.CategoryTree{Category: category})
+
+	return res.Res, nil
+}
+
+func (a api) UpdateCategory(ctx context.Context, input model.UpdateCategoryInput) (*model.Category, error) {
+	_, err := a.service.UpdateCategory(ctx, app.UpdateCategoryParams{
+		ID:        input.ID,
+		Name:      input.Name,
+		Slug:      input.Slug,
+		ParentID:  input.ParentID,
+		MoveToTop: input.MoveToTop != nil && *input.MoveToTop,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the updated category is returned with its subcategories
+	return a.Category(ctx, &input.ID, nil)
+}
+
+func (a api) DeleteCategory(ctx context.Context, id string) (bool, error) {
+	err := a.service.DeleteCategory(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (a api) PlaceOrder(ctx context.Context, productIds []string, items []*model.PlaceOrderItemInput, addressID *string, billingAddressID *string, couponCode *string, currency *string) (*model.Order, error) {
 	userID := httptrans.GetUserFromContext(ctx).UserID
+	orderItems := make([]app.OrderItemParams, len(items))
+	for i, item := range items {
+		orderItems[i] = app.OrderItemParams{ProductID: item.ProductID, VariantID: StringV(item.VariantID), Quantity: item.Quantity}
+	}
 	order, err := a.service.PlaceOrder(ctx, app.PlaceOrderParams{
-		ProductIDs: productIds,
-		UserID:     userID,
+		ProductIDs:       productIds,
+		Items:            orderItems,
+		UserID:           userID,
+		AddressID:        addressID,
+		BillingAddressID: billingAddressID,
+		CouponCode:       StringV(couponCode),
+		Currency:         StringV(currency),
 	})
 	if err != nil {
 		return nil, err
@@ -153,10 +515,11 @@ func (a api) Orders(ctx context.Context, limit *int32, offset *int32) ([]*model.
 }
 
 func (a api) Order(ctx context.Context, id string) (*model.Order, error) {
-	userID := httptrans.GetUserFromContext(ctx).UserID
+	// ownership is checked by the @isOwnerOrHasRole directive on the resolved order, the orders of other users are
+	// looked up as missing first so that order IDs can't be probed
 	o, err := a.query.GetOrder(ctx, app.OrderParams{
 		ID:     id,
-		UserID: userID,
+		UserID: ownerID(ctx),
 	})
 	if err != nil {
 		return nil, err
@@ -168,6 +531,904 @@ func (a api) Order(ctx context.Context, id string) (*model.Order, error) {
 	return res.Res, nil
 }
 
+func (a api) APIKeys(ctx context.Context) ([]*model.APIKey, error) {
+	es, err := a.query.GetAPIKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := APIKeysRes{}
+	res.Bind(es)
+
+	return res.Res, nil
+}
+
+func (a api) CreateAPIKey(ctx context.Context, input model.CreateAPIKeyInput) (*model.CreateAPIKeyPayload, error) {
+	expiresAt, err := ParseTimeP(input.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := make([]string, len(input.Scopes))
+	for i, scope := range input.Scopes {
+		scopes[i] = string(scope)
+	}
+
+	result, err := a.service.CreateAPIKey(ctx, app.CreateAPIKeyParams{
+		Name:      input.Name,
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+		CreatedBy: httptrans.GetUserFromContext(ctx).UserID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := CreateAPIKeyPayloadRes{}
+	res.Bind(result)
+
+	return res.Res, nil
+}
+
+func (a api) RevokeAPIKey(ctx context.Context, id string) (*model.APIKey, error) {
+	apiKey, err := a.service.RevokeAPIKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	res := APIKeyRes{}
+	res.Bind(apiKey)
+
+	return res.Res, nil
+}
+
+func (a api) Impersonate(ctx context.Context, userID string) (*model.ImpersonationPayload, error) {
+	actorID := httptrans.GetUserFromContext(ctx).UserID
+	result, err := a.service.Impersonate(ctx, app.ImpersonateParams{
+		ActorID: actorID,
+		UserID:  userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := ImpersonationPayloadRes{}
+	res.Bind(result)
+
+	return res.Res, nil
+}
+
+func (a api) AuditLog(ctx context.Context, limit *int32, offset *int32, actorID *string, userID *string) ([]*model.AuditEntry, error) {
+	es, err := a.query.GetAuditEntries(ctx, app.AuditEntriesParams{
+		Limit:   limit,
+		Offset:  offset,
+		ActorID: actorID,
+		UserID:  userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := AuditEntriesRes{}
+	res.Bind(es)
+
+	return res.Res, nil
+}
+
+func (a api) Users(ctx context.Context, filter *model.UsersFilter, limit *int32, offset *int32) ([]*model.User, error) {
+	prs := app.UsersParams{
+		Limit:  limit,
+		Offset: offset,
+	}
+	if filter != nil {
+		if filter.Role != nil {
+			role := string(*filter.Role)
+			prs.Role = &role
+		}
+		if filter.Status != nil {
+			status := entity.UserStatus(*filter.Status)
+			prs.Status = &status
+		}
+		prs.Search = filter.Search
+	}
+
+	es, err := a.query.GetUsers(ctx, prs)
+	if err != nil {
+		return nil, err
+	}
+
+	res := UsersRes{}
+	res.Bind(es)
+
+	return res.Res, nil
+}
+
+func (a api) User(ctx context.Context, id string) (*model.User, error) {
+	user, err := a.query.GetUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	res := UserRes{}
+	res.Bind(user)
+
+	return res.Res, nil
+}
+
+func (a api) CreateUser(ctx context.Context, input model.CreateUserInput) (*model.User, error) {
+	user, err := a.service.CreateUser(ctx, app.CreateUserParams{
+		Name:     input.Name,
+		Email:    input.Email,
+		Password: input.Password,
+		Role:     string(input.Role),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := UserRes{}
+	res.Bind(user)
+
+	return res.Res, nil
+}
+
+func (a api) UpdateUserRole(ctx context.Context, id string, role model.Role) (*model.User, error) {
+	user, err := a.service.UpdateUserRole(ctx, app.UpdateUserRoleParams{
+		ActorID: httptrans.GetUserFromContext(ctx).UserID,
+		UserID:  id,
+		Role:    string(role),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := UserRes{}
+	res.Bind(user)
+
+	return res.Res, nil
+}
+
+func (a api) DeactivateUser(ctx context.Context, id string) (*model.User, error) {
+	user, err := a.service.DeactivateUser(ctx, app.UserStatusParams{
+		ActorID: httptrans.GetUserFromContext(ctx).UserID,
+		UserID:  id,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := UserRes{}
+	res.Bind(user)
+
+	return res.Res, nil
+}
+
+func (a api) ReactivateUser(ctx context.Context, id string) (*model.User, error) {
+	user, err := a.service.ReactivateUser(ctx, app.UserStatusParams{
+		ActorID: httptrans.GetUserFromContext(ctx).UserID,
+		UserID:  id,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := UserRes{}
+	res.Bind(user)
+
+	return res.Res, nil
+}
+
+func (a api) UpdateProfile(ctx context.Context, input model.UpdateProfileInput) (*model.User, error) {
+	claims := httptrans.GetUserFromContext(ctx)
+	user, err := a.service.UpdateProfile(ctx, app.UpdateProfileParams{
+		UserID:  claims.UserID,
+		ActorID: claims.ActorID,
+		Name:    input.Name,
+		Email:   input.Email,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := UserRes{}
+	res.Bind(user)
+
+	return res.Res, nil
+}
+
+func (a api) VerifyEmail(ctx context.Context, token string) (*model.User, error) {
+	user, err := a.service.VerifyEmail(ctx, app.VerifyEmailParams{
+		UserID: httptrans.GetUserFromContext(ctx).UserID,
+		Token:  token,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := UserRes{}
+	res.Bind(user)
+
+	return res.Res, nil
+}
+
+func (a api) RequestMyDataExport(ctx context.Context) (*model.DataExport, error) {
+	userID := httptrans.GetUserFromContext(ctx).UserID
+	return a.requestDataExport(ctx, userID, userID)
+}
+
+func (a api) RequestUserDataExport(ctx context.Context, userID string) (*model.DataExport, error) {
+	return a.requestDataExport(ctx, userID, httptrans.GetUserFromContext(ctx).UserID)
+}
+
+func (a api) requestDataExport(ctx context.Context, userID, requestedBy string) (*model.DataExport, error) {
+	export, err := a.service.RequestDataExport(ctx, app.DataExportParams{
+		UserID:      userID,
+		RequestedBy: requestedBy,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := DataExportRes{}
+	res.Bind(export)
+
+	return res.Res, nil
+}
+
+func (a api) DeleteMyAccount(ctx context.Context) (*model.User, error) {
+	userID := httptrans.GetUserFromContext(ctx).UserID
+	return a.deleteAccount(ctx, userID, userID)
+}
+
+func (a api) DeleteUserAccount(ctx context.Context, userID string) (*model.User, error) {
+	return a.deleteAccount(ctx, userID, httptrans.GetUserFromContext(ctx).UserID)
+}
+
+func (a api) deleteAccount(ctx context.Context, userID, requestedBy string) (*model.User, error) {
+	user, err := a.service.DeleteAccount(ctx, app.DeleteAccountParams{
+		UserID:      userID,
+		RequestedBy: requestedBy,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := UserRes{}
+	res.Bind(user)
+
+	return res.Res, nil
+}
+
+func (a api) Addresses(ctx context.Context) ([]*model.Address, error) {
+	es, err := a.query.GetAddresses(ctx, httptrans.GetUserFromContext(ctx).UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := AddressesRes{}
+	res.Bind(es)
+
+	return res.Res, nil
+}
+
+func (a api) CreateAddress(ctx context.Context, input model.CreateAddressInput) (*model.Address, error) {
+	address, err := a.service.CreateAddress(ctx, app.CreateAddressParams{
+		UserID:            httptrans.GetUserFromContext(ctx).UserID,
+		FullName:          input.FullName,
+		Line1:             input.Line1,
+		Line2:             StringV(input.Line2),
+		City:              input.City,
+		Region:            StringV(input.Region),
+		PostalCode:        StringV(input.PostalCode),
+		Country:           input.Country,
+		Phone:             StringV(input.Phone),
+		IsDefaultShipping: BoolV(input.IsDefaultShipping),
+		IsDefaultBilling:  BoolV(input.IsDefaultBilling),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := AddressRes{}
+	res.Bind(address)
+
+	return res.Res, nil
+}
+
+func (a api) UpdateAddress(ctx context.Context, input model.UpdateAddressInput) (*model.Address, error) {
+	address, err := a.service.UpdateAddress(ctx, app.UpdateAddressParams{
+		UserID:            httptrans.GetUserFromContext(ctx).UserID,
+		ID:                input.ID,
+		FullName:          input.FullName,
+		Line1:             input.Line1,
+		Line2:             input.Line2,
+		City:              input.City,
+		Region:            input.Region,
+		PostalCode:        input.PostalCode,
+		Country:           input.Country,
+		Phone:             input.Phone,
+		IsDefaultShipping: input.IsDefaultShipping,
+		IsDefaultBilling:  input.IsDefaultBilling,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := AddressRes{}
+	res.Bind(address)
+
+	return res.Res, nil
+}
+
+func (a api) DeleteAddress(ctx context.Context, id string) (bool, error) {
+	err := a.service.DeleteAddress(ctx, app.DeleteAddressParams{
+		UserID: httptrans.GetUserFromContext(ctx).UserID,
+		ID:     id,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// cartOwner is the logged-in user, otherwise the guest holding the cart token
+func cartOwner(ctx context.Context, cartToken *string) (app.CartOwner, error) {
+	if user := httptrans.GetUserFromContext(ctx); user != nil {
+		return app.CartOwner{UserID: user.UserID}, nil
+	}
+	if httptrans.GetServiceFromContext(ctx) != nil {
+		return app.CartOwner{}, errors.New("api keys have no cart")
+	}
+	return app.CartOwner{GuestToken: StringV(cartToken)}, nil
+}
+
+// actorID is the ID of the logged-in user, or of the API key the request was made with
+func actorID(ctx context.Context) string {
+	if user := httptrans.GetUserFromContext(ctx); user != nil {
+		return user.UserID
+	}
+	if service := httptrans.GetServiceFromContext(ctx); service != nil {
+		return service.KeyID
+	}
+	return ""
+}
+
+func (a api) Cart(ctx context.Context, cartToken *string, currency *string) (*model.Cart, error) {
+	owner, err := cartOwner(ctx, cartToken)
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := a.query.GetCart(ctx, owner, StringV(currency))
+	if err != nil {
+		return nil, err
+	}
+
+	res := CartRes{}
+	res.Bind(cart)
+
+	return res.Res, nil
+}
+
+func (a api) AddToCart(ctx context.Context, productID string, variantID *string, quantity int32, cartToken *string) (*model.Cart, error) {
+	owner, err := cartOwner(ctx, cartToken)
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := a.service.AddToCart(ctx, app.CartItemParams{
+		Owner:     owner,
+		ProductID: productID,
+		VariantID: StringV(variantID),
+		Quantity:  quantity,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := CartRes{}
+	res.Bind(cart)
+
+	return res.Res, nil
+}
+
+func (a api) UpdateCartItem(ctx context.Context, productID string, variantID *string, quantity int32, cartToken *string) (*model.Cart, error) {
+	owner, err := cartOwner(ctx, cartToken)
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := a.service.UpdateCartItem(ctx, app.CartItemParams{
+		Owner:     owner,
+		ProductID: productID,
+		VariantID: StringV(variantID),
+		Quantity:  quantity,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := CartRes{}
+	res.Bind(cart)
+
+	return res.Res, nil
+}
+
+func (a api) RemoveFromCart(ctx context.Context, productID string, variantID *string, cartToken *string) (*model.Cart, error) {
+	owner, err := cartOwner(ctx, cartToken)
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := a.service.RemoveFromCart(ctx, app.CartItemParams{
+		Owner:     owner,
+		ProductID: productID,
+		VariantID: StringV(variantID),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := CartRes{}
+	res.Bind(cart)
+
+	return res.Res, nil
+}
+
+func (a api) ClearCart(ctx context.Context, cartToken *string) (*model.Cart, error) {
+	owner, err := cartOwner(ctx, cartToken)
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := a.service.ClearCart(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	res := CartRes{}
+	res.Bind(cart)
+
+	return res.Res, nil
+}
+
+func (a api) Checkout(ctx context.Context, addressID *string, billingAddressID *string, couponCode *string, currency *string) (*model.Order, error) {
+	order, err := a.service.Checkout(ctx, app.CheckoutParams{
+		UserID:           httptrans.GetUserFromContext(ctx).UserID,
+		AddressID:        addressID,
+		BillingAddressID: billingAddressID,
+		CouponCode:       StringV(couponCode),
+		Currency:         StringV(currency),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := OrderRes{}
+	res.Bind(order)
+
+	return res.Res, nil
+}
+
+func (a api) Promotions(ctx context.Context, limit *int32, offset *int32, active *bool) ([]*model.Promotion, error) {
+	es, err := a.query.GetPromotions(ctx, app.PromotionsParams{
+		Limit:  limit,
+		Offset: offset,
+		Active: active,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := PromotionsRes{}
+	res.Bind(es)
+
+	return res.Res, nil
+}
+
+func (a api) Promotion(ctx context.Context, id string) (*model.Promotion, error) {
+	promotion, err := a.query.GetPromotion(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	res := PromotionRes{}
+	res.Bind(promotion)
+
+	return res.Res, nil
+}
+
+func (a api) CreatePromotion(ctx context.Context, input model.CreatePromotionInput) (*model.Promotion, error) {
+	startsAt, err := ParseTimeP(input.StartsAt)
+	if err != nil {
+		return nil, err
+	}
+	endsAt, err := ParseTimeP(input.EndsAt)
+	if err != nil {
+		return nil, err
+	}
+
+	promotion, err := a.service.CreatePromotion(ctx, app.CreatePromotionParams{
+		Code:              input.Code,
+		Description:       StringV(input.Description),
+		Type:              entity.PromotionType(input.Type),
+		Percentage:        Float64V(input.Percentage),
+		Amount:            MoneyV(input.Amount),
+		Scope:             entity.PromotionScope(input.Scope),
+		CategoryIDs:       input.CategoryIds,
+		ProductIDs:        input.ProductIds,
+		MinOrderValue:     MoneyV(input.MinOrderValue),
+		UsageLimit:        input.UsageLimit,
+		UsageLimitPerUser: input.UsageLimitPerUser,
+		Active:            input.Active == nil || *input.Active,
+		StartsAt:          startsAt,
+		EndsAt:            endsAt,
+		CreatedBy:         httptrans.GetUserFromContext(ctx).UserID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := PromotionRes{}
+	res.Bind(promotion)
+
+	return res.Res, nil
+}
+
+func (a api) UpdatePromotion(ctx context.Context, input model.UpdatePromotionInput) (*model.Promotion, error) {
+	startsAt, err := ParseTimeP(input.StartsAt)
+	if err != nil {
+		return nil, err
+	}
+	endsAt, err := ParseTimeP(input.EndsAt)
+	if err != nil {
+		return nil, err
+	}
+
+	prs := app.UpdatePromotionParams{
+		ID:                input.ID,
+		Code:              input.Code,
+		Description:       input.Description,
+		Percentage:        input.Percentage,
+		Amount:            input.Amount,
+		CategoryIDs:       input.CategoryIds,
+		ProductIDs:        input.ProductIds,
+		MinOrderValue:     input.MinOrderValue,
+		UsageLimit:        input.UsageLimit,
+		UsageLimitPerUser: input.UsageLimitPerUser,
+		Active:            input.Active,
+		StartsAt:          startsAt,
+		EndsAt:            endsAt,
+
+		ClearUsageLimit:        input.ClearUsageLimit != nil && *input.ClearUsageLimit,
+		ClearUsageLimitPerUser: input.ClearUsageLimitPerUser != nil && *input.ClearUsageLimitPerUser,
+		ClearStartsAt:          input.ClearStartsAt != nil && *input.ClearStartsAt,
+		ClearEndsAt:            input.ClearEndsAt != nil && *input.ClearEndsAt,
+	}
+	if input.Type != nil {
+		promotionType := entity.PromotionType(*input.Type)
+		prs.Type = &promotionType
+	}
+	if input.Scope != nil {
+		scope := entity.PromotionScope(*input.Scope)
+		prs.Scope = &scope
+	}
+
+	promotion, err := a.service.UpdatePromotion(ctx, prs)
+	if err != nil {
+		return nil, err
+	}
+
+	res := PromotionRes{}
+	res.Bind(promotion)
+
+	return res.Res, nil
+}
+
+func (a api) PayOrder(ctx context.Context, orderID string, paymentMethod string) (*model.Payment, error) {
+	payment, err := a.service.PayOrder(ctx, app.PayOrderParams{
+		UserID:        httptrans.GetUserFromContext(ctx).UserID,
+		OrderID:       orderID,
+		PaymentMethod: paymentMethod,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := PaymentRes{}
+	res.Bind(payment)
+
+	return res.Res, nil
+}
+
+func (a api) UpdateOrderStatus(ctx context.Context, id string, status string) (*model.Order, error) {
+	order, err := a.service.UpdateOrderStatus(ctx, app.UpdateOrderStatusParams{
+		ID:      id,
+		Status:  entity.OrderStatus(status),
+		ActorID: httptrans.GetUserFromContext(ctx).UserID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := OrderRes{}
+	res.Bind(order)
+
+	return res.Res, nil
+}
+
+func (a api) ReturnRequests(ctx context.Context, status *model.ReturnStatus, limit *int32, offset *int32) ([]*model.ReturnRequest, error) {
+	prs := app.ReturnsParams{
+		Limit:  limit,
+		Offset: offset,
+	}
+	if status != nil {
+		returnStatus := entity.ReturnStatus(*status)
+		prs.Status = &returnStatus
+	}
+
+	es, err := a.query.GetReturns(ctx, prs)
+	if err != nil {
+		return nil, err
+	}
+
+	res := ReturnRequestsRes{}
+	res.Bind(es)
+
+	return res.Res, nil
+}
+
+func (a api) RequestReturn(ctx context.Context, input model.RequestReturnInput) (*model.ReturnRequest, error) {
+	items := make([]entity.ReturnItem, len(input.Items))
+	for i, item := range input.Items {
+		items[i] = entity.ReturnItem{ProductID: item.ProductID, VariantID: StringV(item.VariantID), Quantity: item.Quantity}
+	}
+
+	request, err := a.service.RequestReturn(ctx, app.RequestReturnParams{
+		UserID:  httptrans.GetUserFromContext(ctx).UserID,
+		OrderID: input.OrderID,
+		Items:   items,
+		Reason:  input.Reason,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := ReturnRequestRes{}
+	res.Bind(request)
+
+	return res.Res, nil
+}
+
+func (a api) ApproveReturn(ctx context.Context, id string, restock *bool, note *string) (*model.ReturnRequest, error) {
+	request, err := a.service.ApproveReturn(ctx, app.ReviewReturnParams{
+		ID:         id,
+		ReviewerID: httptrans.GetUserFromContext(ctx).UserID,
+		Note:       StringV(note),
+		Restock:    restock == nil || *restock,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := ReturnRequestRes{}
+	res.Bind(request)
+
+	return res.Res, nil
+}
+
+func (a api) RejectReturn(ctx context.Context, id string, note *string) (*model.ReturnRequest, error) {
+	request, err := a.service.RejectReturn(ctx, app.ReviewReturnParams{
+		ID:         id,
+		ReviewerID: httptrans.GetUserFromContext(ctx).UserID,
+		Note:       StringV(note),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := ReturnRequestRes{}
+	res.Bind(request)
+
+	return res.Res, nil
+}
+
+func (a api) Reviews(ctx context.Context, status *model.ReviewStatus, productID *string, limit *int32, offset *int32) ([]*model.Review, error) {
+	prs := app.ReviewsParams{
+		Limit:     limit,
+		Offset:    offset,
+		ProductID: StringV(productID),
+	}
+	if status != nil {
+		reviewStatus := entity.ReviewStatus(*status)
+		prs.Status = &reviewStatus
+	}
+
+	es, err := a.query.GetReviews(ctx, prs)
+	if err != nil {
+		return nil, err
+	}
+
+	res := ReviewsRes{}
+	res.Bind(es)
+
+	return res.Res, nil
+}
+
+func (a api) CreateReview(ctx context.Context, input model.CreateReviewInput) (*model.Review, error) {
+	review, err := a.service.CreateReview(ctx, app.CreateReviewParams{
+		UserID:    httptrans.GetUserFromContext(ctx).UserID,
+		ProductID: input.ProductID,
+		Rating:    input.Rating,
+		Body:      StringV(input.Body),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := ReviewRes{}
+	res.Bind(review)
+
+	return res.Res, nil
+}
+
+func (a api) ApproveReview(ctx context.Context, id string) (*model.Review, error) {
+	review, err := a.service.ApproveReview(ctx, app.ModerateReviewParams{
+		ID:          id,
+		ModeratorID: httptrans.GetUserFromContext(ctx).UserID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := ReviewRes{}
+	res.Bind(review)
+
+	return res.Res, nil
+}
+
+func (a api) HideReview(ctx context.Context, id string) (*model.Review, error) {
+	review, err := a.service.HideReview(ctx, app.ModerateReviewParams{
+		ID:          id,
+		ModeratorID: httptrans.GetUserFromContext(ctx).UserID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := ReviewRes{}
+	res.Bind(review)
+
+	return res.Res, nil
+}
+
+func (a api) Wishlist(ctx context.Context) ([]*model.WishlistItem, error) {
+	wishlist, err := a.query.GetWishlist(ctx, httptrans.GetUserFromContext(ctx).UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := WishlistRes{}
+	res.Bind(wishlist)
+
+	return res.Res, nil
+}
+
+func (a api) AddToWishlist(ctx context.Context, productID string) ([]*model.WishlistItem, error) {
+	wishlist, err := a.service.AddToWishlist(ctx, app.WishlistItemParams{
+		UserID:    httptrans.GetUserFromContext(ctx).UserID,
+		ProductID: productID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := WishlistRes{}
+	res.Bind(wishlist)
+
+	return res.Res, nil
+}
+
+func (a api) RemoveFromWishlist(ctx context.Context, productID string) ([]*model.WishlistItem, error) {
+	wishlist, err := a.service.RemoveFromWishlist(ctx, app.WishlistItemParams{
+		UserID:    httptrans.GetUserFromContext(ctx).UserID,
+		ProductID: productID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := WishlistRes{}
+	res.Bind(wishlist)
+
+	return res.Res, nil
+}
+
+func (a api) RefundOrder(ctx context.Context, orderID string, amount *entity.Money, reason string) (*model.Order, error) {
+	order, err := a.service.RefundOrder(ctx, app.RefundOrderParams{
+		OrderID: orderID,
+		Amount:  amount,
+		Reason:  reason,
+		ActorID: httptrans.GetUserFromContext(ctx).UserID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := OrderRes{}
+	res.Bind(order)
+
+	return res.Res, nil
+}
+
+func (a api) ScheduledPrices(ctx context.Context, status *model.ScheduledPriceStatus, productID *string, limit *int32, offset *int32) ([]*model.ScheduledPrice, error) {
+	prs := app.ScheduledPricesParams{
+		Limit:     limit,
+		Offset:    offset,
+		ProductID: StringV(productID),
+	}
+	if status != nil {
+		scheduledStatus := entity.ScheduledPriceStatus(*status)
+		prs.Status = &scheduledStatus
+	}
+
+	es, err := a.query.GetScheduledPrices(ctx, prs)
+	if err != nil {
+		return nil, err
+	}
+
+	res := ScheduledPricesRes{}
+	res.Bind(es)
+
+	return res.Res, nil
+}
+
+func (a api) SchedulePrice(ctx context.Context, input model.SchedulePriceInput) (*model.ScheduledPrice, error) {
+	startsAt, err := ParseTimeP(&input.StartsAt)
+	if err != nil {
+		return nil, err
+	}
+	if startsAt == nil {
+		return nil, errors.New("startsAt is required")
+	}
+	endsAt, err := ParseTimeP(input.EndsAt)
+	if err != nil {
+		return nil, err
+	}
+
+	scheduled, err := a.service.SchedulePrice(ctx, app.SchedulePriceParams{
+		ProductID: input.ProductID,
+		Price:     input.Price,
+		StartsAt:  *startsAt,
+		EndsAt:    endsAt,
+		ActorID:   actorID(ctx),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := ScheduledPriceRes{}
+	res.Bind(scheduled)
+
+	return res.Res, nil
+}
+
+func (a api) CancelScheduledPrice(ctx context.Context, id string) (*model.ScheduledPrice, error) {
+	scheduled, err := a.service.CancelScheduledPrice(ctx, app.CancelScheduledPriceParams{
+		ID:      id,
+		ActorID: actorID(ctx),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := ScheduledPriceRes{}
+	res.Bind(scheduled)
+
+	return res.Res, nil
+}
+
 func NewAPI(query app.Query, service app.Service) API {
 	return &api{
 		query:   query,
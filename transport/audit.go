@@ -0,0 +1,50 @@
+package transport
+
+import (
+	"context"
+	"graphql-backend/app"
+	"graphql-backend/entity"
+	httptrans "graphql-backend/pkg/http-transport"
+	"log"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// AuditImpersonation records every mutation performed with an impersonation token,
+// with the acting admin, the impersonated user and whether it succeeded
+func AuditImpersonation(service app.Service) graphql.OperationMiddleware {
+	return func(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+		user := httptrans.GetUserFromContext(ctx)
+		opCtx := graphql.GetOperationContext(ctx)
+		if user == nil || !user.IsImpersonated() || opCtx.Operation == nil || opCtx.Operation.Operation != ast.Mutation {
+			return next(ctx)
+		}
+
+		var fields []string
+		for _, sel := range opCtx.Operation.SelectionSet {
+			if field, ok := sel.(*ast.Field); ok {
+				fields = append(fields, field.Name)
+			}
+		}
+
+		handler := next(ctx)
+		return func(ctx context.Context) *graphql.Response {
+			resp := handler(ctx)
+
+			err := service.RecordAuditEntry(ctx, app.AuditEntryParams{
+				ActorID:   user.ActorID,
+				UserID:    user.UserID,
+				Action:    entity.AuditActionMutation,
+				Operation: strings.Join(fields, ","),
+				Succeeded: resp != nil && len(resp.Errors) == 0,
+			})
+			if err != nil {
+				log.Println("Failed to record audit entry:", err)
+			}
+
+			return resp
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package transport
+
+import (
+	"errors"
+	"graphql-backend/app"
+	"graphql-backend/entity"
+	httptrans "graphql-backend/pkg/http-transport"
+	"net/http"
+	"strings"
+)
+
+// DataExportPath is where data export archives are downloaded from, followed by the export ID
+const DataExportPath = "/exports/"
+
+// DataExportHandler serves data export archives to the user they belong to and to admins.
+// It has to be wrapped with the auth middleware.
+type DataExportHandler struct {
+	query app.Query
+}
+
+func NewDataExportHandler(query app.Query) *DataExportHandler {
+	return &DataExportHandler{query: query}
+}
+
+func (h *DataExportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	claims := httptrans.GetUserFromContext(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if claims.IsImpersonated() {
+		writeError(w, http.StatusForbidden, "not allowed while impersonating a user")
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, DataExportPath)
+	export, content, err := h.query.GetDataExportContent(r.Context(), id)
+	if errors.Is(err, app.ErrDataExportExpired) {
+		writeError(w, http.StatusGone, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusNotFound, "data export not found")
+		return
+	}
+
+	// don't reveal exports of other users
+	if export.UserID != claims.UserID && claims.Role != entity.RoleAdmin {
+		writeError(w, http.StatusNotFound, "data export not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="data-export-`+export.ID+`.json"`)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(content)
+}
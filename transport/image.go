@@ -0,0 +1,50 @@
+package transport
+
+import (
+	"graphql-backend/app"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// ImagePath is where the stored images are served from, followed by their key
+const ImagePath = "/images/"
+
+// ImageHandler serves the images kept in the blob storage. Keys are never reused, so the images are cached for good.
+type ImageHandler struct {
+	blobs app.BlobStorage
+}
+
+func NewImageHandler(blobs app.BlobStorage) *ImageHandler {
+	return &ImageHandler{blobs: blobs}
+}
+
+func (h *ImageHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	key := strings.TrimPrefix(r.URL.Path, ImagePath)
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if !strings.HasPrefix(contentType, "image/") {
+		writeError(w, http.StatusNotFound, "image not found")
+		return
+	}
+
+	// invalid keys are reported as missing images as well
+	content, err := h.blobs.Get(r.Context(), key)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "image not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodGet {
+		_, _ = w.Write(content)
+	}
+}
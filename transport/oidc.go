@@ -0,0 +1,148 @@
+package transport
+
+import (
+	"encoding/json"
+	"graphql-backend/app"
+	"graphql-backend/pkg/oidc"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	oidcStateCookie   = "oidc_state"
+	oidcLoginDuration = 10 * time.Minute
+)
+
+type oidcLoginRequest struct {
+	codeVerifier string
+	nonce        string
+	expiresAt    time.Time
+}
+
+// OIDCHandler serves the OpenID Connect authorization code flow (with PKCE) next to /query,
+// and responds with our own token pair once the user is authenticated by the provider
+type OIDCHandler struct {
+	provider *oidc.Provider
+	service  app.Service
+
+	mu      sync.Mutex
+	pending map[string]oidcLoginRequest
+}
+
+func NewOIDCHandler(provider *oidc.Provider, service app.Service) *OIDCHandler {
+	return &OIDCHandler{
+		provider: provider,
+		service:  service,
+		pending:  map[string]oidcLoginRequest{},
+	}
+}
+
+// Login redirects the user agent to the provider's authorization endpoint
+func (h *OIDCHandler) Login(w http.ResponseWriter, r *http.Request) {
+	state, err := oidc.RandomString()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	nonce, err := oidc.RandomString()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	codeVerifier, err := oidc.RandomString()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	now := time.Now()
+	h.mu.Lock()
+	for k, v := range h.pending {
+		if now.After(v.expiresAt) {
+			delete(h.pending, k)
+		}
+	}
+	h.pending[state] = oidcLoginRequest{
+		codeVerifier: codeVerifier,
+		nonce:        nonce,
+		expiresAt:    now.Add(oidcLoginDuration),
+	}
+	h.mu.Unlock()
+
+	// bind the state to this user agent, so a callback can't be replayed from another one
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     "/",
+		MaxAge:   int(oidcLoginDuration.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, h.provider.AuthCodeURL(state, nonce, codeVerifier), http.StatusFound)
+}
+
+// Callback exchanges the authorization code and signs the user in
+func (h *OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if errCode := q.Get("error"); errCode != "" {
+		writeError(w, http.StatusBadRequest, "oidc provider returned an error: "+errCode)
+		return
+	}
+
+	state := q.Get("state")
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil || state == "" || cookie.Value != state {
+		writeError(w, http.StatusBadRequest, "invalid oidc state")
+		return
+	}
+
+	h.mu.Lock()
+	login, ok := h.pending[state]
+	delete(h.pending, state)
+	h.mu.Unlock()
+
+	if !ok || time.Now().After(login.expiresAt) {
+		writeError(w, http.StatusBadRequest, "oidc login expired, please try again")
+		return
+	}
+
+	claims, err := h.provider.Exchange(r.Context(), q.Get("code"), login.codeVerifier, login.nonce)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	result, err := h.service.LoginWithOIDC(r.Context(), app.OIDCLoginParams{
+		Issuer:        h.provider.Issuer(),
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+	})
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:   oidcStateCookie,
+		Path:   "/",
+		MaxAge: -1,
+	})
+
+	res := AuthPayloadRes{}
+	res.Bind(result)
+	writeJSON(w, http.StatusOK, res.Res)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
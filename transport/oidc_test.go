@@ -0,0 +1,118 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"graphql-backend/app"
+	"graphql-backend/entity"
+	"graphql-backend/pkg/oidc"
+	"graphql-backend/pkg/oidc/oidctest"
+)
+
+const oidcCallbackURL = "http://localhost:8080/auth/oidc/callback"
+
+// oidcService records the OIDC logins, the other methods of Service are not used
+type oidcService struct {
+	app.Service
+	logins []app.OIDCLoginParams
+}
+
+func (s *oidcService) LoginWithOIDC(ctx context.Context, prs app.OIDCLoginParams) (app.LoginResult, error) {
+	s.logins = append(s.logins, prs)
+	return app.LoginResult{
+		AccessToken:  "access-token",
+		RefreshToken: "refresh-token",
+		User:         entity.User{ID: "user-1", Email: prs.Email, Role: entity.RoleCustomer},
+	}, nil
+}
+
+// startOIDCLogin runs the login redirect and the provider's authorization, it returns the callback request
+func startOIDCLogin(t *testing.T, handler *OIDCHandler) *http.Request {
+	login := httptest.NewRecorder()
+	handler.Login(login, httptest.NewRequest(http.MethodGet, "/auth/oidc/login", nil))
+	require.Equal(t, http.StatusFound, login.Code)
+	cookies := login.Result().Cookies()
+	require.Len(t, cookies, 1)
+
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Get(login.Header().Get("Location"))
+	require.NoError(t, err)
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, callback.String(), nil)
+	req.AddCookie(cookies[0])
+	return req
+}
+
+func TestOIDCCallback(t *testing.T) {
+	issuer, err := oidctest.NewServer("graphql-backend", oidctest.User{
+		Subject:       "user-123",
+		Email:         "oidc@example.com",
+		EmailVerified: true,
+		Name:          "OIDC User",
+	})
+	require.NoError(t, err)
+	defer issuer.Close()
+
+	provider, err := oidc.Discover(context.TODO(), oidc.Config{
+		IssuerURL:   issuer.URL,
+		ClientID:    "graphql-backend",
+		RedirectURL: oidcCallbackURL,
+	}, nil)
+	require.NoError(t, err)
+	service := &oidcService{}
+	handler := NewOIDCHandler(provider, service)
+
+	callback := startOIDCLogin(t, handler)
+	res := httptest.NewRecorder()
+	handler.Callback(res, callback)
+	require.Equal(t, http.StatusOK, res.Code)
+
+	var payload struct {
+		AccessToken  string
+		RefreshToken string
+		User         struct{ ID string }
+	}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&payload))
+	require.Equal(t, "access-token", payload.AccessToken)
+	require.Equal(t, "user-1", payload.User.ID)
+	require.Equal(t, []app.OIDCLoginParams{{
+		Issuer:        issuer.URL,
+		Subject:       "user-123",
+		Email:         "oidc@example.com",
+		EmailVerified: true,
+		Name:          "OIDC User",
+	}}, service.logins)
+
+	// the state is single use
+	res = httptest.NewRecorder()
+	handler.Callback(res, callback)
+	require.Equal(t, http.StatusBadRequest, res.Code)
+
+	// the state must come back with the cookie of the user agent that started the login
+	callback = startOIDCLogin(t, handler)
+	replayed := httptest.NewRequest(http.MethodGet, callback.URL.String(), nil)
+	res = httptest.NewRecorder()
+	handler.Callback(res, replayed)
+	require.Equal(t, http.StatusBadRequest, res.Code)
+
+	res = httptest.NewRecorder()
+	handler.Callback(res, httptest.NewRequest(http.MethodGet, oidcCallbackURL+"?error=access_denied", nil))
+	require.Equal(t, http.StatusBadRequest, res.Code)
+	require.Len(t, service.logins, 1)
+}
@@ -0,0 +1,49 @@
+package transport
+
+import (
+	"errors"
+	"graphql-backend/app"
+	"graphql-backend/entity"
+	"io"
+	"net/http"
+)
+
+// PaymentWebhookPath receives the asynchronous notifications of the payment gateway
+const PaymentWebhookPath = "/payments/webhook"
+
+const maxWebhookPayloadSize = 64 << 10
+
+// PaymentWebhookHandler applies payment gateway notifications. It is called by the gateway, not by users,
+// notifications are authenticated by their signature instead.
+type PaymentWebhookHandler struct {
+	service app.Service
+}
+
+func NewPaymentWebhookHandler(service app.Service) *PaymentWebhookHandler {
+	return &PaymentWebhookHandler{service: service}
+}
+
+func (h *PaymentWebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookPayloadSize))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, "payload too large")
+		return
+	}
+
+	err = h.service.HandlePaymentWebhook(r.Context(), payload, r.Header)
+	if errors.Is(err, entity.ErrInvalidWebhookSignature) {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
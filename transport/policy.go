@@ -0,0 +1,45 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"graphql-backend/app"
+	"graphql-backend/graph/model"
+	httptrans "graphql-backend/pkg/http-transport"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+type ownerCtxKey struct{}
+
+// IsOwnerOrHasRole resolves the field first, then lets the policy check that the
+// current user owns the result, or has the given role to override ownership.
+// Without the role the resolver can read the owner with ownerID and look up the user's objects only.
+func IsOwnerOrHasRole(policy app.Policy) func(ctx context.Context, obj interface{}, next graphql.Resolver, role model.Role) (interface{}, error) {
+	return func(ctx context.Context, obj interface{}, next graphql.Resolver, role model.Role) (interface{}, error) {
+		user := httptrans.GetUserFromContext(ctx)
+		if user == nil {
+			return nil, errors.New("unauthorized")
+		}
+		if user.Role != string(role) {
+			ctx = context.WithValue(ctx, ownerCtxKey{}, user.UserID)
+		}
+
+		res, err := next(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := policy.Authorize(ctx, res, string(role)); err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+// ownerID is the user @isOwnerOrHasRole restricts the field to, empty when the caller has the override role
+func ownerID(ctx context.Context) string {
+	id, _ := ctx.Value(ownerCtxKey{}).(string)
+	return id
+}
@@ -1,9 +1,11 @@
 package transport
 
 import (
+	"fmt"
 	"graphql-backend/app"
 	"graphql-backend/entity"
 	"graphql-backend/graph/model"
+	"time"
 )
 
 type ProductsRes struct {
@@ -13,14 +15,9 @@ type ProductsRes struct {
 func (r *ProductsRes) Bind(es []entity.Product) {
 	r.Res = make([]*model.Product, len(es))
 	for i, e := range es {
-		r.Res[i] = &model.Product{
-			ID:          e.ID,
-			Name:        e.Name,
-			Description: StringP(e.Description),
-			Price:       e.Price,
-			Category:    e.Category,
-			InStock:     e.InStock,
-		}
+		res := ProductRes{}
+		res.Bind(e)
+		r.Res[i] = res.Res
 	}
 }
 
@@ -31,11 +28,9 @@ type UsersRes struct {
 func (r *UsersRes) Bind(es []entity.User) {
 	r.Res = make([]*model.User, len(es))
 	for i, e := range es {
-		r.Res[i] = &model.User{
-			ID:    e.ID,
-			Name:  e.Name,
-			Email: e.Email,
-		}
+		res := UserRes{}
+		res.Bind(e)
+		r.Res[i] = res.Res
 	}
 }
 
@@ -46,14 +41,9 @@ type OrdersRes struct {
 func (r *OrdersRes) Bind(es []entity.Order) {
 	r.Res = make([]*model.Order, len(es))
 	for i, e := range es {
-		r.Res[i] = &model.Order{
-			ID:         e.ID,
-			ProductIDs: e.ProductIDs,
-			Total:      e.Total,
-			CreatedAt:  e.CreatedAt.String(),
-			Status:     string(e.Status),
-			UserID:     e.UserID,
-		}
+		order := OrderRes{}
+		order.Bind(e)
+		r.Res[i] = order.Res
 	}
 }
 
@@ -69,6 +59,394 @@ func (r *OrderRes) Bind(e entity.Order) {
 		CreatedAt:  e.CreatedAt.String(),
 		Status:     string(e.Status),
 		UserID:     e.UserID,
+
+		Currency:     e.Currency,
+		ExchangeRate: e.ExchangeRate,
+
+		ShippingAddress: orderAddressRes(e.ShippingAddress),
+		BillingAddress:  orderAddressRes(e.BillingAddress),
+		Items:           make([]*model.OrderItem, len(e.Items)),
+		Subtotal:        e.Subtotal,
+		Discounts:       make([]*model.OrderDiscount, len(e.Discounts)),
+		TaxLines:        make([]*model.TaxLine, len(e.TaxLines)),
+		TaxTotal:        e.GetTaxTotal(),
+		Refunds:         make([]*model.Refund, len(e.Refunds)),
+		RefundedTotal:   e.GetRefundedTotal(),
+	}
+	for i, refund := range e.Refunds {
+		r.Res.Refunds[i] = &model.Refund{
+			ID:        refund.ID,
+			Amount:    refund.Amount,
+			Reason:    refund.Reason,
+			ReturnID:  StringP(refund.ReturnID),
+			CreatedAt: FormatTime(refund.CreatedAt),
+		}
+	}
+	for i, line := range e.TaxLines {
+		r.Res.TaxLines[i] = &model.TaxLine{
+			Name:          line.Name,
+			Rate:          line.Rate,
+			TaxableAmount: line.TaxableAmount,
+			Amount:        line.Amount,
+		}
+	}
+	for i, discount := range e.Discounts {
+		r.Res.Discounts[i] = &model.OrderDiscount{
+			Code:        discount.Code,
+			Description: discount.Description,
+			Amount:      discount.Amount,
+		}
+	}
+	for i, item := range e.Items {
+		r.Res.Items[i] = &model.OrderItem{
+			ProductID: item.ProductID,
+			VariantID: StringP(item.VariantID),
+			Sku:       StringP(item.SKU),
+			Options:   variantOptionsRes(item.Options),
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+		}
+	}
+}
+
+type CartRes struct {
+	Res *model.Cart `json:"cart"`
+}
+
+func (r *CartRes) Bind(e app.CartView) {
+	r.Res = &model.Cart{
+		ID:        e.Cart.ID,
+		Token:     StringP(e.Token),
+		Items:     make([]*model.CartItem, len(e.Lines)),
+		ItemCount: e.ItemCount,
+		Currency:  e.Subtotal.Currency,
+		Subtotal:  e.Subtotal,
+		Orderable: e.Orderable(),
+	}
+	if !e.Cart.UpdatedAt.IsZero() {
+		r.Res.UpdatedAt = StringP(FormatTime(e.Cart.UpdatedAt))
+	}
+	for i, line := range e.Lines {
+		item := &model.CartItem{
+			ProductID:  line.Item.ProductID,
+			VariantID:  StringP(line.Item.VariantID),
+			Quantity:   line.Item.Quantity,
+			UnitPrice:  line.UnitPrice,
+			AddedPrice: line.Item.AddedPrice,
+			LineTotal:  line.LineTotal,
+			Issues:     make([]model.CartItemIssue, len(line.Issues)),
+		}
+		if line.Product != nil {
+			product := ProductRes{}
+			product.Bind(*line.Product)
+			item.Product = product.Res
+		}
+		if line.Variant != nil {
+			item.Variant = productVariantRes(*line.Product, *line.Variant)
+		}
+		for j, issue := range line.Issues {
+			item.Issues[j] = model.CartItemIssue(issue)
+		}
+		r.Res.Items[i] = item
+	}
+}
+
+func orderAddressRes(e *entity.OrderAddress) *model.OrderAddress {
+	if e == nil {
+		return nil
+	}
+	return &model.OrderAddress{
+		FullName:   e.FullName,
+		Line1:      e.Line1,
+		Line2:      StringP(e.Line2),
+		City:       e.City,
+		Region:     StringP(e.Region),
+		PostalCode: StringP(e.PostalCode),
+		Country:    e.Country,
+		Phone:      StringP(e.Phone),
+	}
+}
+
+type AddressesRes struct {
+	Res []*model.Address `json:"addresses"`
+}
+
+func (r *AddressesRes) Bind(es []entity.Address) {
+	r.Res = make([]*model.Address, len(es))
+	for i, e := range es {
+		address := AddressRes{}
+		address.Bind(e)
+		r.Res[i] = address.Res
+	}
+}
+
+type AddressRes struct {
+	Res *model.Address `json:"address"`
+}
+
+func (r *AddressRes) Bind(e entity.Address) {
+	r.Res = &model.Address{
+		ID:                e.ID,
+		FullName:          e.FullName,
+		Line1:             e.Line1,
+		Line2:             StringP(e.Line2),
+		City:              e.City,
+		Region:            StringP(e.Region),
+		PostalCode:        StringP(e.PostalCode),
+		Country:           e.Country,
+		Phone:             StringP(e.Phone),
+		IsDefaultShipping: e.IsDefaultShipping,
+		IsDefaultBilling:  e.IsDefaultBilling,
+		CreatedAt:         FormatTime(e.CreatedAt),
+		UpdatedAt:         FormatTime(e.UpdatedAt),
+	}
+}
+
+type PromotionsRes struct {
+	Res []*model.Promotion `json:"promotions"`
+}
+
+func (r *PromotionsRes) Bind(es []entity.Promotion) {
+	r.Res = make([]*model.Promotion, len(es))
+	for i, e := range es {
+		promotion := PromotionRes{}
+		promotion.Bind(e)
+		r.Res[i] = promotion.Res
+	}
+}
+
+type PaymentRes struct {
+	Res *model.Payment `json:"payment"`
+}
+
+func (r *PaymentRes) Bind(e entity.Payment) {
+	r.Res = &model.Payment{
+		ID:             e.ID,
+		OrderID:        e.OrderID,
+		Amount:         e.Amount,
+		Status:         model.PaymentStatus(e.Status),
+		CardLast4:      StringP(e.CardLast4),
+		FailureReason:  StringP(e.FailureReason),
+		RefundedAmount: e.RefundedAmount,
+		CreatedAt:      FormatTime(e.CreatedAt),
+		UpdatedAt:      FormatTime(e.UpdatedAt),
+	}
+}
+
+type PaymentsRes struct {
+	Res []*model.Payment `json:"payments"`
+}
+
+func (r *PaymentsRes) Bind(es []entity.Payment) {
+	r.Res = make([]*model.Payment, len(es))
+	for i, e := range es {
+		res := PaymentRes{}
+		res.Bind(e)
+		r.Res[i] = res.Res
+	}
+}
+
+type ReturnRequestRes struct {
+	Res *model.ReturnRequest `json:"returnRequest"`
+}
+
+func (r *ReturnRequestRes) Bind(e entity.ReturnRequest) {
+	r.Res = &model.ReturnRequest{
+		ID:         e.ID,
+		OrderID:    e.OrderID,
+		Items:      make([]*model.ReturnItem, len(e.Items)),
+		Reason:     e.Reason,
+		Status:     model.ReturnStatus(e.Status),
+		RefundID:   StringP(e.RefundID),
+		Restocked:  e.Restocked,
+		ReviewNote: StringP(e.ReviewNote),
+		CreatedAt:  FormatTime(e.CreatedAt),
+		UpdatedAt:  FormatTime(e.UpdatedAt),
+	}
+	for i, item := range e.Items {
+		r.Res.Items[i] = &model.ReturnItem{
+			ProductID: item.ProductID,
+			VariantID: StringP(item.VariantID),
+			Quantity:  item.Quantity,
+		}
+	}
+}
+
+type ReturnRequestsRes struct {
+	Res []*model.ReturnRequest `json:"returnRequests"`
+}
+
+func (r *ReturnRequestsRes) Bind(es []entity.ReturnRequest) {
+	r.Res = make([]*model.ReturnRequest, len(es))
+	for i, e := range es {
+		res := ReturnRequestRes{}
+		res.Bind(e)
+		r.Res[i] = res.Res
+	}
+}
+
+type ReviewRes struct {
+	Res *model.Review `json:"review"`
+}
+
+func (r *ReviewRes) Bind(e entity.Review) {
+	r.Res = &model.Review{
+		ID:         e.ID,
+		ProductID:  e.ProductID,
+		AuthorName: e.AuthorName,
+		Rating:     e.Rating,
+		Body:       StringP(e.Body),
+		Status:     model.ReviewStatus(e.Status),
+		CreatedAt:  FormatTime(e.CreatedAt),
+		UpdatedAt:  FormatTime(e.UpdatedAt),
+	}
+}
+
+type ReviewsRes struct {
+	Res []*model.Review `json:"reviews"`
+}
+
+func (r *ReviewsRes) Bind(es []entity.Review) {
+	r.Res = make([]*model.Review, len(es))
+	for i, e := range es {
+		res := ReviewRes{}
+		res.Bind(e)
+		r.Res[i] = res.Res
+	}
+}
+
+type PriceChangesRes struct {
+	Res []*model.PriceChange `json:"priceChanges"`
+}
+
+func (r *PriceChangesRes) Bind(es []entity.PriceChange) {
+	r.Res = make([]*model.PriceChange, len(es))
+	for i, e := range es {
+		r.Res[i] = &model.PriceChange{
+			ID:               e.ID,
+			VariantID:        StringP(e.VariantID),
+			OldPrice:         e.OldPrice,
+			NewPrice:         e.NewPrice,
+			ActorID:          StringP(e.ActorID),
+			Source:           model.PriceChangeSource(e.Source),
+			ScheduledPriceID: StringP(e.ScheduledPriceID),
+			ChangedAt:        FormatTime(e.ChangedAt),
+		}
+	}
+}
+
+type ScheduledPriceRes struct {
+	Res *model.ScheduledPrice `json:"scheduledPrice"`
+}
+
+func (r *ScheduledPriceRes) Bind(e entity.ScheduledPrice) {
+	r.Res = &model.ScheduledPrice{
+		ID:            e.ID,
+		ProductID:     e.ProductID,
+		Price:         e.Price,
+		StartsAt:      FormatTime(e.StartsAt),
+		EndsAt:        FormatTimeP(e.EndsAt),
+		Status:        model.ScheduledPriceStatus(e.Status),
+		PreviousPrice: e.PreviousPrice,
+		CreatedBy:     e.CreatedBy,
+		CreatedAt:     FormatTime(e.CreatedAt),
+		UpdatedAt:     FormatTime(e.UpdatedAt),
+	}
+}
+
+type ScheduledPricesRes struct {
+	Res []*model.ScheduledPrice `json:"scheduledPrices"`
+}
+
+func (r *ScheduledPricesRes) Bind(es []entity.ScheduledPrice) {
+	r.Res = make([]*model.ScheduledPrice, len(es))
+	for i, e := range es {
+		res := ScheduledPriceRes{}
+		res.Bind(e)
+		r.Res[i] = res.Res
+	}
+}
+
+type WishlistRes struct {
+	Res []*model.WishlistItem `json:"wishlist"`
+}
+
+func (r *WishlistRes) Bind(e entity.Wishlist) {
+	r.Res = make([]*model.WishlistItem, len(e.Items))
+	for i, item := range e.Items {
+		r.Res[i] = &model.WishlistItem{
+			ProductID:  item.ProductID,
+			AddedAt:    FormatTime(item.AddedAt),
+			NotifiedAt: FormatTimeP(item.NotifiedAt),
+		}
+	}
+}
+
+type PromotionRes struct {
+	Res *model.Promotion `json:"promotion"`
+}
+
+func (r *PromotionRes) Bind(e entity.Promotion) {
+	r.Res = &model.Promotion{
+		ID:                e.ID,
+		Code:              e.Code,
+		Description:       e.Description,
+		Type:              model.PromotionType(e.Type),
+		Scope:             model.PromotionScope(e.Scope),
+		CategoryIds:       e.CategoryIDs,
+		ProductIds:        e.ProductIDs,
+		MinOrderValue:     e.MinOrderValue,
+		UsageLimit:        e.UsageLimit,
+		UsageLimitPerUser: e.UsageLimitPerUser,
+		UsageCount:        e.UsageCount,
+		Active:            e.Active,
+		StartsAt:          FormatTimeP(e.StartsAt),
+		EndsAt:            FormatTimeP(e.EndsAt),
+		CreatedAt:         FormatTime(e.CreatedAt),
+		UpdatedAt:         FormatTime(e.UpdatedAt),
+	}
+	if r.Res.CategoryIds == nil {
+		r.Res.CategoryIds = []string{}
+	}
+	if r.Res.ProductIds == nil {
+		r.Res.ProductIds = []string{}
+	}
+	switch e.Type {
+	case entity.PromotionTypePercentage:
+		r.Res.Percentage = &e.Percentage
+	case entity.PromotionTypeFixedAmount:
+		r.Res.Amount = &e.Amount
+	}
+}
+
+type CategoryRes struct {
+	Res *model.Category `json:"category"`
+}
+
+func (r *CategoryRes) Bind(e app.CategoryTree) {
+	r.Res = &model.Category{
+		ID:        e.Category.ID,
+		Name:      e.Category.Name,
+		Slug:      e.Category.Slug,
+		ParentID:  StringP(e.Category.ParentID),
+		CreatedAt: FormatTime(e.Category.CreatedAt),
+		UpdatedAt: FormatTime(e.Category.UpdatedAt),
+	}
+	children := CategoriesRes{}
+	children.Bind(e.Children)
+	r.Res.Children = children.Res
+}
+
+type CategoriesRes struct {
+	Res []*model.Category `json:"categories"`
+}
+
+func (r *CategoriesRes) Bind(es []app.CategoryTree) {
+	r.Res = make([]*model.Category, len(es))
+	for i, e := range es {
+		res := CategoryRes{}
+		res.Bind(e)
+		r.Res[i] = res.Res
 	}
 }
 
@@ -78,24 +456,77 @@ type ProductRes struct {
 
 func (r *ProductRes) Bind(e entity.Product) {
 	r.Res = &model.Product{
-		ID:          e.ID,
-		Name:        e.Name,
-		Description: StringP(e.Description),
-		Price:       e.Price,
-		InStock:     e.InStock,
-		Category:    e.Category,
+		ID:            e.ID,
+		Name:          e.Name,
+		Sku:           StringP(e.SKU),
+		Description:   StringP(e.Description),
+		Price:         e.Price,
+		Currency:      e.Price.Currency,
+		InStock:       e.InStock,
+		Category:      e.Category,
+		CategoryID:    StringP(e.CategoryID),
+		Options:       make([]*model.ProductOption, len(e.Options)),
+		Variants:      make([]*model.ProductVariant, len(e.Variants)),
+		Images:        make([]*model.ProductImage, len(e.Images)),
+		ArchivedAt:    FormatTimeP(e.ArchivedAt),
+		AverageRating: e.AverageRating(),
+		ReviewCount:   e.ReviewCount,
+	}
+	for i, image := range e.Images {
+		r.Res.Images[i] = &model.ProductImage{
+			ID:           image.ID,
+			URL:          ImagePath + image.Key,
+			ThumbnailURL: ImagePath + image.ThumbnailKey,
+			ContentType:  image.ContentType,
+			Width:        image.Width,
+			Height:       image.Height,
+			Size:         int32(image.Size),
+			CreatedAt:    FormatTime(image.CreatedAt),
+		}
+	}
+	for i, option := range e.Options {
+		r.Res.Options[i] = &model.ProductOption{Name: option.Name, Values: option.Values}
+	}
+	for i, variant := range e.Variants {
+		r.Res.Variants[i] = productVariantRes(e, variant)
+	}
+}
+
+func productVariantRes(product entity.Product, e entity.ProductVariant) *model.ProductVariant {
+	return &model.ProductVariant{
+		ID:              e.ID,
+		Sku:             e.SKU,
+		Options:         variantOptionsRes(e.Options),
+		Price:           product.VariantPrice(e),
+		PriceOverridden: e.Price != nil,
+		InStock:         e.InStock,
 	}
 }
 
+func variantOptionsRes(es []entity.VariantOption) []*model.VariantOption {
+	options := make([]*model.VariantOption, len(es))
+	for i, e := range es {
+		options[i] = &model.VariantOption{Name: e.Name, Value: e.Value}
+	}
+	return options
+}
+
 type UserRes struct {
 	Res *model.User `json:"user"`
 }
 
 func (r *UserRes) Bind(e entity.User) {
 	r.Res = &model.User{
-		ID:    e.ID,
-		Name:  e.Name,
-		Email: e.Email,
+		ID:        e.ID,
+		Name:      e.Name,
+		Email:     e.Email,
+		Role:      model.Role(e.Role),
+		Status:    model.UserStatus(e.GetStatus()),
+		CreatedAt: FormatTime(e.CreatedAt),
+		UpdatedAt: FormatTime(e.UpdatedAt),
+	}
+	if e.PendingEmail != nil {
+		r.Res.PendingEmail = &e.PendingEmail.Email
 	}
 }
 
@@ -104,15 +535,133 @@ type AuthPayloadRes struct {
 }
 
 func (r *AuthPayloadRes) Bind(e app.LoginResult) {
+	user := UserRes{}
+	user.Bind(e.User)
+
 	r.Res = &model.AuthPayload{
 		AccessToken:  e.AccessToken,
 		RefreshToken: e.RefreshToken,
-		User: &model.User{
-			ID:    e.User.ID,
-			Name:  e.User.Name,
-			Email: e.User.Email,
-		},
+		User:         user.Res,
+	}
+}
+
+type APIKeysRes struct {
+	Res []*model.APIKey `json:"apiKeys"`
+}
+
+func (r *APIKeysRes) Bind(es []entity.APIKey) {
+	r.Res = make([]*model.APIKey, len(es))
+	for i, e := range es {
+		res := APIKeyRes{}
+		res.Bind(e)
+		r.Res[i] = res.Res
+	}
+}
+
+type APIKeyRes struct {
+	Res *model.APIKey `json:"apiKey"`
+}
+
+func (r *APIKeyRes) Bind(e entity.APIKey) {
+	scopes := make([]model.APIKeyScope, len(e.Scopes))
+	for i, scope := range e.Scopes {
+		scopes[i] = model.APIKeyScope(scope)
+	}
+
+	r.Res = &model.APIKey{
+		ID:         e.ID,
+		Name:       e.Name,
+		Prefix:     e.Prefix,
+		Scopes:     scopes,
+		CreatedAt:  FormatTime(e.CreatedAt),
+		ExpiresAt:  FormatTimeP(e.ExpiresAt),
+		LastUsedAt: FormatTimeP(e.LastUsedAt),
+		RevokedAt:  FormatTimeP(e.RevokedAt),
+	}
+}
+
+type CreateAPIKeyPayloadRes struct {
+	Res *model.CreateAPIKeyPayload `json:"createApiKeyPayload"`
+}
+
+func (r *CreateAPIKeyPayloadRes) Bind(e app.CreateAPIKeyResult) {
+	apiKey := APIKeyRes{}
+	apiKey.Bind(e.APIKey)
+
+	r.Res = &model.CreateAPIKeyPayload{
+		Key:    e.Key,
+		APIKey: apiKey.Res,
+	}
+}
+
+type ImpersonationPayloadRes struct {
+	Res *model.ImpersonationPayload `json:"impersonationPayload"`
+}
+
+func (r *ImpersonationPayloadRes) Bind(e app.ImpersonationResult) {
+	user := UserRes{}
+	user.Bind(e.User)
+
+	r.Res = &model.ImpersonationPayload{
+		AccessToken: e.AccessToken,
+		ExpiresAt:   FormatTime(e.ExpiresAt),
+		User:        user.Res,
+	}
+}
+
+type AuditEntriesRes struct {
+	Res []*model.AuditEntry `json:"auditEntries"`
+}
+
+func (r *AuditEntriesRes) Bind(es []entity.AuditEntry) {
+	r.Res = make([]*model.AuditEntry, len(es))
+	for i, e := range es {
+		r.Res[i] = &model.AuditEntry{
+			ID:        e.ID,
+			ActorID:   e.ActorID,
+			UserID:    e.UserID,
+			Action:    e.Action,
+			Operation: e.Operation,
+			Succeeded: e.Succeeded,
+			CreatedAt: FormatTime(e.CreatedAt),
+		}
+	}
+}
+
+type DataExportRes struct {
+	Res *model.DataExport `json:"dataExport"`
+}
+
+func (r *DataExportRes) Bind(e entity.DataExport) {
+	r.Res = &model.DataExport{
+		ID:          e.ID,
+		CreatedAt:   FormatTime(e.CreatedAt),
+		ExpiresAt:   FormatTime(e.ExpiresAt),
+		DownloadURL: DataExportPath + e.ID,
+	}
+}
+
+func FormatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func FormatTimeP(t *time.Time) *string {
+	if t == nil {
+		return nil
 	}
+	s := FormatTime(*t)
+	return &s
+}
+
+func ParseTimeP(s *string) (*time.Time, error) {
+	if s == nil || *s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, *s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time %q, expected RFC 3339 format", *s)
+	}
+	return &t, nil
 }
 
 func StringP(s string) *string {
@@ -121,3 +670,49 @@ func StringP(s string) *string {
 	}
 	return &s
 }
+
+func MoneyV(m *entity.Money) entity.Money {
+	if m == nil {
+		return entity.Money{Currency: entity.DefaultCurrency}
+	}
+	return *m
+}
+
+func Float64V(f *float64) float64 {
+	if f == nil {
+		return 0
+	}
+	return *f
+}
+
+func BoolV(b *bool) bool {
+	return b != nil && *b
+}
+
+type ProductImportResultRes struct {
+	Res *model.ProductImportResult `json:"result"`
+}
+
+func (r *ProductImportResultRes) Bind(e app.ImportProductsResult) {
+	r.Res = &model.ProductImportResult{
+		DryRun:  e.DryRun,
+		Applied: e.Applied,
+		Created: e.Created,
+		Updated: e.Updated,
+		Errors:  make([]*model.ProductImportError, len(e.Errors)),
+	}
+	for i, rowErr := range e.Errors {
+		r.Res.Errors[i] = &model.ProductImportError{
+			Line:    rowErr.Line,
+			Sku:     StringP(rowErr.SKU),
+			Message: rowErr.Message,
+		}
+	}
+}
+
+func StringV(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}